@@ -0,0 +1,327 @@
+// Package client is a Go client for the r3e-leaderboard HTTP API (/api/v1)
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"r3e-leaderboard/internal"
+)
+
+// Response types shared with the server, so the client can't drift from what it writes
+type (
+	DriverProfile     = internal.DriverProfile
+	DriverResult      = internal.DriverResult
+	DriverSuggestion  = internal.DriverSuggestion
+	LeaderboardPage   = internal.LeaderboardResponse
+	LeaderboardResult = internal.LeaderboardResult
+	Status            = internal.StatusData
+	Job               = internal.Job
+	JobAccepted       = internal.JobAcceptedResponse
+	EnvelopeMeta      = internal.EnvelopeMeta
+	DriverSuggestions = internal.DriverSuggestionsResponse
+)
+
+// Job states reported by the server
+const (
+	JobQueued    = internal.JobQueued
+	JobRunning   = internal.JobRunning
+	JobCompleted = internal.JobCompleted
+	JobFailed    = internal.JobFailed
+	JobCancelled = internal.JobCancelled
+)
+
+// Options configures a Client
+type Options struct {
+	APIPrefix  string        // Path the API is mounted under on the server; "/api" when empty
+	APIKey     string        // Sent as X-API-Key for the higher per-key rate limit
+	AdminToken string        // Required by Refresh
+	MaxRetries int           // Retries of rate limited (429), unavailable (502-504) and failed requests
+	RetryDelay time.Duration // Wait before the first retry, doubled after each; Retry-After takes precedence
+	HTTPClient *http.Client  // http.DefaultClient when nil
+	UserAgent  string
+}
+
+// Client calls the versioned API of one server; it is safe for concurrent use
+type Client struct {
+	base    string // Server URL including the API prefix and version
+	options Options
+	http    *http.Client
+}
+
+// New creates a client for the server at baseURL (e.g. "http://localhost:8080")
+func New(baseURL string, options Options) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	prefix := options.APIPrefix
+	if prefix == "" {
+		prefix = internal.DefaultAPIPrefix
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = 500 * time.Millisecond
+	}
+	if options.UserAgent == "" {
+		options.UserAgent = "r3e-leaderboard-client/" + internal.APIVersion
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	base := strings.TrimRight(baseURL, "/") + "/" + strings.Trim(prefix, "/")
+	return &Client{
+		base:    strings.TrimRight(base, "/") + "/" + internal.APIVersion,
+		options: options,
+		http:    httpClient,
+	}, nil
+}
+
+// Error is a failed API request
+type Error struct {
+	Status    int    // HTTP status
+	Code      string // e.g. "not_found", "too_many_requests"
+	Message   string
+	RequestID string // X-Request-ID of the response, for the server logs
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("r3e-leaderboard API: %s (HTTP %d, request %s)", e.Message, e.Status, e.RequestID)
+}
+
+// IsNotFound reports whether err is a 404 from the API
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// PendingError is returned by Leaderboard when the combination isn't cached and the server queued a fetch
+// Wait for the job with WaitJob, then request the leaderboard again
+type PendingError struct {
+	Job JobAccepted
+}
+
+func (e *PendingError) Error() string {
+	return "leaderboard not cached yet, fetch queued as job " + e.Job.JobID
+}
+
+// Search returns the profile of a driver with all their leaderboard entries
+func (c *Client) Search(ctx context.Context, name string) (*DriverProfile, error) {
+	var profile DriverProfile
+	if _, err := c.do(ctx, http.MethodGet, "/driver", url.Values{"name": {name}}, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Autocomplete returns up to limit drivers whose name starts with prefix (0 uses the server default)
+func (c *Client) Autocomplete(ctx context.Context, prefix string, limit int) ([]DriverSuggestion, error) {
+	query := url.Values{"prefix": {prefix}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var suggestions DriverSuggestions
+	if _, err := c.do(ctx, http.MethodGet, "/drivers", query, &suggestions); err != nil {
+		return nil, err
+	}
+	return suggestions.Results, nil
+}
+
+// LeaderboardOptions pages and sorts a leaderboard; zero values use the server defaults
+type LeaderboardOptions struct {
+	Limit  int
+	Offset int
+	Sort   string // "position", "laptime", "country" or "name"
+	Desc   bool
+}
+
+// Leaderboard returns a page of one track/class combination
+// Returns a *PendingError when the server started fetching an uncached combination
+func (c *Client) Leaderboard(ctx context.Context, trackID, classID string, options LeaderboardOptions) (*LeaderboardPage, error) {
+	query := url.Values{"track": {trackID}, "class": {classID}}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if options.Offset > 0 {
+		query.Set("offset", strconv.Itoa(options.Offset))
+	}
+	if options.Sort != "" {
+		query.Set("sort", options.Sort)
+	}
+	if options.Desc {
+		query.Set("order", "desc")
+	}
+
+	var raw json.RawMessage
+	status, err := c.do(ctx, http.MethodGet, "/leaderboard", query, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusAccepted {
+		pending := &PendingError{}
+		if err := json.Unmarshal(raw, &pending.Job); err != nil {
+			return nil, err
+		}
+		return nil, pending
+	}
+	var page LeaderboardPage
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Status returns the server's fetch and index status
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if _, err := c.do(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Refresh queues a refresh (admin token required); targets are track IDs or "trackID-classID" pairs,
+// none refreshes everything
+func (c *Client) Refresh(ctx context.Context, targets ...string) (*JobAccepted, error) {
+	var query url.Values
+	if len(targets) > 0 {
+		query = url.Values{"tracks": {strings.Join(targets, ",")}}
+	}
+	var accepted JobAccepted
+	if _, err := c.do(ctx, http.MethodPost, "/refresh", query, &accepted); err != nil {
+		return nil, err
+	}
+	return &accepted, nil
+}
+
+// Job returns the state of a background job
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if _, err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitJob polls a job every interval until it has finished (completed, failed or cancelled) or ctx is done
+func (c *Client) WaitJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	for {
+		job, err := c.Job(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Status {
+		case JobCompleted, JobFailed, JobCancelled:
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// envelope is the body of every versioned JSON response
+type envelope struct {
+	Data  json.RawMessage         `json:"data"`
+	Meta  EnvelopeMeta            `json:"meta"`
+	Error *internal.EnvelopeError `json:"error"`
+}
+
+// do sends a request, retrying transient failures, and decodes the envelope's data into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, out interface{}) (int, error) {
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	delay := c.options.RetryDelay
+	for attempt := 0; ; attempt++ {
+		status, retryAfter, err := c.send(ctx, method, target, out)
+		if err == nil || attempt >= c.options.MaxRetries || !retryable(status, err) || ctx.Err() != nil {
+			return status, err
+		}
+		wait := delay
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// send performs one attempt, returning the status and the server's Retry-After
+func (c *Client) send(ctx context.Context, method, target string, out interface{}) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.options.UserAgent)
+	if c.options.APIKey != "" {
+		req.Header.Set("X-API-Key", c.options.APIKey)
+	}
+	if c.options.AdminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.options.AdminToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var body envelope
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 256<<20)).Decode(&body); err != nil {
+		return resp.StatusCode, retryAfter, &Error{
+			Status:    resp.StatusCode,
+			Code:      "invalid_response",
+			Message:   "unexpected response body: " + err.Error(),
+			RequestID: resp.Header.Get("X-Request-ID"),
+		}
+	}
+	if body.Error != nil || resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{Status: resp.StatusCode, Message: http.StatusText(resp.StatusCode), RequestID: resp.Header.Get("X-Request-ID")}
+		if body.Error != nil {
+			apiErr.Code, apiErr.Message = body.Error.Code, body.Error.Message
+		}
+		return resp.StatusCode, retryAfter, apiErr
+	}
+	if out != nil && len(body.Data) > 0 {
+		if err := json.Unmarshal(body.Data, out); err != nil {
+			return resp.StatusCode, 0, fmt.Errorf("decoding %s response: %w", target, err)
+		}
+	}
+	return resp.StatusCode, 0, nil
+}
+
+// retryable reports whether a failed attempt may succeed when repeated
+func retryable(status int, err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) // Network error
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"r3e-leaderboard/internal"
+	"r3e-leaderboard/internal/fakeraceroom"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// command is a subcommand of the binary; run receives the arguments after its name and returns the exit code
+type command struct {
+	name    string
+	usage   string // Arguments shown after the name
+	summary string
+	run     func(args []string) int
+}
+
+// commandList returns the subcommands; without one the binary runs serve
+func commandList() []command {
+	return []command{
+		{"serve", "", "Run the daemon: fetching, scheduled refreshes, indexing and the HTTP server (default)", runServe},
+		{"fetch", "--track ID[,ID] [--class ID[,ID]] [--dry-run [--json]]", "Fetch combinations into the cache and exit (all classes when --class is omitted)", runFetch},
+		{"search", "[--json] <driver name>", "Look a driver up in the persisted index", runSearch},
+		{"export", "[--format jsonl|csv] [--output PATH]", "Write every entry of the persisted index (stdout by default; .gz compresses)", runExport},
+		{"cache", "validate [--delete] [--json] | prune [--dry-run]", "Check cache files for corrupt gzip or JSON, or remove combinations no longer configured", runCache},
+		{"discover-tracks", "", "Probe RaceRoom for track IDs, write " + internal.TracksFile + " and exit", runTrackDiscovery},
+		{"fake-raceroom", "[--port N] [--entries N] [--latency D] [--error-rate R]", "Serve a fake RaceRoom leaderboard site for fetch.client.base_url", runFakeRaceRoom},
+	}
+}
+
+// runCommand dispatches to the subcommand named by the first argument
+func runCommand(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	for _, cmd := range commandList() {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commandList() {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.usage, cmd.summary)
+	}
+	tw.Flush()
+}
+
+// newFlagSet creates the flag set of a subcommand
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s %s\n", os.Args[0], name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// loadConfig loads config.json (defaults otherwise), sets up logging and catalogs
+// and applies the settings the fetch, cache and export code read
+func loadConfig() internal.Config {
+	config := internal.LoadConfig(internal.ConfigFile)
+	internal.SetupLogging(config.Logging)
+
+	if _, err := internal.LoadCatalogs(); err != nil {
+		mainLog.Warnf("⚠️ Failed to load catalogs: %v (using built-in tracks and classes)", err)
+	}
+	internal.SetSelectionConfig(config.Selection)
+	internal.SetCacheConfig(config.Cache)
+	internal.SetFetchConfig(config.Fetch)
+	internal.SetSnapshotConfig(config.Snapshots)
+	internal.SetExportConfig(config.Export)
+	internal.SetReportConfig(config.Reports)
+	internal.SetChampionshipConfig(config.Championship)
+	return config
+}
+
+// runTrackDiscovery probes the configured track ID range and writes the tracks file
+// SIGINT/SIGTERM stop the probing without writing
+func runTrackDiscovery(args []string) int {
+	if err := newFlagSet("discover-tracks", "").Parse(args); err != nil {
+		return 2
+	}
+	config := loadConfig()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	tracks, err := internal.DiscoverTracks(ctx, config.Discovery)
+	if err != nil {
+		mainLog.Errorf("❌ Track discovery failed: %v", err)
+		return 1
+	}
+	if err := internal.ExportTrackCatalog(internal.TracksFile, tracks); err != nil {
+		mainLog.Errorf("❌ Failed to write %s: %v", internal.TracksFile, err)
+		return 1
+	}
+	mainLog.Infof("💾 Wrote %d tracks to %s", len(tracks), internal.TracksFile)
+	return 0
+}
+
+// runFakeRaceRoom serves the fake RaceRoom site until SIGINT/SIGTERM
+// Every combination lists generated entries; the class selector lists the configured classes
+func runFakeRaceRoom(args []string) int {
+	flags := newFlagSet("fake-raceroom", "[--port N] [--entries N] [--latency D] [--error-rate R] [--error-status N] [--retry-after N]")
+	port := flags.Int("port", 9000, "port to listen on")
+	entries := flags.Int("entries", 3000, "entries of every combination")
+	latency := flags.Duration("latency", 0, "delay of every response, e.g. 200ms")
+	errorRate := flags.Float64("error-rate", 0, "share (0-1) of listing requests answered with --error-status")
+	errorStatus := flags.Int("error-status", http.StatusServiceUnavailable, "status of the failed listing requests")
+	retryAfter := flags.Int("retry-after", 0, "Retry-After seconds sent with 429 and 503 answers")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	loadConfig()
+
+	fake := fakeraceroom.New(fakeraceroom.Options{
+		Latency:        *latency,
+		ErrorRate:      *errorRate,
+		ErrorStatus:    *errorStatus,
+		RetryAfter:     *retryAfter,
+		DefaultEntries: *entries,
+		Seed:           time.Now().UnixNano(),
+	})
+	for _, class := range internal.GetCarClasses() {
+		fake.AddClass(class.ClassID, class.Name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Addr: fmt.Sprintf(":%d", *port), Handler: fake}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	mainLog.Infof("🎭 Fake RaceRoom listening on port %d - set fetch.client.base_url to http://localhost:%d", *port, *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		mainLog.Errorf("❌ Fake RaceRoom failed: %v", err)
+		return 1
+	}
+	stats := fake.Stats()
+	mainLog.Infof("🎭 Fake RaceRoom stopped after %d session and %d listing requests (%d errors)", stats.SessionRequests, stats.ListingRequests, stats.Errors)
+	return 0
+}
+
+// runFetch fetches the given combinations into the live cache
+// A running daemon picks them up with its next index build
+func runFetch(args []string) int {
+	flags := newFlagSet("fetch", "--track ID[,ID] [--class ID[,ID]] [--dry-run [--json]]")
+	trackList := flags.String("track", "", "comma-separated track IDs (required, except with --dry-run: every track)")
+	classList := flags.String("class", "", "comma-separated class IDs (default: every configured class)")
+	dryRun := flags.Bool("dry-run", false, "list the combinations that would be fetched and the estimated duration, without network calls")
+	asJSON := flags.Bool("json", false, "print the dry run as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *trackList == "" && !*dryRun {
+		flags.Usage()
+		return 2
+	}
+	loadConfig()
+
+	if *dryRun {
+		req := internal.RefreshRequest{Tracks: splitList(*trackList), Classes: splitList(*classList)}
+		plan, err := req.Plan()
+		if err != nil {
+			mainLog.Errorf("❌ %v", err)
+			return 2
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(plan); err != nil {
+				return 1
+			}
+			return 0
+		}
+		printRefreshPlan(os.Stdout, plan)
+		return 0
+	}
+
+	var tracks []internal.TrackConfig
+	for _, id := range splitList(*trackList) {
+		track, ok := internal.FindTrack(id)
+		if !ok {
+			mainLog.Errorf("❌ Unknown track %q", id)
+			return 2
+		}
+		tracks = append(tracks, track)
+	}
+	classes := internal.GetCarClasses()
+	if *classList != "" {
+		classes = nil
+		for _, id := range splitList(*classList) {
+			class, ok := internal.FindCarClass(id)
+			if !ok {
+				mainLog.Errorf("❌ Unknown class %q", id)
+				return 2
+			}
+			classes = append(classes, class)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	total := len(tracks) * len(classes)
+	fetched, failed := 0, 0
+	for _, track := range tracks {
+		for _, class := range classes {
+			if ctx.Err() != nil {
+				mainLog.Warnf("⚠️ Fetch interrupted after %d of %d combinations", fetched+failed, total)
+				return 1
+			}
+			job := internal.Job{Kind: internal.JobKindFetch, TrackID: track.TrackID, Track: track.Name, ClassID: class.ClassID, ClassName: class.Name}
+			entries, err := internal.RunFetchJob(ctx, job, func(internal.FetchProgress) {})
+			if err != nil {
+				failed++
+				mainLog.Warnf("⚠️ [%d/%d] %s + %s failed: %v", fetched+failed, total, track.Name, class.Name, err)
+				continue
+			}
+			fetched++
+			mainLog.Infof("✅ [%d/%d] %s + %s: %d entries", fetched+failed, total, track.Name, class.Name, entries)
+		}
+	}
+
+	mainLog.Infof("🏁 Fetched %d of %d combinations (%d failed)", fetched, total, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printRefreshPlan lists the combinations of a dry run and sums them up
+func printRefreshPlan(w io.Writer, plan internal.RefreshPlan) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TRACK\tCLASS\tREASON\tAGE (H)\tMAX AGE (H)")
+	for _, fetch := range plan.Fetches {
+		age, maxAge := "-", "-"
+		if fetch.Reason != internal.PlanReasonMissing {
+			age, maxAge = fmt.Sprintf("%.1f", fetch.AgeHours), fmt.Sprintf("%.1f", fetch.MaxAgeHours)
+		}
+		fmt.Fprintf(tw, "%s (%s)\t%s (%s)\t%s\t%s\t%s\n", fetch.Track, fetch.TrackID, fetch.Class, fetch.ClassID, fetch.Reason, age, maxAge)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d of %d combinations would be fetched (%d missing, %d expired, %d fresh)\n",
+		plan.Fetch, plan.Combinations, plan.Missing, plan.Expired, plan.Fresh)
+	fmt.Fprintf(w, "Estimated duration: %v (%.2fs per combination, %s)\n",
+		time.Duration(plan.EstimatedSeconds)*time.Second, plan.SecondsPerCombination, strings.ReplaceAll(plan.EstimateSource, "_", " "))
+}
+
+// runSearch prints a driver's results from the persisted index
+func runSearch(args []string) int {
+	flags := newFlagSet("search", "[--json] <driver name>")
+	asJSON := flags.Bool("json", false, "print the driver profile as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	name := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if name == "" {
+		flags.Usage()
+		return 2
+	}
+	loadConfig()
+
+	engine := internal.GetSearchEngine()
+	engine.SetAliases(internal.LoadAliasStore(internal.AliasesFile))
+	if err := engine.LoadPersisted(); err != nil {
+		mainLog.Errorf("❌ No driver index to search: %v", err)
+		return 1
+	}
+	results := engine.Lookup(name)
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No driver named %q\n", name)
+		if suggestions := engine.Autocomplete(name, 5); len(suggestions) > 0 {
+			fmt.Fprintln(os.Stderr, "Did you mean:")
+			for _, suggestion := range suggestions {
+				fmt.Fprintf(os.Stderr, "  %s (%d entries)\n", suggestion.Name, suggestion.Entries)
+			}
+		}
+		return 1
+	}
+
+	profile := engine.DriverProfile(name, results)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(profile); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Printf("%s: %d combinations, best position %d\n\n", profile.Name, profile.TotalCombinations, profile.BestPosition)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TRACK\tCLASS\tPOS\tLAP TIME\tENTRIES")
+	for _, track := range profile.Tracks {
+		for _, result := range track.Results {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\n", track.Track, internal.GetCarClassName(result.ClassID), result.Position, result.LapTime, result.TotalEntries)
+		}
+	}
+	tw.Flush()
+	return 0
+}
+
+// runExport writes every entry of the persisted index as JSON Lines or CSV
+func runExport(args []string) int {
+	flags := newFlagSet("export", "[--format jsonl|csv] [--output PATH]")
+	format := flags.String("format", internal.EntryFormatJSONL, "jsonl or csv")
+	output := flags.String("output", "-", "file to write (- for stdout; a .gz name compresses)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != internal.EntryFormatJSONL && *format != internal.EntryFormatCSV {
+		mainLog.Errorf("❌ Unknown export format %q (expected jsonl or csv)", *format)
+		return 2
+	}
+	loadConfig()
+
+	engine := internal.GetSearchEngine()
+	if err := engine.LoadPersisted(); err != nil {
+		mainLog.Errorf("❌ No driver index to export: %v", err)
+		return 1
+	}
+
+	var written int
+	var err error
+	if *output == "-" {
+		written, err = engine.WriteEntries(os.Stdout, *format)
+	} else {
+		written, err = engine.ExportEntries(*output, *format)
+	}
+	if err != nil {
+		mainLog.Errorf("❌ Failed to write %s: %v", *output, err)
+		return 1
+	}
+	mainLog.Infof("💾 Wrote %d entries to %s", written, *output)
+	return 0
+}
+
+// runCache runs cache maintenance: cache validate, cache prune
+func runCache(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return runCacheValidate(args[1:])
+		case "prune":
+			return runCachePrune(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s cache validate [--delete] [--json]\n       %s cache prune [--dry-run]\n", os.Args[0], os.Args[0])
+	return 2
+}
+
+// runCacheValidate decodes every cache file and reports (and optionally deletes) the broken ones
+func runCacheValidate(args []string) int {
+	flags := newFlagSet("cache validate", "[--delete] [--json]")
+	deleteBroken := flags.Bool("delete", false, "delete broken files so the next refresh refetches them")
+	asJSON := flags.Bool("json", false, "print the status of every file as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	loadConfig()
+
+	validation, err := internal.NewDataCache().ValidateCache(*deleteBroken)
+	if err != nil {
+		mainLog.Errorf("❌ Cache validation failed: %v", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(validation)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, file := range validation.Files {
+			if file.Status == internal.CacheFileOK {
+				continue
+			}
+			action := "kept"
+			if file.Deleted {
+				action = "deleted"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", file.Path, file.Status, action, file.Error)
+		}
+		tw.Flush()
+		fmt.Printf("%d cache files checked, %d broken, %d deleted\n", validation.Checked, validation.Broken, validation.Deleted)
+	}
+	if validation.Broken > validation.Deleted {
+		return 1
+	}
+	return 0
+}
+
+// runCachePrune removes the cache files of combinations outside the configured tracks and classes
+func runCachePrune(args []string) int {
+	flags := newFlagSet("cache prune", "[--dry-run]")
+	dryRun := flags.Bool("dry-run", false, "only list the combinations that would be removed")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	loadConfig()
+
+	result, err := internal.NewDataCache().PruneStaleCombinations(*dryRun)
+	if err != nil {
+		mainLog.Errorf("❌ Cache pruning failed: %v", err)
+		return 1
+	}
+	for _, file := range result.Stale {
+		fmt.Println(file)
+	}
+	if *dryRun {
+		fmt.Printf("%d of %d cached combinations would be pruned\n", len(result.Stale), result.Checked)
+	} else {
+		fmt.Printf("%d of %d cached combinations pruned\n", result.Removed, result.Checked)
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
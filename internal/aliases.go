@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AliasesFile persists the driver alias table edited through /api/aliases
+const AliasesFile = "cache/driver_aliases.json"
+
+// DriverAlias maps the other names and RaceRoom user IDs of a driver to one canonical driver, so
+// lookups, profiles and searches aggregate their results across renames
+type DriverAlias struct {
+	Canonical string    `json:"canonical"`            // Display name the aliases resolve to
+	Names     []string  `json:"names,omitempty"`      // Other names the driver used
+	DriverIDs []string  `json:"driver_ids,omitempty"` // RaceRoom user IDs whose results belong to the driver
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AliasStore holds the alias table; a nil store has no aliases
+type AliasStore struct {
+	mu      sync.RWMutex
+	path    string
+	aliases map[string]*DriverAlias // By normalized canonical name
+	byName  map[string]string       // Normalized canonical or alias name -> normalized canonical name
+	changes uint64                  // Incremented by every change, so cached responses can tell the table changed
+}
+
+// LoadAliasStore loads the alias table from path; a missing file gives an empty table
+func LoadAliasStore(path string) *AliasStore {
+	store := &AliasStore{path: path, aliases: make(map[string]*DriverAlias), byName: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			searchLog.Warnf("⚠️ Failed to read driver aliases from %s: %v", path, err)
+		}
+		return store
+	}
+	var aliases []DriverAlias
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		searchLog.Warnf("⚠️ Invalid driver alias file %s: %v", path, err)
+		return store
+	}
+	for i := range aliases {
+		if err := store.addLocked(&aliases[i]); err != nil {
+			searchLog.Warnf("⚠️ Skipping driver alias %q: %v", aliases[i].Canonical, err)
+		}
+	}
+	searchLog.Infof("🪪 Loaded %d driver aliases from %s", len(store.aliases), path)
+	return store
+}
+
+// List returns the alias table ordered by canonical name
+func (as *AliasStore) List() []DriverAlias {
+	list := []DriverAlias{}
+	if as == nil {
+		return list
+	}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	for _, alias := range as.aliases {
+		list = append(list, *alias)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return NormalizeDriverName(list[i].Canonical) < NormalizeDriverName(list[j].Canonical)
+	})
+	return list
+}
+
+// Set creates or replaces the alias of alias.Canonical and persists the table
+// Fails when one of the names already belongs to another canonical driver
+func (as *AliasStore) Set(alias DriverAlias) (DriverAlias, error) {
+	alias.Canonical = strings.TrimSpace(alias.Canonical)
+	if NormalizeDriverName(alias.Canonical) == "" {
+		return DriverAlias{}, fmt.Errorf("missing canonical name")
+	}
+	key := NormalizeDriverName(alias.Canonical)
+	var names []string
+	for _, name := range cleanAliasList(alias.Names) {
+		if NormalizeDriverName(name) != key {
+			names = append(names, name)
+		}
+	}
+	alias.Names = names
+	alias.DriverIDs = cleanAliasList(alias.DriverIDs)
+	alias.UpdatedAt = time.Now().UTC()
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	previous := as.aliases[key]
+	as.removeLocked(key)
+	if err := as.addLocked(&alias); err != nil {
+		if previous != nil {
+			as.addLocked(previous)
+		}
+		return DriverAlias{}, err
+	}
+	if err := as.saveLocked(); err != nil {
+		as.removeLocked(key)
+		if previous != nil {
+			as.addLocked(previous)
+		}
+		return DriverAlias{}, err
+	}
+	searchLog.Infof("🪪 Driver alias %s: %d name(s), %d user ID(s)", alias.Canonical, len(alias.Names), len(alias.DriverIDs))
+	return alias, nil
+}
+
+// Delete removes the alias of a canonical name and persists the table
+func (as *AliasStore) Delete(canonical string) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	key := NormalizeDriverName(canonical)
+	alias, ok := as.aliases[key]
+	if !ok {
+		return fmt.Errorf("no alias for %q", canonical)
+	}
+	as.removeLocked(key)
+	searchLog.Infof("🪪 Removed driver alias %s", alias.Canonical)
+	return as.saveLocked()
+}
+
+// Resolve returns the alias a name (canonical or alternate) belongs to
+func (as *AliasStore) Resolve(name string) (DriverAlias, bool) {
+	if as == nil {
+		return DriverAlias{}, false
+	}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	canonical, ok := as.byName[NormalizeDriverName(name)]
+	if !ok {
+		return DriverAlias{}, false
+	}
+	return *as.aliases[canonical], true
+}
+
+// canonicalKey returns the normalized canonical name of an index key, or the key itself when it has no alias
+func (as *AliasStore) canonicalKey(key string) string {
+	if as == nil {
+		return key
+	}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	if canonical, ok := as.byName[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// addLocked indexes an alias; as.mu must be held
+func (as *AliasStore) addLocked(alias *DriverAlias) error {
+	key := NormalizeDriverName(alias.Canonical)
+	if key == "" {
+		return fmt.Errorf("missing canonical name")
+	}
+	if _, exists := as.aliases[key]; exists {
+		return fmt.Errorf("duplicate canonical name %q", alias.Canonical)
+	}
+	names := append([]string{key}, alias.Names...)
+	for _, name := range names {
+		if owner, taken := as.byName[NormalizeDriverName(name)]; taken {
+			return fmt.Errorf("name %q already belongs to %q", name, as.aliases[owner].Canonical)
+		}
+	}
+	for _, name := range names {
+		as.byName[NormalizeDriverName(name)] = key
+	}
+	as.aliases[key] = alias
+	as.changes++
+	return nil
+}
+
+// removeLocked drops the alias of a canonical key; as.mu must be held
+func (as *AliasStore) removeLocked(key string) {
+	delete(as.aliases, key)
+	for name, canonical := range as.byName {
+		if canonical == key {
+			delete(as.byName, name)
+		}
+	}
+	as.changes++
+}
+
+// revision returns the number of changes to the table since it was loaded; 0 for a nil store
+func (as *AliasStore) revision() uint64 {
+	if as == nil {
+		return 0
+	}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	return as.changes
+}
+
+// saveLocked writes the table to disk atomically; as.mu must be held
+func (as *AliasStore) saveLocked() error {
+	list := make([]DriverAlias, 0, len(as.aliases))
+	for _, alias := range as.aliases {
+		list = append(list, *alias)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Canonical < list[j].Canonical })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(as.path), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(as.path, data)
+	}
+	return err
+}
+
+// cleanAliasList trims the values of a list and drops blanks and duplicates
+func cleanAliasList(values []string) []string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" && !seen[NormalizeDriverName(value)] {
+			seen[NormalizeDriverName(value)] = true
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -1,11 +1,18 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,38 +29,213 @@ type APIResult struct {
 type APIResponse struct {
 	Context struct {
 		C struct {
-			Results []map[string]interface{} `json:"results"`
+			Results []LeaderboardEntry `json:"results"`
 		} `json:"c"`
 	} `json:"context"`
 }
 
+// leaderboardPageSize is the number of entries per listing request (the API's maximum)
+const leaderboardPageSize = 1500
+
+// Waiting out 429 and 503 answers within a fetch
+const (
+	maxRetryAfterAttempts = 3                // Waits per listing page before the combination is left for a retry
+	maxRetryAfterWait     = 60 * time.Second // Longer Retry-After values aren't waited for within the fetch
+	defaultRetryAfter     = 5 * time.Second  // Wait when a 429 or 503 carries no Retry-After
+)
+
+// APIStatusError is returned when RaceRoom answers a listing request with a non-200 status
+type APIStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header of a 429 or 503
+}
+
+func (e *APIStatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("API returned status code %d (retry after %v)", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("API returned status code %d", e.StatusCode)
+}
+
+// RateLimited reports whether RaceRoom asked to slow down (429) or is temporarily unavailable (503)
+func (e *APIStatusError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// newAPIStatusError builds the error of a non-200 response, reading Retry-After from 429 and 503 answers
+func newAPIStatusError(resp *http.Response) *APIStatusError {
+	statusErr := &APIStatusError{StatusCode: resp.StatusCode}
+	if statusErr.RateLimited() {
+		statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return statusErr
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date; 0 when absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// waitRetryAfter waits out a 429 or 503 before the request is repeated
+// Returns false (without waiting) when the wait is too long or would outlast ctx's deadline
+func waitRetryAfter(ctx context.Context, statusErr *APIStatusError, trackID, classID string) bool {
+	wait := statusErr.RetryAfter
+	if wait <= 0 {
+		wait = defaultRetryAfter
+	}
+	if wait > maxRetryAfterWait {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return false
+	}
+
+	loaderLog.Warnf("⏳ RaceRoom answered %d for track %s class %s - waiting %v before retrying", statusErr.StatusCode, trackID, classID, wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // APIClient handles all API communications with RaceRoom
 type APIClient struct {
-	client    *http.Client
-	timeout   time.Duration
-	transport *http.Transport
+	client             *http.Client
+	combinationTimeout time.Duration // Budget of one combination's fetch, see fetchWithTimeout
+	transport          *http.Transport
+	userAgent          string
+	baseURL            string // Without trailing slash
 }
 
-// NewAPIClient creates a new API client with default settings
+// clientConfig holds the HTTP client settings of new API clients
+var clientConfig = struct {
+	HTTPClientConfig
+	proxy         *url.URL
+	parallelPages int
+	fixtures      FixtureConfig
+}{HTTPClientConfig: GetDefaultConfig().Fetch.Client, parallelPages: GetDefaultConfig().Fetch.ParallelPages}
+
+// SetFetchConfig sets the HTTP client settings and fixture mode of new API clients, the throttle of the
+// refresh fetch loops, the schema check of fetched entries and the raw response archive
+// Must be called before background loading starts; unset values and an invalid proxy fall back to the defaults
+func SetFetchConfig(cfg FetchConfig) {
+	client := cfg.Client
+	defaults := GetDefaultConfig().Fetch.Client
+	if base, err := url.Parse(client.BaseURL); client.BaseURL == "" || err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		if client.BaseURL != "" {
+			configLog.Warnf("⚠️ Ignoring fetch.client.base_url %q: expected an http:// or https:// URL", client.BaseURL)
+		}
+		client.BaseURL = defaults.BaseURL
+	} else if client.BaseURL = strings.TrimRight(client.BaseURL, "/"); client.BaseURL != defaults.BaseURL {
+		configLog.Infof("🌐 Fetching from %s instead of RaceRoom", client.BaseURL)
+	}
+	if client.UserAgent == "" {
+		client.UserAgent = defaults.UserAgent
+	}
+	if client.RequestTimeoutSeconds <= 0 {
+		client.RequestTimeoutSeconds = defaults.RequestTimeoutSeconds
+	}
+	if client.CombinationTimeoutSeconds <= 0 {
+		client.CombinationTimeoutSeconds = defaults.CombinationTimeoutSeconds
+	}
+	if client.DialTimeoutSeconds <= 0 {
+		client.DialTimeoutSeconds = defaults.DialTimeoutSeconds
+	}
+	if client.TLSHandshakeTimeoutSeconds <= 0 {
+		client.TLSHandshakeTimeoutSeconds = defaults.TLSHandshakeTimeoutSeconds
+	}
+	if client.ResponseHeaderTimeoutSeconds <= 0 {
+		client.ResponseHeaderTimeoutSeconds = defaults.ResponseHeaderTimeoutSeconds
+	}
+	if client.MaxIdleConns <= 0 {
+		client.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if client.MaxIdleConnsPerHost <= 0 {
+		client.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if client.IdleConnTimeoutSeconds <= 0 {
+		client.IdleConnTimeoutSeconds = defaults.IdleConnTimeoutSeconds
+	}
+
+	var proxy *url.URL
+	if client.ProxyURL != "" {
+		parsed, err := url.Parse(client.ProxyURL)
+		switch {
+		case err != nil:
+			configLog.Warnf("⚠️ Ignoring fetch.client.proxy_url: %v", err)
+		case parsed.Scheme != "http" && parsed.Scheme != "https" && parsed.Scheme != "socks5":
+			configLog.Warnf("⚠️ Ignoring fetch.client.proxy_url %q: scheme must be http, https or socks5", parsed.Redacted())
+		case parsed.Host == "":
+			configLog.Warnf("⚠️ Ignoring fetch.client.proxy_url %q: no host", parsed.Redacted())
+		default:
+			proxy = parsed
+			configLog.Infof("🌐 Fetching RaceRoom through proxy %s", parsed.Redacted())
+		}
+	}
+
+	clientConfig.HTTPClientConfig = client
+	clientConfig.proxy = proxy
+	clientConfig.parallelPages = cfg.ParallelPages
+	if clientConfig.parallelPages <= 0 {
+		clientConfig.parallelPages = GetDefaultConfig().Fetch.ParallelPages
+	}
+	fetchThrottle.configure(cfg.Throttle)
+	setSchemaCheckConfig(cfg.Schema)
+	setRawArchiveConfig(cfg.RawArchive)
+	clientConfig.fixtures = setFixtureConfig(cfg.Fixtures)
+}
+
+// NewAPIClient creates a new API client with the settings of fetch.client
 func NewAPIClient() *APIClient {
 	jar, _ := cookiejar.New(nil)
+	cfg := clientConfig.HTTPClientConfig
 
 	// Configure transport with connection limits to prevent connection leaks
 	transport := &http.Transport{
-		MaxIdleConns:        5,
-		MaxIdleConnsPerHost: 2,
-		IdleConnTimeout:     30 * time.Second,
-		DisableKeepAlives:   false,
+		DialContext:           (&net.Dialer{Timeout: time.Duration(cfg.DialTimeoutSeconds) * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   time.Duration(cfg.TLSHandshakeTimeoutSeconds) * time.Second,
+		ResponseHeaderTimeout: time.Duration(cfg.ResponseHeaderTimeoutSeconds) * time.Second,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       time.Duration(cfg.IdleConnTimeoutSeconds) * time.Second,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+	}
+	if clientConfig.proxy != nil {
+		transport.Proxy = http.ProxyURL(clientConfig.proxy)
+	}
+
+	// Fixture mode replaces RaceRoom (replay) or records its answers (record)
+	var roundTripper http.RoundTripper = transport
+	switch clientConfig.fixtures.Mode {
+	case FixtureModeReplay:
+		roundTripper = &fixtureTransport{dir: clientConfig.fixtures.Dir}
+	case FixtureModeRecord:
+		roundTripper = &fixtureTransport{dir: clientConfig.fixtures.Dir, next: transport}
 	}
 
 	return &APIClient{
 		client: &http.Client{
-			Timeout:   120 * time.Second,
+			Timeout:   time.Duration(cfg.RequestTimeoutSeconds) * time.Second,
 			Jar:       jar,
-			Transport: transport,
+			Transport: roundTripper,
 		},
-		timeout:   120 * time.Second,
-		transport: transport,
+		combinationTimeout: time.Duration(cfg.CombinationTimeoutSeconds) * time.Second,
+		transport:          transport,
+		userAgent:          cfg.UserAgent,
+		baseURL:            cfg.BaseURL,
 	}
 }
 
@@ -65,20 +247,31 @@ func (api *APIClient) Close() {
 }
 
 // FetchLeaderboardData retrieves leaderboard data from RaceRoom API with pagination
-func (api *APIClient) FetchLeaderboardData(ctx context.Context, trackID, classID string) ([]map[string]interface{}, time.Duration, error) {
+func (api *APIClient) FetchLeaderboardData(ctx context.Context, trackID, classID string) ([]LeaderboardEntry, time.Duration, error) {
+	ctx, span := startSpan(ctx, "raceroom.fetch_leaderboard", spanKindClient, "track_id", trackID, "class_id", classID)
+	defer span.End()
+
+	entries, duration, err := api.fetchLeaderboardData(ctx, trackID, classID)
+	span.SetAttrs("entries", len(entries))
+	span.SetError(err)
+	return entries, duration, err
+}
+
+// fetchLeaderboardData performs the session request and paginated listing requests
+func (api *APIClient) fetchLeaderboardData(ctx context.Context, trackID, classID string) ([]LeaderboardEntry, time.Duration, error) {
 	startTime := time.Now()
 
 	// Add "class-" prefix to the class ID
 	fullClassID := "class-" + classID
 
 	// Establish session
-	mainURL := "https://game.raceroom.com/leaderboard/?car_class=" + fullClassID + "&track=" + trackID
+	mainURL := api.baseURL + "/leaderboard/?car_class=" + fullClassID + "&track=" + trackID
 	req, err := http.NewRequest("GET", mainURL, nil)
 	if err != nil {
 		return nil, 0, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", api.userAgent)
 
 	resp, err := api.client.Do(req)
 	if err != nil {
@@ -87,71 +280,161 @@ func (api *APIClient) FetchLeaderboardData(ctx context.Context, trackID, classID
 	resp.Body.Close()
 
 	// Fetch data with pagination (API limits to 1500 per request)
-	// Pre-allocate with reasonable capacity to avoid repeated allocations
-	allResults := make([]map[string]interface{}, 0, 1500)
-	pageSize := 1500
-	start := 0
-	maxPages := 100 // Safety limit: prevent infinite loops (100 pages = 150k entries)
-
-	for page := 0; page < maxPages; page++ {
-		// Check if context is cancelled before each page fetch
-		select {
-		case <-ctx.Done():
-			return nil, 0, ctx.Err()
-		default:
+	// The listing doesn't report its total, so after a full first page the following pages are requested
+	// in windows of parallel_pages concurrent requests until a page comes back short
+	listingURL := api.baseURL + "/leaderboard/listing/0?track=" + trackID + "&car_class=" + fullClassID
+	raw := newRawCapture(trackID, classID)
+	allResults, err := api.fetchListing(ctx, listingURL, mainURL, trackID, classID, raw)
+	raw.save(err)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	allResults, duplicates := dedupeListingEntries(allResults)
+	if duplicates > 0 {
+		loaderLog.Infof("🧹 Track %s class %s: dropped %d entries repeated across pages", trackID, classID, duplicates)
+		recordFetchDuplicates(trackID, classID, duplicates)
+	}
+	duration := time.Since(startTime)
+	return allResults, duration, nil
+}
+
+// fetchListing requests the listing pages of a combination until one comes back short
+// Responses are recorded in raw when the combination is archived (see rawarchive.go)
+func (api *APIClient) fetchListing(ctx context.Context, listingURL, referer, trackID, classID string, raw *rawCapture) ([]LeaderboardEntry, error) {
+	results, err := api.fetchListingPage(ctx, listingURL, referer, 0, trackID, classID, raw)
+	if err != nil {
+		return nil, err
+	}
+	allResults := results
+	parallel := clientConfig.parallelPages
+	for page, done := 1, len(results) < leaderboardPageSize; !done; page += parallel {
+		if page >= maxListingPages {
+			loaderLog.Warnf("⚠️ Track %s class %s has more than %d pages - stopping at %d entries", trackID, classID, maxListingPages, len(allResults))
+			break
+		}
+		window := min(parallel, maxListingPages-page)
+		pages, err := api.fetchListingPages(ctx, listingURL, referer, page, window, trackID, classID, raw)
+		for _, results := range pages {
+			allResults = append(allResults, results...)
+			if len(results) < leaderboardPageSize {
+				done = true // Later pages of the window are empty
+				break
+			}
 		}
+		if err != nil && !done {
+			return nil, err
+		}
+	}
+	return allResults, nil
+}
 
-		// API call for leaderboard data
-		apiURL := "https://game.raceroom.com/leaderboard/listing/0?track=" + trackID + "&car_class=" + fullClassID + "&start=" + fmt.Sprintf("%d", start) + "&count=" + fmt.Sprintf("%d", pageSize)
+// maxListingPages prevents endless paging (100 pages = 150k entries)
+const maxListingPages = 100
 
-		apiReq, err := http.NewRequest("GET", apiURL, nil)
+// fetchListingPages requests count listing pages from page on concurrently and returns them in order
+// Pages after a short page are dropped, so a failure past the end of the listing doesn't fail the fetch;
+// the error of the first failed page is returned with the pages before it
+func (api *APIClient) fetchListingPages(ctx context.Context, listingURL, referer string, page, count int, trackID, classID string, raw *rawCapture) ([][]LeaderboardEntry, error) {
+	pages := make([][]LeaderboardEntry, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pages[i], errs[i] = api.fetchListingPage(ctx, listingURL, referer, (page+i)*leaderboardPageSize, trackID, classID, raw)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range pages {
+		if errs[i] != nil {
+			return pages[:i], errs[i]
+		}
+		if len(pages[i]) < leaderboardPageSize {
+			return pages[:i+1], nil
+		}
+	}
+	return pages, nil
+}
+
+// fetchListingPage requests the listing entries from start on, waiting out 429 and 503 answers
+func (api *APIClient) fetchListingPage(ctx context.Context, listingURL, referer string, start int, trackID, classID string, raw *rawCapture) ([]LeaderboardEntry, error) {
+	apiURL := listingURL + "&start=" + strconv.Itoa(start) + "&count=" + strconv.Itoa(leaderboardPageSize)
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		apiReq, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		apiReq = apiReq.WithContext(ctx)
-		apiReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+		apiReq.Header.Set("User-Agent", api.userAgent)
 		apiReq.Header.Set("Accept", "application/json")
 		apiReq.Header.Set("X-Requested-With", "XMLHttpRequest")
-		apiReq.Header.Set("Referer", mainURL)
+		apiReq.Header.Set("Referer", referer)
 
 		apiResp, err := api.client.Do(apiReq)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
-		if apiResp.StatusCode != 200 {
+		body := apiResp.Body
+		if raw != nil {
+			data, err := io.ReadAll(apiResp.Body)
 			apiResp.Body.Close()
-			return nil, 0, fmt.Errorf("API returned status code %d", apiResp.StatusCode)
+			if err != nil {
+				return nil, err
+			}
+			raw.add(start, apiResp.StatusCode, data)
+			body = io.NopCloser(bytes.NewReader(data))
 		}
 
-		// Parse JSON response
-		var response APIResponse
-		if err := json.NewDecoder(apiResp.Body).Decode(&response); err != nil {
-			apiResp.Body.Close()
-			return nil, 0, err
+		if apiResp.StatusCode != 200 {
+			body.Close()
+			statusErr := newAPIStatusError(apiResp)
+			if statusErr.RateLimited() && attempt < maxRetryAfterAttempts && waitRetryAfter(ctx, statusErr, trackID, classID) {
+				continue // Same page again
+			}
+			return nil, statusErr
 		}
-		apiResp.Body.Close() // Close immediately after reading
 
-		results := response.Context.C.Results
-		if len(results) == 0 {
-			break // No more results
+		// Parse JSON response straight into typed entries
+		// Mistyped individual fields are left empty rather than failing the page
+		var response APIResponse
+		err = json.NewDecoder(body).Decode(&response)
+		body.Close() // Close immediately after reading
+		if err != nil && !isFieldTypeError(err) {
+			return nil, err
 		}
-
-		allResults = append(allResults, results...)
-
-		// Clear results slice to help GC
+		results := response.Context.C.Results
 		for i := range results {
-			results[i] = nil
+			results[i].parseLapTime()
 		}
+		return results, nil
+	}
+}
 
-		// If we got fewer results than the page size, we're done
-		if len(results) < pageSize {
-			break
+// dedupeListingEntries drops entries repeated across page boundaries (the listing shifts when laps are
+// set during the fetch), keeping the first occurrence of each driver and lap time
+// Drivers are told apart by their RaceRoom ID, or by name when the API sent none. Returns the number dropped.
+func dedupeListingEntries(entries []LeaderboardEntry) ([]LeaderboardEntry, int) {
+	type entryKey struct{ driver, laptime string }
+	seen := make(map[entryKey]bool, len(entries))
+	kept := entries[:0]
+	for _, entry := range entries {
+		driver := "id:" + string(entry.Driver.ID)
+		if entry.Driver.ID == "" {
+			driver = "name:" + entry.Driver.Name
 		}
-
-		start += pageSize
+		key := entryKey{driver, entry.LapTime}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, entry)
 	}
-
-	duration := time.Since(startTime)
-	return allResults, duration, nil
+	return kept, len(entries) - len(kept)
 }
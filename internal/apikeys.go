@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// APIKeysFile persists issued API keys (only key hashes are stored)
+const APIKeysFile = "cache/api_keys.json"
+
+const (
+	apiKeyPrefix        = "r3e_"
+	apiKeyDisplayLength = len(apiKeyPrefix) + 6 // Visible part of a key in listings
+	defaultKeyRateLimit = 600                   // Requests per minute for keys without their own limit
+)
+
+// APIKey is an issued key; the secret itself is only returned once at creation
+type APIKey struct {
+	ID        string    `json:"id"`         // Visible key prefix, e.g. "r3e_1a2b3c"
+	Hash      string    `json:"hash"`       // SHA-256 of the full key
+	Name      string    `json:"name"`       // Owner / purpose
+	RateLimit int       `json:"rate_limit"` // Requests per rate limit window (0 uses the configured default)
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKeyUsage counts requests made with a key since the server started
+type APIKeyUsage struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	RateLimit int        `json:"rate_limit"`
+	Requests  int64      `json:"requests"`
+	Rejected  int64      `json:"rejected"` // Requests refused with 429
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+}
+
+// APIKeyStore holds issued keys and their usage counters
+type APIKeyStore struct {
+	mu     sync.Mutex
+	path   string
+	keys   map[string]*APIKey // By hash
+	usage  map[string]*APIKeyUsage
+	loaded time.Time
+}
+
+// LoadAPIKeyStore loads the key store from path; a missing file gives an empty store
+func LoadAPIKeyStore(path string) *APIKeyStore {
+	store := &APIKeyStore{
+		path:   path,
+		keys:   make(map[string]*APIKey),
+		usage:  make(map[string]*APIKeyUsage),
+		loaded: time.Now(),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			httpLog.Warnf("⚠️ Failed to read API keys from %s: %v", path, err)
+		}
+		return store
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		httpLog.Warnf("⚠️ Invalid API key file %s: %v", path, err)
+		return store
+	}
+	for i := range keys {
+		store.keys[keys[i].Hash] = &keys[i]
+	}
+	httpLog.Infof("🔑 Loaded %d API keys from %s", len(keys), path)
+	return store
+}
+
+// Issue creates and persists a new key, returning the full secret (shown only once)
+func (ks *APIKeyStore) Issue(name string, rateLimit int) (string, APIKey, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", APIKey{}, err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(b)
+
+	key := APIKey{
+		ID:        secret[:apiKeyDisplayLength],
+		Hash:      hashAPIKey(secret),
+		Name:      name,
+		RateLimit: rateLimit,
+		CreatedAt: time.Now(),
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.Hash] = &key
+	if err := ks.saveLocked(); err != nil {
+		delete(ks.keys, key.Hash)
+		return "", APIKey{}, err
+	}
+	httpLog.Infof("🔑 Issued API key %s (%s)", key.ID, name)
+	return secret, key, nil
+}
+
+// Revoke deletes the key with the given ID
+func (ks *APIKeyStore) Revoke(id string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for hash, key := range ks.keys {
+		if key.ID == id {
+			delete(ks.keys, hash)
+			delete(ks.usage, hash)
+			httpLog.Infof("🔑 Revoked API key %s (%s)", key.ID, key.Name)
+			return ks.saveLocked()
+		}
+	}
+	return fmt.Errorf("api key %s not found", id)
+}
+
+// Lookup returns the key matching a presented secret
+func (ks *APIKeyStore) Lookup(secret string) (APIKey, bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.keys[hashAPIKey(secret)]
+	if !ok {
+		return APIKey{}, false
+	}
+	return *key, true
+}
+
+// RecordRequest counts a request made with key; rejected marks it as rate limited
+func (ks *APIKeyStore) RecordRequest(key APIKey, rejected bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	usage, ok := ks.usage[key.Hash]
+	if !ok {
+		usage = &APIKeyUsage{}
+		ks.usage[key.Hash] = usage
+	}
+	now := time.Now()
+	usage.Requests++
+	if rejected {
+		usage.Rejected++
+	}
+	usage.LastUsed = &now
+}
+
+// Usage returns per-key usage since startup, busiest keys first
+// defaultLimit is reported for keys without their own rate limit
+func (ks *APIKeyStore) Usage(defaultLimit int) ([]APIKeyUsage, time.Time) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	report := make([]APIKeyUsage, 0, len(ks.keys))
+	for hash, key := range ks.keys {
+		entry := APIKeyUsage{}
+		if usage, ok := ks.usage[hash]; ok {
+			entry = *usage
+		}
+		entry.ID = key.ID
+		entry.Name = key.Name
+		entry.RateLimit = key.EffectiveRateLimit(defaultLimit)
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Requests != report[j].Requests {
+			return report[i].Requests > report[j].Requests
+		}
+		return report[i].ID < report[j].ID
+	})
+	return report, ks.loaded
+}
+
+// EffectiveRateLimit returns the key's own limit, or defaultLimit when it has none
+func (k APIKey) EffectiveRateLimit(defaultLimit int) int {
+	if k.RateLimit > 0 {
+		return k.RateLimit
+	}
+	return defaultLimit
+}
+
+// saveLocked writes all keys to disk atomically; ks.mu must be held
+func (ks *APIKeyStore) saveLocked() error {
+	keys := make([]APIKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(ks.path, data)
+}
+
+// hashAPIKey returns the hex SHA-256 of a key secret
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
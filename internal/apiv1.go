@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersion is the current version of the JSON API, mounted at <prefix>/v1
+const APIVersion = "v1"
+
+// Envelope wraps every JSON response of a versioned route
+type Envelope struct {
+	Data  interface{}    `json:"data"`  // The response body; null on errors
+	Meta  EnvelopeMeta   `json:"meta"`  // Request metadata
+	Error *EnvelopeError `json:"error"` // Set on 4xx/5xx responses
+}
+
+// EnvelopeMeta describes the request a versioned response answers
+type EnvelopeMeta struct {
+	APIVersion  string    `json:"api_version"`
+	RequestID   string    `json:"request_id,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// EnvelopeError describes a failed versioned request
+type EnvelopeError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"` // Snake-cased HTTP status text, e.g. "not_found"
+	Message string `json:"message"`
+}
+
+// apiRoute is an endpoint of the JSON API, served under <prefix>/v1 and, while enabled, at its legacy path
+type apiRoute struct {
+	path    string
+	handler http.HandlerFunc
+	raw     bool // Writes its own format (SSE, WebSocket, GraphQL, pprof) and is not enveloped
+}
+
+// envelopeWriter marks the response of a versioned route; writeJSON wraps bodies written through it in an Envelope
+type envelopeWriter struct {
+	http.ResponseWriter
+	requestID string
+	base      string // Versioned mount path, for URLs in responses
+}
+
+// envelope wraps a response body written with status
+func (ew *envelopeWriter) envelope(status int, v interface{}) Envelope {
+	envelope := Envelope{Meta: EnvelopeMeta{APIVersion: APIVersion, RequestID: ew.requestID, GeneratedAt: time.Now().UTC()}}
+	if status < http.StatusBadRequest {
+		envelope.Data = v
+		return envelope
+	}
+	message := http.StatusText(status)
+	if body, ok := v.(ErrorResponse); ok {
+		message = body.Error
+	}
+	envelope.Error = &EnvelopeError{
+		Status:  status,
+		Code:    strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		Message: message,
+	}
+	return envelope
+}
+
+// versionPath returns the versioned path of a legacy API path
+func (s *APIServer) versionPath(legacyPath string) string {
+	return s.prefix + "/" + APIVersion + strings.TrimPrefix(legacyPath, s.prefix)
+}
+
+// versioned serves a route under <prefix>/v1: the handler sees the legacy path,
+// and unless the route is raw its JSON responses are enveloped
+func (s *APIServer) versioned(route apiRoute, next http.Handler) http.Handler {
+	base := s.prefix + "/" + APIVersion
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = s.prefix + strings.TrimPrefix(r.URL.Path, base)
+		r2.URL.RawPath = ""
+		if !route.raw {
+			w = &envelopeWriter{ResponseWriter: w, requestID: RequestID(r.Context()), base: base}
+		}
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// deprecated serves a route at its legacy path, pointing clients at the versioned path
+// with Deprecation, Link and (when configured) Sunset headers
+func (s *APIServer) deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+s.versionPath(r.URL.Path)+`>; rel="successor-version"`)
+		if !s.legacySunset.IsZero() {
+			w.Header().Set("Sunset", s.legacySunset.Format(http.TimeFormat))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseSunset parses the legacy route sunset date (YYYY-MM-DD); empty or invalid dates disable the Sunset header
+func parseSunset(date string) time.Time {
+	if date == "" {
+		return time.Time{}
+	}
+	sunset, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		configLog.Warnf("⚠️ Invalid server.legacy_routes.sunset %q (expected YYYY-MM-DD): %v", date, err)
+		return time.Time{}
+	}
+	return sunset
+}
@@ -1,13 +1,17 @@
 package internal
 
 import (
+	"bufio"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -16,32 +20,114 @@ type TrackInfo struct {
 	Name    string
 	TrackID string
 	ClassID string
-	Data    []map[string]interface{}
+	Data    []LeaderboardEntry
 }
 
 // CachedTrackData represents cached track data with metadata
 type CachedTrackData struct {
-	TrackInfo  TrackInfo `json:"track_info"`
-	CachedAt   time.Time `json:"cached_at"`
-	TrackName  string    `json:"track_name"`
-	TrackID    string    `json:"track_id"`
-	EntryCount int       `json:"entry_count"`
+	TrackInfo     TrackInfo `json:"track_info"`
+	CachedAt      time.Time `json:"cached_at"`
+	TrackName     string    `json:"track_name"`
+	TrackID       string    `json:"track_id"`
+	EntryCount    int       `json:"entry_count"`
+	ContentHash   string    `json:"content_hash,omitempty"`   // See HashEntries; also stored in the gzip header
+	FormatVersion int       `json:"format_version,omitempty"` // CacheFormatVersion; absent in files written before checksums
+}
+
+// CacheFormatVersion is the version of the cache file format written by SaveTrackData
+// Bump it when the JSON of LeaderboardEntry changes, since content_hash is verified against the re-encoded entries
+const CacheFormatVersion = 2
+
+// Cache load errors; loadCacheFile wraps every decode, checksum and version error in ErrCacheCorrupt
+var (
+	ErrCacheCorrupt  = errors.New("corrupt cache file")
+	ErrCacheChecksum = errors.New("content hash mismatch")
+	ErrCacheVersion  = errors.New("unsupported cache format version")
+)
+
+// defaultCacheMaxAge is used when no valid max age is configured
+const defaultCacheMaxAge = 24 * time.Hour
+
+// cacheTTLRule is a validated CacheTTLRule with set lookups
+type cacheTTLRule struct {
+	name    string
+	tracks  map[string]bool
+	classes map[string]bool
+	maxAge  time.Duration
+}
+
+// cacheTTL holds the configured max ages (set once at startup by SetCacheConfig)
+var cacheTTL = struct {
+	maxAge time.Duration
+	rules  []cacheTTLRule
+}{maxAge: defaultCacheMaxAge}
+
+// SetCacheConfig sets the default cache max age, the per-track/class overrides, the size limit and pruning
+// Must be called before background loading starts; invalid rules are logged and skipped
+func SetCacheConfig(cfg CacheConfig) {
+	cacheTTL.maxAge = defaultCacheMaxAge
+	if cfg.MaxAgeHours > 0 {
+		cacheTTL.maxAge = time.Duration(cfg.MaxAgeHours * float64(time.Hour))
+	}
+
+	cacheTTL.rules = make([]cacheTTLRule, 0, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.MaxAgeHours <= 0 {
+			cacheLog.Warnf("⚠️ Ignoring cache TTL %s: max_age_hours must be positive", name)
+			continue
+		}
+		maxAge := time.Duration(rule.MaxAgeHours * float64(time.Hour))
+		cacheTTL.rules = append(cacheTTL.rules, cacheTTLRule{
+			name:    name,
+			tracks:  stringSet(rule.Tracks),
+			classes: stringSet(rule.Classes),
+			maxAge:  maxAge,
+		})
+		cacheLog.Infof("⏳ Cache TTL %s: %d track(s), %d class(es) → %v", name, len(rule.Tracks), len(rule.Classes), maxAge)
+	}
+	setCacheMaxSize(cfg.MaxSizeMB)
+	cachePrune = cfg.Prune
+}
+
+// stringSet builds a lookup set from a list; nil for an empty list
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.TrimSpace(value)] = true
+	}
+	return set
+}
+
+// CacheMaxAge returns the max age of a combination: the first matching TTL rule, or the default
+func CacheMaxAge(trackID, classID string) time.Duration {
+	for _, rule := range cacheTTL.rules {
+		if (rule.tracks == nil || rule.tracks[trackID]) && (rule.classes == nil || rule.classes[classID]) {
+			return rule.maxAge
+		}
+	}
+	return cacheTTL.maxAge
 }
 
 // DataCache handles loading and saving track data to disk
 type DataCache struct {
 	cacheDir     string
 	tempCacheDir string
-	maxAge       time.Duration
 	useTemp      bool // Flag to use temp cache for writes
 }
 
 // NewDataCache creates a new data cache manager
+// Cached combinations expire after CacheMaxAge (24 hours unless configured)
 func NewDataCache() *DataCache {
 	return &DataCache{
 		cacheDir:     "cache",
 		tempCacheDir: "cache_temp",
-		maxAge:       24 * time.Hour, // Cache expires after 24 hours
 		useTemp:      false,
 	}
 }
@@ -51,7 +137,6 @@ func NewTempDataCache() *DataCache {
 	return &DataCache{
 		cacheDir:     "cache",
 		tempCacheDir: "cache_temp",
-		maxAge:       24 * time.Hour,
 		useTemp:      true,
 	}
 }
@@ -75,16 +160,62 @@ func (dc *DataCache) CountCachedCombinations() int {
 	return len(files)
 }
 
+// CountCachedCombinationsByTrack returns the number of cached combinations per track ID
+func (dc *DataCache) CountCachedCombinationsByTrack() map[string]int {
+	counts := make(map[string]int)
+	pattern := filepath.Join(dc.cacheDir, "track_*", "class_*.json.gz")
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return counts
+	}
+	for _, file := range files {
+		trackDir := filepath.Base(filepath.Dir(file))
+		counts[strings.TrimPrefix(trackDir, "track_")]++
+	}
+	return counts
+}
+
 // GetCacheFileName returns the cache filename for a track+class combination
 func (dc *DataCache) GetCacheFileName(trackID, classID string) string {
 	baseDir := dc.cacheDir
 	if dc.useTemp {
 		baseDir = dc.tempCacheDir
 	}
+	return cacheFileIn(baseDir, trackID, classID)
+}
+
+// cacheFileIn returns the cache filename for a combination under baseDir
+func cacheFileIn(baseDir, trackID, classID string) string {
 	trackDir := filepath.Join(baseDir, fmt.Sprintf("track_%s", trackID))
 	return filepath.Join(trackDir, fmt.Sprintf("class_%s.json.gz", classID))
 }
 
+// HashEntries returns a content hash of leaderboard entries used to detect unchanged fetches
+func HashEntries(entries []LeaderboardEntry) string {
+	hasher := sha256.New()
+	if err := json.NewEncoder(hasher).Encode(entries); err != nil {
+		return ""
+	}
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// readContentHash returns the content hash stored in a cache file's gzip header
+// Only the header is read, so this is cheap even for large combinations
+func readContentHash(filename string) string {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return ""
+	}
+	defer gzReader.Close()
+	return gzReader.Header.Comment
+}
+
 // IsCacheValid checks if cached data exists and is not expired
 func (dc *DataCache) IsCacheValid(trackID, classID string) bool {
 	filename := dc.GetCacheFileName(trackID, classID)
@@ -96,7 +227,7 @@ func (dc *DataCache) IsCacheValid(trackID, classID string) bool {
 	}
 
 	// Check if file is not too old
-	return time.Since(info.ModTime()) < dc.maxAge
+	return time.Since(info.ModTime()) < CacheMaxAge(trackID, classID)
 }
 
 // CacheExists checks if cached data exists (regardless of age)
@@ -106,14 +237,14 @@ func (dc *DataCache) CacheExists(trackID, classID string) bool {
 	return err == nil
 }
 
-// IsCacheExpired checks if cache exists but is older than maxAge
+// IsCacheExpired checks if cache exists but is older than its max age (see CacheMaxAge)
 func (dc *DataCache) IsCacheExpired(trackID, classID string) bool {
 	filename := dc.GetCacheFileName(trackID, classID)
 	info, err := os.Stat(filename)
 	if err != nil {
 		return false // doesn't exist, so not "expired"
 	}
-	return time.Since(info.ModTime()) >= dc.maxAge
+	return time.Since(info.ModTime()) >= CacheMaxAge(trackID, classID)
 }
 
 // GetCacheAge returns the age of the cache file, or -1 if it doesn't exist
@@ -146,11 +277,13 @@ func (dc *DataCache) SaveTrackData(trackInfo TrackInfo) error {
 	}
 
 	cached := CachedTrackData{
-		TrackInfo:  trackInfo,
-		CachedAt:   time.Now(),
-		TrackName:  trackInfo.Name,
-		TrackID:    trackInfo.TrackID,
-		EntryCount: len(trackInfo.Data),
+		TrackInfo:     trackInfo,
+		CachedAt:      time.Now(),
+		TrackName:     trackInfo.Name,
+		TrackID:       trackInfo.TrackID,
+		EntryCount:    len(trackInfo.Data),
+		ContentHash:   HashEntries(trackInfo.Data),
+		FormatVersion: CacheFormatVersion,
 	}
 
 	filename := dc.GetCacheFileName(trackInfo.TrackID, trackInfo.ClassID)
@@ -162,8 +295,9 @@ func (dc *DataCache) SaveTrackData(trackInfo TrackInfo) error {
 		return err
 	}
 
-	// Create gzip writer
+	// Create gzip writer; the header comment carries the content hash for cheap change checks
 	gzWriter := gzip.NewWriter(file)
+	gzWriter.Comment = cached.ContentHash
 	encoder := json.NewEncoder(gzWriter)
 	encoder.SetIndent("", "  ")
 
@@ -187,6 +321,11 @@ func (dc *DataCache) SaveTrackData(trackInfo TrackInfo) error {
 		return err
 	}
 
+	// Direct writes to the main cache (on-demand fetches) bypass promotion, so check for a record here
+	if !dc.useTemp {
+		detectWorldRecord(trackInfo.TrackID, trackInfo.ClassID, filename, tempFile)
+	}
+
 	// Atomically rename temp file to final file
 	// On error, the old cache file remains untouched
 	if err := os.Rename(tempFile, filename); err != nil {
@@ -199,13 +338,70 @@ func (dc *DataCache) SaveTrackData(trackInfo TrackInfo) error {
 		}
 	}
 
+	// Temp cache files are archived and the size limit applied when they are promoted
+	if !dc.useTemp {
+		archiveSnapshot(trackInfo.TrackID, trackInfo.ClassID, filename)
+		dc.EnforceCacheLimit()
+	}
 	return nil
 }
 
+// SaveIfChanged saves fetched data unless the main cache already holds identical entries
+// In that case the existing main cache file is only touched to renew its age, so nothing
+// is rewritten or promoted. Returns whether the data changed.
+func (dc *DataCache) SaveIfChanged(trackInfo TrackInfo) (bool, error) {
+	mainFile := cacheFileIn(dc.cacheDir, trackInfo.TrackID, trackInfo.ClassID)
+	if existing := readContentHash(mainFile); existing != "" && existing == HashEntries(trackInfo.Data) {
+		now := time.Now()
+		if err := os.Chtimes(mainFile, now, now); err == nil {
+			return false, nil
+		}
+		// Fall through to a normal save if the file could not be touched
+	}
+	return true, dc.SaveTrackData(trackInfo)
+}
+
+// changeNote returns a log suffix for unchanged fetches
+func changeNote(changed bool) string {
+	if changed {
+		return ""
+	}
+	return " (unchanged)"
+}
+
 // LoadTrackData loads track data from cache
+// The file is decoded as a stream: entries are converted one at a time into
+// LeaderboardEntry values instead of buffering and decoding the whole document at once
+// Corrupt files are moved to the quarantine directory, so the combination counts as missing and is refetched
 func (dc *DataCache) LoadTrackData(trackID, classID string) (TrackInfo, error) {
 	filename := dc.GetCacheFileName(trackID, classID)
+	trackInfo, err := loadCacheFile(filename)
+	if errors.Is(err, ErrCacheCorrupt) {
+		dc.quarantine(filename, trackID, classID, err)
+	}
+	return trackInfo, err
+}
 
+// quarantine moves a corrupt cache file to cache/quarantine for inspection
+func (dc *DataCache) quarantine(filename, trackID, classID string, reason error) {
+	dir := filepath.Join(dc.cacheDir, "quarantine")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cacheLog.Warnf("⚠️ Failed to create quarantine directory: %v", err)
+		return
+	}
+	target := filepath.Join(dir, fmt.Sprintf("track_%s_class_%s_%s.json.gz", trackID, classID, time.Now().UTC().Format("20060102T150405Z")))
+	if err := os.Rename(filename, target); err != nil {
+		if !os.IsNotExist(err) { // Already quarantined by a concurrent load
+			cacheLog.Warnf("⚠️ Failed to quarantine %s: %v", filename, err)
+		}
+		return
+	}
+	cacheLog.Warnf("☣️ Quarantined %s → %s: %v", filename, target, reason)
+}
+
+// loadCacheFile loads the track data of a cache (or snapshot) file
+// Errors other than failing to open the file wrap ErrCacheCorrupt
+func loadCacheFile(filename string) (TrackInfo, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return TrackInfo{}, err
@@ -215,16 +411,160 @@ func (dc *DataCache) LoadTrackData(trackID, classID string) (TrackInfo, error) {
 	// Create gzip reader
 	gzReader, err := gzip.NewReader(file)
 	if err != nil {
-		return TrackInfo{}, err
+		return TrackInfo{}, fmt.Errorf("%s: %w: %w", filename, ErrCacheCorrupt, err)
 	}
 	defer gzReader.Close()
 
-	var cached CachedTrackData
-	if err := json.NewDecoder(gzReader).Decode(&cached); err != nil {
-		return TrackInfo{}, err
+	trackInfo, err := decodeCachedTrackData(json.NewDecoder(bufio.NewReader(gzReader)))
+	if err != nil {
+		return TrackInfo{}, fmt.Errorf("%s: %w: %w", filename, ErrCacheCorrupt, err)
 	}
+	return trackInfo, nil
+}
 
-	return cached.TrackInfo, nil
+// decodeCachedTrackData walks a CachedTrackData document token by token
+// Only track_info is materialized; other top-level fields are skipped
+// Files of the current format version are verified against their content hash
+func decodeCachedTrackData(dec *json.Decoder) (TrackInfo, error) {
+	var trackInfo TrackInfo
+	var contentHash string
+	var formatVersion int
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return trackInfo, err
+	}
+	for dec.More() {
+		key, err := readObjectKey(dec)
+		if err != nil {
+			return trackInfo, err
+		}
+		switch key {
+		case "track_info":
+		case "content_hash":
+			if err := dec.Decode(&contentHash); err != nil {
+				return trackInfo, err
+			}
+			continue
+		case "format_version":
+			if err := dec.Decode(&formatVersion); err != nil {
+				return trackInfo, err
+			}
+			continue
+		default:
+			if err := skipValue(dec); err != nil {
+				return trackInfo, err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return trackInfo, err
+		}
+		for dec.More() {
+			field, err := readObjectKey(dec)
+			if err != nil {
+				return trackInfo, err
+			}
+			switch field {
+			case "Name":
+				err = dec.Decode(&trackInfo.Name)
+			case "TrackID":
+				err = dec.Decode(&trackInfo.TrackID)
+			case "ClassID":
+				err = dec.Decode(&trackInfo.ClassID)
+			case "Data":
+				trackInfo.Data, err = decodeEntries(dec)
+			default:
+				err = skipValue(dec)
+			}
+			if err != nil {
+				return trackInfo, err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return trackInfo, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return trackInfo, err
+	}
+
+	if formatVersion > CacheFormatVersion {
+		return trackInfo, fmt.Errorf("%w %d (this build reads up to %d)", ErrCacheVersion, formatVersion, CacheFormatVersion)
+	}
+	if formatVersion == CacheFormatVersion && contentHash != "" {
+		if actual := HashEntries(trackInfo.Data); actual != contentHash {
+			return trackInfo, fmt.Errorf("%w: stored %s, entries hash to %s", ErrCacheChecksum, contentHash, actual)
+		}
+	}
+	return trackInfo, nil
+}
+
+// decodeEntries decodes a JSON array of leaderboard entries one element at a time
+// A null array yields no entries; fields with unexpected types are left empty
+func decodeEntries(dec *json.Decoder) ([]LeaderboardEntry, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected entries array, got %v", tok)
+	}
+
+	entries := make([]LeaderboardEntry, 0, 64)
+	for dec.More() {
+		var entry LeaderboardEntry
+		if err := dec.Decode(&entry); err != nil && !isFieldTypeError(err) {
+			return nil, err
+		}
+		entry.parseLapTime()
+		entries = append(entries, entry)
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// isFieldTypeError reports whether a decode error only concerns a mistyped field
+// encoding/json keeps decoding the rest of the value in that case
+func isFieldTypeError(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &typeErr)
+}
+
+// expectDelim reads the next token and checks it is the given delimiter
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// readObjectKey reads the next object key
+func readObjectKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", tok)
+	}
+	return key, nil
+}
+
+// skipValue consumes the next JSON value without materializing it
+func skipValue(dec *json.Decoder) error {
+	var raw json.RawMessage
+	return dec.Decode(&raw)
 }
 
 // LoadOrFetchTrackData loads from cache or fetches fresh data
@@ -238,7 +578,7 @@ func (dc *DataCache) LoadOrFetchTrackData(ctx context.Context, apiClient *APICli
 			if err == nil {
 				return trackInfo, true, nil // true = loaded from cache
 			} else {
-				log.Printf("⚠️ Cache file exists but failed to load: %s + %s: %v", trackName, className, err)
+				cacheLog.Warnf("⚠️ Cache file exists but failed to load: %s + %s: %v", trackName, className, err)
 			}
 		} else if dc.IsCacheValid(trackID, classID) {
 			// Load only non-expired cache
@@ -246,15 +586,18 @@ func (dc *DataCache) LoadOrFetchTrackData(ctx context.Context, apiClient *APICli
 			if err == nil {
 				return trackInfo, true, nil // true = loaded from cache
 			} else {
-				log.Printf("⚠️ Cache file exists but failed to load: %s + %s: %v", trackName, className, err)
+				cacheLog.Warnf("⚠️ Cache file exists but failed to load: %s + %s: %v", trackName, className, err)
 			}
 		}
 	}
 
 	// Cache miss or expired - fetch fresh data
-	fetchCtx, fetchCancel := context.WithTimeout(ctx, 120*time.Second)
+	fetchCtx, fetchCancel := context.WithTimeout(ctx, apiClient.combinationTimeout)
 	data, duration, err := apiClient.FetchLeaderboardData(fetchCtx, trackID, classID)
 	fetchCancel() // Always cancel to release resources
+	track, class := TrackConfig{Name: trackName, TrackID: trackID}, CarClassConfig{Name: className, ClassID: classID}
+	recordFetchOutcome(ctx, track, class, err)
+	recordFetchStats(ctx, track, class, len(data), duration, err)
 	if err != nil {
 		return TrackInfo{}, false, err
 	}
@@ -266,15 +609,16 @@ func (dc *DataCache) LoadOrFetchTrackData(ctx context.Context, apiClient *APICli
 		Data:    data,
 	}
 
-	// Save to cache
-	if err := dc.SaveTrackData(trackInfo); err != nil {
-		log.Printf("⚠️ Warning: Could not cache %s + %s: %v", trackName, className, err)
+	// Save to cache (skipped when identical to what is already cached)
+	changed, err := dc.SaveIfChanged(trackInfo)
+	if err != nil {
+		cacheLog.Warnf("⚠️ Warning: Could not cache %s + %s: %v", trackName, className, err)
 	}
 
 	if len(data) > 0 {
-		log.Printf("🌐 %s + %s: %.2fs → %d entries [track=%s, class=%s]", trackName, className, duration.Seconds(), len(data), trackID, classID)
+		cacheLog.Infof("🌐 %s + %s: %.2fs → %d entries%s [track=%s, class=%s]", trackName, className, duration.Seconds(), len(data), changeNote(changed), trackID, classID)
 	} else {
-		log.Printf("🌐 %s + %s: %.2fs → no data [track=%s, class=%s]", trackName, className, duration.Seconds(), trackID, classID)
+		cacheLog.Infof("🌐 %s + %s: %.2fs → no data [track=%s, class=%s]", trackName, className, duration.Seconds(), trackID, classID)
 	}
 	return trackInfo, false, nil // false = fetched fresh
 }
@@ -293,44 +637,74 @@ func (dc *DataCache) ClearTempCache() error {
 // This ensures the index always sees consistent data
 // Returns the number of files promoted and any critical error
 func (dc *DataCache) PromoteTempCache() (int, error) {
+	_, span := StartSpan(context.Background(), "cache.promote")
+	defer span.End()
+
+	promoted, err := dc.promoteTempCache()
+	if promoted > 0 {
+		if cachePrune.Enabled {
+			if result, pruneErr := dc.PruneStaleCombinations(cachePrune.DryRun); pruneErr == nil {
+				span.SetAttrs("pruned", result.Removed)
+			}
+		}
+		span.SetAttrs("evicted", dc.EnforceCacheLimit())
+	}
+	span.SetAttrs("promoted", promoted)
+	span.SetError(err)
+	return promoted, err
+}
+
+// promoteTempCache moves every temp cache file into the main cache
+func (dc *DataCache) promoteTempCache() (int, error) {
+	// Another instance may have taken over the cache (stale lock); its promotions must not interleave with ours
+	if err := verifyHeldLock(); err != nil {
+		cacheLog.Errorf("❌ Not promoting temp cache: %v", err)
+		return 0, err
+	}
+	// Entries of a refresh whose responses lack expected fields would replace good leaderboards
+	if err := schemaDriftError(); err != nil {
+		cacheLog.Errorf("❌ Not promoting temp cache: %v", err)
+		return 0, err
+	}
+
 	// Get absolute paths for diagnostics
 	absTemp, _ := filepath.Abs(dc.tempCacheDir)
 	absCache, _ := filepath.Abs(dc.cacheDir)
 	cwd, _ := os.Getwd()
 
-	log.Printf("🔍 PromoteTempCache: cwd=%s, tempCacheDir=%s (abs: %s), cacheDir=%s (abs: %s)",
+	cacheLog.Debugf("🔍 PromoteTempCache: cwd=%s, tempCacheDir=%s (abs: %s), cacheDir=%s (abs: %s)",
 		cwd, dc.tempCacheDir, absTemp, dc.cacheDir, absCache)
 
 	// Check if temp cache exists
 	if _, err := os.Stat(dc.tempCacheDir); os.IsNotExist(err) {
-		log.Printf("ℹ️ No temp cache directory to promote (os.Stat failed on %s)", dc.tempCacheDir)
+		cacheLog.Infof("ℹ️ No temp cache directory to promote (os.Stat failed on %s)", dc.tempCacheDir)
 		return 0, nil
 	} else if err != nil {
-		log.Printf("⚠️ Error checking temp cache dir %s: %v", dc.tempCacheDir, err)
+		cacheLog.Warnf("⚠️ Error checking temp cache dir %s: %v", dc.tempCacheDir, err)
 		return 0, nil
 	}
 
 	// Read all temp cache entries
 	tempFiles, err := filepath.Glob(filepath.Join(dc.tempCacheDir, "track_*", "class_*.json.gz"))
 	if err != nil {
-		log.Printf("⚠️ Failed to list temp cache files: %v", err)
+		cacheLog.Warnf("⚠️ Failed to list temp cache files: %v", err)
 		return 0, fmt.Errorf("failed to list temp cache files: %w", err)
 	}
 
 	if len(tempFiles) == 0 {
-		log.Println("ℹ️ No temp cache files to promote")
+		cacheLog.Infof("ℹ️ No temp cache files to promote")
 		// Clean up empty temp cache directory
 		if err := dc.ClearTempCache(); err != nil {
-			log.Printf("⚠️ Warning: Failed to clean up empty temp cache: %v", err)
+			cacheLog.Warnf("⚠️ Warning: Failed to clean up empty temp cache: %v", err)
 		}
 		return 0, nil
 	}
 
-	log.Printf("🔄 Promoting %d temp cache files to main cache...", len(tempFiles))
+	cacheLog.Infof("🔄 Promoting %d temp cache files to main cache...", len(tempFiles))
 
 	// Ensure main cache directory exists
 	if err := os.MkdirAll(dc.cacheDir, 0755); err != nil {
-		log.Printf("⚠️ Failed to create main cache directory: %v", err)
+		cacheLog.Warnf("⚠️ Failed to create main cache directory: %v", err)
 		return 0, fmt.Errorf("failed to create cache dir: %w", err)
 	}
 
@@ -342,7 +716,7 @@ func (dc *DataCache) PromoteTempCache() (int, error) {
 		// Get relative path from temp cache dir
 		relPath, err := filepath.Rel(dc.tempCacheDir, tempFile)
 		if err != nil {
-			log.Printf("⚠️ Failed to get relative path for %s: %v", tempFile, err)
+			cacheLog.Warnf("⚠️ Failed to get relative path for %s: %v", tempFile, err)
 			failed++
 			continue
 		}
@@ -352,42 +726,52 @@ func (dc *DataCache) PromoteTempCache() (int, error) {
 
 		// Ensure destination directory exists
 		if err := os.MkdirAll(filepath.Dir(destFile), 0755); err != nil {
-			log.Printf("⚠️ Failed to create directory for %s: %v", destFile, err)
+			cacheLog.Warnf("⚠️ Failed to create directory for %s: %v", destFile, err)
 			failed++
 			continue
 		}
 
+		trackID, classID, isCombination := combinationFromCachePath(relPath)
+		if isCombination {
+			detectWorldRecord(trackID, classID, destFile, tempFile)
+		}
+
 		// On Windows, os.Rename fails if destination exists and is open
 		// Remove destination first to avoid conflicts (old cache is replaced)
 		if _, err := os.Stat(destFile); err == nil {
 			// Destination exists, remove it first
 			if err := os.Remove(destFile); err != nil {
-				log.Printf("⚠️ Failed to remove old cache file %s: %v (file may be in use)", destFile, err)
+				cacheLog.Warnf("⚠️ Failed to remove old cache file %s: %v (file may be in use)", destFile, err)
 				// Don't fail - try to rename anyway, might work
 			}
 		}
 
 		// Move (rename) the file - atomic operation on same filesystem
 		if err := os.Rename(tempFile, destFile); err != nil {
-			log.Printf("⚠️ Failed to promote %s to %s: %v", filepath.Base(tempFile), filepath.Base(destFile), err)
+			cacheLog.Warnf("⚠️ Failed to promote %s to %s: %v", filepath.Base(tempFile), filepath.Base(destFile), err)
 			failed++
 			// Don't break - continue with other files
 			continue
 		}
 		promoted++
+		if isCombination {
+			archiveSnapshot(trackID, classID, destFile)
+		}
 	}
 
 	// Log results
+	eventBroker.Publish(EventCachePromoted, map[string]int{"promoted": promoted, "failed": failed})
 	if failed > 0 {
-		log.Printf("⚠️ Cache promotion completed with issues: %d files promoted, %d failed", promoted, failed)
+		cacheLog.Warnf("⚠️ Cache promotion completed with issues: %d files promoted, %d failed", promoted, failed)
+		publishError("cache", fmt.Errorf("%d cache files failed to promote", failed))
 	} else {
-		log.Printf("✅ Successfully promoted %d cache files to main cache", promoted)
+		cacheLog.Infof("✅ Successfully promoted %d cache files to main cache", promoted)
 	}
 
 	// Clean up temp cache directory and empty track directories
 	// This is best-effort cleanup, don't fail if it doesn't work
 	if err := dc.ClearTempCache(); err != nil {
-		log.Printf("⚠️ Warning: Failed to clean up temp cache directory: %v", err)
+		cacheLog.Warnf("⚠️ Warning: Failed to clean up temp cache directory: %v", err)
 		// Not a critical error - old temp files won't cause issues
 	}
 
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cache file states reported by ValidateCache
+const (
+	CacheFileOK          = "ok"
+	CacheFileUnreadable  = "unreadable"          // Can't be opened or read
+	CacheFileCorruptGzip = "corrupt_gzip"        // Not gzip, or a damaged stream or checksum
+	CacheFileTruncated   = "truncated"           // The gzip stream ends early (interrupted write)
+	CacheFileInvalidJSON = "invalid_json"        // Decompresses but isn't a cache document
+	CacheFileChecksum    = "checksum_mismatch"   // Entries don't match the stored content hash
+	CacheFileVersion     = "unsupported_version" // Written by a newer format version
+	CacheFileMismatch    = "mismatch"            // Holds a different combination than its path says
+)
+
+// CacheFileStatus is the validation result of one cache file
+type CacheFileStatus struct {
+	Path    string `json:"path"`
+	TrackID string `json:"track_id"`
+	ClassID string `json:"class_id"`
+	Status  string `json:"status"`
+	Entries int    `json:"entries"`
+	Error   string `json:"error,omitempty"`   // Why the file can't be used; empty when valid
+	Deleted bool   `json:"deleted,omitempty"` // Removed so the next refresh refetches it
+}
+
+// CacheValidation summarizes a validation run over the live cache
+type CacheValidation struct {
+	Checked int               `json:"checked"`
+	Broken  int               `json:"broken"`
+	Deleted int               `json:"deleted"`
+	Files   []CacheFileStatus `json:"files"` // Ordered by path
+}
+
+// ValidateCache decompresses and decodes every file of the live cache and reports the status of each
+// With deleteBroken, files that fail are removed so the next refresh treats them as missing
+func (dc *DataCache) ValidateCache(deleteBroken bool) (CacheValidation, error) {
+	files, err := filepath.Glob(filepath.Join(dc.cacheDir, "track_*", "class_*.json.gz"))
+	if err != nil {
+		return CacheValidation{}, err
+	}
+	sort.Strings(files)
+
+	validation := CacheValidation{Files: make([]CacheFileStatus, 0, len(files))}
+	for _, file := range files {
+		status := validateCacheFile(file)
+		validation.Checked++
+		if status.Status != CacheFileOK {
+			validation.Broken++
+			cacheLog.Warnf("⚠️ Broken cache file %s (%s): %s", file, status.Status, status.Error)
+			if deleteBroken {
+				if err := os.Remove(file); err != nil {
+					cacheLog.Warnf("⚠️ Failed to delete %s: %v", file, err)
+				} else {
+					status.Deleted = true
+					validation.Deleted++
+				}
+			}
+		}
+		validation.Files = append(validation.Files, status)
+	}
+
+	cacheLog.Infof("🩺 Cache validation: %d files checked, %d broken, %d deleted", validation.Checked, validation.Broken, validation.Deleted)
+	return validation, nil
+}
+
+// validateCacheFile reads a cache file completely, so damage after the JSON document
+// (such as a missing gzip trailer) is found as well
+func validateCacheFile(file string) CacheFileStatus {
+	status := CacheFileStatus{
+		Path:    file,
+		TrackID: strings.TrimPrefix(filepath.Base(filepath.Dir(file)), "track_"),
+		ClassID: strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "class_"), ".json.gz"),
+		Status:  CacheFileOK,
+	}
+	fail := func(state string, err error) CacheFileStatus {
+		status.Status, status.Error = state, err.Error()
+		return status
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fail(CacheFileUnreadable, err)
+	}
+	defer f.Close()
+
+	gzReader, err := gzip.NewReader(bufio.NewReader(f))
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fail(CacheFileTruncated, err)
+	} else if err != nil {
+		return fail(CacheFileCorruptGzip, err)
+	}
+	defer gzReader.Close()
+
+	data, err := io.ReadAll(gzReader)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fail(CacheFileTruncated, err)
+	} else if err != nil {
+		return fail(CacheFileCorruptGzip, err)
+	}
+
+	trackInfo, err := decodeCachedTrackData(json.NewDecoder(bytes.NewReader(data)))
+	if errors.Is(err, ErrCacheChecksum) {
+		return fail(CacheFileChecksum, err)
+	} else if errors.Is(err, ErrCacheVersion) {
+		return fail(CacheFileVersion, err)
+	} else if err != nil {
+		return fail(CacheFileInvalidJSON, err)
+	}
+	status.Entries = len(trackInfo.Data)
+	if (trackInfo.TrackID != "" && trackInfo.TrackID != status.TrackID) || (trackInfo.ClassID != "" && trackInfo.ClassID != status.ClassID) {
+		return fail(CacheFileMismatch, errors.New("file holds track "+trackInfo.TrackID+" class "+trackInfo.ClassID))
+	}
+	return status
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cachePrune holds the pruning settings (set once at startup by SetCacheConfig)
+var cachePrune = PruneConfig{Enabled: true}
+
+// PruneResult lists the cached combinations that are no longer configured
+type PruneResult struct {
+	Checked int      `json:"checked"`
+	Stale   []string `json:"stale"`   // Cache files of combinations outside GetTracks × GetCarClasses
+	Removed int      `json:"removed"` // 0 in dry-run mode
+	DryRun  bool     `json:"dry_run"`
+}
+
+// PruneStaleCombinations removes the cache files of combinations whose track or class is no longer
+// selected (removed from the catalogs or excluded by the selection), along with their request counts
+// Snapshots are kept, since their history can't be refetched. With dryRun nothing is removed.
+func (dc *DataCache) PruneStaleCombinations(dryRun bool) (PruneResult, error) {
+	result := PruneResult{Stale: []string{}, DryRun: dryRun}
+	files, err := filepath.Glob(filepath.Join(dc.cacheDir, "track_*", "class_*.json.gz"))
+	if err != nil {
+		return result, err
+	}
+	sort.Strings(files)
+
+	tracks := make(map[string]bool)
+	for _, track := range GetTracks() {
+		tracks[track.TrackID] = true
+	}
+	classes := make(map[string]bool)
+	for _, class := range GetCarClasses() {
+		classes[class.ClassID] = true
+	}
+
+	var staleKeys []string
+	for _, file := range files {
+		result.Checked++
+		trackID, classID, ok := combinationFromCachePath(file)
+		if !ok || (tracks[trackID] && classes[classID]) {
+			continue
+		}
+		result.Stale = append(result.Stale, file)
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			cacheLog.Warnf("⚠️ Failed to prune %s: %v", file, err)
+			continue
+		}
+		result.Removed++
+		staleKeys = append(staleKeys, trackID+"_"+classID)
+		os.Remove(filepath.Dir(file)) // Only succeeds once the track directory is empty
+	}
+	forgetCombinationRequests(staleKeys)
+
+	switch {
+	case len(result.Stale) == 0:
+		cacheLog.Debugf("✂️ No stale combinations in %d cache files", result.Checked)
+	case dryRun:
+		for _, file := range result.Stale {
+			cacheLog.Infof("✂️ Would prune %s", file)
+		}
+		cacheLog.Infof("✂️ Dry run: %d of %d cached combinations are no longer configured", len(result.Stale), result.Checked)
+	default:
+		cacheLog.Infof("✂️ Pruned %d of %d cached combinations that are no longer configured", result.Removed, result.Checked)
+	}
+	return result, nil
+}
+
+// RemoveCombinations removes the cache files of refresh targets ("trackID" for every class, or
+// "trackID-classID") and returns how many were removed; the next refresh or request refetches them
+func (dc *DataCache) RemoveCombinations(targets []string) (int, error) {
+	removed := 0
+	var keys []string
+	for _, target := range targets {
+		trackID, classID, hasClass := strings.Cut(target, "-")
+		pattern := filepath.Join(dc.cacheDir, "track_"+trackID, "class_*.json.gz")
+		if hasClass {
+			pattern = cacheFileIn(dc.cacheDir, trackID, classID)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return removed, err
+		}
+		for _, file := range files {
+			if err := os.Remove(file); err != nil {
+				return removed, err
+			}
+			removed++
+			if _, fileClassID, ok := combinationFromCachePath(file); ok {
+				keys = append(keys, trackID+"_"+fileClassID)
+			}
+		}
+	}
+	forgetCombinationRequests(keys)
+	cacheLog.Infof("✂️ Removed %d cache files of %s", removed, strings.Join(targets, ", "))
+	return removed, nil
+}
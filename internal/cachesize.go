@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheUsage is the disk usage of the live cache directory, reported in status.json
+type CacheUsage struct {
+	Files        int   `json:"files"`      // Cached combinations
+	SizeBytes    int64 `json:"size_bytes"` // Everything under cache/, including indexes and reports
+	MaxSizeBytes int64 `json:"max_size_bytes,omitempty"`
+	Evicted      int   `json:"evicted,omitempty"` // Combinations evicted since startup
+}
+
+// cacheEviction holds the size limit (set once at startup by SetCacheConfig) and serializes eviction runs
+var cacheEviction = struct {
+	mu      sync.Mutex
+	maxSize int64 // 0 disables eviction
+	evicted int
+}{}
+
+// setCacheMaxSize sets the size limit of the live cache in MB
+func setCacheMaxSize(maxSizeMB float64) {
+	cacheEviction.mu.Lock()
+	defer cacheEviction.mu.Unlock()
+	cacheEviction.maxSize = 0
+	if maxSizeMB > 0 {
+		cacheEviction.maxSize = int64(maxSizeMB * 1024 * 1024)
+		cacheLog.Infof("📦 Cache size limit: %.0f MB", maxSizeMB)
+	}
+}
+
+// cachedCombination is a combination file considered for eviction
+type cachedCombination struct {
+	path          string
+	key           string // trackID_classID
+	size          int64
+	lastRequested time.Time // Zero when not requested since startup
+}
+
+// Usage walks the live cache directory and returns its size
+func (dc *DataCache) Usage() CacheUsage {
+	cacheEviction.mu.Lock()
+	usage := CacheUsage{MaxSizeBytes: cacheEviction.maxSize, Evicted: cacheEviction.evicted}
+	cacheEviction.mu.Unlock()
+
+	usage.SizeBytes = directorySize(dc.cacheDir)
+	usage.Files = dc.CountCachedCombinations()
+	return usage
+}
+
+// directorySize returns the total size of the regular files under dir
+func directorySize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// EnforceCacheLimit evicts combinations until the live cache fits the configured max size
+// The least recently requested combinations go first (never requested before any requested one),
+// ties broken by the smallest file, i.e. the fewest entries. Evicted combinations are fetched again
+// on demand or by the next refresh.
+// Returns the number of evicted combinations.
+func (dc *DataCache) EnforceCacheLimit() int {
+	cacheEviction.mu.Lock()
+	defer cacheEviction.mu.Unlock()
+	if cacheEviction.maxSize <= 0 {
+		return 0
+	}
+
+	size := directorySize(dc.cacheDir)
+	if size <= cacheEviction.maxSize {
+		return 0
+	}
+
+	files, err := filepath.Glob(filepath.Join(dc.cacheDir, "track_*", "class_*.json.gz"))
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to list cache files for eviction: %v", err)
+		return 0
+	}
+	lastRequested := lastCombinationRequests()
+	candidates := make([]cachedCombination, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		trackID, classID, ok := combinationFromCachePath(file)
+		if !ok {
+			continue
+		}
+		key := trackID + "_" + classID
+		candidates = append(candidates, cachedCombination{
+			path:          file,
+			key:           key,
+			size:          info.Size(),
+			lastRequested: lastRequested[key],
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.lastRequested.Equal(b.lastRequested) {
+			return a.lastRequested.Before(b.lastRequested)
+		}
+		if a.size != b.size {
+			return a.size < b.size
+		}
+		return a.key < b.key
+	})
+
+	evicted := 0
+	var freed int64
+	for _, candidate := range candidates {
+		if size-freed <= cacheEviction.maxSize {
+			break
+		}
+		if err := os.Remove(candidate.path); err != nil {
+			cacheLog.Warnf("⚠️ Failed to evict %s: %v", candidate.path, err)
+			continue
+		}
+		freed += candidate.size
+		evicted++
+		cacheLog.Debugf("🧹 Evicted %s (%d bytes)", candidate.key, candidate.size)
+	}
+	cacheEviction.evicted += evicted
+
+	if size-freed > cacheEviction.maxSize {
+		cacheLog.Warnf("⚠️ Cache still uses %.1f MB after evicting %d combinations (limit %.1f MB)", float64(size-freed)/1024/1024, evicted, float64(cacheEviction.maxSize)/1024/1024)
+	} else if evicted > 0 {
+		cacheLog.Infof("🧹 Evicted %d combinations (%.1f MB) to stay under the %.1f MB cache limit", evicted, float64(freed)/1024/1024, float64(cacheEviction.maxSize)/1024/1024)
+	}
+	return evicted
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Catalog files overriding the built-in track and class lists (next to config.json)
+const (
+	TracksFile  = "tracks.json"
+	ClassesFile = "classes.json"
+)
+
+// Built-in catalogs, used when TracksFile or ClassesFile is missing
+var (
+	//go:embed catalog/tracks.json
+	builtinTracksJSON []byte
+	//go:embed catalog/classes.json
+	builtinClassesJSON []byte
+)
+
+// TrackCatalog is the content of TracksFile
+type TrackCatalog struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Tracks      []TrackCatalogItem `json:"tracks"`
+}
+
+// TrackCatalogItem is a track layout in TracksFile
+type TrackCatalogItem struct {
+	TrackID string `json:"track_id"`
+	Name    string `json:"name"`
+}
+
+// ClassCatalog is the content of ClassesFile
+type ClassCatalog struct {
+	Classes []ClassCatalogItem `json:"classes"`
+}
+
+// ClassCatalogItem is a car class in ClassesFile
+type ClassCatalogItem struct {
+	ClassID string `json:"class_id"`
+	Name    string `json:"name"`
+}
+
+// CatalogInfo describes the loaded catalogs (returned by /api/catalog/reload)
+type CatalogInfo struct {
+	Tracks        int    `json:"tracks"`
+	TracksSource  string `json:"tracks_source"` // File path, or "built-in"
+	Classes       int    `json:"classes"`
+	ClassesSource string `json:"classes_source"`
+}
+
+// catalog holds the current track and class lists; reloads swap them atomically
+var catalog = struct {
+	sync.RWMutex
+	tracks  []TrackConfig
+	classes []CarClassConfig
+	info    CatalogInfo
+}{}
+
+func init() {
+	tracks, err := parseTrackCatalog(builtinTracksJSON)
+	if err != nil {
+		panic("built-in track catalog: " + err.Error())
+	}
+	classes, err := parseClassCatalog(builtinClassesJSON)
+	if err != nil {
+		panic("built-in class catalog: " + err.Error())
+	}
+	catalog.tracks, catalog.classes = tracks, classes
+	catalog.info = CatalogInfo{Tracks: len(tracks), TracksSource: "built-in", Classes: len(classes), ClassesSource: "built-in"}
+}
+
+// LoadCatalogs replaces the track and class lists with TracksFile and ClassesFile when present
+// Both files are validated before either is applied, so a bad file leaves the current catalogs in place
+func LoadCatalogs() (CatalogInfo, error) {
+	tracks, tracksSource, err := loadCatalogFile(TracksFile, builtinTracksJSON, parseTrackCatalog)
+	if err != nil {
+		return CurrentCatalogInfo(), err
+	}
+	classes, classesSource, err := loadCatalogFile(ClassesFile, builtinClassesJSON, parseClassCatalog)
+	if err != nil {
+		return CurrentCatalogInfo(), err
+	}
+
+	info := CatalogInfo{Tracks: len(tracks), TracksSource: tracksSource, Classes: len(classes), ClassesSource: classesSource}
+	catalog.Lock()
+	catalog.tracks, catalog.classes, catalog.info = tracks, classes, info
+	catalog.Unlock()
+
+	configLog.Infof("🗺️ Catalog: %d tracks (%s), %d classes (%s)", info.Tracks, info.TracksSource, info.Classes, info.ClassesSource)
+	return info, nil
+}
+
+// CurrentCatalogInfo returns the sizes and sources of the loaded catalogs
+func CurrentCatalogInfo() CatalogInfo {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	return catalog.info
+}
+
+// loadCatalogFile parses path, or the built-in data when the file doesn't exist
+func loadCatalogFile[T any](path string, builtin []byte, parse func([]byte) ([]T, error)) ([]T, string, error) {
+	data, err := os.ReadFile(path)
+	source := path
+	if os.IsNotExist(err) {
+		data, source = builtin, "built-in"
+	} else if err != nil {
+		return nil, "", err
+	}
+	items, err := parse(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %w", source, err)
+	}
+	return items, source, nil
+}
+
+// parseTrackCatalog decodes and validates a track catalog
+func parseTrackCatalog(data []byte) ([]TrackConfig, error) {
+	var file TrackCatalog
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	tracks := make([]TrackConfig, 0, len(file.Tracks))
+	seen := make(map[string]bool, len(file.Tracks))
+	for i, item := range file.Tracks {
+		if err := validateCatalogItem(item.TrackID, item.Name, seen); err != nil {
+			return nil, fmt.Errorf("track %d: %w", i+1, err)
+		}
+		tracks = append(tracks, TrackConfig{Name: item.Name, TrackID: item.TrackID})
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no tracks")
+	}
+	return tracks, nil
+}
+
+// parseClassCatalog decodes and validates a class catalog
+func parseClassCatalog(data []byte) ([]CarClassConfig, error) {
+	var file ClassCatalog
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	classes := make([]CarClassConfig, 0, len(file.Classes))
+	seen := make(map[string]bool, len(file.Classes))
+	for i, item := range file.Classes {
+		if err := validateCatalogItem(item.ClassID, item.Name, seen); err != nil {
+			return nil, fmt.Errorf("class %d: %w", i+1, err)
+		}
+		classes = append(classes, CarClassConfig{Name: item.Name, ClassID: item.ClassID})
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("no classes")
+	}
+	return classes, nil
+}
+
+// validateCatalogItem checks for a numeric, unique ID and a name
+func validateCatalogItem(id, name string, seen map[string]bool) error {
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return fmt.Errorf("invalid ID %q", id)
+	}
+	if name == "" {
+		return fmt.Errorf("ID %s has no name", id)
+	}
+	if seen[id] {
+		return fmt.Errorf("duplicate ID %s", id)
+	}
+	seen[id] = true
+	return nil
+}
+
+// ExportTrackCatalog writes tracks to path in the TracksFile format
+func ExportTrackCatalog(path string, tracks []TrackConfig) error {
+	file := TrackCatalog{GeneratedAt: time.Now(), Tracks: make([]TrackCatalogItem, len(tracks))}
+	for i, track := range tracks {
+		file.Tracks[i] = TrackCatalogItem{TrackID: track.TrackID, Name: track.Name}
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// allTracks returns every catalog track, selected or not
+func allTracks() []TrackConfig {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	return append([]TrackConfig(nil), catalog.tracks...)
+}
+
+// allCarClasses returns every catalog car class, selected or not
+func allCarClasses() []CarClassConfig {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	return append([]CarClassConfig(nil), catalog.classes...)
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// championshipConfig holds the scoring table and round limit of /api/championship
+var championshipConfig = GetDefaultConfig().Championship
+
+// SetChampionshipConfig applies the championship settings; unset values fall back to the defaults
+func SetChampionshipConfig(cfg ChampionshipConfig) {
+	defaults := GetDefaultConfig().Championship
+	if len(cfg.Points) == 0 {
+		cfg.Points = defaults.Points
+	}
+	if cfg.MaxCombinations <= 0 {
+		cfg.MaxCombinations = defaults.MaxCombinations
+	}
+	championshipConfig = cfg
+}
+
+// ChampionshipRound is one track/class combination of a championship
+type ChampionshipRound struct {
+	TrackID string `json:"track_id"`
+	Track   string `json:"track"`
+	ClassID string `json:"class_id"`
+	Class   string `json:"class"`
+	Entries int    `json:"entries"`
+	Missing bool   `json:"missing,omitempty"` // Not cached; scores nothing
+}
+
+// ChampionshipResult is a driver's result in one round
+type ChampionshipResult struct {
+	TrackID  string `json:"track_id"`
+	ClassID  string `json:"class_id"`
+	Position int    `json:"position"`
+	LapTime  string `json:"laptime"`
+	Points   int    `json:"points"`
+}
+
+// ChampionshipStanding is a driver's line in the standings
+type ChampionshipStanding struct {
+	Position     int                  `json:"position"`
+	Name         string               `json:"name"`
+	DriverID     string               `json:"driver_id,omitempty"` // RaceRoom user ID; tells apart drivers sharing a name
+	Country      string               `json:"country"`
+	Points       int                  `json:"points"`
+	Rounds       int                  `json:"rounds"` // Rounds with a lap, scoring or not
+	Wins         int                  `json:"wins"`
+	BestPosition int                  `json:"best_position"`
+	Results      []ChampionshipResult `json:"results"` // In round order
+}
+
+// Championship is the standings of a set of combinations scored with one points table
+type Championship struct {
+	Points    []int                  `json:"points"` // Points by position, P1 first
+	Rounds    []ChampionshipRound    `json:"rounds"`
+	Drivers   int                    `json:"drivers"` // Drivers with a lap in any round, before the limit
+	Standings []ChampionshipStanding `json:"standings"`
+}
+
+// ErrTooManyRounds is returned when a championship combines more rounds than championship.max_combinations
+var ErrTooManyRounds = errors.New("too many track/class combinations")
+
+// ParseChampionshipPoints parses a comma-separated points table such as "25,18,15"
+func ParseChampionshipPoints(raw string) ([]int, error) {
+	var points []int
+	for _, field := range strings.Split(raw, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid points value %q", field)
+		}
+		points = append(points, value)
+	}
+	return points, nil
+}
+
+// BuildChampionship scores every track/class combination of trackIDs × classIDs from the main cache:
+// each driver gets points[position-1] per round and the standings are ordered by points, then wins and
+// best position. A nil points table uses championship.points. Uncached combinations are listed as missing.
+func BuildChampionship(trackIDs, classIDs []string, points []int, limit int) (Championship, error) {
+	if len(points) == 0 {
+		points = championshipConfig.Points
+	}
+	if rounds := len(trackIDs) * len(classIDs); rounds > championshipConfig.MaxCombinations {
+		return Championship{}, fmt.Errorf("%w: %d (max %d)", ErrTooManyRounds, rounds, championshipConfig.MaxCombinations)
+	}
+
+	championship := Championship{Points: points, Rounds: []ChampionshipRound{}, Standings: []ChampionshipStanding{}}
+	drivers := make(map[string]*ChampionshipStanding)
+	for _, trackID := range trackIDs {
+		for _, classID := range classIDs {
+			round := ChampionshipRound{TrackID: trackID, Track: reportTrackName(trackID), ClassID: classID, Class: GetCarClassName(classID)}
+			trackInfo, results, err := LoadLeaderboard(trackID, classID)
+			if errors.Is(err, ErrCombinationNotCached) {
+				round.Missing = true
+				championship.Rounds = append(championship.Rounds, round)
+				continue
+			} else if err != nil {
+				return Championship{}, fmt.Errorf("round %s + %s: %w", trackID, classID, err)
+			}
+			if trackInfo.Name != "" {
+				round.Track = trackInfo.Name
+			}
+			round.Entries = len(results)
+			championship.Rounds = append(championship.Rounds, round)
+
+			for _, result := range results {
+				key := championshipDriverKey(result)
+				standing, ok := drivers[key]
+				if !ok {
+					standing = &ChampionshipStanding{Name: result.Name, DriverID: result.DriverID, Country: result.Country, BestPosition: result.Position}
+					drivers[key] = standing
+				}
+				score := 0
+				if result.Position >= 1 && result.Position <= len(points) {
+					score = points[result.Position-1]
+				}
+				standing.Points += score
+				standing.Rounds++
+				if result.Position == 1 {
+					standing.Wins++
+				}
+				standing.BestPosition = min(standing.BestPosition, result.Position)
+				standing.Results = append(standing.Results, ChampionshipResult{
+					TrackID:  trackID,
+					ClassID:  classID,
+					Position: result.Position,
+					LapTime:  result.LapTime,
+					Points:   score,
+				})
+			}
+		}
+	}
+
+	standings := make([]ChampionshipStanding, 0, len(drivers))
+	for _, standing := range drivers {
+		standings = append(standings, *standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		if a.BestPosition != b.BestPosition {
+			return a.BestPosition < b.BestPosition
+		}
+		return a.Name < b.Name
+	})
+	for i := range standings {
+		standings[i].Position = i + 1
+	}
+	championship.Drivers = len(standings)
+	if len(standings) > limit {
+		standings = standings[:limit]
+	}
+	championship.Standings = standings
+	return championship, nil
+}
+
+// championshipDriverKey identifies a driver across rounds: the RaceRoom user ID, or the exact name without one
+// The folded search key isn't used, as it merges different drivers such as "José Silva" and "Jose Silva"
+func championshipDriverKey(result DriverResult) string {
+	if result.DriverID != "" {
+		return "id:" + result.DriverID
+	}
+	return "name:" + result.Name
+}
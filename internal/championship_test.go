@@ -0,0 +1,40 @@
+package internal
+
+import "testing"
+
+func TestBuildChampionshipKeepsFoldedNamesApart(t *testing.T) {
+	chdirTemp(t)
+
+	// "José Silva" and "Jose Silva" share a search key but are different drivers
+	dataCache := NewDataCache()
+	for _, classID := range []string{"1703", "1704"} {
+		err := dataCache.SaveTrackData(TrackInfo{Name: "Hockenheimring - Grand Prix", TrackID: "1693", ClassID: classID, Data: []LeaderboardEntry{
+			{Index: 0, LapTime: "1m 37.000s", Driver: EntryDriver{Name: "José Silva", ID: "101"}},
+			{Index: 1, LapTime: "1m 38.000s", Driver: EntryDriver{Name: "Jose Silva", ID: "202"}},
+			{Index: 2, LapTime: "1m 39.000s", Driver: EntryDriver{Name: "No ID Driver"}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	championship, err := BuildChampionship([]string{"1693"}, []string{"1703", "1704"}, []int{25, 18, 15}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChampionshipStanding{
+		{Position: 1, Name: "José Silva", DriverID: "101", Points: 50, Rounds: 2, Wins: 2, BestPosition: 1},
+		{Position: 2, Name: "Jose Silva", DriverID: "202", Points: 36, Rounds: 2, BestPosition: 2},
+		{Position: 3, Name: "No ID Driver", Points: 30, Rounds: 2, BestPosition: 3},
+	}
+	if championship.Drivers != len(want) || len(championship.Standings) != len(want) {
+		t.Fatalf("standings = %+v, want %d drivers", championship.Standings, len(want))
+	}
+	for i, standing := range championship.Standings {
+		w := want[i]
+		if standing.Position != w.Position || standing.Name != w.Name || standing.DriverID != w.DriverID || standing.Points != w.Points ||
+			standing.Rounds != w.Rounds || standing.Wins != w.Wins || standing.BestPosition != w.BestPosition || len(standing.Results) != 2 {
+			t.Errorf("standing %d = %+v, want %+v with 2 results", i, standing, w)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// changesFileName is the diff of the two newest snapshots, stored in the combination's snapshot directory
+const changesFileName = "changes.json"
+
+// LeaderboardChanges is the difference between the two newest snapshots of a combination
+type LeaderboardChanges struct {
+	TrackID         string        `json:"track_id"`
+	ClassID         string        `json:"class_id"`
+	From            time.Time     `json:"from"` // Previous snapshot
+	To              time.Time     `json:"to"`   // Latest snapshot
+	NewEntries      []EntryChange `json:"new_entries"`
+	Improved        []EntryChange `json:"improved"`         // Faster lap times
+	PositionChanges []EntryChange `json:"position_changes"` // Drivers present in both whose position moved
+}
+
+// EntryChange describes how one driver's entry changed between two snapshots
+type EntryChange struct {
+	Name          string `json:"name"`
+	OldPosition   int    `json:"old_position,omitempty"`
+	NewPosition   int    `json:"new_position"`
+	OldLapTime    string `json:"old_laptime,omitempty"`
+	NewLapTime    string `json:"new_laptime"`
+	ImprovementMs int64  `json:"improvement_ms,omitempty"`
+}
+
+// changesFileFor returns the path of a combination's changes file
+func changesFileFor(trackID, classID string) string {
+	return filepath.Join(snapshotDirFor(trackID, classID), changesFileName)
+}
+
+// recordChanges diffs the two newest snapshots of a combination and stores the result for /api/changes
+// Returns false when there is no previous snapshot or a snapshot cannot be read
+func recordChanges(trackID, classID string) (LeaderboardChanges, bool) {
+	snapshots, err := ListSnapshots(trackID, classID)
+	if err != nil || len(snapshots) < 2 {
+		return LeaderboardChanges{}, false
+	}
+	previous, latest := snapshots[len(snapshots)-2], snapshots[len(snapshots)-1]
+	before, err := LoadSnapshot(previous)
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to load snapshot for changes: %v", err)
+		return LeaderboardChanges{}, false
+	}
+	after, err := LoadSnapshot(latest)
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to load snapshot for changes: %v", err)
+		return LeaderboardChanges{}, false
+	}
+
+	changes := DiffLeaderboards(before, after)
+	changes.TrackID, changes.ClassID = trackID, classID
+	changes.From, changes.To = previous.Time, latest.Time
+
+	data, err := json.Marshal(changes)
+	if err == nil {
+		err = writeFileAtomic(changesFileFor(trackID, classID), data)
+	}
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to store changes of %s + %s: %v", trackID, classID, err)
+	}
+	return changes, true
+}
+
+// DiffLeaderboards compares two versions of a leaderboard by normalized driver name
+func DiffLeaderboards(before, after TrackInfo) LeaderboardChanges {
+	previous := make(map[string]DriverResult, len(before.Data))
+	beforeLeaderMs := leaderLapTimeMs(before.Data)
+	for i := range before.Data {
+		if result, ok := extractDriverResult(&before, &before.Data[i], beforeLeaderMs); ok {
+			previous[NormalizeDriverName(result.Name)] = result
+		}
+	}
+
+	changes := LeaderboardChanges{NewEntries: []EntryChange{}, Improved: []EntryChange{}, PositionChanges: []EntryChange{}}
+	afterLeaderMs := leaderLapTimeMs(after.Data)
+	for i := range after.Data {
+		current, ok := extractDriverResult(&after, &after.Data[i], afterLeaderMs)
+		if !ok {
+			continue
+		}
+		change := EntryChange{Name: current.Name, NewPosition: current.Position, NewLapTime: current.LapTime}
+		old, existed := previous[NormalizeDriverName(current.Name)]
+		if !existed {
+			changes.NewEntries = append(changes.NewEntries, change)
+			continue
+		}
+		change.OldPosition, change.OldLapTime = old.Position, old.LapTime
+
+		if old.LapTimeMs > 0 && current.LapTimeMs > 0 && current.LapTimeMs < old.LapTimeMs {
+			improved := change
+			improved.ImprovementMs = old.LapTimeMs - current.LapTimeMs
+			changes.Improved = append(changes.Improved, improved)
+		}
+		if old.Position != current.Position {
+			changes.PositionChanges = append(changes.PositionChanges, change)
+		}
+	}
+
+	byPosition := func(list []EntryChange) {
+		sort.Slice(list, func(i, j int) bool { return list[i].NewPosition < list[j].NewPosition })
+	}
+	byPosition(changes.NewEntries)
+	byPosition(changes.Improved)
+	byPosition(changes.PositionChanges)
+	return changes
+}
+
+// ReadChanges returns the stored changes of a combination
+func ReadChanges(trackID, classID string) (LeaderboardChanges, error) {
+	var changes LeaderboardChanges
+	data, err := os.ReadFile(changesFileFor(trackID, classID))
+	if err != nil {
+		return changes, err
+	}
+	err = json.Unmarshal(data, &changes)
+	return withoutExcludedChanges(changes), err
+}
+
+// ParseLapTime parses RaceRoom lap times such as "1:23.456", "1m 23.456s" or "59.123s"
+func ParseLapTime(lapTime string) (time.Duration, bool) {
+	s := strings.ReplaceAll(strings.TrimSpace(lapTime), " ", "")
+	if s == "" {
+		return 0, false
+	}
+	minutes := 0
+	if before, after, found := strings.Cut(s, "m"); found {
+		m, err := strconv.Atoi(before)
+		if err != nil {
+			return 0, false
+		}
+		minutes, s = m, after
+	} else if before, after, found := strings.Cut(s, ":"); found {
+		m, err := strconv.Atoi(before)
+		if err != nil {
+			return 0, false
+		}
+		minutes, s = m, after
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+	if err != nil || seconds < 0 || minutes < 0 {
+		return 0, false
+	}
+	return time.Duration(minutes)*time.Minute + time.Duration(math.Round(seconds*1000))*time.Millisecond, true
+}
+
+// FormatLapTime formats milliseconds like RaceRoom: "1m 23.456s"
+func FormatLapTime(ms int64) string {
+	return fmt.Sprintf("%dm %06.3fs", ms/60000, float64(ms%60000)/1000)
+}
@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CombinationStatsFile holds the lap time statistics of every combination, computed at index time
+const CombinationStatsFile = "cache/combination_stats.json.gz"
+
+// histogramBuckets is the number of equal-width buckets between the fastest lap and P99
+const histogramBuckets = 20
+
+// targetPercents are the "top N%" shares whose required lap time is reported
+var targetPercents = []float64{1, 5, 10, 25, 50}
+
+// CombinationStatistics describes the lap time distribution of one combination
+type CombinationStatistics struct {
+	TrackID   string            `json:"track_id"`
+	Track     string            `json:"track"`
+	ClassID   string            `json:"class_id"`
+	ClassName string            `json:"class_name"`
+	Entries   int               `json:"entries"`  // Entries with a parseable lap time
+	Unparsed  int               `json:"unparsed"` // Entries left out because their lap time couldn't be parsed
+	FastestMs int64             `json:"fastest_ms"`
+	MedianMs  int64             `json:"median_ms"`
+	P90Ms     int64             `json:"p90_ms"`
+	P99Ms     int64             `json:"p99_ms"`
+	SlowestMs int64             `json:"slowest_ms"`
+	Histogram []HistogramBucket `json:"histogram"`        // From the fastest lap to P99
+	Outliers  int               `json:"outliers"`         // Entries slower than the last bucket
+	Targets   []TargetTime      `json:"time_for_top_pct"` // Lap time needed to be in the top N%
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// HistogramBucket counts the entries with a lap time in [FromMs, ToMs)
+type HistogramBucket struct {
+	FromMs int64 `json:"from_ms"`
+	ToMs   int64 `json:"to_ms"`
+	Count  int   `json:"count"`
+}
+
+// TargetTime is the slowest lap time that still ranks in the top Percent of a combination
+type TargetTime struct {
+	Percent   float64 `json:"percent"`
+	Position  int     `json:"position"` // 1-based position of that lap
+	LapTimeMs int64   `json:"laptime_ms"`
+	LapTime   string  `json:"laptime"`
+}
+
+// combinationStats caches the statistics of the last index build, loaded from CombinationStatsFile on first use
+var combinationStats = struct {
+	mu     sync.RWMutex
+	loaded bool
+	byKey  map[string]CombinationStatistics // By trackID_classID
+}{}
+
+// ComputeCombinationStats computes the lap time distribution of a combination
+// Returns false when none of its lap times can be parsed
+func ComputeCombinationStats(track TrackInfo) (CombinationStatistics, bool) {
+	stats := CombinationStatistics{
+		TrackID:   track.TrackID,
+		Track:     track.Name,
+		ClassID:   track.ClassID,
+		ClassName: GetCarClassName(track.ClassID),
+	}
+	lapTimes := make([]int64, 0, len(track.Data))
+	for i := range track.Data {
+		if lapTime := track.Data[i].lapTimeMs(); lapTime > 0 {
+			lapTimes = append(lapTimes, lapTime)
+		} else {
+			stats.Unparsed++
+		}
+	}
+	if len(lapTimes) == 0 {
+		return stats, false
+	}
+	sort.Slice(lapTimes, func(i, j int) bool { return lapTimes[i] < lapTimes[j] })
+
+	n := len(lapTimes)
+	stats.Entries = n
+	stats.FastestMs = lapTimes[0]
+	stats.SlowestMs = lapTimes[n-1]
+	stats.MedianMs = percentileMs(lapTimes, 50)
+	stats.P90Ms = percentileMs(lapTimes, 90)
+	stats.P99Ms = percentileMs(lapTimes, 99)
+
+	// Buckets are at least 1ms wide; with fewer distinct times than buckets some stay empty
+	width := max((stats.P99Ms-stats.FastestMs+histogramBuckets)/histogramBuckets, 1)
+	stats.Histogram = make([]HistogramBucket, histogramBuckets)
+	for i := range stats.Histogram {
+		from := stats.FastestMs + int64(i)*width
+		stats.Histogram[i] = HistogramBucket{FromMs: from, ToMs: from + width}
+	}
+	for _, lapTime := range lapTimes {
+		bucket := int((lapTime - stats.FastestMs) / width)
+		if bucket >= histogramBuckets {
+			stats.Outliers++
+			continue
+		}
+		stats.Histogram[bucket].Count++
+	}
+
+	for _, percent := range targetPercents {
+		position := max(int(float64(n)*percent/100), 1)
+		lapTime := lapTimes[position-1]
+		stats.Targets = append(stats.Targets, TargetTime{
+			Percent:   percent,
+			Position:  position,
+			LapTimeMs: lapTime,
+			LapTime:   FormatLapTime(lapTime),
+		})
+	}
+	return stats, true
+}
+
+// percentileMs returns the nearest-rank percentile of sorted lap times
+func percentileMs(sorted []int64, percentile float64) int64 {
+	rank := int(math.Ceil(float64(len(sorted))*percentile/100)) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// ExportCombinationStats computes the statistics of every indexed combination, writes them to
+// CombinationStatsFile and serves them from memory until the next build
+func ExportCombinationStats(tracks []TrackInfo) error {
+	updatedAt := time.Now().UTC()
+	byKey := make(map[string]CombinationStatistics, len(tracks))
+	for _, track := range tracks {
+		stats, ok := ComputeCombinationStats(track)
+		if !ok {
+			continue
+		}
+		stats.UpdatedAt = updatedAt
+		byKey[track.TrackID+"_"+track.ClassID] = stats
+	}
+	return writeCombinationStats(byKey)
+}
+
+// writeCombinationStats writes precomputed statistics to CombinationStatsFile and serves them from memory
+func writeCombinationStats(byKey map[string]CombinationStatistics) error {
+	combinationStats.mu.Lock()
+	combinationStats.byKey = byKey
+	combinationStats.loaded = true
+	combinationStats.mu.Unlock()
+
+	list := make([]CombinationStatistics, 0, len(byKey))
+	for _, stats := range byKey {
+		list = append(list, stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TrackID != list[j].TrackID {
+			return list[i].TrackID < list[j].TrackID
+		}
+		return list[i].ClassID < list[j].ClassID
+	})
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	err := json.NewEncoder(gz).Encode(list)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(CombinationStatsFile), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(CombinationStatsFile, buf.Bytes())
+	}
+	if err != nil {
+		return err
+	}
+	exportLog.Debugf("📊 Exported lap time statistics of %d combinations", len(list))
+	return nil
+}
+
+// GetCombinationStats returns the statistics of a combination from the last index build
+func GetCombinationStats(trackID, classID string) (CombinationStatistics, bool) {
+	combinationStats.mu.RLock()
+	if combinationStats.loaded {
+		stats, ok := combinationStats.byKey[trackID+"_"+classID]
+		combinationStats.mu.RUnlock()
+		return stats, ok
+	}
+	combinationStats.mu.RUnlock()
+
+	combinationStats.mu.Lock()
+	defer combinationStats.mu.Unlock()
+	if !combinationStats.loaded {
+		combinationStats.byKey = readCombinationStats()
+		combinationStats.loaded = true
+	}
+	stats, ok := combinationStats.byKey[trackID+"_"+classID]
+	return stats, ok
+}
+
+// readCombinationStats reads CombinationStatsFile; a missing or unreadable file yields no statistics
+func readCombinationStats() map[string]CombinationStatistics {
+	byKey := make(map[string]CombinationStatistics)
+	file, err := os.Open(CombinationStatsFile)
+	if err != nil {
+		return byKey
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		exportLog.Warnf("⚠️ Ignoring unreadable %s: %v", CombinationStatsFile, err)
+		return byKey
+	}
+	defer gz.Close()
+	var list []CombinationStatistics
+	if err := json.NewDecoder(gz).Decode(&list); err != nil {
+		exportLog.Warnf("⚠️ Ignoring unreadable %s: %v", CombinationStatsFile, err)
+		return byKey
+	}
+	for _, stats := range list {
+		byKey[stats.TrackID+"_"+stats.ClassID] = stats
+	}
+	return byKey
+}
@@ -1,33 +1,414 @@
 package internal
 
+import (
+	"encoding/json"
+	"os"
+
+	"r3e-leaderboard/internal/notify"
+)
+
+// ConfigFile is the optional JSON file overlaying the default configuration
+const ConfigFile = "config.json"
+
 // Config holds application configuration
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Schedule ScheduleConfig `json:"schedule"`
+	Server       ServerConfig       `json:"server"`
+	Schedule     ScheduleConfig     `json:"schedule"`
+	Cache        CacheConfig        `json:"cache"`
+	Fetch        FetchConfig        `json:"fetch"`
+	Selection    SelectionConfig    `json:"selection"`
+	Discovery    DiscoveryConfig    `json:"discovery"`
+	Snapshots    SnapshotConfig     `json:"snapshots"`
+	Notify       NotifyConfig       `json:"notify"`
+	Reports      ReportConfig       `json:"reports"`
+	Championship ChampionshipConfig `json:"championship"`
+	Export       ExportConfig       `json:"export"`
+	Logging      LoggingConfig      `json:"logging"`
+	Tracing      TracingConfig      `json:"tracing"`
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port int `json:"port"`
+	Port          int                `json:"port"`
+	APIPrefix     string             `json:"api_prefix"`      // Path the JSON API is mounted under ("/" mounts it at the root)
+	OnDemandFetch bool               `json:"on_demand_fetch"` // Fetch uncached combinations requested via /api/leaderboard
+	AdminToken    string             `json:"admin_token"`     // Enables admin endpoints such as /api/refresh when set
+	RequireAPIKey bool               `json:"require_api_key"` // Reject API requests without a key issued via /api/keys
+	RateLimit     RateLimitConfig    `json:"rate_limit"`
+	LegacyRoutes  LegacyRoutesConfig `json:"legacy_routes"`
+	TLS           TLSConfig          `json:"tls"`
+}
+
+// TLSConfig serves HTTPS (with HTTP/2) on the server port instead of plain HTTP
+type TLSConfig struct {
+	CertFile     string `json:"cert_file"`     // PEM certificate chain; TLS is enabled when both files are set
+	KeyFile      string `json:"key_file"`      // PEM private key
+	RedirectPort int    `json:"redirect_port"` // Plain HTTP port redirecting to HTTPS and serving ACME challenges (0 disables), e.g. 80
+}
+
+// Enabled reports whether a certificate and key are configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// LegacyRoutesConfig controls the unversioned API paths kept as aliases of /api/v1 during the deprecation window
+type LegacyRoutesConfig struct {
+	Enabled bool   `json:"enabled"` // Serve e.g. /api/leaderboard alongside /api/v1/leaderboard
+	Sunset  string `json:"sunset"`  // Announced removal date (YYYY-MM-DD) sent in the Sunset header
+}
+
+// RateLimitConfig controls API rate limiting and client IP detection
+type RateLimitConfig struct {
+	Requests       int      `json:"requests"`        // Requests per window per anonymous client IP (0 disables)
+	KeyRequests    int      `json:"key_requests"`    // Default requests per window for API keys without their own limit
+	WindowSeconds  int      `json:"window_seconds"`  // Length of the fixed rate limit window
+	TrustedProxies []string `json:"trusted_proxies"` // CIDRs or IPs whose X-Forwarded-For / X-Real-IP headers are honored
+
+	// Routes gives individual endpoints (e.g. "/api/leaderboard", including the API prefix) their own bucket instead of the shared one
+	Routes map[string]RoutePolicy `json:"routes"`
+}
+
+// RoutePolicy is the rate limit of a single API route
+type RoutePolicy struct {
+	Requests      int `json:"requests"`       // Requests per window per client IP (0 disables limiting for the route)
+	KeyRequests   int `json:"key_requests"`   // Requests per window per API key (0 uses Requests)
+	WindowSeconds int `json:"window_seconds"` // Window length (0 uses the global window)
 }
 
 // ScheduleConfig holds scheduling configuration
 type ScheduleConfig struct {
-	RefreshHour     int `json:"refresh_hour"`
-	RefreshMinute   int `json:"refresh_minute"`
-	IndexingMinutes int `json:"indexing_minutes"`
+	RefreshHour     int               `json:"refresh_hour"`
+	RefreshMinute   int               `json:"refresh_minute"`
+	Times           []string          `json:"times"`          // "HH:MM" refresh times; replaces refresh_hour/refresh_minute
+	Cron            string            `json:"cron"`           // Cron expression; replaces times and refresh_hour/refresh_minute
+	JitterMinutes   int               `json:"jitter_minutes"` // Random delay of up to this many minutes per refresh
+	IndexingMinutes int               `json:"indexing_minutes"`
+	Popularity      PopularityConfig  `json:"popularity"`
+	HealthCheck     HealthCheckConfig `json:"health_check"`
+}
+
+// HealthCheckConfig controls the RaceRoom health check before scheduled refreshes
+type HealthCheckConfig struct {
+	Enabled          bool   `json:"enabled"`
+	TrackID          string `json:"track_id"`           // Known combination that always has entries
+	ClassID          string `json:"class_id"`           //
+	TimeoutSeconds   int    `json:"timeout_seconds"`    // RaceRoom must answer within this long
+	RetryMinutes     int    `json:"retry_minutes"`      // First postponement; doubles after every failed check
+	MaxRetryMinutes  int    `json:"max_retry_minutes"`  // Longest postponement between checks
+	MaxPostponeHours int    `json:"max_postpone_hours"` // Skip the refresh when RaceRoom is still unhealthy after this long
+}
+
+// PopularityConfig controls popularity-weighted refreshing, which replaces the nightly full refresh when enabled
+type PopularityConfig struct {
+	Enabled             bool `json:"enabled"`
+	HotCount            int  `json:"hot_count"`             // Top combinations by entries (and by leaderboard requests) in the hot tier
+	HotIntervalHours    int  `json:"hot_interval_hours"`    // Refresh interval of hot combinations
+	NormalIntervalHours int  `json:"normal_interval_hours"` // Refresh interval of other populated combinations
+	ColdIntervalHours   int  `json:"cold_interval_hours"`   // Refresh interval of combinations without entries
+	CheckMinutes        int  `json:"check_minutes"`         // How often the queue is checked for due combinations
+	BatchSize           int  `json:"batch_size"`            // Most combinations refreshed per check
+}
+
+// CacheConfig controls how long cached combinations stay fresh before they are refetched
+type CacheConfig struct {
+	MaxAgeHours float64        `json:"max_age_hours"` // Default max age of a cached combination
+	Rules       []CacheTTLRule `json:"rules"`         // Per-track/class overrides; the first matching rule wins
+	MaxSizeMB   float64        `json:"max_size_mb"`   // Evict combinations when cache/ grows beyond this; 0 disables
+	Prune       PruneConfig    `json:"prune"`
+	Lock        LockConfig     `json:"lock"`
+}
+
+// LockConfig controls the cache/instance.lock file that keeps two instances from sharing a cache
+type LockConfig struct {
+	OnConflict   string `json:"on_conflict"`   // "exit", "wait" or "read_only" when another instance holds the lock
+	WaitSeconds  int    `json:"wait_seconds"`  // Give up waiting after this long ("wait" mode); 0 waits indefinitely
+	StaleSeconds int    `json:"stale_seconds"` // A lock not refreshed for this long is taken over
+}
+
+// PruneConfig controls removing cache files of combinations that are no longer configured
+type PruneConfig struct {
+	Enabled bool `json:"enabled"` // Prune after every cache promotion
+	DryRun  bool `json:"dry_run"` // Only log what would be removed
+}
+
+// CacheTTLRule overrides the max age of the combinations matching its tracks and classes
+type CacheTTLRule struct {
+	Name        string   `json:"name"`          // Shown in logs only
+	Tracks      []string `json:"tracks"`        // Track IDs; empty matches every track
+	Classes     []string `json:"classes"`       // Class IDs; empty matches every class
+	MaxAgeHours float64  `json:"max_age_hours"` // Max age of matching combinations
+}
+
+// SelectionConfig limits the tracks and classes that are fetched, cached and indexed
+// Empty include lists select everything; excludes apply after includes
+type SelectionConfig struct {
+	IncludeTracks  []string `json:"include_tracks"`
+	ExcludeTracks  []string `json:"exclude_tracks"`
+	IncludeClasses []string `json:"include_classes"`
+	ExcludeClasses []string `json:"exclude_classes"`
+}
+
+// FetchConfig controls how RaceRoom is fetched during refreshes
+type FetchConfig struct {
+	Client        HTTPClientConfig  `json:"client"`
+	Throttle      ThrottleConfig    `json:"throttle"`
+	ParallelPages int               `json:"parallel_pages"` // Listing pages of one combination requested concurrently
+	Schema        SchemaCheckConfig `json:"schema"`
+	RawArchive    RawArchiveConfig  `json:"raw_archive"`
+	Fixtures      FixtureConfig     `json:"fixtures"`
+}
+
+// FixtureConfig controls replaying stored RaceRoom responses instead of fetching, and recording them
+type FixtureConfig struct {
+	Mode string `json:"mode"` // "" (off), "replay" or "record"
+	Dir  string `json:"dir"`  // Fixture files, one per request
+}
+
+// RawArchiveConfig controls the debug archive of raw RaceRoom responses under cache/raw
+type RawArchiveConfig struct {
+	Enabled            bool     `json:"enabled"`
+	Combinations       []string `json:"combinations"`         // Always archived: "trackID", "trackID-classID" or "*-classID"
+	SampleRate         float64  `json:"sample_rate"`          // Share (0-1) of the other combinations archived, the same ones on every run
+	KeepPerCombination int      `json:"keep_per_combination"` // Archived fetches kept per combination, newest first
+	MaxSizeMB          float64  `json:"max_size_mb"`          // Oldest archived fetches are removed beyond this total
+}
+
+// SchemaCheckConfig controls the check of fetched entries for missing fields (RaceRoom API changes)
+type SchemaCheckConfig struct {
+	Enabled        bool    `json:"enabled"`
+	MinEntries     int     `json:"min_entries"`     // Entries a refresh must have fetched before it is checked
+	SpikeThreshold float64 `json:"spike_threshold"` // Drift when a field's missing rate exceeds the baseline by this much (0-1)
+}
+
+// HTTPClientConfig controls the HTTP client used for RaceRoom requests
+type HTTPClientConfig struct {
+	BaseURL                      string `json:"base_url"`                    // RaceRoom site; point it at a fake server (see fakeraceroom) for testing
+	ProxyURL                     string `json:"proxy_url"`                   // http://, https:// or socks5:// proxy; empty connects directly
+	UserAgent                    string `json:"user_agent"`                  // Sent with every request
+	RequestTimeoutSeconds        int    `json:"request_timeout_seconds"`     // Limit for one request, reading the response included
+	CombinationTimeoutSeconds    int    `json:"combination_timeout_seconds"` // Limit for fetching one combination (all pages and Retry-After waits)
+	DialTimeoutSeconds           int    `json:"dial_timeout_seconds"`
+	TLSHandshakeTimeoutSeconds   int    `json:"tls_handshake_timeout_seconds"`
+	ResponseHeaderTimeoutSeconds int    `json:"response_header_timeout_seconds"` // Wait for the headers of a response after sending the request
+	MaxIdleConns                 int    `json:"max_idle_conns"`
+	MaxIdleConnsPerHost          int    `json:"max_idle_conns_per_host"`
+	IdleConnTimeoutSeconds       int    `json:"idle_conn_timeout_seconds"` // Keep-alive connections unused this long are closed
+	DisableKeepAlives            bool   `json:"disable_keep_alives"`
+}
+
+// ThrottleConfig controls the adaptive delay between leaderboard fetches
+// The delay shrinks while RaceRoom answers quickly and grows sharply on 429, 5xx, errors and slow responses
+type ThrottleConfig struct {
+	MinDelayMs     int `json:"min_delay_ms"`     // Delay while RaceRoom answers quickly
+	MaxDelayMs     int `json:"max_delay_ms"`     // Upper bound when backing off
+	InitialDelayMs int `json:"initial_delay_ms"` // Delay at the start of a run
+	SlowResponseMs int `json:"slow_response_ms"` // A request taking longer than this counts as slow (averaged over a combination's pages)
+}
+
+// DiscoveryConfig controls track discovery (run with -discover-tracks)
+type DiscoveryConfig struct {
+	TrackIDMin   int      `json:"track_id_min"`  // First track ID probed
+	TrackIDMax   int      `json:"track_id_max"`  // Last track ID probed
+	ProbeClasses []string `json:"probe_classes"` // A track ID is valid when any of these classes has a leaderboard on it
+	DelayMs      int      `json:"delay_ms"`      // Pause between probe requests
+
+	ClassCheckHours int `json:"class_check_hours"` // How often the RaceRoom class list is compared with the catalog (0 disables)
+}
+
+// SnapshotConfig controls the archive of leaderboard versions in snapshots/
+type SnapshotConfig struct {
+	Enabled           bool `json:"enabled"`
+	RetentionDays     int  `json:"retention_days"`      // Older snapshots are pruned (0 keeps them forever)
+	MaxPerCombination int  `json:"max_per_combination"` // Most snapshots kept per combination (0 = unlimited)
+}
+
+// NotifyConfig lists the outbound webhooks and their delivery settings
+type NotifyConfig struct {
+	Webhooks       []notify.Webhook        `json:"webhooks"`
+	Discord        []notify.DiscordChannel `json:"discord"`
+	MaxAttempts    int                     `json:"max_attempts"`    // Attempts per event, including the first
+	RetrySeconds   int                     `json:"retry_seconds"`   // First retry delay, doubled after each failure
+	TimeoutSeconds int                     `json:"timeout_seconds"` // Per request
+}
+
+// ReportConfig schedules the summary reports written to cache/reports
+type ReportConfig struct {
+	Daily   bool     `json:"daily"`
+	Weekly  bool     `json:"weekly"`  // Generated on Mondays
+	Hour    int      `json:"hour"`    // Local hour (0-23) the reports are generated at
+	Formats []string `json:"formats"` // "markdown" and/or "html" copies next to the JSON report
+}
+
+// ChampionshipConfig controls the virtual championship standings of /api/championship
+type ChampionshipConfig struct {
+	Points          []int `json:"points"`           // Points by leaderboard position, P1 first; positions beyond the table score nothing
+	MaxCombinations int   `json:"max_combinations"` // Most track/class combinations (rounds) one standings request may combine
+}
+
+// LoggingConfig controls log verbosity and output format
+type LoggingConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // "text" for humans, "json" for Loki/ELK ingestion
+}
+
+// TracingConfig controls OpenTelemetry trace export over OTLP/HTTP (JSON encoding)
+type TracingConfig struct {
+	Endpoint    string            `json:"endpoint"`     // Collector base URL, e.g. http://localhost:4318 (empty disables tracing)
+	ServiceName string            `json:"service_name"` // Reported as service.name (default r3e-leaderboard)
+	Headers     map[string]string `json:"headers"`      // Extra request headers, e.g. credentials for a hosted collector
+}
+
+// ExportConfig selects which driver index formats are written on each index build
+type ExportConfig struct {
+	DriverIndexJSON    bool `json:"driver_index_json"`     // Gzipped JSON for web clients
+	DriverIndexRawJSON bool `json:"driver_index_raw_json"` // Uncompressed JSON next to the gzipped copy
+	DriverIndexBinary  bool `json:"driver_index_binary"`   // Gob binary for fast server startup
+	DriverIndexShards  bool `json:"driver_index_shards"`   // Gzipped per-letter shards plus a manifest, for clients that only need a few drivers
+	DriverIndexDelta   bool `json:"driver_index_delta"`    // Drivers added, changed and removed since the previous export
+	StreamingBuild     bool `json:"streaming_build"`       // Build the index from the cache files one at a time instead of the loaded data
 }
 
 // GetDefaultConfig returns default configuration
 func GetDefaultConfig() Config {
 	return Config{
 		Server: ServerConfig{
-			Port: 8080,
+			Port:          8080,
+			APIPrefix:     DefaultAPIPrefix,
+			OnDemandFetch: false,
+			RateLimit: RateLimitConfig{
+				Requests:       60,
+				KeyRequests:    600,
+				WindowSeconds:  60,
+				TrustedProxies: []string{}, // Forwarding headers are ignored unless the proxy is listed
+			},
+			LegacyRoutes: LegacyRoutesConfig{
+				Enabled: true,
+			},
 		},
 		Schedule: ScheduleConfig{
 			RefreshHour:     4,  // 4 AM
 			RefreshMinute:   45, // At the top of the hour
 			IndexingMinutes: 30, // Every 30 minutes during fetching
+			Popularity: PopularityConfig{
+				Enabled:             false,
+				HotCount:            200,
+				HotIntervalHours:    4,
+				NormalIntervalHours: 24,
+				ColdIntervalHours:   168, // Weekly
+				CheckMinutes:        15,
+				BatchSize:           300,
+			},
+			HealthCheck: HealthCheckConfig{
+				Enabled:          true,
+				TrackID:          "1693", // Hockenheimring - Grand Prix
+				ClassID:          "1703", // GTR 3
+				TimeoutSeconds:   15,
+				RetryMinutes:     5,
+				MaxRetryMinutes:  60,
+				MaxPostponeHours: 6,
+			},
+		},
+		Cache: CacheConfig{
+			MaxAgeHours: 24,
+			Rules:       []CacheTTLRule{},
+			Prune:       PruneConfig{Enabled: true},
+			Lock:        LockConfig{OnConflict: LockConflictExit, StaleSeconds: 300},
+		},
+		Fetch: FetchConfig{
+			ParallelPages: 4,
+			Schema: SchemaCheckConfig{
+				Enabled:        true,
+				MinEntries:     1000,
+				SpikeThreshold: 0.25,
+			},
+			RawArchive: RawArchiveConfig{
+				Combinations:       []string{},
+				KeepPerCombination: 3,
+				MaxSizeMB:          200,
+			},
+			Fixtures: FixtureConfig{Dir: "testdata/raceroom"},
+			Client: HTTPClientConfig{
+				BaseURL:                      "https://game.raceroom.com",
+				UserAgent:                    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+				RequestTimeoutSeconds:        60,
+				CombinationTimeoutSeconds:    120,
+				DialTimeoutSeconds:           30,
+				TLSHandshakeTimeoutSeconds:   10,
+				ResponseHeaderTimeoutSeconds: 60,
+				MaxIdleConns:                 5,
+				MaxIdleConnsPerHost:          2,
+				IdleConnTimeoutSeconds:       30,
+			},
+			Throttle: ThrottleConfig{
+				MinDelayMs:     20,
+				MaxDelayMs:     60000,
+				InitialDelayMs: 100,
+				SlowResponseMs: 5000,
+			},
+		},
+		Discovery: DiscoveryConfig{
+			TrackIDMin:   1600,
+			TrackIDMax:   14000,
+			ProbeClasses: []string{"1703", "1704"}, // GTR 3 and GTR 2 run on nearly every track
+			DelayMs:      250,
+
+			ClassCheckHours: 24,
+		},
+		Snapshots: SnapshotConfig{
+			Enabled:           true,
+			RetentionDays:     30,
+			MaxPerCombination: 30,
+		},
+		Notify: NotifyConfig{
+			Webhooks:       []notify.Webhook{},
+			Discord:        []notify.DiscordChannel{},
+			MaxAttempts:    5,
+			RetrySeconds:   10,
+			TimeoutSeconds: 10,
+		},
+		Reports: ReportConfig{
+			Daily:   true,
+			Weekly:  true,
+			Hour:    23, // Late enough to cover the nightly refresh
+			Formats: []string{},
+		},
+		Championship: ChampionshipConfig{
+			Points:          []int{25, 18, 15, 12, 10, 8, 6, 4, 2, 1},
+			MaxCombinations: 50,
+		},
+		Export: ExportConfig{
+			DriverIndexJSON:    true,
+			DriverIndexRawJSON: false, // Raw JSON is several times larger than the gz copy
+			DriverIndexBinary:  true,
+			DriverIndexShards:  true,
+			DriverIndexDelta:   true,
+			StreamingBuild:     false,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: LogFormatText,
 		},
 	}
 }
+
+// LoadConfig returns the default configuration overlaid with the values found in path
+// A missing file is not an error; an unreadable or invalid file is logged and defaults are used
+func LoadConfig(path string) Config {
+	config := GetDefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			configLog.Warnf("⚠️ Failed to read config file %s: %v (using defaults)", path, err)
+		}
+		return config
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		configLog.Warnf("⚠️ Invalid config file %s: %v (using defaults)", path, err)
+		return GetDefaultConfig()
+	}
+
+	configLog.Infof("⚙️ Loaded configuration from %s", path)
+	return config
+}
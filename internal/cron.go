@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule yields the run times of a Scheduler
+type schedule interface {
+	// Next returns the first run time after t, or the zero time when there is none
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// dailyTimes runs at fixed times of day
+type dailyTimes []int // Minutes after midnight, ascending
+
+// parseDailyTimes parses "HH:MM" times of day
+func parseDailyTimes(times []string) (dailyTimes, error) {
+	minutes := make(dailyTimes, 0, len(times))
+	seen := make(map[int]bool)
+	for _, value := range times {
+		at, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q (expected HH:MM)", value)
+		}
+		minute := at.Hour()*60 + at.Minute()
+		if !seen[minute] {
+			seen[minute] = true
+			minutes = append(minutes, minute)
+		}
+	}
+	if len(minutes) == 0 {
+		return nil, fmt.Errorf("no times given")
+	}
+	sort.Ints(minutes)
+	return minutes, nil
+}
+
+func (d dailyTimes) Next(t time.Time) time.Time {
+	for day := 0; day <= 1; day++ {
+		for _, minute := range d {
+			at := time.Date(t.Year(), t.Month(), t.Day()+day, minute/60, minute%60, 0, 0, t.Location())
+			if at.After(t) {
+				return at
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (d dailyTimes) String() string {
+	times := make([]string, len(d))
+	for i, minute := range d {
+		times[i] = fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+	}
+	return "daily at " + strings.Join(times, ", ")
+}
+
+// cronSchedule is a parsed five-field cron expression: minute hour day-of-month month day-of-week
+// Each field is a bit set of the allowed values
+type cronSchedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool // Field doesn't start with *; both restricted: a day matches either (standard cron)
+}
+
+// cronField describes the range and names of one cron field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{ // 0 and 7 are Sunday
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros are the supported shorthand expressions
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// parseCron parses a cron expression such as "30 4,16 * * *" or "0 6 * * sat,sun"
+// Fields accept *, values, names (jan-dec, sun-sat), ranges (1-5), lists (1,3) and steps (*/15, 0-30/10)
+func parseCron(spec string) (*cronSchedule, error) {
+	expression := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+
+	schedule := &cronSchedule{spec: spec}
+	var err error
+	if schedule.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = cronDom.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = cronDow.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1 // 7 is Sunday too
+	}
+	// Like Vixie cron, a field starting with * (e.g. */2) doesn't count as restricted, so its days
+	// narrow the other field's instead of adding to them
+	schedule.domRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// parse returns the bit set of a field's values
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(strings.ToLower(field), ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			rangePart = before
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field %q", after, f.name, field)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowText, highText, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(lowText); err != nil {
+				return 0, fmt.Errorf("%w in %s field %q", err, f.name, field)
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(highText); err != nil {
+					return 0, fmt.Errorf("%w in %s field %q", err, f.name, field)
+				}
+			} else if step > 1 {
+				high = f.max // "5/15" means from 5 to the end in steps of 15
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name within the field's range
+func (f cronField) value(text string) (int, error) {
+	if value, ok := f.names[text]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
+
+// dayMatches applies the day-of-month and day-of-week fields
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next skips non-matching months, days and hours at a time; there is no match within five years
+// only for impossible dates like "0 0 30 2 *"
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case c.month&(1<<uint(next.Month())) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(next.Hour())) == 0:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(next.Minute())) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) String() string {
+	return "cron " + strconv.Quote(c.spec)
+}
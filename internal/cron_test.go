@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	// 2026-10-16 is a Friday
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"30 4,16 * * *", "2026-10-16 10:00", "2026-10-16 16:30"},
+		{"*/15 * * * *", "2026-10-16 10:00", "2026-10-16 10:15"},
+		{"@daily", "2026-10-16 10:00", "2026-10-17 00:00"},
+		{"0 6 * * sat,sun", "2026-10-16 10:00", "2026-10-17 06:00"},
+		{"0 0 * * 7", "2026-10-16 10:00", "2026-10-18 00:00"},
+		{"0 0 1 * *", "2026-10-16 10:00", "2026-11-01 00:00"},
+		// Both day fields restricted: either one matches
+		{"0 3 20 * mon", "2026-10-16 10:00", "2026-10-19 03:00"},
+		{"0 3 20 * mon", "2026-10-19 04:00", "2026-10-20 03:00"},
+		// A day of month starting with * isn't restricted, so it narrows the days of week: Mondays on odd days
+		{"0 3 */2 * mon", "2026-10-16 10:00", "2026-10-19 03:00"},
+		{"0 3 */2 * mon", "2026-10-19 04:00", "2026-11-09 03:00"},
+		{"0 3 1-31 * mon", "2026-10-16 10:00", "2026-10-17 03:00"},
+		{"0 0 30 2 *", "2026-10-16 10:00", ""},
+	}
+	for _, test := range tests {
+		schedule, err := parseCron(test.spec)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", test.spec, err)
+			continue
+		}
+		got := schedule.Next(at(test.from))
+		if test.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q from %s: next %s, want none", test.spec, test.from, got.Format("2006-01-02 15:04"))
+			}
+		} else if !got.Equal(at(test.want)) {
+			t.Errorf("%q from %s: next %s, want %s", test.spec, test.from, got.Format("2006-01-02 15:04"), test.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// csvHeader is the column order of CSV exports
+var csvHeader = []string{
+	"position", "name", "laptime", "laptime_ms", "time_diff", "country", "country_code", "car", "car_class",
+	"team", "rank", "difficulty", "track", "track_id", "class_id", "date_time", "total_entries",
+}
+
+// wantsCSV reports whether a request asked for CSV output (?format=csv)
+func wantsCSV(r *http.Request) bool {
+	return strings.EqualFold(r.URL.Query().Get("format"), "csv")
+}
+
+// writeResultsCSV streams results as a CSV attachment named filename
+func writeResultsCSV(w http.ResponseWriter, filename string, results []DriverResult) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+csvFileName(filename)+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := NewResultsCSVWriter(w)
+	for i := range results {
+		if err := writer.Write(&results[i]); err != nil {
+			return err
+		}
+		// Flush periodically so large leaderboards stream instead of buffering
+		if i%500 == 499 {
+			writer.Flush()
+		}
+	}
+	return writer.Flush()
+}
+
+// ResultsCSVWriter writes driver results as CSV rows below a header line
+type ResultsCSVWriter struct {
+	writer *csv.Writer
+}
+
+// NewResultsCSVWriter creates a CSV writer for driver results and buffers the header line
+func NewResultsCSVWriter(w io.Writer) *ResultsCSVWriter {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader) // Buffered; write errors surface in Flush
+	return &ResultsCSVWriter{writer: writer}
+}
+
+// Write writes one result as a CSV row
+func (rw *ResultsCSVWriter) Write(result *DriverResult) error {
+	return rw.writer.Write([]string{
+		strconv.Itoa(result.Position),
+		csvText(result.Name),
+		result.LapTime,
+		strconv.FormatInt(result.LapTimeMs, 10),
+		strconv.FormatFloat(result.TimeDiff, 'f', 3, 64),
+		csvText(result.Country),
+		result.CountryCode,
+		csvText(result.Car),
+		csvText(result.CarClass),
+		csvText(result.Team),
+		csvText(result.Rank),
+		csvText(result.Difficulty),
+		csvText(result.Track),
+		result.TrackID,
+		result.ClassID,
+		result.DateTime,
+		strconv.Itoa(result.TotalEntries),
+	})
+}
+
+// Flush writes buffered rows and returns any write error
+func (rw *ResultsCSVWriter) Flush() error {
+	rw.writer.Flush()
+	return rw.writer.Error()
+}
+
+// csvText neutralizes values a spreadsheet would evaluate as a formula (=, +, -, @)
+// Quoting and escaping of commas, quotes and newlines is left to encoding/csv
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvFileName replaces characters that don't belong in a download file name
+func csvFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.') {
+			return r
+		}
+		return '_'
+	}, name)
+}
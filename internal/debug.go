@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+)
+
+// DebugProfileDir is where heap profiles captured via /api/debug/runtime are written
+const DebugProfileDir = "cache/profiles"
+
+// debugPprofPath is the pprof route below the API prefix
+const debugPprofPath = "/debug/pprof/"
+
+// processStart is reported as the start time by /api/debug/runtime
+var processStart = time.Now()
+
+// RuntimeStats is the response of /api/debug/runtime
+type RuntimeStats struct {
+	StartedAt   time.Time    `json:"started_at"`
+	Uptime      string       `json:"uptime"`
+	GoVersion   string       `json:"go_version"`
+	NumCPU      int          `json:"num_cpu"`
+	GOMAXPROCS  int          `json:"gomaxprocs"`
+	Goroutines  int          `json:"goroutines"`
+	MemoryLimit int64        `json:"memory_limit_bytes,omitempty"` // From MEMORY_LIMIT_MB; omitted when unlimited
+	Memory      MemoryStats  `json:"memory"`
+	GC          GCStats      `json:"gc"`
+	Fetch       FetchRuntime `json:"fetch"`
+	Indexing    bool         `json:"indexing"` // True while an index is being built
+}
+
+// MemoryStats is the heap/OS memory part of RuntimeStats (bytes)
+type MemoryStats struct {
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+}
+
+// GCStats is the garbage collector part of RuntimeStats
+type GCStats struct {
+	NumGC        uint32     `json:"num_gc"`
+	NumForcedGC  uint32     `json:"num_forced_gc"`
+	LastGC       *time.Time `json:"last_gc,omitempty"`
+	LastPauseMs  float64    `json:"last_pause_ms"`
+	PauseTotalMs float64    `json:"pause_total_ms"`
+	NextGC       uint64     `json:"next_gc"`
+	CPUFraction  float64    `json:"cpu_fraction"`
+	GOGCPercent  int        `json:"gogc_percent"`
+}
+
+// FetchRuntime is the fetch/pause part of RuntimeStats
+type FetchRuntime struct {
+	Progress FetchProgress `json:"progress"`
+	Paused   bool          `json:"paused"`
+}
+
+// HandleDebugRuntime reports goroutine, memory and GC statistics: GET /api/debug/runtime (admin)
+// POST /api/debug/runtime?action=gc forces a collection and returns memory to the OS;
+// POST /api/debug/runtime?action=heap_profile writes a heap profile to DebugProfileDir
+func (s *APIServer) HandleDebugRuntime(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, ReadRuntimeStats())
+	case http.MethodPost:
+		switch action := r.URL.Query().Get("action"); action {
+		case "gc":
+			before := ReadRuntimeStats().Memory.HeapInuse
+			debug.FreeOSMemory()
+			stats := ReadRuntimeStats()
+			requestLog(r).Infof("🧹 Forced GC via API: heap in use %dMB → %dMB", before/1024/1024, stats.Memory.HeapInuse/1024/1024)
+			writeJSON(w, http.StatusOK, stats)
+		case "heap_profile":
+			path, err := WriteHeapProfile()
+			if err != nil {
+				requestLog(r).Warnf("⚠️ Failed to write heap profile: %v", err)
+				writeError(w, http.StatusInternalServerError, "failed to write heap profile")
+				return
+			}
+			requestLog(r).Infof("📸 Heap profile written to %s", path)
+			writeJSON(w, http.StatusCreated, HeapProfileResponse{Path: path})
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown action %q (expected gc or heap_profile)", action))
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleDebugPprof serves the net/http/pprof profiles under /api/debug/pprof/ (admin)
+func (s *APIServer) HandleDebugPprof(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch name := strings.TrimPrefix(r.URL.Path, s.prefix+debugPprofPath); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		if runtimepprof.Lookup(name) == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("unknown profile %q", name))
+			return
+		}
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// ReadRuntimeStats collects the current runtime statistics
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		StartedAt:  processStart,
+		Uptime:     time.Since(processStart).Round(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			Alloc:        m.Alloc,
+			TotalAlloc:   m.TotalAlloc,
+			Sys:          m.Sys,
+			HeapInuse:    m.HeapInuse,
+			HeapIdle:     m.HeapIdle,
+			HeapReleased: m.HeapReleased,
+			HeapObjects:  m.HeapObjects,
+			StackInuse:   m.StackInuse,
+		},
+		GC: GCStats{
+			NumGC:        m.NumGC,
+			NumForcedGC:  m.NumForcedGC,
+			PauseTotalMs: float64(m.PauseTotalNs) / 1e6,
+			NextGC:       m.NextGC,
+			CPUFraction:  m.GCCPUFraction,
+		},
+		Indexing: IsIndexing(),
+	}
+	if m.NumGC > 0 {
+		lastGC := time.Unix(0, int64(m.LastGC))
+		stats.GC.LastGC = &lastGC
+		stats.GC.LastPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6
+	}
+	// SetGCPercent and SetMemoryLimit return the previous value; restore it right away
+	stats.GC.GOGCPercent = debug.SetGCPercent(-1)
+	debug.SetGCPercent(stats.GC.GOGCPercent)
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		stats.MemoryLimit = limit
+	}
+
+	stats.Fetch.Progress = CurrentFetchProgress()
+	stats.Fetch.Paused, _ = FetchPaused()
+	return stats
+}
+
+// WriteHeapProfile writes a heap profile to DebugProfileDir and returns its path
+// Inspect it with: go tool pprof -http=:6060 <path>
+func WriteHeapProfile() (string, error) {
+	if err := os.MkdirAll(DebugProfileDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(DebugProfileDir, fmt.Sprintf("heap-%s.pprof", time.Now().Format("20060102-150405")))
+
+	// Collect first so the profile reflects live objects, not garbage awaiting collection
+	runtime.GC()
+	var data bytes.Buffer
+	if err := runtimepprof.Lookup("heap").WriteTo(&data, 0); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, data.Bytes()); err != nil {
+		return "", err
+	}
+	return path, nil
+}
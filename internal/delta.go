@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DriverIndexDeltaFile = "cache/driver_index_delta.json"
+	// driverFingerprintsFile keeps the per-driver fingerprints of the last export, so the first delta after a restart has a baseline
+	driverFingerprintsFile = "cache/driver_index_fingerprints.bin"
+)
+
+// DriverIndexDelta lists the drivers that differ between two consecutive driver index exports
+// Applying it to the index of FromVersion (set Added and Changed, delete Removed) gives the index of ToVersion
+type DriverIndexDelta struct {
+	FromVersion string      `json:"from_version"`
+	ToVersion   string      `json:"to_version"`
+	BuiltAt     time.Time   `json:"built_at"`
+	Drivers     int         `json:"drivers"` // Drivers in the ToVersion index
+	Added       DriverIndex `json:"added"`
+	Changed     DriverIndex `json:"changed"` // Every result of the driver, not only the changed ones
+	Removed     []string    `json:"removed"`
+}
+
+// driverFingerprints is the baseline of the next delta: the data version and per-driver fingerprints of an export
+type driverFingerprints struct {
+	DataVersion  string
+	Fingerprints map[string]uint64 // Index key -> fingerprint of the driver's results
+}
+
+// lastDriverFingerprints caches the baseline between exports; loaded from driverFingerprintsFile when nil
+// Only used by the driver_index_delta exporter, which runs under indexBuildMu
+var lastDriverFingerprints *driverFingerprints
+
+// fingerprintDriverResults hashes every field of a driver's results, in order
+func fingerprintDriverResults(results []DriverResult) uint64 {
+	hasher := fnv.New64a()
+	var scratch []byte
+	for i := range results {
+		result := &results[i]
+		scratch = scratch[:0]
+		for _, field := range []string{result.Name, result.DriverID, result.LapTime, result.Country, result.CountryCode, result.Car,
+			result.CarClass, result.Team, result.Rank, result.Difficulty, result.Track, result.TrackID, result.ClassID, result.DateTime} {
+			scratch = append(append(scratch, field...), 0)
+		}
+		scratch = strconv.AppendInt(scratch, int64(result.Position), 10)
+		scratch = strconv.AppendInt(append(scratch, 0), result.LapTimeMs, 10)
+		scratch = strconv.AppendUint(append(scratch, 0), math.Float64bits(result.TimeDiff), 16)
+		scratch = strconv.AppendBool(append(scratch, 0), result.Found)
+		scratch = strconv.AppendInt(append(scratch, 0), int64(result.TotalEntries), 10)
+		hasher.Write(append(scratch, '\n'))
+	}
+	return hasher.Sum64()
+}
+
+// ExportDriverIndexDelta writes the drivers added, changed and removed since the previous export to
+// DriverIndexDeltaFile (gzipped), then records the index as the baseline of the next delta
+// Without a baseline (first export) no delta is written and a stale one is removed: consumers need the full index
+func ExportDriverIndexDelta(index DriverIndex, dataVersion string) error {
+	start := time.Now()
+	current := &driverFingerprints{DataVersion: dataVersion, Fingerprints: make(map[string]uint64, len(index))}
+	for key, results := range index {
+		current.Fingerprints[key] = fingerprintDriverResults(results)
+	}
+
+	previous := lastDriverFingerprints
+	if previous == nil {
+		previous = readDriverFingerprints()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(DriverIndexDeltaFile), 0755); err != nil {
+		exportLog.Errorf("❌ Failed to create cache directory: %v", err)
+		return err
+	}
+	if previous == nil {
+		exportLog.Infof("📝 No previous driver index export to diff against - skipping delta")
+		if err := os.Remove(DriverIndexDeltaFile + ".gz"); err != nil && !os.IsNotExist(err) {
+			exportLog.Warnf("⚠️ Failed to remove stale driver index delta: %v", err)
+		}
+	} else if err := writeDriverIndexDelta(index, current, previous, start); err != nil {
+		return err
+	}
+
+	lastDriverFingerprints = current
+	if err := writeDriverFingerprints(current); err != nil {
+		// The in-memory baseline still serves the next export; only a restart loses it
+		exportLog.Warnf("⚠️ Failed to save driver index fingerprints: %v", err)
+	}
+	return nil
+}
+
+// writeDriverIndexDelta diffs the fingerprints and writes the delta file
+func writeDriverIndexDelta(index DriverIndex, current, previous *driverFingerprints, start time.Time) error {
+	delta := DriverIndexDelta{
+		FromVersion: previous.DataVersion,
+		ToVersion:   current.DataVersion,
+		BuiltAt:     time.Now().UTC(),
+		Drivers:     len(index),
+		Added:       DriverIndex{},
+		Changed:     DriverIndex{},
+		Removed:     []string{},
+	}
+	for key, fingerprint := range current.Fingerprints {
+		if before, ok := previous.Fingerprints[key]; !ok {
+			delta.Added[key] = index[key]
+		} else if before != fingerprint {
+			delta.Changed[key] = index[key]
+		}
+	}
+	for key := range previous.Fingerprints {
+		if _, ok := current.Fingerprints[key]; !ok {
+			delta.Removed = append(delta.Removed, key)
+		}
+	}
+	sort.Strings(delta.Removed)
+
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	gzWriter.Name = filepath.Base(DriverIndexDeltaFile)
+	err := json.NewEncoder(gzWriter).Encode(delta)
+	if closeErr := gzWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = writeFileAtomic(DriverIndexDeltaFile+".gz", buf.Bytes())
+	}
+	if err != nil {
+		exportLog.Errorf("❌ Failed to write driver index delta: %v", err)
+		return err
+	}
+	exportLog.Infof("💾 Driver index delta exported to %s.gz (%.3f seconds, %d added, %d changed, %d removed, %.2f MB)",
+		DriverIndexDeltaFile, time.Since(start).Seconds(), len(delta.Added), len(delta.Changed), len(delta.Removed),
+		float64(buf.Len())/(1024*1024))
+	return nil
+}
+
+// readDriverFingerprints loads the baseline saved by the last export; nil when missing or unreadable
+func readDriverFingerprints() *driverFingerprints {
+	file, err := os.Open(driverFingerprintsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			exportLog.Warnf("⚠️ Failed to open %s: %v", driverFingerprintsFile, err)
+		}
+		return nil
+	}
+	defer file.Close()
+
+	var fingerprints driverFingerprints
+	if err := gob.NewDecoder(file).Decode(&fingerprints); err != nil {
+		exportLog.Warnf("⚠️ Ignoring invalid %s: %v", driverFingerprintsFile, err)
+		return nil
+	}
+	return &fingerprints
+}
+
+// writeDriverFingerprints saves the baseline of the next delta
+func writeDriverFingerprints(fingerprints *driverFingerprints) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(fingerprints); err != nil {
+		return err
+	}
+	return writeFileAtomic(driverFingerprintsFile, buf.Bytes())
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"r3e-leaderboard/internal/notify"
+)
+
+const (
+	discordUsername       = "R3E Leaderboard"
+	discordSummaryRecords = 5 // Records listed in a refresh summary
+)
+
+// postDiscord turns an event into Discord messages for the channels that want it
+func postDiscord(notifier *notify.Notifier, channels []notify.DiscordChannel, event Event) {
+	var message notify.DiscordMessage
+	var wants func(notify.DiscordChannel) bool
+	switch data := event.Data.(type) {
+	case WorldRecord:
+		message = discordRecordMessage(data)
+		wants = func(channel notify.DiscordChannel) bool { return channel.Records }
+	case FetchCompletedEvent:
+		message = discordSummaryMessage(data, event.Time)
+		wants = func(channel notify.DiscordChannel) bool {
+			for _, origin := range channel.SummaryOrigins {
+				if origin == data.Origin {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return
+	}
+	for _, channel := range channels {
+		if wants(channel) {
+			notifier.PostDiscord(channel, event.Type, message)
+		}
+	}
+}
+
+// discordRecordMessage announces a world record
+func discordRecordMessage(record WorldRecord) notify.DiscordMessage {
+	detected := record.DetectedAt
+	return notify.DiscordMessage{
+		Username: discordUsername,
+		Embeds: []notify.DiscordEmbed{{
+			Title:       "🏆 New world record: " + record.Track,
+			Description: fmt.Sprintf("**%s** set **%s** in %s", record.NewHolder, record.NewLapTime, record.Class),
+			Color:       notify.DiscordColorGold,
+			Fields: []notify.DiscordEmbedField{
+				{Name: "Improvement", Value: formatDeltaMs(record.DeltaMs), Inline: true},
+				{Name: "Previous record", Value: record.OldLapTime + " by " + record.OldHolder, Inline: true},
+			},
+			Timestamp: &detected,
+		}},
+	}
+}
+
+// discordSummaryMessage summarizes a finished refresh run with the records it set
+func discordSummaryMessage(completed FetchCompletedEvent, finishedAt time.Time) notify.DiscordMessage {
+	color := notify.DiscordColorGreen
+	if completed.Failed > 0 {
+		color = notify.DiscordColorRed
+	}
+	duration := (time.Duration(completed.DurationMs) * time.Millisecond).Round(time.Second)
+	embed := notify.DiscordEmbed{
+		Title: fmt.Sprintf("✅ Refresh finished (%s)", completed.Origin),
+		Color: color,
+		Fields: []notify.DiscordEmbedField{
+			{Name: "Combinations", Value: fmt.Sprintf("%d/%d", completed.Processed, completed.Total), Inline: true},
+			{Name: "Errors", Value: fmt.Sprintf("%d", completed.Failed), Inline: true},
+			{Name: "Duration", Value: duration.String(), Inline: true},
+		},
+		Timestamp: &finishedAt,
+	}
+
+	// Records detected while the run was promoting its cache, biggest improvements first
+	var records []WorldRecord
+	for _, record := range RecentWorldRecords(maxWorldRecords) {
+		if !record.DetectedAt.Before(completed.StartedAt) {
+			records = append(records, record)
+		}
+	}
+	if len(records) > 0 {
+		sort.Slice(records, func(i, j int) bool { return records[i].DeltaMs > records[j].DeltaMs })
+		lines := make([]string, 0, discordSummaryRecords)
+		for i, record := range records {
+			if i == discordSummaryRecords {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("**%s** — %s / %s: %s (%s)",
+				record.NewHolder, record.Track, record.Class, record.NewLapTime, formatDeltaMs(record.DeltaMs)))
+		}
+		embed.Fields = append(embed.Fields, notify.DiscordEmbedField{
+			Name:  fmt.Sprintf("🏆 New records (%d)", len(records)),
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+	return notify.DiscordMessage{Username: discordUsername, Embeds: []notify.DiscordEmbed{embed}}
+}
+
+// formatDeltaMs formats a lap time improvement as "-0.255s"
+func formatDeltaMs(deltaMs int64) string {
+	return fmt.Sprintf("-%.3fs", float64(deltaMs)/1000)
+}
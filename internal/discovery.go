@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscoveredClassesFile holds the result of the last class discovery
+const DiscoveredClassesFile = "cache/discovered_classes.json"
+
+// leaderboardPagePath is the page listing every car class in its class selector
+const leaderboardPagePath = "/leaderboard/"
+
+// classOptionPattern matches the class selector options: <option value="class-1703">GTR 3</option>
+var classOptionPattern = regexp.MustCompile(`<option[^>]*value="class-(\d+)"[^>]*>([^<]+)</option>`)
+
+// DiscoverTracks probes every track ID in the configured range against the RaceRoom leaderboard
+// A track ID is valid when any of the probe classes has a leaderboard entry on it.
+// Catalog tracks are not probed again; the result holds catalog and discovered tracks sorted by name
+func DiscoverTracks(ctx context.Context, config DiscoveryConfig) ([]TrackConfig, error) {
+	if config.TrackIDMin < 1 || config.TrackIDMax < config.TrackIDMin {
+		return nil, fmt.Errorf("invalid track ID range %d-%d", config.TrackIDMin, config.TrackIDMax)
+	}
+	if len(config.ProbeClasses) == 0 {
+		return nil, fmt.Errorf("no probe classes configured")
+	}
+
+	tracks := allTracks()
+	known := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		known[track.TrackID] = true
+	}
+
+	apiClient := NewAPIClient()
+	defer apiClient.Close()
+	delay := time.Duration(config.DelayMs) * time.Millisecond
+	candidates := config.TrackIDMax - config.TrackIDMin + 1
+	discovered := 0
+
+	loaderLog.Infof("🔭 Probing %d track IDs (%d-%d) with classes %s...",
+		candidates, config.TrackIDMin, config.TrackIDMax, strings.Join(config.ProbeClasses, ", "))
+	for id := config.TrackIDMin; id <= config.TrackIDMax; id++ {
+		if (id-config.TrackIDMin)%500 == 0 && id > config.TrackIDMin {
+			loaderLog.Infof("🔭 Probed %d/%d track IDs, %d new track(s)", id-config.TrackIDMin, candidates, discovered)
+		}
+		trackID := strconv.Itoa(id)
+		if known[trackID] {
+			continue
+		}
+		if err := waitWhilePaused(ctx); err != nil {
+			return nil, err
+		}
+
+		for _, classID := range config.ProbeClasses {
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			name, found, err := apiClient.ProbeTrack(ctx, trackID, classID)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				loaderLog.Debugf("🔭 Probe %s + %s failed: %v", trackID, classID, err)
+			}
+			if found {
+				if name == "" {
+					name = "Track " + trackID
+				}
+				tracks = append(tracks, TrackConfig{Name: name, TrackID: trackID})
+				discovered++
+				loaderLog.Infof("🆕 Discovered track %s: %s", trackID, name)
+				break
+			}
+		}
+	}
+
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].Name < tracks[j].Name
+	})
+	loaderLog.Infof("✅ Track discovery complete: %d new track(s), %d in total", discovered, len(tracks))
+	return tracks, nil
+}
+
+// probeResponse is the part of a leaderboard listing read by ProbeTrack
+type probeResponse struct {
+	Context struct {
+		C struct {
+			Results []APIResult `json:"results"`
+		} `json:"c"`
+	} `json:"context"`
+}
+
+// ProbeTrack requests a single leaderboard entry of trackID + classID
+// found reports whether an entry exists; name is the track name the entry carries, if any
+func (api *APIClient) ProbeTrack(ctx context.Context, trackID, classID string) (string, bool, error) {
+	apiURL := api.baseURL + "/leaderboard/listing/0?track=" + trackID + "&car_class=class-" + classID + "&start=0&count=1"
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("User-Agent", api.userAgent)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, newAPIStatusError(resp)
+	}
+
+	var response probeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && !isFieldTypeError(err) {
+		return "", false, err
+	}
+	results := response.Context.C.Results
+	if len(results) == 0 {
+		return "", false, nil
+	}
+	return trackNameFromResult(results[0].Track), true, nil
+}
+
+// trackNameFromResult builds "Venue - Layout" from the track object of a listing entry
+func trackNameFromResult(track map[string]interface{}) string {
+	name, _ := track["name"].(string)
+	layout, _ := track["layout"].(string)
+	if layout == "" {
+		layout, _ = track["layout_name"].(string)
+	}
+	if name != "" && layout != "" {
+		return name + " - " + layout
+	}
+	return name
+}
+
+// ClassDiscoveryResult is the difference between the RaceRoom class list and the catalog
+type ClassDiscoveryResult struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	Listed    int                `json:"listed"`  // Classes offered by the RaceRoom leaderboard
+	New       []ClassCatalogItem `json:"new"`     // Listed but not in the catalog
+	Missing   []ClassCatalogItem `json:"missing"` // In the catalog but no longer listed
+}
+
+// StartClassDiscovery compares the RaceRoom class list with the catalog now and every interval until ctx is done
+func StartClassDiscovery(ctx context.Context, interval time.Duration) {
+	go func() {
+		schedulerLog.Infof("🔭 Class discovery checking RaceRoom every %v", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := DiscoverClasses(ctx); err != nil && ctx.Err() == nil {
+				loaderLog.Warnf("⚠️ Class discovery failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// DiscoverClasses scrapes the leaderboard class selector, diffs it with the catalog and writes DiscoveredClassesFile
+// New classes are logged and published as an EventClassesDiscovered event; add them to classes.json to fetch them
+func DiscoverClasses(ctx context.Context) (ClassDiscoveryResult, error) {
+	result := ClassDiscoveryResult{CheckedAt: time.Now(), New: []ClassCatalogItem{}, Missing: []ClassCatalogItem{}}
+	if err := waitWhilePaused(ctx); err != nil {
+		return result, err
+	}
+
+	apiClient := NewAPIClient()
+	defer apiClient.Close()
+	listed, err := apiClient.FetchClassList(ctx)
+	if err != nil {
+		return result, err
+	}
+	if len(listed) == 0 {
+		return result, fmt.Errorf("no car classes found on %s (page layout changed?)", apiClient.baseURL+leaderboardPagePath)
+	}
+	result.Listed = len(listed)
+
+	known := make(map[string]bool)
+	for _, class := range allCarClasses() {
+		known[class.ClassID] = true
+		if _, ok := listed[class.ClassID]; !ok {
+			result.Missing = append(result.Missing, ClassCatalogItem{ClassID: class.ClassID, Name: class.Name})
+		}
+	}
+	for id, name := range listed {
+		if !known[id] {
+			result.New = append(result.New, ClassCatalogItem{ClassID: id, Name: name})
+		}
+	}
+	sort.Slice(result.New, func(i, j int) bool { return result.New[i].Name < result.New[j].Name })
+
+	for _, class := range result.New {
+		loaderLog.Infof("🆕 New car class on RaceRoom: %s (%s)", class.Name, class.ClassID)
+	}
+	if len(result.Missing) > 0 {
+		loaderLog.Infof("ℹ️ %d catalog class(es) no longer listed by RaceRoom", len(result.Missing))
+	}
+	if len(result.New) > 0 {
+		eventBroker.Publish(EventClassesDiscovered, result)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	if err := writeFileAtomic(DiscoveredClassesFile, data); err != nil {
+		return result, err
+	}
+	loaderLog.Infof("🔭 Class discovery: %d listed, %d new, %d missing → %s", result.Listed, len(result.New), len(result.Missing), DiscoveredClassesFile)
+	return result, nil
+}
+
+// FetchClassList returns the car classes (ID → name) of the leaderboard page's class selector
+func (api *APIClient) FetchClassList(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", api.baseURL+leaderboardPagePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", api.userAgent)
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leaderboard page returned status code %d", resp.StatusCode)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseClassOptions(page), nil
+}
+
+// parseClassOptions extracts the class options of a leaderboard page
+func parseClassOptions(page []byte) map[string]string {
+	classes := make(map[string]string)
+	for _, match := range classOptionPattern.FindAllSubmatch(page, -1) {
+		name := strings.TrimSpace(html.UnescapeString(string(match[2])))
+		if name != "" {
+			classes[string(match[1])] = name
+		}
+	}
+	return classes
+}
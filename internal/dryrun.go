@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultSecondsPerCombination estimates the fetch time of a combination before any run has finished
+const defaultSecondsPerCombination = 1.5
+
+// Why a combination would be fetched
+const (
+	PlanReasonMissing = "missing" // Not cached
+	PlanReasonExpired = "expired" // Cached longer than its max age
+	PlanReasonForced  = "forced"  // Fresh, but the refresh forces a refetch
+)
+
+// RefreshPlan is the result of a dry run: the combinations a refresh would fetch, without any network calls
+type RefreshPlan struct {
+	DryRun                bool           `json:"dry_run"`
+	Force                 bool           `json:"force"`
+	Combinations          int            `json:"combinations"` // In the refresh's scope
+	Missing               int            `json:"missing"`
+	Expired               int            `json:"expired"`
+	Fresh                 int            `json:"fresh"`
+	Fetch                 int            `json:"fetch"` // Missing and expired, plus fresh ones when forced
+	SecondsPerCombination float64        `json:"seconds_per_combination"`
+	EstimateSource        string         `json:"estimate_source"` // last_run or default
+	EstimatedSeconds      int            `json:"estimated_seconds"`
+	Fetches               []PlannedFetch `json:"fetches"`
+}
+
+// PlannedFetch is one combination a refresh would fetch
+type PlannedFetch struct {
+	TrackID     string  `json:"track_id"`
+	Track       string  `json:"track"`
+	ClassID     string  `json:"class_id"`
+	Class       string  `json:"class"`
+	Reason      string  `json:"reason"`
+	AgeHours    float64 `json:"age_hours,omitempty"`     // Age of the cache file
+	MaxAgeHours float64 `json:"max_age_hours,omitempty"` // Max age from cache.max_age_hours and the TTL rules
+}
+
+// PlanRefresh walks the combinations of refresh targets (as accepted by PerformTargetedRefresh; empty means
+// every configured combination) and reports which would be fetched and roughly how long that takes
+// Only cache file ages are read, so the selection and TTL rules can be checked without touching RaceRoom
+func PlanRefresh(targets []string, force bool) RefreshPlan {
+	plan := RefreshPlan{DryRun: true, Force: force, Fetches: []PlannedFetch{}}
+	cache := NewDataCache()
+	for _, combination := range expandRefreshTargets(targets) {
+		track, class := combination.track, combination.class
+		plan.Combinations++
+		fetch := PlannedFetch{TrackID: track.TrackID, Track: track.Name, ClassID: class.ClassID, Class: class.Name}
+		maxAge := CacheMaxAge(track.TrackID, class.ClassID)
+		age := cache.GetCacheAge(track.TrackID, class.ClassID)
+		switch {
+		case age < 0:
+			plan.Missing++
+			fetch.Reason = PlanReasonMissing
+		case age >= maxAge:
+			plan.Expired++
+			fetch.Reason = PlanReasonExpired
+		default:
+			plan.Fresh++
+			if !force {
+				continue
+			}
+			fetch.Reason = PlanReasonForced
+		}
+		if age >= 0 {
+			fetch.AgeHours = roundHours(age)
+			fetch.MaxAgeHours = roundHours(maxAge)
+		}
+		plan.Fetches = append(plan.Fetches, fetch)
+	}
+	plan.Fetch = len(plan.Fetches)
+
+	plan.SecondsPerCombination, plan.EstimateSource = defaultSecondsPerCombination, "default"
+	if last := ReadStatusData().FetchProgress; last != nil && last.FinishedAt != nil && last.Processed > 0 {
+		plan.SecondsPerCombination = last.FinishedAt.Sub(last.StartedAt).Seconds() / float64(last.Processed)
+		plan.EstimateSource = "last_run"
+	}
+	plan.EstimatedSeconds = int(plan.SecondsPerCombination * float64(plan.Fetch))
+	return plan
+}
+
+// refreshCombination is one track/class pair in the scope of a refresh
+type refreshCombination struct {
+	track TrackConfig
+	class CarClassConfig
+}
+
+// expandRefreshTargets resolves refresh tokens ("trackID", "trackID-classID", "*-classID") to configured
+// combinations, each once; no tokens selects every combination
+func expandRefreshTargets(targets []string) []refreshCombination {
+	tracks, classes := GetTracks(), GetCarClasses()
+	if len(targets) == 0 {
+		combinations := make([]refreshCombination, 0, len(tracks)*len(classes))
+		for _, track := range tracks {
+			for _, class := range classes {
+				combinations = append(combinations, refreshCombination{track, class})
+			}
+		}
+		return combinations
+	}
+
+	var combinations []refreshCombination
+	seen := make(map[string]bool)
+	add := func(track TrackConfig, class CarClassConfig) {
+		if key := track.TrackID + "_" + class.ClassID; !seen[key] {
+			seen[key] = true
+			combinations = append(combinations, refreshCombination{track, class})
+		}
+	}
+	for _, token := range targets {
+		trackID, classID, hasClass := strings.Cut(token, "-")
+		for _, track := range tracks {
+			if trackID != AllTracksToken && track.TrackID != trackID {
+				continue
+			}
+			for _, class := range classes {
+				if !hasClass || class.ClassID == classID {
+					add(track, class)
+				}
+			}
+		}
+	}
+	return combinations
+}
+
+// roundHours converts a duration to hours with one decimal
+func roundHours(d time.Duration) float64 {
+	return float64(d.Round(6*time.Minute)) / float64(time.Hour)
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published on the event stream
+const (
+	EventFetchProgress = "fetch_progress" // One combination processed (FetchProgressEvent)
+	EventCachePromoted = "cache_promoted" // Temp cache promoted to the main cache
+	EventIndexStarted  = "index_started"  // Index rebuild started
+	EventIndexFinished = "index_finished" // Index rebuild finished (IndexFinishedEvent)
+	EventError         = "error"          // Fetch, promotion or export failure
+
+	EventClassesDiscovered = "classes_discovered" // Class discovery found classes missing from the catalog (ClassDiscoveryResult)
+	EventWorldRecord       = "world_record"       // A combination's P1 lap time improved (WorldRecord)
+
+	EventFetchCompleted        = "fetch_completed"         // A fetch run finished or was cancelled (FetchCompletedEvent)
+	EventFetchFailedRepeatedly = "fetch_failed_repeatedly" // A combination also failed its retry (FetchFailedEvent)
+	EventSchemaDrift           = "schema_drift"            // A refresh's entries lack expected fields; it won't be promoted (SchemaCheck)
+)
+
+// eventBufferSize is how many events a slow subscriber may lag behind before events are dropped for it
+const eventBufferSize = 64
+
+// Event is a single message on the event stream
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// FetchProgressEvent is the payload of EventFetchProgress
+type FetchProgressEvent struct {
+	FetchProgress
+	TrackID string `json:"track_id"`
+	ClassID string `json:"class_id"`
+	Track   string `json:"track"`
+	Class   string `json:"class"`
+}
+
+// IndexFinishedEvent is the payload of EventIndexFinished
+type IndexFinishedEvent struct {
+	Drivers     int    `json:"drivers"`
+	Entries     int    `json:"entries"`
+	DurationMs  int64  `json:"duration_ms"`
+	DataVersion string `json:"data_version"`
+	Skipped     bool   `json:"skipped,omitempty"` // Data unchanged, nothing rebuilt
+}
+
+// FetchCompletedEvent is the payload of EventFetchCompleted
+type FetchCompletedEvent struct {
+	FetchProgress
+	Origin     string    `json:"origin"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// FetchFailedEvent is the payload of EventFetchFailedRepeatedly
+type FetchFailedEvent struct {
+	TrackID string `json:"track_id"`
+	ClassID string `json:"class_id"`
+	Track   string `json:"track"`
+	Class   string `json:"class"`
+	Message string `json:"message"`
+}
+
+// ErrorEvent is the payload of EventError
+type ErrorEvent struct {
+	Source  string `json:"source"` // fetch, cache, index, health
+	Message string `json:"message"`
+}
+
+// EventBroker fans published events out to all current subscribers
+// Publishing never blocks: a subscriber whose buffer is full misses the event
+type EventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// eventBroker is the process-wide broker used by the loader, cache and indexer
+var eventBroker = NewEventBroker()
+
+// NewEventBroker creates a broker without subscribers
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// GetEventBroker returns the shared event broker
+func GetEventBroker() *EventBroker {
+	return eventBroker
+}
+
+// Subscribe returns a channel receiving all future events and a function to unsubscribe
+func (b *EventBroker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends an event to every subscriber without blocking
+func (b *EventBroker) Publish(eventType string, data interface{}) {
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// publishError publishes an EventError
+func publishError(source string, err error) {
+	eventBroker.Publish(EventError, ErrorEvent{Source: source, Message: err.Error()})
+}
@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExclusionsFile persists the drivers removed on request through /api/exclusions
+const ExclusionsFile = "cache/excluded_drivers.json"
+
+// DriverExclusion removes a driver from every index build, export and API response
+// An entry matches results with the same (normalized) name or the same RaceRoom user ID
+type DriverExclusion struct {
+	Name        string    `json:"name,omitempty"`
+	DriverID    string    `json:"driver_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"` // Free text, e.g. a ticket reference
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// ErrAlreadyExcluded is returned when adding an exclusion for a name or user ID that is already excluded
+var ErrAlreadyExcluded = errors.New("already excluded")
+
+// driverExclusions holds the exclusion list; loaded from ExclusionsFile on first use
+var driverExclusions = struct {
+	sync.RWMutex
+	load     sync.Once
+	list     []DriverExclusion
+	names    map[string]bool // Normalized names
+	ids      map[string]bool
+	revision uint64 // Fingerprint of the list, so a changed list forces the next index export
+}{}
+
+// loadExclusions reads ExclusionsFile once
+func loadExclusions() {
+	driverExclusions.load.Do(func() {
+		driverExclusions.Lock()
+		defer driverExclusions.Unlock()
+		data, err := os.ReadFile(ExclusionsFile)
+		if err == nil {
+			if err := json.Unmarshal(data, &driverExclusions.list); err != nil {
+				indexerLog.Warnf("⚠️ Ignoring invalid %s: %v", ExclusionsFile, err)
+				driverExclusions.list = nil
+			}
+		} else if !os.IsNotExist(err) {
+			indexerLog.Warnf("⚠️ Failed to read %s: %v", ExclusionsFile, err)
+		}
+		reindexExclusionsLocked()
+		if len(driverExclusions.list) > 0 {
+			indexerLog.Infof("🚫 Loaded %d driver exclusions from %s", len(driverExclusions.list), ExclusionsFile)
+		}
+	})
+}
+
+// DriverExclusions returns the exclusion list, oldest first
+func DriverExclusions() []DriverExclusion {
+	loadExclusions()
+	driverExclusions.RLock()
+	defer driverExclusions.RUnlock()
+	return append([]DriverExclusion{}, driverExclusions.list...)
+}
+
+// AddDriverExclusion adds a driver to the exclusion list and persists it
+// Fails when neither a name nor a user ID is given, or when either is already excluded
+func AddDriverExclusion(exclusion DriverExclusion) (DriverExclusion, error) {
+	exclusion.Name = strings.TrimSpace(exclusion.Name)
+	exclusion.DriverID = strings.TrimSpace(exclusion.DriverID)
+	exclusion.Reason = strings.TrimSpace(exclusion.Reason)
+	if NormalizeDriverName(exclusion.Name) == "" && exclusion.DriverID == "" {
+		return DriverExclusion{}, fmt.Errorf("missing name or driver_id")
+	}
+	exclusion.RequestedAt = time.Now().UTC()
+
+	loadExclusions()
+	driverExclusions.Lock()
+	defer driverExclusions.Unlock()
+	if key := NormalizeDriverName(exclusion.Name); key != "" && driverExclusions.names[key] {
+		return DriverExclusion{}, fmt.Errorf("driver %q: %w", exclusion.Name, ErrAlreadyExcluded)
+	}
+	if exclusion.DriverID != "" && driverExclusions.ids[exclusion.DriverID] {
+		return DriverExclusion{}, fmt.Errorf("driver ID %q: %w", exclusion.DriverID, ErrAlreadyExcluded)
+	}
+
+	previous := driverExclusions.list
+	driverExclusions.list = append(append([]DriverExclusion(nil), previous...), exclusion)
+	if err := saveExclusionsLocked(); err != nil {
+		driverExclusions.list = previous
+		return DriverExclusion{}, err
+	}
+	reindexExclusionsLocked()
+	indexerLog.Infof("🚫 Excluded driver %q (ID %q) from indexes and exports", exclusion.Name, exclusion.DriverID)
+	return exclusion, nil
+}
+
+// RemoveDriverExclusion drops the entries matching a name or a user ID and persists the list
+// The driver's results come back with the next index build
+func RemoveDriverExclusion(name, driverID string) error {
+	key := NormalizeDriverName(name)
+	driverID = strings.TrimSpace(driverID)
+
+	loadExclusions()
+	driverExclusions.Lock()
+	defer driverExclusions.Unlock()
+	previous := driverExclusions.list
+	var kept []DriverExclusion
+	for _, exclusion := range previous {
+		if (key != "" && NormalizeDriverName(exclusion.Name) == key) || (driverID != "" && exclusion.DriverID == driverID) {
+			continue
+		}
+		kept = append(kept, exclusion)
+	}
+	if len(kept) == len(previous) {
+		return fmt.Errorf("no exclusion for %q", strings.TrimSpace(name+" "+driverID))
+	}
+	driverExclusions.list = kept
+	if err := saveExclusionsLocked(); err != nil {
+		driverExclusions.list = previous
+		return err
+	}
+	reindexExclusionsLocked()
+	indexerLog.Infof("🚫 Removed %d driver exclusion(s)", len(previous)-len(kept))
+	return nil
+}
+
+// isDriverExcluded reports whether a result with this name or user ID belongs to an excluded driver
+func isDriverExcluded(name, driverID string) bool {
+	loadExclusions()
+	driverExclusions.RLock()
+	defer driverExclusions.RUnlock()
+	if len(driverExclusions.list) == 0 {
+		return false
+	}
+	return (driverID != "" && driverExclusions.ids[driverID]) || driverExclusions.names[NormalizeDriverName(name)]
+}
+
+// exclusionsRevision returns the fingerprint of the exclusion list; 0 when it is empty
+func exclusionsRevision() uint64 {
+	loadExclusions()
+	driverExclusions.RLock()
+	defer driverExclusions.RUnlock()
+	return driverExclusions.revision
+}
+
+// withoutExcludedDrivers returns the index without the results of excluded drivers
+// The index is returned as is when nothing is excluded
+func withoutExcludedDrivers(index DriverIndex) DriverIndex {
+	if exclusionsRevision() == 0 {
+		return index
+	}
+	filtered := make(DriverIndex, len(index))
+	for key, results := range index {
+		var kept []DriverResult
+		for _, result := range results {
+			if !isDriverExcluded(result.Name, result.DriverID) {
+				kept = append(kept, result)
+			}
+		}
+		if len(kept) > 0 {
+			filtered[key] = kept
+		}
+	}
+	return filtered
+}
+
+// withoutExcludedChanges drops excluded drivers from a combination's changes
+func withoutExcludedChanges(changes LeaderboardChanges) LeaderboardChanges {
+	if exclusionsRevision() == 0 {
+		return changes
+	}
+	keep := func(entries []EntryChange) []EntryChange {
+		kept := []EntryChange{}
+		for _, entry := range entries {
+			if !isDriverExcluded(entry.Name, "") {
+				kept = append(kept, entry)
+			}
+		}
+		return kept
+	}
+	changes.NewEntries = keep(changes.NewEntries)
+	changes.Improved = keep(changes.Improved)
+	changes.PositionChanges = keep(changes.PositionChanges)
+	return changes
+}
+
+// reindexExclusionsLocked rebuilds the lookup maps and the revision; callers hold driverExclusions
+func reindexExclusionsLocked() {
+	driverExclusions.names = make(map[string]bool)
+	driverExclusions.ids = make(map[string]bool)
+	var keys []string
+	for _, exclusion := range driverExclusions.list {
+		if key := NormalizeDriverName(exclusion.Name); key != "" {
+			driverExclusions.names[key] = true
+			keys = append(keys, "name:"+key)
+		}
+		if exclusion.DriverID != "" {
+			driverExclusions.ids[exclusion.DriverID] = true
+			keys = append(keys, "id:"+exclusion.DriverID)
+		}
+	}
+
+	driverExclusions.revision = 0
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		hasher := fnv.New64a()
+		for _, key := range keys {
+			hasher.Write([]byte(key))
+			hasher.Write([]byte{0})
+		}
+		driverExclusions.revision = hasher.Sum64() | 1 // Never 0, which means no exclusions
+	}
+}
+
+// saveExclusionsLocked writes the list to disk atomically; callers hold driverExclusions
+func saveExclusionsLocked() error {
+	list := driverExclusions.list
+	if list == nil {
+		list = []DriverExclusion{}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(ExclusionsFile), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(ExclusionsFile, data)
+	}
+	return err
+}
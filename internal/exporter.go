@@ -1,10 +1,13 @@
 package internal
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"encoding/gob"
 	"encoding/json"
-	"log"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -12,11 +15,141 @@ import (
 )
 
 const (
-	DriverIndexFile     = "cache/driver_index.json"
-	StatusFile          = "cache/status.json"
-	TopCombinationsFile = "cache/top_combinations.json"
+	DriverIndexFile       = "cache/driver_index.json"
+	DriverIndexBinaryFile = "cache/driver_index.bin"
+	StatusFile            = "cache/status.json"
+	TopCombinationsFile   = "cache/top_combinations.json"
 )
 
+// binaryIndexVersion is bumped whenever BinaryDriverIndex changes incompatibly
+const binaryIndexVersion = 1
+
+// BinaryDriverIndex is the gob-encoded payload of driver_index.bin
+// It carries the entry counts too so a loaded index can serve per-track/class stats
+type BinaryDriverIndex struct {
+	Version          int
+	BuiltAt          time.Time
+	Index            DriverIndex
+	TrackEntryCounts map[string]int
+}
+
+// exportConfig selects the driver index formats written by BuildAndExportIndex
+var exportConfig = GetDefaultConfig().Export
+
+// IndexBuild is the result of an index build handed to every IndexExporter
+type IndexBuild struct {
+	Tracks           []TrackInfo
+	Index            DriverIndex
+	TrackEntryCounts map[string]int // trackID_classID -> entry count
+	UniqueTracks     int
+	TotalEntries     int
+	BuildDuration    time.Duration
+	DataVersion      string
+	CombinationStats map[string]CombinationStatistics // trackID_classID -> statistics; computed from Tracks when nil
+}
+
+// IndexExporter writes one output of an index build
+type IndexExporter struct {
+	Name     string
+	Enabled  func() bool // nil means always enabled
+	Required bool        // A failure aborts the export and fails BuildAndExportIndex
+	Export   func(build *IndexBuild) error
+}
+
+// indexExporters run in order after every index build
+var indexExporters = []IndexExporter{
+	{
+		Name:     "driver_index_json",
+		Enabled:  func() bool { return exportConfig.DriverIndexJSON },
+		Required: true,
+		Export: func(build *IndexBuild) error {
+			return ExportDriverIndex(build.Index, build.BuildDuration)
+		},
+	},
+	{
+		Name:    "driver_index_shards",
+		Enabled: func() bool { return exportConfig.DriverIndexShards },
+		Export: func(build *IndexBuild) error {
+			return ExportDriverIndexShards(build.Index, build.DataVersion)
+		},
+	},
+	{
+		Name:    "driver_index_delta",
+		Enabled: func() bool { return exportConfig.DriverIndexDelta },
+		Export: func(build *IndexBuild) error {
+			return ExportDriverIndexDelta(build.Index, build.DataVersion)
+		},
+	},
+	{
+		Name:    "driver_index_binary",
+		Enabled: func() bool { return exportConfig.DriverIndexBinary },
+		Export: func(build *IndexBuild) error {
+			return ExportDriverIndexBinary(build.Index, build.TrackEntryCounts)
+		},
+	},
+	{
+		Name: "status",
+		Export: func(build *IndexBuild) error {
+			return UpdateStatusWithIndexMetrics(build.Tracks, build.Index, build.UniqueTracks, build.TotalEntries, build.BuildDuration, build.DataVersion)
+		},
+	},
+	{
+		Name:     "top_combinations",
+		Required: true,
+		Export: func(build *IndexBuild) error {
+			return ExportTopCombinations(build.Tracks, build.TrackEntryCounts)
+		},
+	},
+	{
+		Name: "combination_stats",
+		Export: func(build *IndexBuild) error {
+			if build.CombinationStats != nil {
+				return writeCombinationStats(build.CombinationStats)
+			}
+			return ExportCombinationStats(build.Tracks)
+		},
+	},
+}
+
+// RegisterIndexExporter adds an exporter that runs after the built-in ones
+// Must be called before background indexing starts
+func RegisterIndexExporter(exporter IndexExporter) {
+	indexExporters = append(indexExporters, exporter)
+}
+
+// runIndexExporters runs every enabled exporter in order
+// Optional exporters only log failures; the first required failure stops the run
+func runIndexExporters(ctx context.Context, build *IndexBuild) error {
+	for _, exporter := range indexExporters {
+		if exporter.Enabled != nil && !exporter.Enabled() {
+			continue
+		}
+		_, span := StartSpan(ctx, "index.export", "exporter", exporter.Name)
+		err := exporter.Export(build)
+		span.SetError(err)
+		span.End()
+		if err == nil {
+			continue
+		}
+		if exporter.Required {
+			return fmt.Errorf("%s export: %w", exporter.Name, err)
+		}
+		indexerLog.Warnf("⚠️ Failed to export %s: %v", exporter.Name, err)
+	}
+	return nil
+}
+
+// StreamingBuildEnabled reports whether index builds stream the cache files (export.streaming_build)
+func StreamingBuildEnabled() bool {
+	return exportConfig.StreamingBuild
+}
+
+// SetExportConfig sets which driver index formats are exported
+// Must be called before background indexing starts
+func SetExportConfig(cfg ExportConfig) {
+	exportConfig = cfg
+}
+
 // FailedFetch represents a failed fetch attempt
 type FailedFetch struct {
 	TrackName string    `json:"track_name"`
@@ -43,6 +176,16 @@ type StatusData struct {
 	FailedFetchCount         int           `json:"failed_fetch_count"`
 	FailedFetches            []FailedFetch `json:"failed_fetches,omitempty"`
 	RetriedFetchCount        int           `json:"retried_fetch_count"`
+	FailedFetchQueue         int           `json:"failed_fetch_queue"`     // Combinations in cache/failed_fetches.json awaiting a retry
+	DataVersion              string        `json:"data_version,omitempty"` // Fingerprint of the indexed data
+	Cache                    *CacheUsage   `json:"cache,omitempty"`        // Disk usage of cache/
+
+	FetchProgress *FetchProgressStatus `json:"fetch_progress,omitempty"` // Current or last fetch run of this process
+	RecentRecords []WorldRecord        `json:"recent_records,omitempty"` // Latest world records, newest first
+
+	RefreshPostponed *RefreshPostponement `json:"refresh_postponed,omitempty"` // Scheduled refresh waiting for RaceRoom to recover
+	FetchStats       *FetchStatsSummary   `json:"fetch_stats,omitempty"`       // Aggregates of cache/fetch_stats.json
+	SchemaCheck      *SchemaCheck         `json:"schema_check,omitempty"`      // Missing fields of the current or last refresh
 }
 
 // TrackCombination represents a track/class combination with entry count
@@ -71,13 +214,24 @@ func ReadStatusData() StatusData {
 
 	var status StatusData
 	if err := json.Unmarshal(data, &status); err != nil {
-		log.Printf("⚠️ Failed to parse status file: %v", err)
+		exportLog.Warnf("⚠️ Failed to parse status file: %v", err)
 		return StatusData{}
 	}
 
 	return status
 }
 
+// ReadTopCombinations reads the last exported top combinations from disk
+func ReadTopCombinations() (TopCombinationsData, error) {
+	var top TopCombinationsData
+	data, err := os.ReadFile(TopCombinationsFile)
+	if err != nil {
+		return top, err
+	}
+	err = json.Unmarshal(data, &top)
+	return top, err
+}
+
 // ExportDriverIndex exports the driver index to a JSON file on disk
 // Uses atomic write (temp file + rename) with fallback to handle file locking
 func ExportDriverIndex(index DriverIndex, buildDuration time.Duration) error {
@@ -86,92 +240,254 @@ func ExportDriverIndex(index DriverIndex, buildDuration time.Duration) error {
 	// Convert the index to compact JSON (smaller, parses faster)
 	jsonData, err := json.Marshal(index)
 	if err != nil {
-		log.Printf("❌ Failed to marshal driver index: %v", err)
+		exportLog.Errorf("❌ Failed to marshal driver index: %v", err)
 		return err
 	}
 
 	// Ensure cache directory exists
 	cacheDir := filepath.Dir(DriverIndexFile)
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		log.Printf("❌ Failed to create cache directory: %v", err)
+		exportLog.Errorf("❌ Failed to create cache directory: %v", err)
 		return err
 	}
 
-	// Write a gzip-compressed version for faster downloads (only gz is persisted)
+	// Write a gzip-compressed version for faster downloads
 	gzStart := time.Now()
 	var buf bytes.Buffer
 	gzWriter := gzip.NewWriter(&buf)
 	// Set a filename in the gzip header (optional)
 	gzWriter.Name = filepath.Base(DriverIndexFile)
 	if _, err := gzWriter.Write(jsonData); err != nil {
-		log.Printf("❌ Failed to gzip driver index: %v", err)
+		exportLog.Errorf("❌ Failed to gzip driver index: %v", err)
 		gzWriter.Close()
 		// Proceed without gz if compression fails
 	} else if err := gzWriter.Close(); err != nil {
-		log.Printf("❌ Failed to finalize gzip driver index: %v", err)
+		exportLog.Errorf("❌ Failed to finalize gzip driver index: %v", err)
 	} else {
 		gzTemp := DriverIndexFile + ".gz.tmp"
 		gzFinal := DriverIndexFile + ".gz"
 		if err := os.WriteFile(gzTemp, buf.Bytes(), 0644); err != nil {
-			log.Printf("❌ Failed to write temporary gz driver index: %v", err)
+			exportLog.Errorf("❌ Failed to write temporary gz driver index: %v", err)
 		} else if err := os.Rename(gzTemp, gzFinal); err != nil {
-			log.Printf("⚠️ WARNING: Atomic rename failed for gz: %v", err)
+			exportLog.Warnf("⚠️ WARNING: Atomic rename failed for gz: %v", err)
 			if directErr := os.WriteFile(gzFinal, buf.Bytes(), 0644); directErr != nil {
-				log.Printf("❌ ERROR: Direct write also failed for gz: %v", directErr)
+				exportLog.Errorf("❌ ERROR: Direct write also failed for gz: %v", directErr)
 				os.Remove(gzTemp)
 			} else {
-				log.Printf("✅ Fallback write successful (gz)")
+				exportLog.Infof("✅ Fallback write successful (gz)")
 				os.Remove(gzTemp)
 			}
 		}
-		log.Printf("💾 Driver index exported (gz) to %s (%.3f seconds, %.2f MB → %.2f MB)",
+		exportLog.Infof("💾 Driver index exported (gz) to %s (%.3f seconds, %.2f MB → %.2f MB)",
 			gzFinal, time.Since(gzStart).Seconds(), float64(len(jsonData))/(1024*1024), float64(buf.Len())/(1024*1024))
 	}
 
+	// Optionally persist the uncompressed JSON for clients that can't accept gzip
+	if exportConfig.DriverIndexRawJSON {
+		if err := writeFileAtomic(DriverIndexFile, jsonData); err != nil {
+			exportLog.Errorf("❌ Failed to write raw driver index: %v", err)
+		} else {
+			exportLog.Infof("💾 Driver index exported (raw) to %s (%.2f MB)", DriverIndexFile, float64(len(jsonData))/(1024*1024))
+		}
+	} else if err := os.Remove(DriverIndexFile); err != nil && !os.IsNotExist(err) {
+		// A stale raw copy would be served instead of the fresh gz data
+		exportLog.Warnf("⚠️ Failed to remove stale raw driver index: %v", err)
+	}
+
 	// Release jsonData memory immediately
 	jsonData = nil
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temp file and renames it over path
+// Falls back to a direct write when the rename fails (e.g. file locked by an editor on Windows)
+func writeFileAtomic(path string, data []byte) error {
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		exportLog.Warnf("⚠️ WARNING: Atomic rename failed for %s: %v", path, err)
+		directErr := os.WriteFile(path, data, 0644)
+		os.Remove(tempFile)
+		return directErr
+	}
+	return nil
+}
+
+// ExportDriverIndexBinary writes the driver index in gob format to DriverIndexBinaryFile
+// The file is streamed to a temp file and renamed into place so readers never see partial data
+func ExportDriverIndexBinary(index DriverIndex, trackEntryCounts map[string]int) error {
+	start := time.Now()
+
+	// Ensure cache directory exists
+	if err := os.MkdirAll(filepath.Dir(DriverIndexBinaryFile), 0755); err != nil {
+		exportLog.Errorf("❌ Failed to create cache directory: %v", err)
+		return err
+	}
+
+	tempFile := DriverIndexBinaryFile + ".tmp"
+	file, err := os.Create(tempFile)
+	if err != nil {
+		exportLog.Errorf("❌ Failed to create temporary binary driver index: %v", err)
+		return err
+	}
+
+	writer := bufio.NewWriterSize(file, 1<<20)
+	payload := BinaryDriverIndex{
+		Version:          binaryIndexVersion,
+		BuiltAt:          time.Now(),
+		Index:            index,
+		TrackEntryCounts: trackEntryCounts,
+	}
+	if err := gob.NewEncoder(writer).Encode(&payload); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		exportLog.Errorf("❌ Failed to encode binary driver index: %v", err)
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	// Atomically rename temp file to final file
+	if err := os.Rename(tempFile, DriverIndexBinaryFile); err != nil {
+		// On Windows, rename fails if destination exists
+		os.Remove(DriverIndexBinaryFile)
+		if retryErr := os.Rename(tempFile, DriverIndexBinaryFile); retryErr != nil {
+			os.Remove(tempFile)
+			exportLog.Errorf("❌ Failed to move binary driver index into place: %v", retryErr)
+			return retryErr
+		}
+	}
+
+	var size float64
+	if info, statErr := os.Stat(DriverIndexBinaryFile); statErr == nil {
+		size = float64(info.Size()) / (1024 * 1024)
+	}
+	exportLog.Infof("💾 Driver index exported (bin) to %s (%.3f seconds, %.2f MB)",
+		DriverIndexBinaryFile, time.Since(start).Seconds(), size)
+
+	return nil
+}
+
+// ReadDriverIndexBinary loads the gob driver index written by ExportDriverIndexBinary
+func ReadDriverIndexBinary() (BinaryDriverIndex, error) {
+	var payload BinaryDriverIndex
+
+	file, err := os.Open(DriverIndexBinaryFile)
+	if err != nil {
+		return payload, err
+	}
+	defer file.Close()
+
+	if err := gob.NewDecoder(bufio.NewReaderSize(file, 1<<20)).Decode(&payload); err != nil {
+		return payload, fmt.Errorf("decode %s: %w", DriverIndexBinaryFile, err)
+	}
+	if payload.Version != binaryIndexVersion {
+		return BinaryDriverIndex{}, fmt.Errorf("%s has version %d, expected %d", DriverIndexBinaryFile, payload.Version, binaryIndexVersion)
+	}
+	return payload, nil
+}
+
+// ReadDriverIndexJSON loads the exported JSON driver index (gz copy first, then raw)
+// Returns the index and the modification time of the file it was read from
+func ReadDriverIndexJSON() (DriverIndex, time.Time, error) {
+	var index DriverIndex
+
+	gzPath := DriverIndexFile + ".gz"
+	if file, err := os.Open(gzPath); err == nil {
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("open %s: %w", gzPath, err)
+		}
+		defer gzReader.Close()
+		if err := json.NewDecoder(gzReader).Decode(&index); err != nil {
+			return nil, time.Time{}, fmt.Errorf("decode %s: %w", gzPath, err)
+		}
+		return index, info.ModTime(), nil
+	}
+
+	file, err := os.Open(DriverIndexFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if err := json.NewDecoder(bufio.NewReader(file)).Decode(&index); err != nil {
+		return nil, time.Time{}, fmt.Errorf("decode %s: %w", DriverIndexFile, err)
+	}
+	return index, info.ModTime(), nil
+}
+
 // ExportStatusData exports the status information to a JSON file on disk
 // Uses atomic write (temp file + rename) with fallback to handle file locking
 func ExportStatusData(status StatusData) error {
+	if progress, ok := CurrentFetchStatus(); ok {
+		status.FetchProgress = &progress
+	}
+	status.RecentRecords = RecentWorldRecords(statusRecordCount)
+	status.FailedFetchQueue = len(QueuedFailures())
+	status.FetchStats = SummarizeFetchStats()
+	status.SchemaCheck = nil
+	if check, ok := CurrentSchemaCheck(); ok {
+		status.SchemaCheck = &check
+	}
+	status.RefreshPostponed = nil
+	if postponement, ok := CurrentRefreshPostponement(); ok {
+		status.RefreshPostponed = &postponement
+	}
+
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(status, "", "  ")
 	if err != nil {
-		log.Printf("❌ Failed to marshal status data: %v", err)
+		exportLog.Errorf("❌ Failed to marshal status data: %v", err)
 		return err
 	}
 
 	// Ensure cache directory exists
 	cacheDir := filepath.Dir(StatusFile)
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		log.Printf("❌ Failed to create cache directory: %v", err)
+		exportLog.Errorf("❌ Failed to create cache directory: %v", err)
 		return err
 	}
 
 	// Write to temporary file first (atomic write pattern)
 	tempFile := StatusFile + ".tmp"
 	if err := os.WriteFile(tempFile, jsonData, 0644); err != nil {
-		log.Printf("❌ Failed to write temporary status file: %v", err)
+		exportLog.Errorf("❌ Failed to write temporary status file: %v", err)
 		return err
 	}
 
 	// Rename temp file to final file (atomic operation)
 	if err := os.Rename(tempFile, StatusFile); err != nil {
-		log.Printf("⚠️ WARNING: Atomic rename failed: %v", err)
-		log.Printf("   Attempting direct write as fallback (file may be locked by editor)")
+		exportLog.Warnf("⚠️ WARNING: Atomic rename failed: %v", err)
+		exportLog.Warnf("   Attempting direct write as fallback (file may be locked by editor)")
 
 		// Fallback: try direct write
 		if directErr := os.WriteFile(StatusFile, jsonData, 0644); directErr != nil {
-			log.Printf("❌ ERROR: Direct write also failed: %v", directErr)
-			log.Printf("   Please close %s in your editor and try again", StatusFile)
+			exportLog.Errorf("❌ ERROR: Direct write also failed: %v", directErr)
+			exportLog.Warnf("   Please close %s in your editor and try again", StatusFile)
 			os.Remove(tempFile) // Clean up temp file
 			return directErr
 		}
 
-		log.Printf("✅ Fallback write successful")
+		exportLog.Infof("✅ Fallback write successful")
 		os.Remove(tempFile) // Clean up temp file after successful fallback
 	}
 
@@ -181,7 +497,7 @@ func ExportStatusData(status StatusData) error {
 
 // UpdateStatusWithIndexMetrics updates the status file with index statistics
 // This is exported so indexer.go can update status after building the index
-func UpdateStatusWithIndexMetrics(tracks []TrackInfo, index DriverIndex, uniqueTrackCount, totalEntries int, buildDuration time.Duration) error {
+func UpdateStatusWithIndexMetrics(tracks []TrackInfo, index DriverIndex, uniqueTrackCount, totalEntries int, buildDuration time.Duration, dataVersion string) error {
 	// Read current memory stats
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -191,7 +507,7 @@ func UpdateStatusWithIndexMetrics(tracks []TrackInfo, index DriverIndex, uniqueT
 
 	// Count total cached combinations (including empty)
 	dataCache := NewDataCache()
-	totalCached := dataCache.CountCachedCombinations()
+	usage := dataCache.Usage()
 
 	status := StatusData{
 		// Preserve orchestrator-managed fields
@@ -200,7 +516,7 @@ func UpdateStatusWithIndexMetrics(tracks []TrackInfo, index DriverIndex, uniqueT
 		LastScrapeEnd:   existingStatus.LastScrapeEnd,
 		// Update index-related metrics
 		TrackCount:               len(tracks),
-		TotalFetchedCombinations: totalCached,
+		TotalFetchedCombinations: usage.Files,
 		TotalUniqueTracks:        uniqueTrackCount,
 		TotalDrivers:             len(index),
 		TotalEntries:             totalEntries,
@@ -208,6 +524,8 @@ func UpdateStatusWithIndexMetrics(tracks []TrackInfo, index DriverIndex, uniqueT
 		IndexBuildTimeMs:         buildDuration.Seconds() * 1000,
 		MemoryAllocMB:            m.Alloc / 1024 / 1024,
 		MemorySysMB:              m.Sys / 1024 / 1024,
+		DataVersion:              dataVersion,
+		Cache:                    &usage,
 	}
 	return ExportStatusData(status)
 }
@@ -267,41 +585,41 @@ func ExportTopCombinations(tracks []TrackInfo, trackEntryCounts map[string]int)
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(topData, "", "  ")
 	if err != nil {
-		log.Printf("❌ Failed to marshal top combinations: %v", err)
+		exportLog.Errorf("❌ Failed to marshal top combinations: %v", err)
 		return err
 	}
 
 	// Ensure cache directory exists
 	cacheDir := filepath.Dir(TopCombinationsFile)
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		log.Printf("❌ Failed to create cache directory: %v", err)
+		exportLog.Errorf("❌ Failed to create cache directory: %v", err)
 		return err
 	}
 
 	// Write to temporary file first (atomic write pattern)
 	tempFile := TopCombinationsFile + ".tmp"
 	if err := os.WriteFile(tempFile, jsonData, 0644); err != nil {
-		log.Printf("❌ Failed to write temporary top combinations file: %v", err)
+		exportLog.Errorf("❌ Failed to write temporary top combinations file: %v", err)
 		return err
 	}
 
 	// Rename temp file to final file (atomic operation)
 	if err := os.Rename(tempFile, TopCombinationsFile); err != nil {
-		log.Printf("⚠️ WARNING: Atomic rename failed: %v", err)
-		log.Printf("   Attempting direct write as fallback")
+		exportLog.Warnf("⚠️ WARNING: Atomic rename failed: %v", err)
+		exportLog.Warnf("   Attempting direct write as fallback")
 
 		// Fallback: try direct write
 		if directErr := os.WriteFile(TopCombinationsFile, jsonData, 0644); directErr != nil {
-			log.Printf("❌ ERROR: Direct write also failed: %v", directErr)
+			exportLog.Errorf("❌ ERROR: Direct write also failed: %v", directErr)
 			os.Remove(tempFile)
 			return directErr
 		}
 
-		log.Printf("✅ Fallback write successful")
+		exportLog.Infof("✅ Fallback write successful")
 		os.Remove(tempFile)
 	}
 
-	log.Printf("💾 Top combinations exported to %s (%d combinations, %.2f KB)",
+	exportLog.Infof("💾 Top combinations exported to %s (%d combinations, %.2f KB)",
 		TopCombinationsFile, len(combinations), float64(len(jsonData))/1024)
 
 	return nil
@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FailedFetchesFile persists the combinations whose last fetch failed, so they are retried by the next run
+const FailedFetchesFile = "cache/failed_fetches.json"
+
+// QueuedFailure is a combination whose last fetch failed, kept until a fetch of it succeeds
+type QueuedFailure struct {
+	TrackID       string    `json:"track_id"`
+	Track         string    `json:"track"`
+	ClassID       string    `json:"class_id"`
+	Class         string    `json:"class"`
+	Error         string    `json:"error"`    // Last error
+	Attempts      int       `json:"attempts"` // Failed fetches since the last success
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
+
+// failedFetchQueue holds the persisted failures, loaded from FailedFetchesFile on first use
+var failedFetchQueue struct {
+	mu       sync.Mutex
+	loaded   bool
+	failures map[string]*QueuedFailure // By "trackID_classID"
+}
+
+// loadFailedFetchQueueLocked reads FailedFetchesFile once; a missing or unreadable file starts an empty queue
+func loadFailedFetchQueueLocked() {
+	if failedFetchQueue.loaded {
+		return
+	}
+	failedFetchQueue.loaded = true
+	failedFetchQueue.failures = make(map[string]*QueuedFailure)
+
+	data, err := os.ReadFile(FailedFetchesFile)
+	if err != nil {
+		return
+	}
+	var failures []QueuedFailure
+	if err := json.Unmarshal(data, &failures); err != nil {
+		loaderLog.Warnf("⚠️ Ignoring unreadable %s: %v", FailedFetchesFile, err)
+		return
+	}
+	for i := range failures {
+		failure := failures[i]
+		failedFetchQueue.failures[failure.TrackID+"_"+failure.ClassID] = &failure
+	}
+}
+
+// saveFailedFetchQueueLocked writes the queue to FailedFetchesFile, oldest failure first
+func saveFailedFetchQueueLocked() {
+	data, err := json.MarshalIndent(queuedFailuresLocked(), "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(FailedFetchesFile), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(FailedFetchesFile, data)
+	}
+	if err != nil {
+		loaderLog.Warnf("⚠️ Failed to save %s: %v", FailedFetchesFile, err)
+	}
+}
+
+// queuedFailuresLocked returns a copy of the queue, oldest failure first
+func queuedFailuresLocked() []QueuedFailure {
+	failures := make([]QueuedFailure, 0, len(failedFetchQueue.failures))
+	for _, failure := range failedFetchQueue.failures {
+		failures = append(failures, *failure)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if !failures[i].FirstFailedAt.Equal(failures[j].FirstFailedAt) {
+			return failures[i].FirstFailedAt.Before(failures[j].FirstFailedAt)
+		}
+		return failures[i].TrackID+"_"+failures[i].ClassID < failures[j].TrackID+"_"+failures[j].ClassID
+	})
+	return failures
+}
+
+// QueuedFailures returns the combinations waiting for a retry, oldest failure first
+func QueuedFailures() []QueuedFailure {
+	failedFetchQueue.mu.Lock()
+	defer failedFetchQueue.mu.Unlock()
+	loadFailedFetchQueueLocked()
+	return queuedFailuresLocked()
+}
+
+// recordFetchOutcome updates the queue after a fetch: a failure is added (or its attempts counted),
+// a success removes the combination. Fetches stopped by cancellation aren't failures.
+func recordFetchOutcome(ctx context.Context, track TrackConfig, class CarClassConfig, err error) {
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		return
+	}
+
+	failedFetchQueue.mu.Lock()
+	defer failedFetchQueue.mu.Unlock()
+	loadFailedFetchQueueLocked()
+
+	key := track.TrackID + "_" + class.ClassID
+	if err == nil {
+		if _, queued := failedFetchQueue.failures[key]; queued {
+			delete(failedFetchQueue.failures, key)
+			saveFailedFetchQueueLocked()
+		}
+		return
+	}
+
+	now := time.Now().UTC()
+	failure, queued := failedFetchQueue.failures[key]
+	if !queued {
+		failure = &QueuedFailure{TrackID: track.TrackID, Track: track.Name, ClassID: class.ClassID, Class: class.Name, FirstFailedAt: now}
+		failedFetchQueue.failures[key] = failure
+	}
+	failure.Error = err.Error()
+	failure.Attempts++
+	failure.LastFailedAt = now
+	saveFailedFetchQueueLocked()
+}
+
+// QueuedFailureTargets returns the queued failures as refresh targets ("trackID-classID")
+func QueuedFailureTargets() []string {
+	failures := QueuedFailures()
+	targets := make([]string, len(failures))
+	for i, failure := range failures {
+		targets[i] = failure.TrackID + "-" + failure.ClassID
+	}
+	return targets
+}
+
+// retryQueuedFailures retries, at the start of a run, the failures of earlier runs that the run won't fetch
+// anyway. Combinations that are no longer configured are dropped from the queue.
+func retryQueuedFailures(ctx context.Context, apiClient *APIClient, tempCache *DataCache, fetchedAnyway func(trackID, classID string) bool) []TrackInfo {
+	var retries []FailedFetchInfo
+	var dropped []string
+	for _, failure := range QueuedFailures() {
+		if fetchedAnyway(failure.TrackID, failure.ClassID) {
+			continue
+		}
+		track, trackOK := FindTrack(failure.TrackID)
+		class, classOK := FindCarClass(failure.ClassID)
+		if !trackOK || !classOK { // Removed from the catalogs or the selection
+			dropped = append(dropped, failure.TrackID+"_"+failure.ClassID)
+			continue
+		}
+		retries = append(retries, FailedFetchInfo{track, class, errors.New(failure.Error)})
+	}
+	forgetQueuedFailures(dropped)
+
+	if len(retries) == 0 {
+		return nil
+	}
+	loaderLog.Infof("🔁 Retrying %d combinations that failed in earlier runs (see %s)", len(retries), FailedFetchesFile)
+	return retryFailedFetches(ctx, apiClient, tempCache, retries)
+}
+
+// forgetQueuedFailures removes combinations ("trackID_classID") from the queue
+func forgetQueuedFailures(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	failedFetchQueue.mu.Lock()
+	defer failedFetchQueue.mu.Unlock()
+	loadFailedFetchQueueLocked()
+	for _, key := range keys {
+		delete(failedFetchQueue.failures, key)
+	}
+	saveFailedFetchQueueLocked()
+}
@@ -0,0 +1,300 @@
+// Package fakeraceroom is a stand-in for the RaceRoom leaderboard site: the session page with its class
+// selector and the paginated listing endpoint, with configurable latency and errors. Point
+// fetch.client.base_url at it to run refreshes without touching RaceRoom.
+package fakeraceroom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxPageSize is the largest count the listing endpoint honors, like RaceRoom's
+const MaxPageSize = 1500
+
+// Entry is a leaderboard entry; Index and the gap to the leader are derived from its position
+type Entry struct {
+	Driver      string
+	DriverID    int64
+	Country     string
+	CountryCode string
+	Car         string
+	Team        string
+	LapTime     time.Duration
+}
+
+// Options controls the answers of the fake
+type Options struct {
+	Latency        time.Duration // Added to every response
+	ErrorRate      float64       // Share (0-1) of listing requests answered with ErrorStatus
+	ErrorStatus    int           // Status of the random errors (default 503)
+	RetryAfter     int           // Seconds sent as Retry-After with 429 and 503 answers (0 omits the header)
+	DefaultEntries int           // Entries generated for combinations without a leaderboard of their own (0 lists none)
+	Seed           int64         // Seeds the random errors, so runs are repeatable
+}
+
+// Stats counts the requests answered
+type Stats struct {
+	SessionRequests int `json:"session_requests"`
+	ListingRequests int `json:"listing_requests"`
+	Errors          int `json:"errors"` // Answered with an error status
+}
+
+// Server is the fake RaceRoom site; it is an http.Handler
+type Server struct {
+	mu       sync.Mutex
+	opts     Options
+	random   *rand.Rand
+	classes  map[string]string  // Class ID → name, listed in the class selector
+	boards   map[string][]Entry // By "trackID_classID", fastest first
+	failures []int              // Statuses of the next listing requests, see FailNext
+	stats    Stats
+}
+
+// New returns a fake with the given options and no leaderboards
+func New(opts Options) *Server {
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusServiceUnavailable
+	}
+	return &Server{
+		opts:    opts,
+		random:  rand.New(rand.NewSource(opts.Seed)),
+		classes: make(map[string]string),
+		boards:  make(map[string][]Entry),
+	}
+}
+
+// Start serves the fake on a local port; the caller closes the returned server
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// AddClass lists a class in the class selector of the session page
+func (s *Server) AddClass(classID, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.classes[classID] = name
+}
+
+// SetLeaderboard replaces the entries of a combination; they are sorted by lap time
+func (s *Server) SetLeaderboard(trackID, classID string, entries []Entry) {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LapTime < sorted[j].LapTime })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.boards[trackID+"_"+classID] = sorted
+}
+
+// FailNext answers the next count listing requests with status, before any random errors
+func (s *Server) FailNext(status, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// Stats returns the requests answered so far
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// ServeHTTP answers the session page (/leaderboard/) and the listing (/leaderboard/listing/0)
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !sleep(r.Context(), s.opts.Latency) {
+		return
+	}
+	switch r.URL.Path {
+	case "/leaderboard/", "/leaderboard":
+		s.serveSessionPage(w)
+	case "/leaderboard/listing/0":
+		s.serveListing(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveSessionPage answers the leaderboard page with its class selector
+func (s *Server) serveSessionPage(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.stats.SessionRequests++
+	ids := make([]string, 0, len(s.classes))
+	for id := range s.classes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var page strings.Builder
+	page.WriteString("<html><body><select name=\"car_class\">\n")
+	for _, id := range ids {
+		fmt.Fprintf(&page, "<option value=\"class-%s\">%s</option>\n", id, html.EscapeString(s.classes[id]))
+	}
+	s.mu.Unlock()
+	page.WriteString("</select></body></html>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: "fake", Path: "/"})
+	w.Write([]byte(page.String()))
+}
+
+// serveListing answers a page of a combination's leaderboard
+func (s *Server) serveListing(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	trackID := query.Get("track")
+	classID, hasPrefix := strings.CutPrefix(query.Get("car_class"), "class-")
+	start, startErr := strconv.Atoi(query.Get("start"))
+	count, countErr := strconv.Atoi(query.Get("count"))
+	if trackID == "" || !hasPrefix || startErr != nil || countErr != nil || start < 0 || count < 0 {
+		http.Error(w, "bad listing request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.stats.ListingRequests++
+	status := 0
+	if len(s.failures) > 0 {
+		status, s.failures = s.failures[0], s.failures[1:]
+	} else if s.opts.ErrorRate > 0 && s.random.Float64() < s.opts.ErrorRate {
+		status = s.opts.ErrorStatus
+	}
+	if status != 0 {
+		s.stats.Errors++
+		s.mu.Unlock()
+		if s.opts.RetryAfter > 0 && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) {
+			w.Header().Set("Retry-After", strconv.Itoa(s.opts.RetryAfter))
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	entries, ok := s.boards[trackID+"_"+classID]
+	if !ok && s.opts.DefaultEntries > 0 {
+		entries = GenerateEntries(trackID, classID, s.opts.DefaultEntries)
+		s.boards[trackID+"_"+classID] = entries
+	}
+	s.mu.Unlock()
+
+	count = min(count, MaxPageSize)
+	results := []listingResult{}
+	for i := start; i < min(start+count, len(entries)); i++ {
+		results = append(results, newListingResult(entries, i))
+	}
+	var response listingResponse
+	response.Context.C.Results = results
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// listingResponse is the listing endpoint's body
+type listingResponse struct {
+	Context struct {
+		C struct {
+			Results []listingResult `json:"results"`
+		} `json:"c"`
+	} `json:"context"`
+}
+
+// listingResult is an entry as the listing endpoint sends it
+type listingResult struct {
+	Driver struct {
+		Name string `json:"name"`
+		ID   int64  `json:"id"`
+	} `json:"driver"`
+	Index           int    `json:"index"`
+	LapTime         string `json:"laptime"`
+	RelativeLaptime string `json:"relative_laptime"`
+	Country         struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	} `json:"country"`
+	CarClass struct {
+		Car struct {
+			Name string `json:"name"`
+		} `json:"car"`
+	} `json:"car_class"`
+	Team string `json:"team,omitempty"`
+}
+
+// newListingResult converts the entry at position i
+func newListingResult(entries []Entry, i int) listingResult {
+	entry := entries[i]
+	var result listingResult
+	result.Driver.Name = entry.Driver
+	result.Driver.ID = entry.DriverID
+	result.Index = i
+	result.LapTime = formatLapTime(entry.LapTime)
+	if i > 0 {
+		result.RelativeLaptime = fmt.Sprintf("+%.3fs", (entry.LapTime - entries[0].LapTime).Seconds())
+	}
+	result.Country.Name = entry.Country
+	result.Country.Code = entry.CountryCode
+	result.CarClass.Car.Name = entry.Car
+	result.Team = entry.Team
+	return result
+}
+
+// formatLapTime formats a lap time like RaceRoom: "1m 23.456s"
+func formatLapTime(lapTime time.Duration) string {
+	minutes := int(lapTime / time.Minute)
+	seconds := (lapTime % time.Minute).Seconds()
+	return fmt.Sprintf("%dm %06.3fs", minutes, seconds)
+}
+
+// generatedCountries are assigned to generated drivers in turn
+var generatedCountries = [][2]string{{"Germany", "DE"}, {"Netherlands", "NL"}, {"United Kingdom", "GB"}, {"Brazil", "BR"}, {"Finland", "FI"}, {"Italy", "IT"}}
+
+// generatedCars are assigned to generated entries in turn
+var generatedCars = []string{"Audi R8 LMS", "BMW M6 GT3", "Mercedes-AMG GT3", "Porsche 911 GT3 R"}
+
+// GenerateEntries returns n entries for a combination, fastest first. The same combination always gets
+// the same entries; drivers are shared between combinations, so they show up on several tracks.
+func GenerateEntries(trackID, classID string, n int) []Entry {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(trackID + "_" + classID))
+	random := rand.New(rand.NewSource(int64(hasher.Sum64())))
+
+	base := 80*time.Second + time.Duration(random.Intn(40000))*time.Millisecond
+	lapTime := base
+	drivers := random.Perm(max(n*2, 100)) // One lap per driver, like RaceRoom
+	entries := make([]Entry, n)
+	for i := range entries {
+		driver := drivers[i]
+		country := generatedCountries[driver%len(generatedCountries)]
+		entries[i] = Entry{
+			Driver:      fmt.Sprintf("Fake Driver %d", driver),
+			DriverID:    int64(1000000 + driver),
+			Country:     country[0],
+			CountryCode: country[1],
+			Car:         generatedCars[i%len(generatedCars)],
+			LapTime:     lapTime,
+		}
+		lapTime += time.Duration(1+random.Intn(80)) * time.Millisecond
+	}
+	return entries
+}
+
+// sleep waits d; false when the request was cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
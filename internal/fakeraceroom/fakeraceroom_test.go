@@ -0,0 +1,102 @@
+package fakeraceroom_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"r3e-leaderboard/internal"
+	"r3e-leaderboard/internal/fakeraceroom"
+)
+
+// TestFetchPipelineAgainstFake runs a startup load against the fake: one combination is cached and indexed,
+// the other keeps failing and ends up in the failed-fetch queue
+func TestFetchPipelineAgainstFake(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	const trackID = "1693"
+	internal.SetSelectionConfig(internal.SelectionConfig{IncludeTracks: []string{trackID}, IncludeClasses: []string{"1703", "1704"}})
+	classes := internal.GetCarClasses()
+	if len(classes) != 2 {
+		t.Fatalf("selection has %d classes, want 2", len(classes))
+	}
+	failing, fetched := classes[0], classes[1] // Fetched in this order
+
+	fake := fakeraceroom.New(fakeraceroom.Options{RetryAfter: 1})
+	fake.SetLeaderboard(trackID, failing.ClassID, []fakeraceroom.Entry{
+		{Driver: "Never Fetched", DriverID: 1, Car: "Audi R8 LMS", LapTime: 100 * time.Second},
+	})
+	fake.SetLeaderboard(trackID, fetched.ClassID, []fakeraceroom.Entry{
+		{Driver: "Fake Second", DriverID: 3, Country: "Netherlands", CountryCode: "NL", Car: "BMW M6 GT3", LapTime: 101500 * time.Millisecond},
+		{Driver: "Fake First", DriverID: 2, Country: "Germany", CountryCode: "DE", Car: "Audi R8 LMS", LapTime: 100250 * time.Millisecond},
+	})
+	// Listing requests in order: the failing combination is rate limited and waits out the Retry-After,
+	// then gets a 500; the other combination gets a 500 too; both are retried, and only the first fails again
+	fake.FailNext(429, 1)
+	fake.FailNext(500, 3)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	fetchConfig := internal.GetDefaultConfig().Fetch
+	fetchConfig.Client.BaseURL = server.URL
+	fetchConfig.Throttle = internal.ThrottleConfig{MinDelayMs: 1, MaxDelayMs: 10, InitialDelayMs: 1, SlowResponseMs: 10000}
+	internal.SetFetchConfig(fetchConfig)
+
+	tracks := internal.LoadAllTrackDataWithCallback(context.Background(), nil, nil)
+	if len(tracks) != 1 || tracks[0].ClassID != fetched.ClassID || len(tracks[0].Data) != 2 {
+		t.Fatalf("loaded %+v, want the 2 entries of class %s", tracks, fetched.ClassID)
+	}
+
+	// The loader already promoted its fetches; promoting again finds nothing left
+	if promoted, err := internal.NewTempDataCache().PromoteTempCache(); err != nil || promoted != 0 {
+		t.Fatalf("PromoteTempCache() = %d, %v; want 0, nil", promoted, err)
+	}
+
+	dataCache := internal.NewDataCache()
+	if _, err := os.Stat(dataCache.GetCacheFileName(trackID, failing.ClassID)); !os.IsNotExist(err) {
+		t.Errorf("failed combination has a cache file (stat: %v)", err)
+	}
+	cached, err := dataCache.LoadTrackData(trackID, fetched.ClassID)
+	if err != nil {
+		t.Fatalf("promoted cache file doesn't decode: %v", err)
+	}
+	if len(cached.Data) != 2 || cached.Data[0].Driver.Name != "Fake First" || cached.Data[1].Driver.Name != "Fake Second" {
+		t.Fatalf("cached entries = %+v, want Fake First then Fake Second", cached.Data)
+	}
+
+	if err := internal.BuildAndExportIndex(tracks); err != nil {
+		t.Fatal(err)
+	}
+	engine := internal.GetSearchEngine()
+	for position, name := range []string{"Fake First", "Fake Second"} {
+		results := engine.Lookup(name)
+		if len(results) != 1 || results[0].ClassID != fetched.ClassID || results[0].Position != position+1 {
+			t.Errorf("Lookup(%q) = %+v, want position %d in class %s", name, results, position+1, fetched.ClassID)
+		}
+	}
+	if results := engine.Lookup("Never Fetched"); len(results) != 0 {
+		t.Errorf("Lookup(Never Fetched) = %+v, want no results", results)
+	}
+
+	failures := internal.QueuedFailures()
+	if len(failures) != 1 {
+		t.Fatalf("failed-fetch queue = %+v, want the failing combination only", failures)
+	}
+	if failure := failures[0]; failure.TrackID != trackID || failure.ClassID != failing.ClassID ||
+		failure.Attempts != 2 || !strings.Contains(failure.Error, "500") {
+		t.Errorf("queued failure = %+v, want 2 attempts of class %s ending in a 500", failure, failing.ClassID)
+	}
+	if stats := fake.Stats(); stats.Errors != 4 {
+		t.Errorf("fake answered %d errors, want 4", stats.Errors)
+	}
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FetchStatsFile persists per-combination fetch statistics across runs
+const FetchStatsFile = "cache/fetch_stats.json"
+
+const (
+	fetchStatusHistoryLength = 10               // HTTP statuses kept per combination
+	fetchStatsSaveInterval   = 30 * time.Second // Saves during a run are throttled; the end of a run always saves
+	fetchStatsTopCount       = 5                // Combinations listed per aggregate in status.json
+)
+
+// CombinationFetchStats are the fetch statistics of one track/class combination
+type CombinationFetchStats struct {
+	TrackID        string    `json:"track_id"`
+	Track          string    `json:"track"`
+	ClassID        string    `json:"class_id"`
+	Class          string    `json:"class"`
+	Fetches        int       `json:"fetches"`
+	Errors         int       `json:"errors"`
+	LastDurationMs int64     `json:"last_duration_ms"`
+	LastEntries    int       `json:"last_entries"`
+	LastFetchedAt  time.Time `json:"last_fetched_at"`
+	LastError      string    `json:"last_error,omitempty"`
+	Duplicates     int       `json:"duplicates,omitempty"` // Entries repeated across listing pages, dropped over all fetches
+	StatusHistory  []int     `json:"status_history"`       // HTTP status of the latest fetches, oldest first; 0 is no response
+}
+
+// ErrorRate is the share of failed fetches
+func (s CombinationFetchStats) ErrorRate() float64 {
+	if s.Fetches == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Fetches)
+}
+
+// FetchStatsSummary aggregates the fetch statistics for status.json
+type FetchStatsSummary struct {
+	Combinations int                     `json:"combinations"`
+	Fetches      int                     `json:"fetches"`
+	Errors       int                     `json:"errors"`
+	ErrorRate    float64                 `json:"error_rate"`
+	Duplicates   int                     `json:"duplicates"`  // Entries repeated across listing pages and dropped
+	Slowest      []CombinationFetchStats `json:"slowest"`     // By last fetch duration
+	MostErrors   []CombinationFetchStats `json:"most_errors"` // By error rate, then errors
+}
+
+// fetchStats holds the statistics, loaded from FetchStatsFile on first use
+var fetchStats struct {
+	mu      sync.Mutex
+	loaded  bool
+	dirty   bool
+	savedAt time.Time
+	combos  map[string]*CombinationFetchStats // By "trackID_classID"
+}
+
+// loadFetchStatsLocked reads FetchStatsFile once; a missing or unreadable file starts empty statistics
+func loadFetchStatsLocked() {
+	if fetchStats.loaded {
+		return
+	}
+	fetchStats.loaded = true
+	fetchStats.combos = make(map[string]*CombinationFetchStats)
+
+	data, err := os.ReadFile(FetchStatsFile)
+	if err != nil {
+		return
+	}
+	var combos []CombinationFetchStats
+	if err := json.Unmarshal(data, &combos); err != nil {
+		loaderLog.Warnf("⚠️ Ignoring unreadable %s: %v", FetchStatsFile, err)
+		return
+	}
+	for i := range combos {
+		stats := combos[i]
+		fetchStats.combos[stats.TrackID+"_"+stats.ClassID] = &stats
+	}
+}
+
+// saveFetchStatsLocked writes the statistics to FetchStatsFile, sorted by track and class
+func saveFetchStatsLocked() {
+	data, err := json.MarshalIndent(fetchStatsLocked(), "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(FetchStatsFile), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(FetchStatsFile, data)
+	}
+	if err != nil {
+		loaderLog.Warnf("⚠️ Failed to save %s: %v", FetchStatsFile, err)
+		return
+	}
+	fetchStats.dirty = false
+	fetchStats.savedAt = time.Now()
+}
+
+// fetchStatsLocked returns a copy of the statistics, sorted by track and class
+func fetchStatsLocked() []CombinationFetchStats {
+	combos := make([]CombinationFetchStats, 0, len(fetchStats.combos))
+	for _, stats := range fetchStats.combos {
+		copied := *stats
+		copied.StatusHistory = append([]int(nil), stats.StatusHistory...)
+		combos = append(combos, copied)
+	}
+	sort.Slice(combos, func(i, j int) bool {
+		if combos[i].TrackID != combos[j].TrackID {
+			return combos[i].TrackID < combos[j].TrackID
+		}
+		return combos[i].ClassID < combos[j].ClassID
+	})
+	return combos
+}
+
+// FetchStats returns the statistics of every fetched combination, sorted by track and class
+func FetchStats() []CombinationFetchStats {
+	fetchStats.mu.Lock()
+	defer fetchStats.mu.Unlock()
+	loadFetchStatsLocked()
+	return fetchStatsLocked()
+}
+
+// recordFetchStats counts a fetch of a combination. Fetches stopped by cancellation aren't counted.
+func recordFetchStats(ctx context.Context, track TrackConfig, class CarClassConfig, entries int, duration time.Duration, err error) {
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		return
+	}
+
+	fetchStats.mu.Lock()
+	defer fetchStats.mu.Unlock()
+	loadFetchStatsLocked()
+
+	stats := fetchStatsEntryLocked(track.TrackID, class.ClassID)
+	if track.Name != "" {
+		stats.Track = track.Name
+	}
+	if class.Name != "" {
+		stats.Class = class.Name
+	}
+	stats.Fetches++
+	stats.LastDurationMs = duration.Milliseconds()
+	stats.LastFetchedAt = time.Now().UTC()
+
+	status := 200
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+		status = 0
+		var statusErr *APIStatusError
+		if errors.As(err, &statusErr) {
+			status = statusErr.StatusCode
+		}
+	} else {
+		stats.LastEntries = entries
+		stats.LastError = ""
+	}
+	stats.StatusHistory = append(stats.StatusHistory, status)
+	if len(stats.StatusHistory) > fetchStatusHistoryLength {
+		stats.StatusHistory = stats.StatusHistory[len(stats.StatusHistory)-fetchStatusHistoryLength:]
+	}
+
+	fetchStats.dirty = true
+	if time.Since(fetchStats.savedAt) >= fetchStatsSaveInterval {
+		saveFetchStatsLocked()
+	}
+}
+
+// recordFetchDuplicates counts the entries a fetch dropped as repeated across listing pages
+// They are saved with the fetch itself (see recordFetchStats)
+func recordFetchDuplicates(trackID, classID string, duplicates int) {
+	fetchStats.mu.Lock()
+	defer fetchStats.mu.Unlock()
+	loadFetchStatsLocked()
+	fetchStatsEntryLocked(trackID, classID).Duplicates += duplicates
+}
+
+// fetchStatsEntryLocked returns the statistics of a combination, adding them on its first fetch
+func fetchStatsEntryLocked(trackID, classID string) *CombinationFetchStats {
+	key := trackID + "_" + classID
+	stats, ok := fetchStats.combos[key]
+	if !ok {
+		stats = &CombinationFetchStats{TrackID: trackID, ClassID: classID}
+		fetchStats.combos[key] = stats
+	}
+	return stats
+}
+
+// flushFetchStats saves statistics recorded since the last save
+func flushFetchStats() {
+	fetchStats.mu.Lock()
+	defer fetchStats.mu.Unlock()
+	if fetchStats.dirty {
+		saveFetchStatsLocked()
+	}
+}
+
+// SummarizeFetchStats aggregates the statistics: totals, the slowest combinations and the most error-prone ones
+// Returns nil before the first fetch
+func SummarizeFetchStats() *FetchStatsSummary {
+	combos := FetchStats()
+	if len(combos) == 0 {
+		return nil
+	}
+
+	summary := &FetchStatsSummary{Combinations: len(combos)}
+	failing := []CombinationFetchStats{}
+	for _, stats := range combos {
+		summary.Fetches += stats.Fetches
+		summary.Errors += stats.Errors
+		summary.Duplicates += stats.Duplicates
+		if stats.Errors > 0 {
+			failing = append(failing, stats)
+		}
+	}
+	if summary.Fetches > 0 {
+		summary.ErrorRate = float64(summary.Errors) / float64(summary.Fetches)
+	}
+
+	sort.SliceStable(combos, func(i, j int) bool {
+		return combos[i].LastDurationMs > combos[j].LastDurationMs
+	})
+	summary.Slowest = combos[:min(fetchStatsTopCount, len(combos))]
+
+	sort.SliceStable(failing, func(i, j int) bool {
+		if rateI, rateJ := failing[i].ErrorRate(), failing[j].ErrorRate(); rateI != rateJ {
+			return rateI > rateJ
+		}
+		return failing[i].Errors > failing[j].Errors
+	})
+	summary.MostErrors = failing[:min(fetchStatsTopCount, len(failing))]
+	return summary
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ResultFilter narrows a driver's indexed results; every set field must match (AND), and a field
+// listing several comma-separated values matches any of them (OR). Values are compared case-insensitively.
+type ResultFilter struct {
+	Track      []string // Track ID
+	Class      []string // Class ID or car class name
+	Country    []string // Country name or ISO code
+	Car        []string // Part of the car name
+	Team       []string // Part of the team name
+	Rank       []string // RaceRoom rank, e.g. "A"
+	Difficulty []string // Driving model, e.g. "Get Real"
+}
+
+// ParseResultFilter reads the track, class, country, car, team, rank and difficulty query parameters
+func ParseResultFilter(query url.Values) ResultFilter {
+	values := func(key string) []string {
+		var list []string
+		for _, value := range strings.Split(query.Get(key), ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				list = append(list, strings.ToLower(value))
+			}
+		}
+		return list
+	}
+	return ResultFilter{
+		Track:      values("track"),
+		Class:      values("class"),
+		Country:    values("country"),
+		Car:        values("car"),
+		Team:       values("team"),
+		Rank:       values("rank"),
+		Difficulty: values("difficulty"),
+	}
+}
+
+// IsEmpty reports whether the filter matches every result
+func (f ResultFilter) IsEmpty() bool {
+	return len(f.Track) == 0 && len(f.Class) == 0 && len(f.Country) == 0 && len(f.Car) == 0 &&
+		len(f.Team) == 0 && len(f.Rank) == 0 && len(f.Difficulty) == 0
+}
+
+// Match reports whether a result passes the filter
+func (f ResultFilter) Match(result DriverResult) bool {
+	return matchAny(f.Track, false, result.TrackID) &&
+		matchAny(f.Class, false, result.ClassID, result.CarClass) &&
+		matchAny(f.Country, false, result.Country, result.CountryCode) &&
+		matchAny(f.Car, true, result.Car) &&
+		matchAny(f.Team, true, result.Team) &&
+		matchAny(f.Rank, false, result.Rank) &&
+		matchAny(f.Difficulty, false, result.Difficulty)
+}
+
+// Apply returns the results passing the filter; the input is returned as is when the filter is empty
+func (f ResultFilter) Apply(results []DriverResult) []DriverResult {
+	if f.IsEmpty() {
+		return results
+	}
+	filtered := make([]DriverResult, 0, len(results))
+	for _, result := range results {
+		if f.Match(result) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// matchAny reports whether any wanted (lowercase) value equals - or with partial, is contained in - any field
+// An empty wanted list matches everything
+func matchAny(wanted []string, partial bool, fields ...string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, field := range fields {
+		field = strings.ToLower(field)
+		if field == "" {
+			continue
+		}
+		for _, value := range wanted {
+			if field == value || (partial && strings.Contains(field, value)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fixture modes of fetch.fixtures.mode
+const (
+	FixtureModeOff    = ""       // Talk to RaceRoom
+	FixtureModeReplay = "replay" // Answer from the fixture directory; RaceRoom is never contacted
+	FixtureModeRecord = "record" // Talk to RaceRoom and store every response in the fixture directory
+)
+
+// Fixture is a stored RaceRoom response
+type Fixture struct {
+	URL        string          `json:"url"`
+	Status     int             `json:"status"`
+	RetryAfter string          `json:"retry_after,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Text       string          `json:"text,omitempty"` // A body that isn't valid JSON (e.g. the HTML leaderboard page), verbatim
+}
+
+// fixtureNameChars are the characters replaced in fixture file names
+var fixtureNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixturePath returns the fixture file of a request: the host and path as directories, the query
+// parameters sorted into the file name, e.g. game.raceroom.com/leaderboard/listing/0/car_class-class-1703_count-1500_start-0_track-9473.json
+func fixturePath(dir string, req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, key+"-"+strings.Join(query[key], ","))
+	}
+	name := fixtureNameChars.ReplaceAllString(strings.Join(params, "_"), "_")
+	if name == "" {
+		name = "index"
+	}
+
+	segments := []string{dir, fixtureNameChars.ReplaceAllString(req.URL.Host, "_")}
+	for _, segment := range strings.Split(strings.Trim(req.URL.Path, "/"), "/") {
+		if segment = fixtureNameChars.ReplaceAllString(segment, "_"); segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	return filepath.Join(append(segments, name+".json")...)
+}
+
+// fixtureTransport answers RaceRoom requests from fixture files (replay) or stores the answers of
+// the real transport in them (record)
+type fixtureTransport struct {
+	dir  string
+	next http.RoundTripper // Real transport when recording; nil replays
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(t.dir, req)
+	if t.next == nil {
+		return t.replay(req, path)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	fixture := Fixture{URL: req.URL.String(), Status: resp.StatusCode, RetryAfter: resp.Header.Get("Retry-After")}
+	if json.Valid(data) {
+		fixture.Body = data
+	} else {
+		fixture.Text = string(data)
+	}
+	if err := writeFixture(path, fixture); err != nil {
+		loaderLog.Warnf("⚠️ Failed to record fixture %s: %v", path, err)
+	}
+	return resp, nil
+}
+
+// replay answers a request from its fixture file; requests without one are answered 404
+func (t *fixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	fixture := Fixture{Status: http.StatusNotFound}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		loaderLog.Debugf("📼 No fixture for %s (%s)", req.URL, path)
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+	}
+
+	body := []byte(fixture.Body)
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	if fixture.Body == nil {
+		body = []byte(fixture.Text)
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if fixture.RetryAfter != "" {
+		header.Set("Retry-After", fixture.RetryAfter)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(fixture.Status) + " " + http.StatusText(fixture.Status),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// writeFixture stores a fixture, indented so recorded fixtures can be read and edited
+func writeFixture(path string, fixture Fixture) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // Keep the URL's "&" readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// setFixtureConfig validates fetch.fixtures; an unknown mode falls back to talking to RaceRoom
+func setFixtureConfig(cfg FixtureConfig) FixtureConfig {
+	if cfg.Dir == "" {
+		cfg.Dir = GetDefaultConfig().Fetch.Fixtures.Dir
+	}
+	switch cfg.Mode {
+	case FixtureModeOff:
+	case FixtureModeReplay:
+		configLog.Infof("📼 Replaying RaceRoom responses from %s - RaceRoom won't be contacted", cfg.Dir)
+	case FixtureModeRecord:
+		configLog.Infof("📼 Recording RaceRoom responses to %s", cfg.Dir)
+	default:
+		configLog.Warnf("⚠️ Ignoring fetch.fixtures.mode %q (expected \"replay\" or \"record\")", cfg.Mode)
+		cfg.Mode = FixtureModeOff
+	}
+	return cfg
+}
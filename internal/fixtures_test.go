@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// TestFixtureReplay replays the checked-in fixtures through the loader, the cache and the index build,
+// then reads them back through the search and leaderboard endpoints
+func TestFixtureReplay(t *testing.T) {
+	fixtureDir, err := filepath.Abs(filepath.Join("..", "testdata", "raceroom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chdirTemp(t)
+
+	SetSelectionConfig(SelectionConfig{IncludeTracks: []string{"1693"}, IncludeClasses: []string{"1703"}})
+	fetchConfig := GetDefaultConfig().Fetch
+	fetchConfig.Fixtures = FixtureConfig{Mode: FixtureModeReplay, Dir: fixtureDir}
+	fetchConfig.Throttle = ThrottleConfig{MinDelayMs: 1, MaxDelayMs: 10, InitialDelayMs: 1, SlowResponseMs: 10000}
+	SetFetchConfig(fetchConfig)
+	defer SetFetchConfig(GetDefaultConfig().Fetch)
+
+	tracks := LoadAllTrackDataWithCallback(context.Background(), nil, nil)
+	if len(tracks) != 1 || len(tracks[0].Data) != 3 {
+		t.Fatalf("loaded %+v, want the 3 fixture entries of 1693 + 1703", tracks)
+	}
+	cached, err := NewDataCache().LoadTrackData("1693", "1703")
+	if err != nil || len(cached.Data) != 3 {
+		t.Fatalf("cache of 1693 + 1703: %d entries, %v; want 3 entries", len(cached.Data), err)
+	}
+
+	if err := BuildAndExportIndex(tracks); err != nil {
+		t.Fatal(err)
+	}
+	server := &APIServer{engine: GetSearchEngine()}
+	drivers := []struct {
+		name    string
+		id      string
+		lapTime string
+		gapMs   int64
+	}{
+		{"Fixture Driver One", "1000001", "1m 37.512s", 0},
+		{"Fixture Driver Two", "1000002", "1m 38.004s", 492},
+		{"Fixture Driver Three", "1000003", "1m 39.870s", 2358},
+	}
+
+	recorder := httptest.NewRecorder()
+	server.HandleSearch(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=fixture+driver+two", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("search: status %d: %s", recorder.Code, recorder.Body)
+	}
+	var search SearchResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &search); err != nil {
+		t.Fatal(err)
+	}
+	if search.Total != 1 || len(search.Results) != 1 {
+		t.Fatalf("search = %+v, want one result", search)
+	}
+	if match := search.Results[0]; match.Name != drivers[1].name || match.DriverID != drivers[1].id ||
+		match.Position != 2 || match.LapTime != drivers[1].lapTime || match.TrackID != "1693" || match.ClassID != "1703" ||
+		match.TotalEntries != 3 || match.Score != scoreExact {
+		t.Errorf("search result = %+v, want %s at position 2 of 3 on 1693 + 1703", match, drivers[1].name)
+	}
+	if len(search.Shards) != 1 || search.Shards[0] != "f" {
+		t.Errorf("search shards = %v, want [f]", search.Shards)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.HandleLeaderboard(recorder, httptest.NewRequest(http.MethodGet, "/api/leaderboard?track=1693&class=1703", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("leaderboard: status %d: %s", recorder.Code, recorder.Body)
+	}
+	var leaderboard LeaderboardResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &leaderboard); err != nil {
+		t.Fatal(err)
+	}
+	if leaderboard.TrackID != "1693" || leaderboard.ClassID != "1703" || leaderboard.ClassName != "GTR 3" ||
+		leaderboard.Total != 3 || leaderboard.Count != 3 || leaderboard.Sort != SortByPosition || leaderboard.Order != "asc" {
+		t.Errorf("leaderboard = %+v, want all 3 entries of 1693 + GTR 3 by position", leaderboard)
+	}
+	if len(leaderboard.Results) != len(drivers) {
+		t.Fatalf("leaderboard has %d results, want %d", len(leaderboard.Results), len(drivers))
+	}
+	for i, want := range drivers {
+		result := leaderboard.Results[i]
+		if result.Name != want.name || result.DriverID != want.id || result.Position != i+1 || result.LapTime != want.lapTime {
+			t.Errorf("leaderboard result %d = %+v, want %s in %s", i, result.DriverResult, want.name, want.lapTime)
+		}
+		if result.GapMs == nil || *result.GapMs != want.gapMs {
+			t.Errorf("leaderboard result %d: gap_ms %v, want %d", i, result.GapMs, want.gapMs)
+		}
+	}
+}
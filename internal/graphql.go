@@ -0,0 +1,855 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The /api/graphql endpoint implements the read-only subset of GraphQL the frontends need:
+// one query operation with fields, aliases, arguments (literals or $variables with defaults)
+// and nested selections. Fragments, directives, mutations and introspection are rejected.
+// Object fields are named after the JSON fields of the REST responses.
+
+// maxGraphQLQueryBytes bounds the size of a query document
+const maxGraphQLQueryBytes = 16 << 10
+
+// graphQLRequest is the POST body (or GET parameters) of a GraphQL request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// graphQLError is an entry of the response's errors list
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlField is a parsed field selection
+type gqlField struct {
+	Alias     string
+	Name      string
+	Args      map[string]interface{}
+	Selection []gqlField
+}
+
+// responseKey is the key of the field in the response object
+func (f gqlField) responseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// gqlResolver resolves a root query field from its arguments
+type gqlResolver func(args gqlArgs) (interface{}, error)
+
+// gqlArgs are the arguments of a root field
+type gqlArgs map[string]interface{}
+
+// string returns a string argument, or "" when absent
+func (a gqlArgs) string(name string) string {
+	switch v := a[name].(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// requiredString returns a non-empty string argument or an error
+func (a gqlArgs) requiredString(name string) (string, error) {
+	if v := a.string(name); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("missing argument %q", name)
+}
+
+// int returns an integer argument, or def when absent
+func (a gqlArgs) int(name string, def int) int {
+	switch v := a[name].(type) {
+	case int:
+		return v
+	case float64: // JSON variables decode as float64
+		return int(v)
+	}
+	return def
+}
+
+// graphQLResolvers returns the root fields of the query type
+func (s *APIServer) graphQLResolvers() map[string]gqlResolver {
+	return map[string]gqlResolver{
+		// driver(name: String!): the driver profile of /api/driver
+		"driver": func(args gqlArgs) (interface{}, error) {
+			name, err := args.requiredString("name")
+			if err != nil {
+				return nil, err
+			}
+			results := s.engine.Lookup(name)
+			if len(results) == 0 {
+				return nil, nil
+			}
+			return s.engine.DriverProfile(name, results), nil
+		},
+		// drivers(prefix: String!, limit: Int): autocomplete suggestions of /api/drivers
+		"drivers": func(args gqlArgs) (interface{}, error) {
+			prefix, err := args.requiredString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			limit := clampLimit(args.int("limit", defaultAutocompleteLimit), maxAutocompleteLimit)
+			return s.engine.Autocomplete(prefix, limit), nil
+		},
+		// tracks(search: String): the tracks of /api/tracks whose name contains search
+		"tracks": func(args gqlArgs) (interface{}, error) {
+			search := strings.ToLower(args.string("search"))
+			tracks := make([]TrackSummary, 0)
+			for _, track := range s.trackSummaries() {
+				if strings.Contains(strings.ToLower(track.Name), search) {
+					tracks = append(tracks, track)
+				}
+			}
+			return tracks, nil
+		},
+		// classes(search: String): the classes of /api/classes whose name contains search
+		"classes": func(args gqlArgs) (interface{}, error) {
+			search := strings.ToLower(args.string("search"))
+			classes := make([]ClassSummary, 0)
+			for _, class := range s.classSummaries() {
+				if strings.Contains(strings.ToLower(class.Name), search) {
+					classes = append(classes, class)
+				}
+			}
+			return classes, nil
+		},
+		// leaderboard(track: ID!, class: ID!, limit: Int, offset: Int, sort: String, order: String, country: String)
+		"leaderboard": func(args gqlArgs) (interface{}, error) {
+			return s.graphQLLeaderboard(args)
+		},
+		// status: the document of /api/status
+		"status": func(args gqlArgs) (interface{}, error) {
+			return ReadStatusData(), nil
+		},
+	}
+}
+
+// graphQLLeaderboard pages through a cached leaderboard, optionally filtered by country name or code
+// total counts the entries left after the country filter
+func (s *APIServer) graphQLLeaderboard(args gqlArgs) (interface{}, error) {
+	trackID, err := args.requiredString("track")
+	if err != nil {
+		return nil, err
+	}
+	classID, err := args.requiredString("class")
+	if err != nil {
+		return nil, err
+	}
+	sortKey := args.string("sort")
+	if sortKey == "" {
+		sortKey = SortByPosition
+	}
+	order := "asc"
+	if strings.EqualFold(args.string("order"), "desc") {
+		order = "desc"
+	}
+	view, err := LoadLeaderboardView(trackID, classID, sortKey, order == "desc")
+	if err != nil {
+		return nil, err
+	}
+	RecordCombinationRequest(trackID, classID)
+
+	results := view.Results
+	if country := args.string("country"); country != "" {
+		var filtered []DriverResult // The view is shared, so it is never filtered in place
+		for _, result := range results {
+			if strings.EqualFold(result.Country, country) || strings.EqualFold(result.CountryCode, country) {
+				filtered = append(filtered, result)
+			}
+		}
+		results = filtered
+	}
+
+	page := LeaderboardResponse{
+		Track:     view.Track,
+		TrackID:   trackID,
+		ClassID:   classID,
+		ClassName: GetCarClassName(classID),
+		Total:     len(results),
+		Offset:    args.int("offset", 0),
+		Limit:     clampLimit(args.int("limit", defaultLeaderboardLimit), maxLeaderboardLimit),
+		Sort:      sortKey,
+		Order:     order,
+	}
+	if page.Offset < 0 || page.Offset > page.Total {
+		page.Offset = min(max(page.Offset, 0), page.Total)
+	}
+	end := min(page.Offset+page.Limit, page.Total)
+	page.Results = withGaps(results[page.Offset:end], view.gaps)
+	page.Count = len(page.Results)
+	return page, nil
+}
+
+// clampLimit bounds a limit argument to 1..max
+func clampLimit(limit, max int) int {
+	if limit < 1 {
+		return 1
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// HandleGraphQL executes a GraphQL query: POST /api/graphql {"query", "variables"} or GET ?query=&variables=
+func (s *APIServer) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		if raw := r.URL.Query().Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				writeGraphQLErrors(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLQueryBytes*4))
+		if err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+		req.Variables = normalizeJSONNumbers(req.Variables).(map[string]interface{})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeGraphQLErrors(w, http.StatusBadRequest, "missing query")
+		return
+	}
+	if len(req.Query) > maxGraphQLQueryBytes {
+		writeGraphQLErrors(w, http.StatusBadRequest, fmt.Sprintf("query exceeds %d bytes", maxGraphQLQueryBytes))
+		return
+	}
+
+	fields, err := parseGraphQL(req.Query, req.Variables)
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resolvers := s.graphQLResolvers()
+	data := gqlObject{}
+	var errs []graphQLError
+	for _, field := range fields {
+		key := field.responseKey()
+		if field.Name == "__typename" {
+			data = append(data, gqlEntry{key, "Query"})
+			continue
+		}
+		resolve, ok := resolvers[field.Name]
+		if !ok {
+			errs = append(errs, graphQLError{Message: fmt.Sprintf("unknown field %q on Query", field.Name), Path: []interface{}{key}})
+			data = append(data, gqlEntry{key, nil})
+			continue
+		}
+		value, err := resolve(gqlArgs(field.Args))
+		if err == nil {
+			value, err = selectGraphQL(reflect.ValueOf(value), field.Selection, []interface{}{key})
+		}
+		if err != nil {
+			path := []interface{}{key}
+			if pathErr, ok := err.(gqlPathError); ok {
+				path = pathErr.path
+			}
+			errs = append(errs, graphQLError{Message: err.Error(), Path: path})
+			value = nil
+		}
+		data = append(data, gqlEntry{key, value})
+	}
+
+	response := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeGraphQLErrors writes a request-level error in the GraphQL response format
+func writeGraphQLErrors(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"errors": []graphQLError{{Message: message}}})
+}
+
+// normalizeJSONNumbers converts json.Number values to int or float64
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{}
+	case json.Number:
+		if n, err := strconv.Atoi(string(v)); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			if item != nil {
+				v[key] = normalizeJSONNumbers(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			if item != nil {
+				v[i] = normalizeJSONNumbers(item)
+			}
+		}
+		return v
+	}
+	return value
+}
+
+// gqlEntry is a key of a response object
+type gqlEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// gqlObject is a response object whose keys keep the order of the selection
+type gqlObject []gqlEntry
+
+// MarshalJSON writes the entries in order
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.Key)
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlPathError is a selection error at a response path
+type gqlPathError struct {
+	path    []interface{}
+	message string
+}
+
+func (e gqlPathError) Error() string { return e.message }
+
+// timeType is encoded as a scalar rather than an object
+var timeType = reflect.TypeOf(time.Time{})
+
+// selectGraphQL projects a resolved value onto a selection set
+// Structs are objects whose fields are their JSON field names; slices are lists
+func selectGraphQL(v reflect.Value, selection []gqlField, path []interface{}) (interface{}, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch {
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []interface{}{}, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			item, err := selectGraphQL(v.Index(i), selection, append(path, i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+
+	case v.Kind() == reflect.Struct && v.Type() != timeType:
+		if len(selection) == 0 {
+			return nil, gqlPathError{path, fmt.Sprintf("field %q of object type must have a selection of subfields", gqlFieldName(path))}
+		}
+		fields := jsonFields(v)
+		object := make(gqlObject, 0, len(selection))
+		for _, field := range selection {
+			key := field.responseKey()
+			if field.Name == "__typename" {
+				object = append(object, gqlEntry{key, v.Type().Name()})
+				continue
+			}
+			if len(field.Args) > 0 {
+				return nil, gqlPathError{append(path, key), fmt.Sprintf("field %q takes no arguments", field.Name)}
+			}
+			value, ok := fields[field.Name]
+			if !ok {
+				return nil, gqlPathError{append(path, key), fmt.Sprintf("unknown field %q on %s", field.Name, v.Type().Name())}
+			}
+			selected, err := selectGraphQL(value, field.Selection, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, gqlEntry{key, selected})
+		}
+		return object, nil
+	}
+
+	if len(selection) > 0 {
+		return nil, gqlPathError{path, fmt.Sprintf("field %q is a scalar and has no subfields", gqlFieldName(path))}
+	}
+	return v.Interface(), nil
+}
+
+// gqlFieldName returns the last field name of a response path, skipping list indexes
+func gqlFieldName(path []interface{}) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if name, ok := path[i].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// jsonFields maps the JSON field names of a struct (embedded structs flattened) to their values
+func jsonFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, value := range jsonFields(v.Field(i)) {
+				if _, shadowed := fields[embeddedName]; !shadowed {
+					fields[embeddedName] = value
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// gqlToken is a lexical token of a query document
+type gqlToken struct {
+	kind  byte // 'n' name, 'i' int, 'f' float, 's' string, 'p' punctuator, 0 end
+	value string
+}
+
+// gqlParser parses a query document into its root field selections
+type gqlParser struct {
+	src       string
+	pos       int
+	tok       gqlToken
+	variables map[string]interface{}
+}
+
+// parseGraphQL parses a single query operation, substituting variables
+func parseGraphQL(query string, variables map[string]interface{}) ([]gqlField, error) {
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	p := &gqlParser{src: query, variables: variables}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == 'n' {
+		switch p.tok.value {
+		case "query":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind == 'n' { // Operation name
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+			}
+			if p.is("(") {
+				if err := p.parseVariableDefinitions(); err != nil {
+					return nil, err
+				}
+			}
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%ss are not supported", p.tok.value)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, fmt.Errorf("unexpected %q", p.tok.value)
+		}
+	}
+	fields, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != 0 {
+		return nil, fmt.Errorf("only a single query operation is supported (unexpected %q)", p.tok.value)
+	}
+	return fields, nil
+}
+
+// parseVariableDefinitions reads ($name: Type = default, ...), applying defaults and checking required variables
+func (p *gqlParser) parseVariableDefinitions() error {
+	if err := p.next(); err != nil {
+		return err
+	}
+	for !p.is(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		required, err := p.parseType()
+		if err != nil {
+			return err
+		}
+		if p.is("=") {
+			if err := p.next(); err != nil {
+				return err
+			}
+			def, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if _, ok := p.variables[name]; !ok {
+				p.variables[name] = def
+			}
+		}
+		if value, ok := p.variables[name]; required && (!ok || value == nil) {
+			return fmt.Errorf("variable $%s is required", name)
+		}
+	}
+	return p.next()
+}
+
+// parseType reads a type reference and reports whether it is non-null
+func (p *gqlParser) parseType() (bool, error) {
+	if p.is("[") {
+		if err := p.next(); err != nil {
+			return false, err
+		}
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return false, err
+	}
+	if p.is("!") {
+		return true, p.next()
+	}
+	return false, nil
+}
+
+// parseSelectionSet reads { field ... }
+func (p *gqlParser) parseSelectionSet() ([]gqlField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []gqlField
+	for !p.is("}") {
+		if p.is("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return fields, p.next()
+}
+
+// parseField reads alias: name(args) { selection }
+func (p *gqlParser) parseField() (gqlField, error) {
+	var field gqlField
+	name, err := p.expectName()
+	if err != nil {
+		return field, err
+	}
+	field.Name = name
+	if p.is(":") {
+		if err := p.next(); err != nil {
+			return field, err
+		}
+		if field.Name, err = p.expectName(); err != nil {
+			return field, err
+		}
+		field.Alias = name
+	}
+	if p.is("(") {
+		if field.Args, err = p.parseArguments(); err != nil {
+			return field, err
+		}
+	}
+	if p.is("@") {
+		return field, fmt.Errorf("directives are not supported")
+	}
+	if p.is("{") {
+		if field.Selection, err = p.parseSelectionSet(); err != nil {
+			return field, err
+		}
+	}
+	return field, nil
+}
+
+// parseArguments reads (name: value, ...)
+func (p *gqlParser) parseArguments() (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for !p.is(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, p.next()
+}
+
+// parseValue reads a literal, list, object or $variable
+func (p *gqlParser) parseValue() (interface{}, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == 'p' && tok.value == "$":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		value, ok := p.variables[name]
+		if !ok {
+			return nil, nil // Unset optional variable
+		}
+		return value, nil
+	case tok.kind == 'p' && tok.value == "[":
+		list := []interface{}{}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.is("]") {
+			item, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.next()
+	case tok.kind == 'p' && tok.value == "{":
+		object := map[string]interface{}{}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.is("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.next()
+	case tok.kind == 'i':
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %s", tok.value)
+		}
+		return n, p.next()
+	case tok.kind == 'f':
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", tok.value)
+		}
+		return f, p.next()
+	case tok.kind == 's':
+		return tok.value, p.next()
+	case tok.kind == 'n':
+		var value interface{} = tok.value // Enum values are passed as strings
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		}
+		return value, p.next()
+	}
+	return nil, fmt.Errorf("unexpected %q, expected a value", tok.value)
+}
+
+// is reports whether the current token is the punctuator value
+func (p *gqlParser) is(value string) bool {
+	return p.tok.kind == 'p' && p.tok.value == value
+}
+
+// expect consumes the punctuator value
+func (p *gqlParser) expect(value string) error {
+	if !p.is(value) {
+		return p.unexpected(value)
+	}
+	return p.next()
+}
+
+// expectName consumes a name and returns it
+func (p *gqlParser) expectName() (string, error) {
+	if p.tok.kind != 'n' {
+		return "", p.unexpected("a name")
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+// unexpected describes the current token as a syntax error
+func (p *gqlParser) unexpected(wanted string) error {
+	if p.tok.kind == 0 {
+		return fmt.Errorf("unexpected end of query, expected %s", wanted)
+	}
+	return fmt.Errorf("unexpected %q at offset %d, expected %s", p.tok.value, p.pos, wanted)
+}
+
+// next reads the next token, skipping whitespace, commas and comments
+func (p *gqlParser) next() error {
+	src := p.src
+	for p.pos < len(src) {
+		c := src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(src) && src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			break
+		}
+	}
+	if p.pos >= len(src) {
+		p.tok = gqlToken{}
+		return nil
+	}
+
+	start := p.pos
+	c := src[p.pos]
+	switch {
+	case c == '_' || isASCIILetter(c):
+		for p.pos < len(src) && (src[p.pos] == '_' || isASCIILetter(src[p.pos]) || isASCIIDigit(src[p.pos])) {
+			p.pos++
+		}
+		p.tok = gqlToken{'n', src[start:p.pos]}
+	case c == '-' || isASCIIDigit(c):
+		p.pos++
+		kind := byte('i')
+		for p.pos < len(src) && (isASCIIDigit(src[p.pos]) || strings.IndexByte(".eE+-", src[p.pos]) >= 0) {
+			if !isASCIIDigit(src[p.pos]) {
+				kind = 'f'
+			}
+			p.pos++
+		}
+		p.tok = gqlToken{kind, src[start:p.pos]}
+	case c == '"':
+		value, err := p.readString()
+		if err != nil {
+			return err
+		}
+		p.tok = gqlToken{'s', value}
+	case strings.HasPrefix(src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = gqlToken{'p', "..."}
+	case strings.IndexByte("{}()[]:!$=@|&", c) >= 0:
+		p.pos++
+		p.tok = gqlToken{'p', string(c)}
+	default:
+		return fmt.Errorf("unexpected character %q at offset %d", c, p.pos)
+	}
+	return nil
+}
+
+// readString reads a double-quoted string with JSON-style escapes (block strings are not supported)
+func (p *gqlParser) readString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			var value string
+			if err := json.Unmarshal([]byte(p.src[start:p.pos]), &value); err != nil {
+				return "", fmt.Errorf("invalid string at offset %d", start)
+			}
+			return value, nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string at offset %d", start)
+		default:
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isASCIILetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isASCIIDigit(c byte) bool  { return c >= '0' && c <= '9' }
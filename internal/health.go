@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RefreshPostponement is a scheduled refresh waiting for RaceRoom to pass the health check, reported in status.json
+type RefreshPostponement struct {
+	Origin      string    `json:"origin"`
+	Since       time.Time `json:"since"`
+	Attempts    int       `json:"attempts"` // Failed health checks so far
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error"`
+}
+
+// refreshPostponement is the postponed refresh of this process, if any
+var refreshPostponement struct {
+	mu      sync.Mutex
+	current *RefreshPostponement
+}
+
+// CurrentRefreshPostponement returns the refresh waiting for RaceRoom to recover, if any
+func CurrentRefreshPostponement() (RefreshPostponement, bool) {
+	refreshPostponement.mu.Lock()
+	defer refreshPostponement.mu.Unlock()
+	if refreshPostponement.current == nil {
+		return RefreshPostponement{}, false
+	}
+	return *refreshPostponement.current, true
+}
+
+// setRefreshPostponement records (or with nil clears) the postponed refresh and writes status.json
+func setRefreshPostponement(postponement *RefreshPostponement) {
+	refreshPostponement.mu.Lock()
+	refreshPostponement.current = postponement
+	refreshPostponement.mu.Unlock()
+	if err := ExportStatusData(ReadStatusData()); err != nil {
+		schedulerLog.Warnf("⚠️ Failed to record refresh postponement: %v", err)
+	}
+}
+
+// CheckRaceRoomHealth requests one entry of the configured known combination
+// RaceRoom is healthy when it answers within the timeout with at least one entry
+func CheckRaceRoomHealth(ctx context.Context, config HealthCheckConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	api := NewAPIClient()
+	defer api.Close()
+	_, found, err := api.ProbeTrack(ctx, config.TrackID, config.ClassID)
+	if err != nil {
+		return fmt.Errorf("health check of track %s class %s failed: %w", config.TrackID, config.ClassID, err)
+	}
+	if !found {
+		return fmt.Errorf("health check of track %s class %s returned no entries", config.TrackID, config.ClassID)
+	}
+	return nil
+}
+
+// WaitForRaceRoom runs the health check before a scheduled refresh. While it fails, the refresh is postponed
+// with exponential backoff (retry_minutes, doubling up to max_retry_minutes) and recorded in status.json.
+// Returns an error when RaceRoom is still unhealthy after max_postpone_hours or ctx is done.
+func WaitForRaceRoom(ctx context.Context, config HealthCheckConfig, origin string) error {
+	if !config.Enabled {
+		return nil
+	}
+	config = config.Normalized()
+
+	var postponement *RefreshPostponement
+	delay := time.Duration(config.RetryMinutes) * time.Minute
+	maxDelay := time.Duration(config.MaxRetryMinutes) * time.Minute
+	deadline := time.Now().Add(time.Duration(config.MaxPostponeHours) * time.Hour)
+	for {
+		err := CheckRaceRoomHealth(ctx, config)
+		if err == nil {
+			if postponement != nil {
+				schedulerLog.Infof("✅ RaceRoom healthy again after %d failed checks - starting %s refresh", postponement.Attempts, origin)
+				setRefreshPostponement(nil)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			setRefreshPostponement(nil)
+			return ctx.Err()
+		}
+
+		if postponement == nil {
+			postponement = &RefreshPostponement{Origin: origin, Since: time.Now()}
+		}
+		postponement.Attempts++
+		postponement.LastError = err.Error()
+		postponement.NextAttempt = time.Now().Add(delay)
+		if postponement.NextAttempt.After(deadline) {
+			setRefreshPostponement(nil)
+			err = fmt.Errorf("RaceRoom still unhealthy after %d checks over %v: %w", postponement.Attempts, time.Since(postponement.Since).Round(time.Minute), err)
+			publishError("health", err)
+			return err
+		}
+
+		next := *postponement
+		setRefreshPostponement(&next)
+		schedulerLog.Warnf("🩺 %v - postponing %s refresh by %v (attempt %d)", err, origin, delay, postponement.Attempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			setRefreshPostponement(nil)
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// Normalized fills unset health check settings with the defaults
+func (c HealthCheckConfig) Normalized() HealthCheckConfig {
+	defaults := GetDefaultConfig().Schedule.HealthCheck
+	if c.TrackID == "" || c.ClassID == "" {
+		c.TrackID, c.ClassID = defaults.TrackID, defaults.ClassID
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	if c.RetryMinutes <= 0 {
+		c.RetryMinutes = defaults.RetryMinutes
+	}
+	if c.MaxRetryMinutes < c.RetryMinutes {
+		c.MaxRetryMinutes = max(defaults.MaxRetryMinutes, c.RetryMinutes)
+	}
+	if c.MaxPostponeHours <= 0 {
+		c.MaxPostponeHours = defaults.MaxPostponeHours
+	}
+	return c
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// apiCacheControl lets browsers and CDNs store cacheable API responses but revalidate them on every use,
+// so an unchanged response costs a 304 and a refresh is seen at once
+const apiCacheControl = "public, no-cache"
+
+// weakETag formats a fingerprint as a weak entity tag
+// Weak because versioned responses carry a request ID and timestamp: equal tags mean equal data, not equal bytes
+func weakETag(fingerprint uint64) string {
+	return fmt.Sprintf(`W/"%016x"`, fingerprint)
+}
+
+// fingerprint hashes values into one uint64
+func fingerprint(values ...uint64) uint64 {
+	hasher := fnv.New64a()
+	var buf [8]byte
+	for _, value := range values {
+		binary.LittleEndian.PutUint64(buf[:], value)
+		hasher.Write(buf[:])
+	}
+	return hasher.Sum64()
+}
+
+// indexETag tags responses computed from the search index: it changes when an index is swapped in,
+// the exclusion list changes or the alias table is edited
+func (se *SearchEngine) indexETag() string {
+	return weakETag(fingerprint(uint64(se.LastUpdate().UnixNano()), exclusionsRevision(), se.Aliases().revision()))
+}
+
+// leaderboardETag tags responses computed from a combination's cache file; ok is false when it isn't cached
+func leaderboardETag(trackID, classID string) (string, bool) {
+	info, err := os.Stat(NewDataCache().GetCacheFileName(trackID, classID))
+	if err != nil {
+		return "", false
+	}
+	return weakETag(fingerprint(uint64(info.ModTime().UnixNano()), uint64(info.Size()), exclusionsRevision())), true
+}
+
+// contentETag tags a response by its JSON body, for data that changes outside index updates
+func contentETag(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	hasher := fnv.New64a()
+	hasher.Write(data)
+	return weakETag(hasher.Sum64())
+}
+
+// notModified sets the ETag and Cache-Control headers of a cacheable GET response and reports whether the
+// client already holds it, in which case 304 Not Modified has been written and the handler is done
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", apiCacheControl)
+	if !ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ETagMatches reports whether an If-None-Match header lists etag or is "*", using the weak comparison of RFC 9110
+func ETagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,10 +2,15 @@ package internal
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,7 +39,7 @@ type PeriodicIndexer struct {
 func NewPeriodicIndexer(ctx context.Context, intervalMinutes int, callbacks IndexerCallbacks) *PeriodicIndexer {
 	// Validate interval; default to 30 minutes if invalid
 	if intervalMinutes < 1 {
-		log.Printf("⚠️ Invalid periodic indexing interval (%d). Defaulting to 30 minutes.", intervalMinutes)
+		indexerLog.Warnf("⚠️ Invalid periodic indexing interval (%d). Defaulting to 30 minutes.", intervalMinutes)
 		intervalMinutes = 30
 	}
 	return &PeriodicIndexer{
@@ -48,7 +53,7 @@ func NewPeriodicIndexer(ctx context.Context, intervalMinutes int, callbacks Inde
 func (pi *PeriodicIndexer) Start() {
 	go func() {
 		defer func() {
-			log.Println("⏹️ Periodic indexing goroutine exiting")
+			indexerLog.Debugf("⏹️ Periodic indexing goroutine exiting")
 		}()
 
 		// Get current state
@@ -57,9 +62,9 @@ func (pi *PeriodicIndexer) Start() {
 		// Immediate indexing once if we have no previous index
 		if state.FetchInProgress && len(state.Tracks) > 0 && state.LastIndexedCount == 0 {
 			if err := BuildAndExportIndex(state.Tracks); err != nil {
-				log.Printf("⚠️ Failed to export index: %v", err)
+				indexerLog.Warnf("⚠️ Failed to export index: %v", err)
 			} else {
-				log.Printf("🔍 Initial periodic index built: %d track/class combinations", len(state.Tracks))
+				indexerLog.Infof("🔍 Initial periodic index built: %d track/class combinations", len(state.Tracks))
 				pi.callbacks.UpdateIndexed(len(state.Tracks))
 			}
 			pi.callbacks.ExportStatus()
@@ -72,13 +77,13 @@ func (pi *PeriodicIndexer) Start() {
 			// Check if fetch is complete before waiting on ticker
 			state = pi.callbacks.GetState()
 			if !state.FetchInProgress {
-				log.Println("⏹️ Stopping periodic indexing - data loading completed")
+				indexerLog.Infof("⏹️ Stopping periodic indexing - data loading completed")
 				return
 			}
 
 			select {
 			case <-ticker.C:
-				log.Println("⏱️ Periodic indexing tick fired")
+				indexerLog.Debugf("⏱️ Periodic indexing tick fired")
 				state = pi.callbacks.GetState()
 
 				// Only index if we're still fetching and have some data
@@ -86,48 +91,65 @@ func (pi *PeriodicIndexer) Start() {
 					// Promote temp cache before indexing to ensure consistency
 					tempCache := NewTempDataCache()
 					promotedCount, err := tempCache.PromoteTempCache()
+					if errors.Is(err, ErrSchemaDrift) {
+						indexerLog.Warnf("⏭️ Skipping index update: %v", err)
+						continue
+					}
 					if err != nil {
-						log.Printf("⚠️ Failed to promote temp cache: %v", err)
+						indexerLog.Warnf("⚠️ Failed to promote temp cache: %v", err)
 					} else if promotedCount > 0 {
-						log.Printf("🔄 Promoted %d new cache files before indexing", promotedCount)
+						indexerLog.Infof("🔄 Promoted %d new cache files before indexing", promotedCount)
 					}
 
 					// Rebuild index every interval during fetching
 					if err := BuildAndExportIndex(state.Tracks); err != nil {
-						log.Printf("⚠️ Failed to export index: %v", err)
+						indexerLog.Warnf("⚠️ Failed to export index: %v", err)
 					} else {
-						log.Printf("🔍 Index updated: %d track/class combinations", len(state.Tracks))
+						indexerLog.Infof("🔍 Index updated: %d track/class combinations", len(state.Tracks))
 						pi.callbacks.UpdateIndexed(len(state.Tracks))
 					}
 					pi.callbacks.ExportStatus()
 				} else if !state.FetchInProgress {
-					log.Println("⏹️ Stopping periodic indexing - data loading completed")
+					indexerLog.Infof("⏹️ Stopping periodic indexing - data loading completed")
 					return
 				}
 			case <-pi.ctx.Done():
-				log.Println("⏹️ Periodic indexing cancelled via context")
+				indexerLog.Infof("⏹️ Periodic indexing cancelled via context")
 				return
 			}
 		}
 	}()
 }
 
+var (
+	// indexBuildMu serializes BuildAndExportIndex calls from the loader, refreshes and periodic indexer
+	indexBuildMu sync.Mutex
+	// lastIndexedVersion is the data version of the last successful export
+	lastIndexedVersion string
+	// lastIndexedExclusions is the exclusion list revision of the last successful export
+	lastIndexedExclusions uint64
+	// indexBuilding is set while BuildAndExportIndex builds and exports an index
+	indexBuilding atomic.Bool
+)
+
+// IsIndexing reports whether an index build is in progress
+func IsIndexing() bool {
+	return indexBuilding.Load()
+}
+
+// indexChunksPerWorker controls how finely tracks are split across index workers
+// More chunks than workers keeps all cores busy when combination sizes vary a lot
+const indexChunksPerWorker = 4
+
 // buildDriverIndex builds a driver index from track data
+// Tracks are split into contiguous chunks indexed concurrently by a worker pool;
+// per-chunk maps are merged in chunk order so result ordering stays deterministic.
 // Returns the index, track entry counts, unique track count, and total entries
 func buildDriverIndex(tracks []TrackInfo) (DriverIndex, map[string]int, int, int) {
-	// Pre-allocate map with estimated capacity to reduce reallocations
-	estimatedDrivers := len(tracks) / 5
-	if estimatedDrivers < 1000 {
-		estimatedDrivers = 1000
-	}
-	index := make(DriverIndex, estimatedDrivers)
 	totalEntries := 0
 
 	// Track unique track IDs (not names, as multiple layouts can share the same track)
 	uniqueTracksMap := make(map[string]bool)
-
-	// First pass: count entries per driver to pre-allocate slices
-	driverCounts := make(map[string]int, estimatedDrivers)
 	trackEntryCounts := make(map[string]int, len(tracks))
 
 	for i := range tracks {
@@ -141,175 +163,261 @@ func buildDriverIndex(tracks []TrackInfo) (DriverIndex, map[string]int, int, int
 		if track.TrackID != "" {
 			uniqueTracksMap[track.TrackID] = true
 		}
-
-		for _, entry := range track.Data {
-			if driverInterface, exists := entry["driver"]; exists {
-				if driverMap, ok := driverInterface.(map[string]interface{}); ok {
-					if nameInterface, exists := driverMap["name"]; exists {
-						if name, ok := nameInterface.(string); ok && name != "" {
-							lowerName := strings.ToLower(name)
-							driverCounts[lowerName]++
-						}
-					}
-				}
-			}
-		}
 	}
 
-	// Pre-allocate slices for each driver with exact capacity
-	for driver, count := range driverCounts {
-		index[driver] = make([]DriverResult, 0, count)
+	// Split tracks into contiguous chunks
+	workers := runtime.GOMAXPROCS(0)
+	chunkCount := workers * indexChunksPerWorker
+	if chunkCount > len(tracks) {
+		chunkCount = len(tracks)
 	}
-	// Clear the counting map to free memory
-	for k := range driverCounts {
-		delete(driverCounts, k)
+	if chunkCount < 1 {
+		chunkCount = 1
 	}
-	driverCounts = nil
-
-	// Second pass: populate the index
-	for _, track := range tracks {
-		for _, entry := range track.Data {
-			// Extract driver name
-			driverInterface, driverExists := entry["driver"]
-			if !driverExists {
-				continue
-			}
-
-			driverMap, driverOk := driverInterface.(map[string]interface{})
-			if !driverOk {
-				continue
-			}
+	chunkSize := (len(tracks) + chunkCount - 1) / chunkCount
 
-			nameInterface, nameExists := driverMap["name"]
-			if !nameExists {
-				continue
+	shards := make([]DriverIndex, chunkCount)
+	jobs := make(chan int, chunkCount)
+	for i := 0; i < chunkCount; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < chunkCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interner := newStringInterner() // Shared by the worker's chunks
+			for chunk := range jobs {
+				start := chunk * chunkSize
+				end := start + chunkSize
+				if start > len(tracks) {
+					start = len(tracks)
+				}
+				if end > len(tracks) {
+					end = len(tracks)
+				}
+				shards[chunk] = buildIndexShard(tracks[start:end], interner)
 			}
+		}()
+	}
+	wg.Wait()
 
-			name, nameOk := nameInterface.(string)
-			if !nameOk || name == "" {
-				continue
-			}
+	index := mergeIndexShards(shards)
+	for _, results := range index {
+		sortDriverResults(results)
+	}
 
-			// Get position
-			position := 1
-			if posInterface, posExists := entry["index"]; posExists {
-				if posFloat, ok := posInterface.(float64); ok {
-					position = int(posFloat) + 1
-				}
-			}
+	uniqueTrackCount := len(uniqueTracksMap)
+	uniqueTracksMap = nil // Clean up
 
-			result := DriverResult{
-				Name:         name,
-				Position:     position,
-				TrackID:      track.TrackID,
-				ClassID:      track.ClassID,
-				Track:        track.Name,
-				Found:        true,
-				TotalEntries: len(track.Data),
-			}
+	return index, trackEntryCounts, uniqueTrackCount, totalEntries
+}
 
-			// Extract lap time
-			if lapTime, ok := entry["laptime"].(string); ok {
-				result.LapTime = lapTime
-			}
+// buildIndexShard indexes a contiguous slice of tracks into its own map
+func buildIndexShard(tracks []TrackInfo, interner *stringInterner) DriverIndex {
+	shard := make(DriverIndex)
+	for i := range tracks {
+		buildIndexShardInto(shard, &tracks[i], interner)
+	}
+	return shard
+}
 
-			// Extract time difference
-			if relativeLaptime, ok := entry["relative_laptime"].(string); ok && relativeLaptime != "" {
-				timeStr := strings.TrimPrefix(relativeLaptime, "+")
-				timeStr = strings.TrimSuffix(timeStr, "s")
-				if timeDiff, err := strconv.ParseFloat(timeStr, 64); err == nil {
-					result.TimeDiff = timeDiff
-				}
-			}
+// buildIndexShardInto adds the results of one track to a shard
+// Result strings are interned, so the index doesn't keep the decoded leaderboards' strings alive
+func buildIndexShardInto(shard DriverIndex, track *TrackInfo, interner *stringInterner) {
+	leaderMs := leaderLapTimeMs(track.Data)
+	for j := range track.Data {
+		result, ok := extractDriverResult(track, &track.Data[j], leaderMs)
+		if !ok {
+			continue
+		}
+		interner.internResult(&result)
+		// Add to shard under the normalized key (case- and accent-insensitive)
+		key := interner.key(result.Name)
+		shard[key] = append(shard[key], result)
+	}
+}
 
-			// Extract country
-			if countryInterface, countryExists := entry["country"]; countryExists {
-				if countryMap, countryOk := countryInterface.(map[string]interface{}); countryOk {
-					if countryName, nameOk := countryMap["name"].(string); nameOk {
-						result.Country = countryName
-					}
-				}
-			}
+// mergeIndexShards merges per-chunk indexes in order into a single index
+// Drivers found in a single shard reuse that shard's slice; others get an exact-size slice
+func mergeIndexShards(shards []DriverIndex) DriverIndex {
+	// Count results per driver across shards to pre-allocate merged slices
+	sizes := make(map[string]int)
+	for _, shard := range shards {
+		for key, results := range shard {
+			sizes[key] += len(results)
+		}
+	}
 
-			// Extract car information
-			if carClassInterface, carClassExists := entry["car_class"]; carClassExists {
-				if carClassMap, carClassOk := carClassInterface.(map[string]interface{}); carClassOk {
-					if carInterface, carExists := carClassMap["car"]; carExists {
-						if carMap, carOk := carInterface.(map[string]interface{}); carOk {
-							if carName, carNameOk := carMap["name"].(string); carNameOk {
-								result.Car = carName
-							}
-							if className, classNameOk := carMap["class-name"].(string); classNameOk {
-								result.CarClass = className
-							}
-						}
-					}
-				}
+	index := make(DriverIndex, len(sizes))
+	for i, shard := range shards {
+		for key, results := range shard {
+			if existing, exists := index[key]; exists {
+				index[key] = append(existing, results...)
+			} else if sizes[key] == len(results) {
+				index[key] = results
+			} else {
+				merged := make([]DriverResult, len(results), sizes[key])
+				copy(merged, results)
+				index[key] = merged
 			}
+		}
+		// Release the shard map as soon as it has been merged
+		shards[i] = nil
+	}
 
-			// Extract team
-			if teamStr, teamOk := entry["team"].(string); teamOk && teamStr != "" {
-				result.Team = teamStr
-			}
+	return index
+}
 
-			// Extract rank
-			if rankStr, rankOk := entry["rank"].(string); rankOk && rankStr != "" {
-				result.Rank = rankStr
-			}
+// sortDriverResults orders a driver's results numerically: smallest gap to the leader first, results
+// with an unparseable lap time last, then by position and track
+func sortDriverResults(results []DriverResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := &results[i], &results[j]
+		if (a.LapTimeMs == 0) != (b.LapTimeMs == 0) {
+			return b.LapTimeMs == 0
+		}
+		if a.TimeDiff != b.TimeDiff {
+			return a.TimeDiff < b.TimeDiff
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Track < b.Track
+	})
+}
 
-			// Extract difficulty
-			if drivingModel, dmOk := entry["driving_model"].(string); dmOk && drivingModel != "" {
-				result.Difficulty = drivingModel
-			}
+// extractDriverResult converts a leaderboard entry into a DriverResult
+// leaderMs is the leaderboard's fastest lap (see leaderLapTimeMs), from which the gap is computed.
+// Returns false when the entry has no usable driver name or belongs to an excluded driver
+func extractDriverResult(track *TrackInfo, entry *LeaderboardEntry, leaderMs int64) (DriverResult, bool) {
+	if entry.Driver.Name == "" || isDriverExcluded(entry.Driver.Name, string(entry.Driver.ID)) {
+		return DriverResult{}, false
+	}
 
-			// Extract date_time
-			if dateTime, dtOk := entry["date_time"].(string); dtOk && dateTime != "" {
-				result.DateTime = dateTime
-			}
+	result := DriverResult{
+		Name:         entry.Driver.Name,
+		DriverID:     string(entry.Driver.ID),
+		Position:     entry.Index + 1,
+		LapTime:      entry.LapTime,
+		LapTimeMs:    entry.lapTimeMs(),
+		Country:      entry.Country.Name,
+		CountryCode:  strings.ToUpper(entry.Country.Code),
+		Car:          entry.CarClass.Car.Name,
+		CarClass:     entry.CarClass.Car.ClassName,
+		Team:         entry.Team,
+		Rank:         entry.Rank,
+		Difficulty:   entry.DrivingModel,
+		DateTime:     entry.DateTime,
+		TrackID:      track.TrackID,
+		ClassID:      track.ClassID,
+		Track:        track.Name,
+		Found:        true,
+		TotalEntries: len(track.Data),
+	}
 
-			// Add to index (case-insensitive)
-			lowerName := strings.ToLower(name)
-			index[lowerName] = append(index[lowerName], result)
+	// Gap to the leader from the parsed lap times; RaceRoom's relative_laptime only when they can't be parsed
+	if result.LapTimeMs > 0 && leaderMs > 0 {
+		result.TimeDiff = float64(result.LapTimeMs-leaderMs) / 1000
+	} else if entry.RelativeLaptime != "" {
+		timeStr := strings.TrimPrefix(entry.RelativeLaptime, "+")
+		timeStr = strings.TrimSuffix(timeStr, "s")
+		if timeDiff, err := strconv.ParseFloat(timeStr, 64); err == nil {
+			result.TimeDiff = timeDiff
 		}
 	}
 
-	uniqueTrackCount := len(uniqueTracksMap)
-	uniqueTracksMap = nil // Clean up
-
-	return index, trackEntryCounts, uniqueTrackCount, totalEntries
+	return result, true
 }
 
 // BuildAndExportIndex builds the driver index and exports all related files
 // This is the main entry point that coordinates index building, exporting, and status updates
+// It is a no-op when the data fingerprint matches the last successful export.
+// With export.streaming_build the tracks only name the combinations, which are read back from the cache (see streamDriverIndex)
 func BuildAndExportIndex(tracks []TrackInfo) error {
 	if len(tracks) == 0 {
-		log.Println("⚠️ No tracks to index - skipping export")
+		indexerLog.Warnf("⚠️ No tracks to index - skipping export")
 		return nil
 	}
 
+	// Serialize builds so the fingerprint check and the export stay consistent
+	indexBuildMu.Lock()
+	defer indexBuildMu.Unlock()
+
+	if exportConfig.StreamingBuild {
+		_, err := buildAndExportStreamed(tracks)
+		return err
+	}
+
+	version := dataVersion(tracks)
+	exclusions := exclusionsRevision()
+	if version == lastIndexedVersion && exclusions == lastIndexedExclusions {
+		indexerLog.Infof("⏭️ Index unchanged since last export (data version %s) - skipping rebuild", version)
+		eventBroker.Publish(EventIndexFinished, IndexFinishedEvent{DataVersion: version, Skipped: true})
+		return nil
+	}
+
+	indexBuilding.Store(true)
+	defer indexBuilding.Store(false)
+
+	ctx, span := StartSpan(context.Background(), "index.build_and_export", "combinations", len(tracks), "data_version", version)
+	defer span.End()
+
 	indexStart := time.Now()
+	eventBroker.Publish(EventIndexStarted, map[string]int{"combinations": len(tracks)})
 
 	// Build the driver index
+	_, buildSpan := StartSpan(ctx, "index.build")
 	index, trackEntryCounts, uniqueTrackCount, totalEntries := buildDriverIndex(tracks)
+	buildSpan.SetAttrs("drivers", len(index), "entries", totalEntries)
+	buildSpan.End()
+
+	err := exportIndexBuild(ctx, &IndexBuild{
+		Tracks:           tracks,
+		Index:            index,
+		TrackEntryCounts: trackEntryCounts,
+		UniqueTracks:     uniqueTrackCount,
+		TotalEntries:     totalEntries,
+		BuildDuration:    time.Since(indexStart),
+		DataVersion:      version,
+	})
+	span.SetError(err)
+	if err == nil {
+		lastIndexedVersion = version
+		lastIndexedExclusions = exclusions
+	}
+	return err
+}
 
-	buildDuration := time.Since(indexStart)
-	log.Printf("🔍 Index built: %.3f seconds (%d drivers, %d entries, %d tracks)",
-		buildDuration.Seconds(), len(index), totalEntries, uniqueTrackCount)
+// exportIndexBuild publishes a built index to the search engine, runs the exporters and reports the build
+// Callers hold indexBuildMu
+func exportIndexBuild(ctx context.Context, build *IndexBuild) error {
+	indexerLog.Infof("🔍 Index built: %.3f seconds (%d drivers, %d entries, %d tracks)",
+		build.BuildDuration.Seconds(), len(build.Index), build.TotalEntries, build.UniqueTracks)
 
-	// Export the driver index
-	if err := ExportDriverIndex(index, buildDuration); err != nil {
-		index = nil
-		runtime.GC()
-		return err
-	}
+	// Publish the new index to the API before exporting it to disk
+	searchEngine.SetIndex(build.Index, build.TrackEntryCounts)
+
+	// Write the driver index, status and top combinations through the exporter pipeline
+	exportErr := runIndexExporters(ctx, build)
+	driverCount := len(build.Index)
 
-	// Update status with index statistics
-	if err := UpdateStatusWithIndexMetrics(tracks, index, uniqueTrackCount, totalEntries, buildDuration); err != nil {
-		log.Printf("⚠️ Failed to update status with index stats: %v", err)
+	// Drop our references after export (the search engine keeps the live copy)
+	build.Index = nil
+	if exportErr != nil {
+		runtime.GC()
+		publishError("index", exportErr)
+		return exportErr
 	}
 
-	// Clean up index variable after export
-	index = nil
+	eventBroker.Publish(EventIndexFinished, IndexFinishedEvent{
+		Drivers:     driverCount,
+		Entries:     build.TotalEntries,
+		DurationMs:  build.BuildDuration.Milliseconds(),
+		DataVersion: build.DataVersion,
+	})
 
 	// Read memory stats before GC for comparison
 	var mBefore runtime.MemStats
@@ -321,10 +429,46 @@ func BuildAndExportIndex(tracks []TrackInfo) error {
 	// Read memory stats after GC
 	var mAfter runtime.MemStats
 	runtime.ReadMemStats(&mAfter)
-	log.Printf("💾 Memory after index: %.1f MB allocated, %.1f MB freed by GC",
+	indexerLog.Infof("💾 Memory after index: %.1f MB allocated, %.1f MB freed by GC",
 		float64(mAfter.Alloc)/(1024*1024),
 		float64(mBefore.Alloc-mAfter.Alloc)/(1024*1024))
+	return nil
+}
+
+// dataVersion returns an order-independent fingerprint of the indexed data
+// Each combination hashes its IDs, its track name and every entry field that ends up in a DriverResult;
+// the per-combination hashes are summed so slice order doesn't matter
+func dataVersion(tracks []TrackInfo) string {
+	var sum uint64
+	for i := range tracks {
+		sum += combinationVersion(&tracks[i])
+	}
+	return formatDataVersion(sum, len(tracks))
+}
+
+// combinationVersion hashes one combination for dataVersion
+// Any field read by extractDriverResult must be hashed here, or a change to it alone leaves the index stale
+func combinationVersion(track *TrackInfo) uint64 {
+	hasher := fnv.New64a()
+	var scratch []byte
+	for _, field := range []string{track.TrackID, track.ClassID, track.Name} {
+		scratch = append(append(scratch, field...), 0)
+	}
+	hasher.Write(scratch)
+	for j := range track.Data {
+		entry := &track.Data[j]
+		scratch = strconv.AppendInt(scratch[:0], int64(entry.Index), 10)
+		for _, field := range []string{entry.Driver.Name, string(entry.Driver.ID), entry.LapTime, entry.RelativeLaptime,
+			entry.Country.Name, entry.Country.Code, entry.CarClass.Car.Name, entry.CarClass.Car.ClassName,
+			entry.Team, entry.Rank, entry.DrivingModel, entry.DateTime} {
+			scratch = append(append(scratch, 0), field...)
+		}
+		hasher.Write(append(scratch, '\n'))
+	}
+	return hasher.Sum64()
+}
 
-	// Export top combinations
-	return ExportTopCombinations(tracks, trackEntryCounts)
+// formatDataVersion formats the summed combination hashes of a data version
+func formatDataVersion(sum uint64, combinations int) string {
+	return fmt.Sprintf("%016x-%d", sum, combinations)
 }
@@ -0,0 +1,58 @@
+package internal
+
+import "testing"
+
+// indexerTestTrack is a one-entry combination whose entry fields can be changed one at a time
+func indexerTestTrack(team string) []TrackInfo {
+	return []TrackInfo{{
+		Name:    "Hockenheimring - Grand Prix",
+		TrackID: "1693",
+		ClassID: "1703",
+		Data: []LeaderboardEntry{{
+			Driver:   EntryDriver{Name: "Index Test Driver", ID: "4200"},
+			LapTime:  "1m 37.512s",
+			Country:  EntryCountry{Name: "Germany", Code: "DE"},
+			CarClass: EntryCarClass{Car: EntryCar{Name: "Audi R8 LMS", ClassName: "GTR 3"}},
+			Team:     team,
+			Rank:     "A",
+		}},
+	}}
+}
+
+func TestBuildAndExportIndexRebuildsOnTeamChange(t *testing.T) {
+	chdirTemp(t)
+
+	for _, team := range []string{"Old Team", "New Team"} {
+		if err := BuildAndExportIndex(indexerTestTrack(team)); err != nil {
+			t.Fatal(err)
+		}
+		results := GetSearchEngine().Lookup("Index Test Driver")
+		if len(results) != 1 || results[0].Team != team {
+			t.Fatalf("after indexing team %q: Lookup = %+v", team, results)
+		}
+	}
+}
+
+func TestDataVersionCoversDriverResultFields(t *testing.T) {
+	base := dataVersion(indexerTestTrack("Team"))
+	changes := map[string]func(track *TrackInfo, entry *LeaderboardEntry){
+		"track name":       func(track *TrackInfo, entry *LeaderboardEntry) { track.Name = "Hockenheimring - National" },
+		"driver ID":        func(track *TrackInfo, entry *LeaderboardEntry) { entry.Driver.ID = "4201" },
+		"relative laptime": func(track *TrackInfo, entry *LeaderboardEntry) { entry.RelativeLaptime = "+0.100s" },
+		"country":          func(track *TrackInfo, entry *LeaderboardEntry) { entry.Country.Name = "Austria" },
+		"country code":     func(track *TrackInfo, entry *LeaderboardEntry) { entry.Country.Code = "AT" },
+		"car":              func(track *TrackInfo, entry *LeaderboardEntry) { entry.CarClass.Car.Name = "BMW M6 GT3" },
+		"class name":       func(track *TrackInfo, entry *LeaderboardEntry) { entry.CarClass.Car.ClassName = "GTR 3 (2024)" },
+		"team":             func(track *TrackInfo, entry *LeaderboardEntry) { entry.Team = "Other Team" },
+		"rank":             func(track *TrackInfo, entry *LeaderboardEntry) { entry.Rank = "B" },
+		"difficulty":       func(track *TrackInfo, entry *LeaderboardEntry) { entry.DrivingModel = "Amateur" },
+		"date":             func(track *TrackInfo, entry *LeaderboardEntry) { entry.DateTime = "2026-09-01T18:22:10Z" },
+	}
+	for field, change := range changes {
+		tracks := indexerTestTrack("Team")
+		change(&tracks[0], &tracks[0].Data[0])
+		if dataVersion(tracks) == base {
+			t.Errorf("changing the %s leaves the data version unchanged", field)
+		}
+	}
+}
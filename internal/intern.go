@@ -0,0 +1,56 @@
+package internal
+
+// stringInterner deduplicates the strings of index results, so millions of results share one copy of each
+// car, class, country, team and driver name instead of each holding its own decoded string
+// Not safe for concurrent use: every index worker has its own
+type stringInterner struct {
+	strings map[string]string
+	keys    map[string]string // Driver name -> normalized index key
+}
+
+// newStringInterner creates an empty interner
+func newStringInterner() *stringInterner {
+	return &stringInterner{
+		strings: make(map[string]string),
+		keys:    make(map[string]string),
+	}
+}
+
+// intern returns the shared copy of s
+func (in *stringInterner) intern(s string) string {
+	if s == "" {
+		return s
+	}
+	if shared, ok := in.strings[s]; ok {
+		return shared
+	}
+	in.strings[s] = s
+	return s
+}
+
+// key returns the normalized index key of a driver name, normalizing each distinct name once
+func (in *stringInterner) key(name string) string {
+	if key, ok := in.keys[name]; ok {
+		return key
+	}
+	key := in.intern(NormalizeDriverName(name))
+	in.keys[name] = key
+	return key
+}
+
+// internResult points the repeated strings of a result at their shared copies
+// LapTime and DateTime are left alone: they rarely repeat
+func (in *stringInterner) internResult(result *DriverResult) {
+	result.Name = in.intern(result.Name)
+	result.DriverID = in.intern(result.DriverID)
+	result.Country = in.intern(result.Country)
+	result.CountryCode = in.intern(result.CountryCode)
+	result.Car = in.intern(result.Car)
+	result.CarClass = in.intern(result.CarClass)
+	result.Team = in.intern(result.Team)
+	result.Rank = in.intern(result.Rank)
+	result.Difficulty = in.intern(result.Difficulty)
+	result.Track = in.intern(result.Track)
+	result.TrackID = in.intern(result.TrackID)
+	result.ClassID = in.intern(result.ClassID)
+}
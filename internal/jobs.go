@@ -0,0 +1,360 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job kinds
+const (
+	JobKindFetch   = "fetch"   // One-off fetch of a single combination into the main cache
+	JobKindRefresh = "refresh" // Full or targeted refresh run by the orchestrator
+)
+
+// Job states reported by the jobs endpoint
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+const (
+	jobQueueSize     = 64
+	jobRetention     = time.Hour // Finished jobs stay queryable this long
+	jobFetchInterval = 100 * time.Millisecond
+)
+
+// ErrJobQueueFull is returned when no more jobs can be queued
+var ErrJobQueueFull = fmt.Errorf("job queue full")
+
+// ErrJobFinished is returned when cancelling a job that already finished
+var ErrJobFinished = fmt.Errorf("job already finished")
+
+// Job is a background operation tracked by ID
+type Job struct {
+	ID         string         `json:"id"`
+	Kind       string         `json:"kind"`
+	TrackID    string         `json:"track_id,omitempty"`   // Fetch jobs
+	ClassID    string         `json:"class_id,omitempty"`   // Fetch jobs
+	Track      string         `json:"track,omitempty"`      // Fetch jobs
+	ClassName  string         `json:"class_name,omitempty"` // Fetch jobs
+	Targets    []string       `json:"targets,omitempty"`    // Refresh jobs: "trackID" or "trackID-classID"; empty means full
+	Status     string         `json:"status"`
+	Progress   *FetchProgress `json:"progress,omitempty"`
+	Entries    int            `json:"entries,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+
+	cancel context.CancelFunc // Set while running
+}
+
+// JobRunner performs a job, calling report with progress as it goes
+// Returns the number of entries fetched (if meaningful) or an error
+type JobRunner func(ctx context.Context, job Job, report func(FetchProgress)) (int, error)
+
+// jobLane runs the jobs of one kind sequentially
+type jobLane struct {
+	runner  JobRunner
+	pending chan string
+}
+
+// JobQueue tracks background jobs by ID and runs each kind on its own sequential worker,
+// so a long refresh never blocks a quick on-demand fetch
+type JobQueue struct {
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	lanes map[string]*jobLane
+}
+
+// NewJobQueue creates an empty job queue; register runners with Handle, then call Start
+func NewJobQueue() *JobQueue {
+	return &JobQueue{
+		jobs:  make(map[string]*Job),
+		lanes: make(map[string]*jobLane),
+	}
+}
+
+// Handle registers the runner for a job kind; must be called before Start
+func (q *JobQueue) Handle(kind string, runner JobRunner) {
+	q.lanes[kind] = &jobLane{
+		runner:  runner,
+		pending: make(chan string, jobQueueSize),
+	}
+}
+
+// Start runs one worker per registered kind until ctx is cancelled
+func (q *JobQueue) Start(ctx context.Context) {
+	for _, lane := range q.lanes {
+		go func(lane *jobLane) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-lane.pending:
+					q.run(ctx, lane.runner, id)
+				}
+			}
+		}(lane)
+	}
+}
+
+// EnqueueFetch queues a fetch of one combination into the main cache
+// A queued or running fetch of the same combination is returned instead of a new job
+func (q *JobQueue) EnqueueFetch(track TrackConfig, class CarClassConfig) (Job, error) {
+	return q.enqueue(Job{
+		Kind:      JobKindFetch,
+		TrackID:   track.TrackID,
+		ClassID:   class.ClassID,
+		Track:     track.Name,
+		ClassName: class.Name,
+	})
+}
+
+// EnqueueRefresh queues a refresh of the given targets (empty for a full refresh)
+// A queued or running refresh of the same targets is returned instead of a new job
+func (q *JobQueue) EnqueueRefresh(targets []string) (Job, error) {
+	targets = append([]string(nil), targets...)
+	sort.Strings(targets)
+	return q.enqueue(Job{
+		Kind:    JobKindRefresh,
+		Targets: targets,
+	})
+}
+
+// enqueue registers a new job unless an identical one is still pending
+func (q *JobQueue) enqueue(job Job) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lane, ok := q.lanes[job.Kind]
+	if !ok {
+		return Job{}, fmt.Errorf("no runner for %s jobs", job.Kind)
+	}
+
+	q.pruneLocked()
+	key := job.coalesceKey()
+	for _, existing := range q.jobs {
+		if existing.coalesceKey() == key && (existing.Status == JobQueued || existing.Status == JobRunning) {
+			return *existing, nil
+		}
+	}
+
+	job.ID = newRandomID()
+	job.Status = JobQueued
+	job.CreatedAt = time.Now()
+
+	select {
+	case lane.pending <- job.ID:
+	default:
+		return Job{}, ErrJobQueueFull
+	}
+	q.jobs[job.ID] = &job
+	jobsLog.Infof("📥 Queued %s job %s%s", job.Kind, job.ID, job.describe())
+	return job, nil
+}
+
+// Get returns a snapshot of the job with the given ID
+func (q *JobQueue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	snapshot := *job
+	if job.Progress != nil {
+		progress := *job.Progress
+		snapshot.Progress = &progress
+	}
+	return snapshot, true
+}
+
+// Cancel stops a job: a queued job is dropped, a running job has its context cancelled
+// Other jobs and the server keep running
+func (q *JobQueue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("job not found")
+	}
+
+	switch job.Status {
+	case JobQueued:
+		// The worker skips jobs that are no longer queued
+		finished := time.Now()
+		job.Status = JobCancelled
+		job.FinishedAt = &finished
+		jobsLog.Infof("🛑 Cancelled queued %s job %s%s", job.Kind, id, job.describe())
+	case JobRunning:
+		job.cancel()
+		jobsLog.Infof("🛑 Cancelling running %s job %s%s", job.Kind, id, job.describe())
+	default:
+		return *job, ErrJobFinished
+	}
+	return *job, nil
+}
+
+// run executes one job with its runner and records the outcome
+func (q *JobQueue) run(ctx context.Context, runner JobRunner, id string) {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok || job.Status != JobQueued {
+		q.mu.Unlock()
+		return
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	started := time.Now()
+	job.Status = JobRunning
+	job.StartedAt = &started
+	job.cancel = cancel
+	snapshot := *job
+	q.mu.Unlock()
+
+	report := func(progress FetchProgress) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		job.Progress = &progress
+	}
+
+	entries, err := runner(jobCtx, snapshot, report)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.cancel = nil
+	if jobCtx.Err() != nil && ctx.Err() == nil {
+		job.Status = JobCancelled
+		jobsLog.Infof("🛑 %s job %s cancelled%s", job.Kind, id, job.describe())
+		return
+	}
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+		jobsLog.Warnf("⚠️ %s job %s failed%s: %v", job.Kind, id, job.describe(), err)
+		return
+	}
+	job.Status = JobCompleted
+	job.Entries = entries
+	jobsLog.Infof("✅ %s job %s complete%s in %.1fs", job.Kind, id, job.describe(), finished.Sub(started).Seconds())
+}
+
+// pruneLocked drops finished jobs older than jobRetention; q.mu must be held
+func (q *JobQueue) pruneLocked() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range q.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// coalesceKey identifies jobs that would do the same work
+func (j *Job) coalesceKey() string {
+	return j.Kind + ":" + j.TrackID + "_" + j.ClassID + ":" + strings.Join(j.Targets, ",")
+}
+
+// describe returns a short log suffix naming the job's target
+func (j *Job) describe() string {
+	switch {
+	case j.Kind == JobKindFetch:
+		return fmt.Sprintf(" (%s + %s)", j.Track, j.ClassName)
+	case len(j.Targets) > 10:
+		return fmt.Sprintf(" (%s ... %d targets)", strings.Join(j.Targets[:5], " "), len(j.Targets))
+	case len(j.Targets) > 0:
+		return fmt.Sprintf(" (%s)", strings.Join(j.Targets, " "))
+	default:
+		return " (full)"
+	}
+}
+
+// RunFetchJob is the JobRunner for JobKindFetch: it downloads one combination into the main cache
+func RunFetchJob(ctx context.Context, job Job, report func(FetchProgress)) (int, error) {
+	apiClient := NewAPIClient()
+	defer apiClient.Close()
+
+	track := TrackConfig{Name: job.Track, TrackID: job.TrackID}
+	class := CarClassConfig{Name: job.ClassName, ClassID: job.ClassID}
+	data, _, err := fetchWithTimeout(ctx, apiClient, track, class)
+	report(FetchProgress{Processed: 1, Total: 1, Failed: boolToInt(err != nil)})
+	if err != nil {
+		return 0, err
+	}
+
+	trackInfo := TrackInfo{
+		Name:    track.Name,
+		TrackID: track.TrackID,
+		ClassID: class.ClassID,
+		Data:    data,
+	}
+	// Empty leaderboards are cached too so the next request gets an empty page, not another fetch
+	if _, err := NewDataCache().SaveIfChanged(trackInfo); err != nil {
+		return 0, fmt.Errorf("save cache: %w", err)
+	}
+
+	// Keep back-to-back on-demand fetches from hammering the API; the fetch is saved, so a cancel only cuts the pause short
+	select {
+	case <-ctx.Done():
+	case <-time.After(jobFetchInterval):
+	}
+	return len(data), nil
+}
+
+// AllTracksToken stands for every track in a "*-classID" refresh token
+const AllTracksToken = "*"
+
+// ParseRefreshTargets validates refresh tokens ("trackID", "trackID-classID" or "*-classID") against the configuration
+func ParseRefreshTargets(tokens []string) ([]string, error) {
+	targets := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		trackID, classID, hasClass := strings.Cut(token, "-")
+		if trackID == AllTracksToken && !hasClass {
+			return nil, fmt.Errorf("%q needs a class: %s-classID", token, AllTracksToken)
+		}
+		if _, ok := FindTrack(trackID); !ok && trackID != AllTracksToken {
+			return nil, fmt.Errorf("unknown track %q", trackID)
+		}
+		if hasClass {
+			if _, ok := FindCarClass(classID); !ok {
+				return nil, fmt.Errorf("unknown class %q", classID)
+			}
+		}
+		targets = append(targets, token)
+	}
+	return targets, nil
+}
+
+// boolToInt returns 1 for true and 0 for false
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// newRandomID returns a random 16-character hex ID (job and request IDs)
+func newRandomID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
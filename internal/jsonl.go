@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EntryRecord is one line of the JSON Lines export: an indexed entry with its class name
+type EntryRecord struct {
+	DriverResult
+	ClassName string `json:"class_name"`
+}
+
+// Formats of the full entry export
+const (
+	EntryFormatJSONL = "jsonl"
+	EntryFormatCSV   = "csv"
+)
+
+// eachEntry calls fn for every indexed entry, ordered by driver key, and returns how many it visited
+// The index is only locked while its driver lists are collected, not while fn runs
+func (se *SearchEngine) eachEntry(fn func(result *DriverResult) error) (int, error) {
+	type driverResults struct {
+		key     string
+		results []DriverResult
+	}
+	drivers := make([]driverResults, 0, se.DriverCount())
+	se.Scan(func(key string, results []DriverResult) {
+		drivers = append(drivers, driverResults{key, results})
+	})
+	sort.Slice(drivers, func(i, j int) bool { return drivers[i].key < drivers[j].key })
+
+	visited := 0
+	for _, driver := range drivers {
+		for i := range driver.results {
+			if err := fn(&driver.results[i]); err != nil {
+				return visited, err
+			}
+			visited++
+		}
+	}
+	return visited, nil
+}
+
+// WriteJSONL writes every indexed entry as one JSON object per line, ordered by driver key
+func (se *SearchEngine) WriteJSONL(w io.Writer) (int, error) {
+	buffered := bufio.NewWriterSize(w, 64<<10)
+	encoder := json.NewEncoder(buffered)
+	written, err := se.eachEntry(func(result *DriverResult) error {
+		return encoder.Encode(EntryRecord{DriverResult: *result, ClassName: GetCarClassName(result.ClassID)})
+	})
+	if err != nil {
+		return written, err
+	}
+	return written, buffered.Flush()
+}
+
+// WriteCSV writes every indexed entry as a CSV row, ordered by driver key
+func (se *SearchEngine) WriteCSV(w io.Writer) (int, error) {
+	writer := NewResultsCSVWriter(w) // encoding/csv buffers itself
+	written, err := se.eachEntry(writer.Write)
+	if err != nil {
+		return written, err
+	}
+	return written, writer.Flush()
+}
+
+// WriteEntries writes every indexed entry in the given format (EntryFormatJSONL or EntryFormatCSV)
+func (se *SearchEngine) WriteEntries(w io.Writer, format string) (int, error) {
+	switch format {
+	case EntryFormatJSONL:
+		return se.WriteJSONL(w)
+	case EntryFormatCSV:
+		return se.WriteCSV(w)
+	}
+	return 0, fmt.Errorf("unknown export format %q (expected %s or %s)", format, EntryFormatJSONL, EntryFormatCSV)
+}
+
+// ExportJSONL writes the JSON Lines export to path, gzip-compressed when path ends in .gz
+func (se *SearchEngine) ExportJSONL(path string) (int, error) {
+	return se.ExportEntries(path, EntryFormatJSONL)
+}
+
+// ExportEntries writes every indexed entry to path in format, gzip-compressed when path ends in .gz
+func (se *SearchEngine) ExportEntries(path, format string) (int, error) {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return 0, err
+	}
+	var out io.Writer = file
+	var gzWriter *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gzWriter = gzip.NewWriter(file)
+		out = gzWriter
+	}
+
+	written, err := se.WriteEntries(out, format)
+	if err == nil && gzWriter != nil {
+		err = gzWriter.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return written, err
+	}
+	return written, os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Leaderboard sort keys accepted by SortLeaderboard
+const (
+	SortByPosition = "position"
+	SortByLapTime  = "laptime"
+	SortByCountry  = "country"
+	SortByName     = "name"
+)
+
+// ErrCombinationNotCached is returned when a track/class combination has no cache file
+var ErrCombinationNotCached = fmt.Errorf("combination not cached")
+
+// ErrUnknownSortKey is returned by SortLeaderboard for a key that isn't one of the Sort* constants
+var ErrUnknownSortKey = errors.New("unknown sort key")
+
+// LoadLeaderboard loads a combination from the main cache and converts it to DriverResults
+// Results are in leaderboard order (position ascending)
+func LoadLeaderboard(trackID, classID string) (TrackInfo, []DriverResult, error) {
+	dataCache := NewDataCache()
+	trackInfo, err := dataCache.LoadTrackData(trackID, classID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return TrackInfo{}, nil, ErrCombinationNotCached
+		}
+		return TrackInfo{}, nil, err
+	}
+
+	results := make([]DriverResult, 0, len(trackInfo.Data))
+	leaderMs := leaderLapTimeMs(trackInfo.Data)
+	for i := range trackInfo.Data {
+		if result, ok := extractDriverResult(&trackInfo, &trackInfo.Data[i], leaderMs); ok {
+			results = append(results, result)
+		}
+	}
+
+	// Entries are no longer needed once converted
+	trackInfo.Data = nil
+	return trackInfo, results, nil
+}
+
+// SortLeaderboard sorts results in place by the given key; ties fall back to position
+// Lap times are compared numerically (laptime_ms), so a missing relative_laptime can't break the order.
+// Returns an error for unknown sort keys
+func SortLeaderboard(results []DriverResult, sortKey string, descending bool) error {
+	var less func(a, b DriverResult) bool
+	switch sortKey {
+	case "", SortByPosition:
+		less = func(a, b DriverResult) bool { return a.Position < b.Position }
+	case SortByLapTime:
+		less = func(a, b DriverResult) bool {
+			if a.LapTimeMs != b.LapTimeMs {
+				return a.LapTimeMs < b.LapTimeMs
+			}
+			return a.Position < b.Position
+		}
+	case SortByCountry:
+		less = func(a, b DriverResult) bool {
+			if !strings.EqualFold(a.Country, b.Country) {
+				return strings.ToLower(a.Country) < strings.ToLower(b.Country)
+			}
+			return a.Position < b.Position
+		}
+	case SortByName:
+		less = func(a, b DriverResult) bool {
+			an, bn := NormalizeDriverName(a.Name), NormalizeDriverName(b.Name)
+			if an != bn {
+				return an < bn
+			}
+			return a.Position < b.Position
+		}
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownSortKey, sortKey)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		// Unparseable lap times go last in either order
+		if sortKey == SortByLapTime && (results[i].LapTimeMs == 0) != (results[j].LapTimeMs == 0) {
+			return results[j].LapTimeMs == 0
+		}
+		if descending {
+			return less(results[j], results[i])
+		}
+		return less(results[i], results[j])
+	})
+	return nil
+}
+
+// leaderboardGap holds the gaps of the entry at one position
+type leaderboardGap struct {
+	toLeader   *int64
+	toPrevious *int64
+}
+
+// leaderboardGaps computes the gaps of every entry by position; results must be in position order (as
+// returned by LoadLeaderboard), so call it before sorting
+func leaderboardGaps(results []DriverResult) map[int]leaderboardGap {
+	var leaderMs int64
+	for _, result := range results {
+		if result.LapTimeMs > 0 && (leaderMs == 0 || result.LapTimeMs < leaderMs) {
+			leaderMs = result.LapTimeMs
+		}
+	}
+
+	gaps := make(map[int]leaderboardGap, len(results))
+	for i, result := range results {
+		if result.LapTimeMs == 0 {
+			continue
+		}
+		var gap leaderboardGap
+		toLeader := result.LapTimeMs - leaderMs
+		gap.toLeader = &toLeader
+		if i > 0 && results[i-1].LapTimeMs > 0 {
+			toPrevious := result.LapTimeMs - results[i-1].LapTimeMs
+			gap.toPrevious = &toPrevious
+		}
+		gaps[result.Position] = gap
+	}
+	return gaps
+}
+
+// withGaps attaches the gaps computed by leaderboardGaps to a page of results
+func withGaps(page []DriverResult, gaps map[int]leaderboardGap) []LeaderboardResult {
+	results := make([]LeaderboardResult, len(page))
+	for i, result := range page {
+		gap := gaps[result.Position]
+		results[i] = LeaderboardResult{DriverResult: result, GapMs: gap.toLeader, GapToPreviousMs: gap.toPrevious}
+	}
+	return results
+}
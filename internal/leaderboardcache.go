@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// leaderboardCacheSize is the number of combinations whose converted leaderboard is kept in memory
+const leaderboardCacheSize = 64
+
+// LeaderboardView is a converted leaderboard in one sort order, shared by every request reading it
+// Results must not be modified
+type LeaderboardView struct {
+	Track   string
+	Results []DriverResult
+	gaps    map[int]leaderboardGap // Position -> gaps, computed in leaderboard order
+}
+
+// cachedLeaderboard is one combination of leaderboardViews, valid while its cache file is unchanged
+type cachedLeaderboard struct {
+	key        string
+	modTime    time.Time
+	size       int64
+	exclusions uint64                      // Exclusion list revision the results were filtered with
+	views      map[string]*LeaderboardView // sortKey + "_" + order -> view; the position order is always present
+}
+
+// leaderboardViews is the LRU cache behind LoadLeaderboardView
+var leaderboardViews = struct {
+	sync.Mutex
+	order   *list.List // Front is the most recently used *cachedLeaderboard
+	entries map[string]*list.Element
+}{order: list.New(), entries: make(map[string]*list.Element)}
+
+// LoadLeaderboardView returns a combination's leaderboard sorted like SortLeaderboard, from memory when
+// the cache file is unchanged since it was last converted. The least recently used combination is evicted
+// beyond leaderboardCacheSize. Returns ErrCombinationNotCached without a cache file and ErrUnknownSortKey for a bad sortKey
+func LoadLeaderboardView(trackID, classID, sortKey string, descending bool) (*LeaderboardView, error) {
+	info, err := os.Stat(NewDataCache().GetCacheFileName(trackID, classID))
+	if os.IsNotExist(err) {
+		return nil, ErrCombinationNotCached
+	} else if err != nil {
+		return nil, err
+	}
+	if sortKey == "" {
+		sortKey = SortByPosition
+	}
+	if err := SortLeaderboard(nil, sortKey, descending); err != nil {
+		return nil, err // Unknown sort key, rejected before any conversion
+	}
+	viewKey := sortKey + "_asc"
+	if descending {
+		viewKey = sortKey + "_desc"
+	}
+
+	key := trackID + "_" + classID
+	exclusions := exclusionsRevision()
+	leaderboardViews.Lock()
+	if element, ok := leaderboardViews.entries[key]; ok {
+		cached := element.Value.(*cachedLeaderboard)
+		if cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() && cached.exclusions == exclusions {
+			leaderboardViews.order.MoveToFront(element)
+			view := cached.views[viewKey]
+			leaderboardViews.Unlock()
+			if view != nil {
+				return view, nil
+			}
+			return sortedLeaderboardView(key, cached.views[SortByPosition+"_asc"], viewKey, sortKey, descending)
+		}
+	}
+	leaderboardViews.Unlock()
+
+	// Converted outside the lock: other combinations stay available while a large one loads
+	trackInfo, results, err := LoadLeaderboard(trackID, classID)
+	if err != nil {
+		return nil, err
+	}
+	base := &LeaderboardView{Track: trackInfo.Name, Results: results, gaps: leaderboardGaps(results)}
+
+	leaderboardViews.Lock()
+	if element, ok := leaderboardViews.entries[key]; ok {
+		leaderboardViews.order.Remove(element)
+	}
+	leaderboardViews.entries[key] = leaderboardViews.order.PushFront(&cachedLeaderboard{
+		key:        key,
+		modTime:    info.ModTime(),
+		size:       info.Size(),
+		exclusions: exclusions,
+		views:      map[string]*LeaderboardView{SortByPosition + "_asc": base},
+	})
+	for leaderboardViews.order.Len() > leaderboardCacheSize {
+		oldest := leaderboardViews.order.Back()
+		leaderboardViews.order.Remove(oldest)
+		delete(leaderboardViews.entries, oldest.Value.(*cachedLeaderboard).key)
+	}
+	leaderboardViews.Unlock()
+
+	if viewKey == SortByPosition+"_asc" {
+		return base, nil
+	}
+	return sortedLeaderboardView(key, base, viewKey, sortKey, descending)
+}
+
+// sortedLeaderboardView sorts a copy of the position-ordered view and caches it under viewKey
+// The view is only cached while the combination's entry still holds base, so a stale sort is never stored
+func sortedLeaderboardView(key string, base *LeaderboardView, viewKey, sortKey string, descending bool) (*LeaderboardView, error) {
+	results := append([]DriverResult(nil), base.Results...)
+	if err := SortLeaderboard(results, sortKey, descending); err != nil {
+		return nil, err
+	}
+	view := &LeaderboardView{Track: base.Track, Results: results, gaps: base.gaps}
+
+	leaderboardViews.Lock()
+	defer leaderboardViews.Unlock()
+	if element, ok := leaderboardViews.entries[key]; ok {
+		cached := element.Value.(*cachedLeaderboard)
+		if cached.views[SortByPosition+"_asc"] == base {
+			cached.views[viewKey] = view
+		}
+	}
+	return view, nil
+}
+
+// Page returns the results in [offset, end) with their gaps to the leader and the previous position
+func (v *LeaderboardView) Page(offset, end int) []LeaderboardResult {
+	return withGaps(v.Results[offset:end], v.gaps)
+}
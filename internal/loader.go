@@ -2,8 +2,8 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"runtime"
 	"strings"
 	"time"
@@ -36,7 +36,7 @@ func LoadAllCachedData(ctx context.Context) []TrackInfo {
 		}
 	}
 
-	log.Printf("✅ Loaded %d cached combinations for bootstrap", len(cached))
+	loaderLog.Infof("✅ Loaded %d cached combinations for bootstrap", len(cached))
 	return cached
 }
 
@@ -50,7 +50,7 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 	trackConfigs := GetTracks()
 	classConfigs := GetCarClasses()
 
-	log.Printf("📊 Loading data for %d tracks × %d classes = %d combinations...",
+	loaderLog.Infof("📊 Loading data for %d tracks × %d classes = %d combinations...",
 		len(trackConfigs), len(classConfigs), len(trackConfigs)*len(classConfigs))
 
 	apiClient := NewAPIClient()
@@ -64,8 +64,9 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 	totalCombinations := len(trackConfigs) * len(classConfigs)
 
 	// PHASE 1: Load ALL existing cache (even if expired)
-	log.Println("🔄 Phase 1: Loading all cached data...")
+	loaderLog.Infof("🔄 Phase 1: Loading all cached data...")
 	cacheLoadCount := 0
+	brokenCount := 0
 	// Pre-allocate with estimated capacity to avoid repeated allocations
 	allTrackData = make([]TrackInfo, 0, totalCombinations/2)
 	for _, track := range trackConfigs {
@@ -73,7 +74,7 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 			// Check if cancellation was requested
 			select {
 			case <-ctx.Done():
-				log.Printf("🛑 Cancelled during cache loading")
+				loaderLog.Infof("🛑 Cancelled during cache loading")
 				return allTrackData
 			default:
 			}
@@ -81,7 +82,10 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 			// Only load from cache, don't fetch
 			if dataCache.CacheExists(track.TrackID, class.ClassID) {
 				trackInfo, err := dataCache.LoadTrackData(track.TrackID, class.ClassID)
-				if err == nil && len(trackInfo.Data) > 0 {
+				if err != nil {
+					brokenCount++
+					loaderLog.Debugf("Cache file failed to load: %v", err)
+				} else if len(trackInfo.Data) > 0 {
 					allTrackData = append(allTrackData, trackInfo)
 					cacheLoadCount++
 				}
@@ -89,36 +93,40 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 		}
 	}
 
-	log.Printf("✅ Cache loaded: %d combinations", cacheLoadCount)
+	loaderLog.Infof("✅ Cache loaded: %d combinations", cacheLoadCount)
+	if brokenCount > 0 {
+		loaderLog.Warnf("⚠️ %d cache files failed to load (corrupt ones were quarantined and will be refetched; see `cache validate`)", brokenCount)
+	}
 
-	// PHASE 2: Check if we need to fetch
-	needsFetching := false
+	// PHASE 2: Count missing and expired combinations (the fetch progress total)
+	staleCount := 0
 	for _, track := range trackConfigs {
 		for _, class := range classConfigs {
 			if !dataCache.CacheExists(track.TrackID, class.ClassID) || dataCache.IsCacheExpired(track.TrackID, class.ClassID) {
-				needsFetching = true
-				break
+				staleCount++
 			}
 		}
-		if needsFetching {
-			break
-		}
 	}
+	needsFetching := staleCount > 0
 
 	// Trigger cache complete callback with whether we'll fetch
 	// Always invoke so orchestrator can decide to start periodic indexing
 	if cacheCompleteCallback != nil {
-		log.Printf("📊 Building initial index from %d cached combinations...", len(allTrackData))
+		loaderLog.Infof("📊 Building initial index from %d cached combinations...", len(allTrackData))
 		cacheCompleteCallback(allTrackData, needsFetching)
 	}
 
 	if !needsFetching {
-		log.Println("✅ All cache is fresh - no fetching needed")
+		loaderLog.Infof("✅ All cache is fresh - no fetching needed")
 		return allTrackData
 	}
 
 	// PHASE 3: Fetch missing and expired data
-	log.Println("🔄 Phase 3: Fetching missing and expired data...")
+	loaderLog.Infof("🔄 Phase 3: Fetching %d missing and expired combinations...", staleCount)
+	fetchProgress.begin(staleCount, "startup")
+	defer fetchProgress.finish()
+	fetchThrottle.reset()
+	beginSchemaCheck()
 
 	currentCombination := 0
 	fetchedCount := 0
@@ -138,7 +146,7 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 			// Check if cancellation was requested
 			select {
 			case <-ctx.Done():
-				log.Printf("🛑 Fetch cancelled at %d/%d combinations", currentCombination, totalCombinations)
+				loaderLog.Infof("🛑 Fetch cancelled at %d/%d combinations", currentCombination, totalCombinations)
 				return allTrackData
 			default:
 			}
@@ -175,8 +183,9 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 			// Fetch fresh data - always fetch (don't check cache) and write to tempCache
 			// We use dataCache to check if cache exists/expired above, but write to tempCache
 			data, duration, err := fetchWithTimeout(ctx, apiClient, track, class)
+			fetchProgress.advance(track, class, err)
 			if err != nil {
-				log.Printf("⚠️ Fetch error %s + %s: %v (will retry later)", track.Name, class.Name, err)
+				combinationLog(track, class).Warnf("⚠️ Fetch error %s + %s: %v (will retry later)", track.Name, class.Name, err)
 				failedFetches = append(failedFetches, FailedFetchInfo{track, class, err})
 				continue // Skip on fetch error but log it - we'll retry in PHASE 4
 			}
@@ -188,19 +197,19 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 				Data:    data,
 			}
 
-			// Always save to temp cache to update timestamp, even for empty data
-			if saveErr := tempCache.SaveTrackData(trackInfo); saveErr != nil {
-				log.Printf("⚠️ Warning: Could not save to temp cache %s + %s: %v", track.Name, class.Name, saveErr)
+			// Save to temp cache to update timestamp, even for empty data
+			// (unchanged data only renews the existing cache file's age)
+			changed, saveErr := tempCache.SaveIfChanged(trackInfo)
+			if saveErr != nil {
+				loaderLog.Warnf("⚠️ Warning: Could not save to temp cache %s + %s: %v", track.Name, class.Name, saveErr)
 			}
 
 			if len(data) > 0 {
-				log.Printf("🌐 %s + %s: %.2fs → %d entries (cache age: %s) [track=%s, class=%s]", track.Name, class.Name, duration.Seconds(), len(data), cacheAgeStr, track.TrackID, class.ClassID)
+				fetchResultLog(track, class, len(data), duration).Infof("🌐 %s + %s: %.2fs → %d entries%s (cache age: %s)", track.Name, class.Name, duration.Seconds(), len(data), changeNote(changed), cacheAgeStr)
 			} else {
-				log.Printf("🌐 %s + %s: %.2fs → no data (cache age: %s) [track=%s, class=%s]", track.Name, class.Name, duration.Seconds(), cacheAgeStr, track.TrackID, class.ClassID)
+				fetchResultLog(track, class, 0, duration).Infof("🌐 %s + %s: %.2fs → no data (cache age: %s)", track.Name, class.Name, duration.Seconds(), cacheAgeStr)
 			}
 
-			fromCache := false
-
 			// Update or add the track data
 			if len(trackInfo.Data) > 0 {
 				existingData[key] = trackInfo
@@ -216,25 +225,6 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 					progressCallback(allTrackData)
 				}
 			}
-
-			// Rate limiting for API calls
-			if !fromCache {
-				sleepDuration := 20 * time.Millisecond
-				for i := 0; i < int(sleepDuration/time.Millisecond); i += 100 {
-					select {
-					case <-ctx.Done():
-						log.Printf("🛑 Fetch cancelled at %d/%d combinations", currentCombination, totalCombinations)
-						// Rebuild final data from map
-						allTrackData = make([]TrackInfo, 0, len(existingData))
-						for _, v := range existingData {
-							allTrackData = append(allTrackData, v)
-						}
-						return allTrackData
-					default:
-					}
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
 		}
 	}
 
@@ -255,16 +245,20 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 	allTrackData = append(allTrackData, retriedTracks...)
 
 	// Promote temp cache to main cache atomically
-	log.Println("🔄 Promoting temporary cache to main cache...")
+	loaderLog.Infof("🔄 Promoting temporary cache to main cache...")
 	promotedCount, err := tempCache.PromoteTempCache()
+	if errors.Is(err, ErrSchemaDrift) {
+		loaderLog.Errorf("🧬 Discarding the fetched leaderboards, keeping the cached ones: %v", err)
+		return LoadAllCachedData(ctx)
+	}
 	if err != nil {
-		log.Printf("⚠️ Critical error promoting temp cache: %v", err)
+		loaderLog.Warnf("⚠️ Critical error promoting temp cache: %v", err)
 		// Continue anyway - we still have the in-memory data
 	} else if promotedCount > 0 {
-		log.Printf("✅ Promoted %d cache files successfully", promotedCount)
+		loaderLog.Infof("✅ Promoted %d cache files successfully", promotedCount)
 	}
 
-	log.Printf("✅ Loaded %d total combinations (%d from cache, %d fetched)",
+	loaderLog.Infof("✅ Loaded %d total combinations (%d from cache, %d fetched)",
 		len(allTrackData), cacheLoadCount, fetchedCount)
 
 	// Export failed fetch statistics to status file
@@ -280,7 +274,7 @@ func LoadAllTrackDataWithCallback(ctx context.Context, progressCallback func([]T
 
 // fetchCombinations is a shared helper that fetches data for a list of track configurations
 // It handles the fetch loop, error handling, logging, rate limiting, and cache promotion
-func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classConfigs []CarClassConfig, progressCallback func([]TrackInfo), logPrefix string) []TrackInfo {
+func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classConfigs []CarClassConfig, progressCallback func([]TrackInfo), logPrefix, origin string) []TrackInfo {
 	apiClient := NewAPIClient()
 	defer apiClient.Close()
 
@@ -290,6 +284,22 @@ func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classCon
 	var failedFetches []FailedFetchInfo
 
 	processed := 0
+	fetchProgress.begin(totalCombinations, origin)
+	defer fetchProgress.finish()
+	fetchThrottle.reset()
+	beginSchemaCheck()
+
+	// Failures of earlier runs outside this run's scope go first
+	inScope := make(map[string]bool, totalCombinations)
+	for _, track := range trackConfigs {
+		for _, class := range classConfigs {
+			inScope[track.TrackID+"_"+class.ClassID] = true
+		}
+	}
+	allTrackData = append(allTrackData, retryQueuedFailures(ctx, apiClient, tempCache, func(trackID, classID string) bool {
+		return inScope[trackID+"_"+classID]
+	})...)
+
 	// Fetch ALL combinations unconditionally
 	for _, track := range trackConfigs {
 		for _, class := range classConfigs {
@@ -298,15 +308,16 @@ func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classCon
 			// Check cancellation
 			select {
 			case <-ctx.Done():
-				log.Printf("🛑 Fetch cancelled at %d/%d combinations", processed, totalCombinations)
+				loaderLog.Infof("🛑 Fetch cancelled at %d/%d combinations", processed, totalCombinations)
 				return allTrackData
 			default:
 			}
 
 			data, duration, err := fetchWithTimeout(ctx, apiClient, track, class)
+			fetchProgress.advance(track, class, err)
 			if err != nil {
 				// Log and continue on error to avoid losing large portions
-				log.Printf("⚠️ Fetch error %s + %s: %v (will retry later)", track.Name, class.Name, err)
+				combinationLog(track, class).Warnf("⚠️ Fetch error %s + %s: %v (will retry later)", track.Name, class.Name, err)
 				failedFetches = append(failedFetches, FailedFetchInfo{track, class, err})
 				// still report progress periodically
 				if progressCallback != nil && (processed%50 == 0 || processed == 1) {
@@ -322,9 +333,11 @@ func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classCon
 				Data:    data,
 			}
 
-			// Always save to temp cache to update timestamp, even for empty data
-			if saveErr := tempCache.SaveTrackData(ti); saveErr != nil {
-				log.Printf("⚠️ Warning: Could not save to temp cache %s + %s: %v", track.Name, class.Name, saveErr)
+			// Save to temp cache to update timestamp, even for empty data
+			// (unchanged data only renews the existing cache file's age)
+			changed, saveErr := tempCache.SaveIfChanged(ti)
+			if saveErr != nil {
+				loaderLog.Warnf("⚠️ Warning: Could not save to temp cache %s + %s: %v", track.Name, class.Name, saveErr)
 			}
 
 			// Append only if we have entries; keep empty combos out to avoid bloating
@@ -333,29 +346,17 @@ func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classCon
 			}
 
 			if len(data) > 0 {
-				log.Printf("🌐 %s + %s: %.2fs → %d entries [track=%s, class=%s]",
-					track.Name, class.Name, duration.Seconds(), len(data), track.TrackID, class.ClassID)
+				fetchResultLog(track, class, len(data), duration).Infof("🌐 %s + %s: %.2fs → %d entries%s",
+					track.Name, class.Name, duration.Seconds(), len(data), changeNote(changed))
 			} else {
-				log.Printf("🌐 %s + %s: %.2fs → no data [track=%s, class=%s]",
-					track.Name, class.Name, duration.Seconds(), track.TrackID, class.ClassID)
+				fetchResultLog(track, class, 0, duration).Infof("🌐 %s + %s: %.2fs → no data",
+					track.Name, class.Name, duration.Seconds())
 			}
 
 			// Periodic progress updates
 			if progressCallback != nil && (processed%50 == 0 || processed == 1) {
 				progressCallback(allTrackData)
 			}
-
-			// Rate limit API calls
-			sleepDuration := 20 * time.Millisecond
-			for i := 0; i < int(sleepDuration/time.Millisecond); i += 100 {
-				select {
-				case <-ctx.Done():
-					log.Printf("🛑 Fetch cancelled at %d/%d combinations", processed, totalCombinations)
-					return allTrackData
-				default:
-				}
-				time.Sleep(100 * time.Millisecond)
-			}
 		}
 	}
 
@@ -364,15 +365,19 @@ func fetchCombinations(ctx context.Context, trackConfigs []TrackConfig, classCon
 	allTrackData = append(allTrackData, retriedTracks...)
 
 	// Promote temp cache to main cache atomically
-	log.Println("🔄 Promoting temporary cache to main cache...")
+	loaderLog.Infof("🔄 Promoting temporary cache to main cache...")
 	promotedCount, err := tempCache.PromoteTempCache()
+	if errors.Is(err, ErrSchemaDrift) {
+		loaderLog.Errorf("🧬 Discarding the fetched leaderboards, keeping the cached ones: %v", err)
+		return nil // Callers merge the result over the cache
+	}
 	if err != nil {
-		log.Printf("⚠️ Critical error promoting temp cache: %v", err)
+		loaderLog.Warnf("⚠️ Critical error promoting temp cache: %v", err)
 	} else if promotedCount > 0 {
-		log.Printf("✅ Promoted %d cache files successfully", promotedCount)
+		loaderLog.Infof("✅ Promoted %d cache files successfully", promotedCount)
 	}
 
-	log.Printf("%s: fetched %d combinations (kept %d with data)", logPrefix, totalCombinations, len(allTrackData))
+	loaderLog.Infof("%s: fetched %d combinations (kept %d with data)", logPrefix, totalCombinations, len(allTrackData))
 
 	// Export failed fetch statistics to status file
 	if len(failedFetches) > 0 {
@@ -390,10 +395,10 @@ func FetchAllTrackDataWithCallback(ctx context.Context, progressCallback func([]
 	trackConfigs := GetTracks()
 	classConfigs := GetCarClasses()
 
-	log.Printf("📊 Scheduled refresh: force-fetch %d tracks × %d classes = %d combinations...",
+	loaderLog.Infof("📊 Scheduled refresh: force-fetch %d tracks × %d classes = %d combinations...",
 		len(trackConfigs), len(classConfigs), len(trackConfigs)*len(classConfigs))
 
-	return fetchCombinations(ctx, trackConfigs, classConfigs, progressCallback, "✅ Force-fetched")
+	return fetchCombinations(ctx, trackConfigs, classConfigs, progressCallback, "✅ Force-fetched", origin)
 }
 
 // exportFailedFetches saves failed fetch information to the status file
@@ -413,7 +418,7 @@ func exportFailedFetches(failedFetches []FailedFetchInfo) {
 	}
 
 	if err := ExportStatusData(status); err != nil {
-		log.Printf("⚠️ Failed to export failed fetch data: %v", err)
+		loaderLog.Warnf("⚠️ Failed to export failed fetch data: %v", err)
 	}
 }
 
@@ -424,7 +429,7 @@ type targetCombo struct {
 }
 
 // fetchSpecificCombinations fetches only the specific track-class combinations requested
-func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo, trackConfigs []TrackConfig, allClassConfigs []CarClassConfig, progressCallback func([]TrackInfo)) []TrackInfo {
+func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo, trackConfigs []TrackConfig, allClassConfigs []CarClassConfig, progressCallback func([]TrackInfo), origin string) []TrackInfo {
 	apiClient := NewAPIClient()
 	defer apiClient.Close()
 
@@ -444,6 +449,21 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 		}
 	}
 
+	fetchProgress.begin(totalCombinations, origin)
+	defer fetchProgress.finish()
+	fetchThrottle.reset()
+	beginSchemaCheck()
+
+	// Failures of earlier runs outside the requested combinations go first
+	allTrackData = append(allTrackData, retryQueuedFailures(ctx, apiClient, tempCache, func(trackID, classID string) bool {
+		for _, combo := range targetCombos {
+			if combo.trackID == trackID && (combo.classID == "" || combo.classID == classID) {
+				return true
+			}
+		}
+		return false
+	})...)
+
 	// Fetch each requested combination
 	for _, combo := range targetCombos {
 		// Find the track config
@@ -480,14 +500,15 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 			// Check cancellation
 			select {
 			case <-ctx.Done():
-				log.Printf("🛑 Fetch cancelled at %d/%d combinations", processed, totalCombinations)
+				loaderLog.Infof("🛑 Fetch cancelled at %d/%d combinations", processed, totalCombinations)
 				return allTrackData
 			default:
 			}
 
 			data, duration, err := fetchWithTimeout(ctx, apiClient, *trackConfig, class)
+			fetchProgress.advance(*trackConfig, class, err)
 			if err != nil {
-				log.Printf("⚠️ Fetch error %s + %s: %v (will retry later)", trackConfig.Name, class.Name, err)
+				combinationLog(*trackConfig, class).Warnf("⚠️ Fetch error %s + %s: %v (will retry later)", trackConfig.Name, class.Name, err)
 				failedFetches = append(failedFetches, FailedFetchInfo{*trackConfig, class, err})
 				if progressCallback != nil && (processed%50 == 0 || processed == 1) {
 					progressCallback(allTrackData)
@@ -502,9 +523,10 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 				Data:    data,
 			}
 
-			// Always save to temp cache
-			if saveErr := tempCache.SaveTrackData(ti); saveErr != nil {
-				log.Printf("⚠️ Warning: Could not save to temp cache %s + %s: %v", trackConfig.Name, class.Name, saveErr)
+			// Save to temp cache (unchanged data only renews the existing cache file's age)
+			changed, saveErr := tempCache.SaveIfChanged(ti)
+			if saveErr != nil {
+				loaderLog.Warnf("⚠️ Warning: Could not save to temp cache %s + %s: %v", trackConfig.Name, class.Name, saveErr)
 			}
 
 			// Append only if we have entries
@@ -513,28 +535,17 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 			}
 
 			if len(data) > 0 {
-				log.Printf("🌐 %s + %s: %.2fs → %d entries [track=%s, class=%s]",
-					trackConfig.Name, class.Name, duration.Seconds(), len(data), trackConfig.TrackID, class.ClassID)
+				fetchResultLog(*trackConfig, class, len(data), duration).Infof("🌐 %s + %s: %.2fs → %d entries%s",
+					trackConfig.Name, class.Name, duration.Seconds(), len(data), changeNote(changed))
 			} else {
-				log.Printf("🌐 %s + %s: %.2fs → no data [track=%s, class=%s]",
-					trackConfig.Name, class.Name, duration.Seconds(), trackConfig.TrackID, class.ClassID)
+				fetchResultLog(*trackConfig, class, 0, duration).Infof("🌐 %s + %s: %.2fs → no data",
+					trackConfig.Name, class.Name, duration.Seconds())
 			}
 
 			// Periodic progress updates
 			if progressCallback != nil && (processed%50 == 0 || processed == 1) {
 				progressCallback(allTrackData)
 			}
-
-			// Rate limit API calls
-			sleepDuration := 20 * time.Millisecond
-			for i := 0; i < int(sleepDuration/time.Millisecond); i += 100 {
-				select {
-				case <-ctx.Done():
-					return allTrackData
-				default:
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
 		}
 	}
 
@@ -548,17 +559,21 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 	allTrackData = append(allTrackData, retriedTracks...)
 
 	// Promote temp cache to main cache atomically
-	log.Println("🔄 Promoting temporary cache to main cache...")
+	loaderLog.Infof("🔄 Promoting temporary cache to main cache...")
 	promotedCount, err := tempCache.PromoteTempCache()
+	if errors.Is(err, ErrSchemaDrift) {
+		loaderLog.Errorf("🧬 Discarding the fetched leaderboards, keeping the cached ones: %v", err)
+		return nil // Callers merge the result over the cache
+	}
 	if err != nil {
-		log.Printf("⚠️ Critical error promoting temp cache: %v", err)
+		loaderLog.Warnf("⚠️ Critical error promoting temp cache: %v", err)
 	} else if promotedCount > 0 {
-		log.Printf("✅ Promoted %d cache files successfully", promotedCount)
+		loaderLog.Infof("✅ Promoted %d cache files successfully", promotedCount)
 	}
 
 	// Export failed fetches
 	if len(failedFetches) > 0 {
-		log.Printf("⚠️ %d combination(s) failed to fetch (will retry later)", len(failedFetches))
+		loaderLog.Warnf("⚠️ %d combination(s) failed to fetch (will retry later)", len(failedFetches))
 	}
 
 	status := ReadStatusData()
@@ -576,19 +591,41 @@ func fetchSpecificCombinations(ctx context.Context, targetCombos []targetCombo,
 	}
 
 	if err := ExportStatusData(status); err != nil {
-		log.Printf("⚠️ Failed to export failed fetch data: %v", err)
+		loaderLog.Warnf("⚠️ Failed to export failed fetch data: %v", err)
 	}
 
-	log.Printf("✅ Targeted refresh complete: fetched %d combinations", len(allTrackData))
+	loaderLog.Infof("✅ Targeted refresh complete: fetched %d combinations", len(allTrackData))
 	return allTrackData
 }
 
 // FetchTargetedTrackDataWithCallback fetches data for specific track IDs or track-class couples
 // trackIDs is a slice of tokens: either "trackID" (all classes) or "trackID-classID" (specific class)
+// A "*-classID" token selects the class on every track; when all tokens are of that form, the refresh is
+// delegated to FetchSelectedClassesDataWithCallback
 func FetchTargetedTrackDataWithCallback(ctx context.Context, trackIDs []string, progressCallback func([]TrackInfo), origin string) []TrackInfo {
 	allTrackConfigs := GetTracks()
 	allClassConfigs := GetCarClasses()
 
+	// Class-wide tokens: "*-1703"
+	var classIDs, expanded []string
+	for _, token := range trackIDs {
+		classID, ok := strings.CutPrefix(token, AllTracksToken+"-")
+		if !ok {
+			expanded = append(expanded, token)
+			continue
+		}
+		classIDs = append(classIDs, classID)
+		for _, track := range allTrackConfigs {
+			expanded = append(expanded, track.TrackID+"-"+classID)
+		}
+	}
+	if len(classIDs) > 0 {
+		if len(classIDs) == len(trackIDs) {
+			return FetchSelectedClassesDataWithCallback(ctx, classIDs, progressCallback, origin)
+		}
+		trackIDs = expanded
+	}
+
 	// Parse tokens to separate track-only IDs from track-class couples
 	targetCombos := make([]targetCombo, 0)
 	for _, token := range trackIDs {
@@ -616,7 +653,7 @@ func FetchTargetedTrackDataWithCallback(ctx context.Context, trackIDs []string,
 	}
 
 	if len(trackConfigs) == 0 {
-		log.Printf("⚠️ No valid tracks found for tokens: %v", trackIDs)
+		loaderLog.Warnf("⚠️ No valid tracks found for tokens: %v", trackIDs)
 		return []TrackInfo{}
 	}
 
@@ -671,7 +708,7 @@ func FetchTargetedTrackDataWithCallback(ctx context.Context, trackIDs []string,
 		}
 	}
 
-	log.Printf("📊 Targeted refresh: force-fetch %d combinations...", totalCombos)
+	loaderLog.Infof("📊 Targeted refresh: force-fetch %d combinations...", totalCombos)
 
 	// Log what we're refreshing
 	for _, combo := range targetCombos {
@@ -683,7 +720,7 @@ func FetchTargetedTrackDataWithCallback(ctx context.Context, trackIDs []string,
 			}
 		}
 		if combo.classID == "" {
-			log.Printf("  🎯 %s (ID: %s) - all classes", trackName, combo.trackID)
+			loaderLog.Infof("  🎯 %s (ID: %s) - all classes", trackName, combo.trackID)
 		} else {
 			className := combo.classID
 			for _, class := range allClassConfigs {
@@ -692,15 +729,54 @@ func FetchTargetedTrackDataWithCallback(ctx context.Context, trackIDs []string,
 					break
 				}
 			}
-			log.Printf("  🎯 %s (ID: %s) - class %s (ID: %s)", trackName, combo.trackID, className, combo.classID)
+			loaderLog.Infof("  🎯 %s (ID: %s) - class %s (ID: %s)", trackName, combo.trackID, className, combo.classID)
 		}
 	}
 
 	// If we have specific track-class couples, we need to filter combinations
 	if hasSpecificCombos {
 		// Build a custom fetcher that only fetches the requested combinations
-		return fetchSpecificCombinations(ctx, targetCombos, trackConfigs, allClassConfigs, progressCallback)
+		return fetchSpecificCombinations(ctx, targetCombos, trackConfigs, allClassConfigs, progressCallback, origin)
 	}
 
-	return fetchCombinations(ctx, trackConfigs, classConfigs, progressCallback, "✅ Targeted refresh complete")
+	return fetchCombinations(ctx, trackConfigs, classConfigs, progressCallback, "✅ Targeted refresh complete", origin)
+}
+
+// FetchSelectedClassesDataWithCallback force-fetches every track for the given class IDs, e.g. to populate a
+// newly released car class without refreshing the whole matrix. It writes to the temporary cache and
+// promotes it at the end, like the full refresh.
+func FetchSelectedClassesDataWithCallback(ctx context.Context, classIDs []string, progressCallback func([]TrackInfo), origin string) []TrackInfo {
+	trackConfigs := GetTracks()
+	selected := make(map[string]bool, len(classIDs))
+	for _, classID := range classIDs {
+		selected[classID] = true
+	}
+	classConfigs := make([]CarClassConfig, 0, len(classIDs))
+	for _, class := range GetCarClasses() {
+		if selected[class.ClassID] {
+			classConfigs = append(classConfigs, class)
+		}
+	}
+	if len(classConfigs) == 0 {
+		loaderLog.Warnf("⚠️ No valid classes found for IDs: %v", classIDs)
+		return []TrackInfo{}
+	}
+
+	loaderLog.Infof("📊 Class refresh: force-fetch %d tracks × %d classes = %d combinations...",
+		len(trackConfigs), len(classConfigs), len(trackConfigs)*len(classConfigs))
+	for _, class := range classConfigs {
+		loaderLog.Infof("  🎯 %s (ID: %s) - all tracks", class.Name, class.ClassID)
+	}
+
+	return fetchCombinations(ctx, trackConfigs, classConfigs, progressCallback, "✅ Class refresh complete", origin)
+}
+
+// combinationLog returns the loader logger tagged with a track/class combination
+func combinationLog(track TrackConfig, class CarClassConfig) Logger {
+	return loaderLog.With("track_id", track.TrackID, "class_id", class.ClassID)
+}
+
+// fetchResultLog returns the loader logger tagged with the outcome of a single fetch
+func fetchResultLog(track TrackConfig, class CarClassConfig, entries int, duration time.Duration) Logger {
+	return combinationLog(track, class).With("entries", entries, "duration_ms", duration.Milliseconds())
 }
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// InstanceLockFile is held by the instance that fetches into and promotes the cache
+const InstanceLockFile = "cache/instance.lock"
+
+// What a serving instance does when another one holds the lock (cache.lock.on_conflict)
+const (
+	LockConflictExit     = "exit"      // Exit with an error (default)
+	LockConflictWait     = "wait"      // Wait for the lock, up to wait_seconds
+	LockConflictReadOnly = "read_only" // Serve the persisted index without fetching or promoting
+)
+
+// ErrLockHeld is returned when another live instance holds the lock
+var ErrLockHeld = errors.New("cache is locked by another instance")
+
+// LockOwner is the content of the lock file
+type LockOwner struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Token     string    `json:"token"` // Random per instance; PIDs are reused, e.g. PID 1 in containers
+	StartedAt time.Time `json:"started_at"`
+}
+
+func (o LockOwner) String() string {
+	return fmt.Sprintf("pid %d on %s since %s", o.PID, o.Host, o.StartedAt.Format(time.RFC3339))
+}
+
+// InstanceLock is an exclusive lock file kept fresh by a heartbeat
+// A lock whose file hasn't been touched for the stale period belongs to a crashed instance and is taken over
+type InstanceLock struct {
+	path  string
+	owner LockOwner
+	stale time.Duration
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// heldLock is the lock of this process, verified before every promotion; nil when not locking (CLI commands)
+var heldLock struct {
+	mu   sync.Mutex
+	lock *InstanceLock
+}
+
+// AcquireInstanceLock takes the lock at path, or returns an error wrapping ErrLockHeld with the current owner
+// The lock is refreshed every stale/3 until Release
+func AcquireInstanceLock(path string, stale time.Duration) (*InstanceLock, error) {
+	if stale <= 0 {
+		stale = 5 * time.Minute
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	lock := &InstanceLock{
+		path:  path,
+		owner: LockOwner{PID: os.Getpid(), Host: host, Token: newRandomID(), StartedAt: time.Now().UTC()},
+		stale: stale,
+		stop:  make(chan struct{}),
+	}
+	if err := lock.create(); err != nil {
+		return nil, err
+	}
+
+	heldLock.mu.Lock()
+	heldLock.lock = lock
+	heldLock.mu.Unlock()
+	go lock.heartbeat()
+	cacheLog.Infof("🔒 Acquired %s", path)
+	return lock, nil
+}
+
+// WaitInstanceLock retries AcquireInstanceLock every few seconds until it succeeds or ctx is done
+func WaitInstanceLock(ctx context.Context, path string, stale time.Duration) (*InstanceLock, error) {
+	for {
+		lock, err := AcquireInstanceLock(path, stale)
+		if !errors.Is(err, ErrLockHeld) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// create writes the lock file exclusively, taking over a stale one
+func (l *InstanceLock) create() error {
+	data, err := json.MarshalIndent(l.owner, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	if !os.IsExist(err) {
+		return err
+	}
+
+	owner, info, readErr := readLockFile(l.path)
+	if info != nil {
+		age := time.Since(info.ModTime())
+		sameProcess := readErr == nil && owner.Host == l.owner.Host && owner.PID == l.owner.PID
+		if age < l.stale && !sameProcess {
+			holder := owner.String()
+			if readErr != nil {
+				holder = "owner unreadable" // Possibly still being written
+			}
+			return fmt.Errorf("%w (%s, last heartbeat %s ago)", ErrLockHeld, holder, age.Round(time.Second))
+		}
+		cacheLog.Warnf("⚠️ Taking over stale lock %s (last heartbeat %s ago)", l.path, age.Round(time.Second))
+	}
+
+	// Stale (or just removed): replace it atomically, then check no other instance replaced it at the same time
+	tempFile := l.path + "." + l.owner.Token
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, l.path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	time.Sleep(100 * time.Millisecond)
+	return l.Verify()
+}
+
+// readLockFile reads the owner and file info of a lock file
+func readLockFile(path string) (LockOwner, os.FileInfo, error) {
+	var owner LockOwner
+	info, err := os.Stat(path)
+	if err != nil {
+		return owner, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner, info, err
+	}
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return owner, info, err
+	}
+	return owner, info, nil
+}
+
+// Verify checks that the lock file still belongs to this instance
+func (l *InstanceLock) Verify() error {
+	owner, _, err := readLockFile(l.path)
+	if err != nil {
+		return fmt.Errorf("instance lock %s lost: %w", l.path, err)
+	}
+	if owner.Token != l.owner.Token {
+		return fmt.Errorf("%w: %s was taken over (%s)", ErrLockHeld, l.path, owner)
+	}
+	return nil
+}
+
+// heartbeat touches the lock file so other instances see it is alive
+func (l *InstanceLock) heartbeat() {
+	ticker := time.NewTicker(l.stale / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := os.Chtimes(l.path, now, now); err != nil {
+				cacheLog.Warnf("⚠️ Failed to refresh %s: %v", l.path, err)
+			}
+		}
+	}
+}
+
+// Release stops the heartbeat and removes the lock file if it is still ours; a nil lock is a no-op
+func (l *InstanceLock) Release() {
+	if l == nil {
+		return
+	}
+	l.once.Do(func() {
+		close(l.stop)
+		heldLock.mu.Lock()
+		if heldLock.lock == l {
+			heldLock.lock = nil
+		}
+		heldLock.mu.Unlock()
+		if l.Verify() == nil {
+			os.Remove(l.path)
+			cacheLog.Infof("🔓 Released %s", l.path)
+		}
+	})
+}
+
+// verifyHeldLock checks the lock of this process, if it holds one
+func verifyHeldLock() error {
+	heldLock.mu.Lock()
+	lock := heldLock.lock
+	heldLock.mu.Unlock()
+	if lock == nil {
+		return nil
+	}
+	return lock.Verify()
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log output formats
+const (
+	LogFormatText = "text" // Human-friendly console lines (default)
+	LogFormatJSON = "json" // One JSON object per line for Loki/ELK
+)
+
+// Logger is a leveled logger tagged with a component name (loader, cache, indexer, http, ...)
+// It resolves slog.Default() on every call, so loggers declared at package level
+// pick up the handler installed later by SetupLogging
+type Logger struct {
+	component string
+	attrs     []any
+}
+
+// NewLogger returns a logger for the given component
+func NewLogger(component string) Logger {
+	return Logger{component: component}
+}
+
+// Component loggers of the internal package
+var (
+	apiLog       = NewLogger("api")
+	cacheLog     = NewLogger("cache")
+	configLog    = NewLogger("config")
+	exportLog    = NewLogger("exporter")
+	httpLog      = NewLogger("http")
+	indexerLog   = NewLogger("indexer")
+	jobsLog      = NewLogger("jobs")
+	loaderLog    = NewLogger("loader")
+	notifyLog    = NewLogger("notify")
+	schedulerLog = NewLogger("scheduler")
+	searchLog    = NewLogger("search")
+	tracingLog   = NewLogger("tracing")
+)
+
+// With returns a logger that adds the given key/value pairs to every record
+func (l Logger) With(args ...any) Logger {
+	attrs := make([]any, 0, len(l.attrs)+len(args))
+	attrs = append(attrs, l.attrs...)
+	attrs = append(attrs, args...)
+	return Logger{component: l.component, attrs: attrs}
+}
+
+// Debugf logs a formatted message at debug level
+func (l Logger) Debugf(format string, args ...any) { l.logf(slog.LevelDebug, format, args...) }
+
+// Infof logs a formatted message at info level
+func (l Logger) Infof(format string, args ...any) { l.logf(slog.LevelInfo, format, args...) }
+
+// Warnf logs a formatted message at warn level
+func (l Logger) Warnf(format string, args ...any) { l.logf(slog.LevelWarn, format, args...) }
+
+// Errorf logs a formatted message at error level
+func (l Logger) Errorf(format string, args ...any) { l.logf(slog.LevelError, format, args...) }
+
+// logf formats and emits a record if the level is enabled
+func (l Logger) logf(level slog.Level, format string, args ...any) {
+	logger := slog.Default()
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]any, 0, len(l.attrs)+2)
+	attrs = append(attrs, "component", l.component)
+	attrs = append(attrs, l.attrs...)
+	logger.Log(ctx, level, fmt.Sprintf(format, args...), attrs...)
+}
+
+// SetupLogging installs the default slog handler from the logging config
+// Output of the standard log package is routed through the same handler
+func SetupLogging(config LoggingConfig) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		configLog.Warnf("⚠️ Invalid log level %q, using info", config.Level)
+		level = slog.LevelInfo
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	case "", LogFormatText:
+		handler = newConsoleHandler(os.Stderr, level)
+	default:
+		configLog.Warnf("⚠️ Invalid log format %q, using text", config.Format)
+		handler = newConsoleHandler(os.Stderr, level)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// consoleHandler writes "[component] message key=value ..." lines without timestamps
+// (systemd/journalctl already provides them)
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+	prefix string // Group prefix for attribute keys
+}
+
+// newConsoleHandler creates the human-friendly text handler
+func newConsoleHandler(w io.Writer, level slog.Leveler) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled reports whether records at level are written
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes a single record
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	component := ""
+	var attrs []slog.Attr
+	collect := func(a slog.Attr) bool {
+		if a.Key == "component" {
+			component = a.Value.String()
+		} else {
+			attrs = append(attrs, a)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.prefix + a.Key
+		return collect(a)
+	})
+
+	if component != "" {
+		buf.WriteString("[" + component + "] ")
+	}
+	if r.Level >= slog.LevelError && !strings.HasPrefix(r.Message, "❌") {
+		buf.WriteString("ERROR ")
+	} else if r.Level == slog.LevelDebug {
+		buf.WriteString("DEBUG ")
+	}
+	buf.WriteString(r.Message)
+	for _, a := range attrs {
+		buf.WriteByte(' ')
+		buf.WriteString(a.Key)
+		buf.WriteByte('=')
+		buf.WriteString(formatConsoleValue(a.Value))
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	clone.attrs = append(clone.attrs, h.attrs...)
+	for _, a := range attrs {
+		if a.Key != "component" {
+			a.Key = h.prefix + a.Key
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that prefixes later attribute keys with name
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// formatConsoleValue renders a value, quoting strings that contain spaces
+func formatConsoleValue(v slog.Value) string {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		s := v.String()
+		if s == "" || strings.ContainsAny(s, " \t\"=") {
+			return strconv.Quote(s)
+		}
+		return s
+	case slog.KindDuration:
+		return v.Duration().Round(time.Millisecond).String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339)
+	default:
+		return v.String()
+	}
+}
@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRateWindow is used when no valid window is configured
+const defaultRateWindow = time.Minute
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 64
+)
+
+// requestIDKey is the context key holding the ID of the current request
+type requestIDKey struct{}
+
+// logRequests assigns every API request an ID (reusing a sane incoming X-Request-ID),
+// returns it in the X-Request-ID response header, traces it as a server span of route
+// and logs method, path, status, latency and client IP
+func (s *APIServer) logRequests(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRandomID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx, span := startServerSpan(r, route)
+		defer span.End()
+		r = r.WithContext(context.WithValue(ctx, requestIDKey{}, id))
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK // Nothing written, or the connection was hijacked
+		}
+		duration := time.Since(start)
+		span.SetAttrs("http.status_code", status, "request_id", id)
+		if status >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("HTTP %d", status))
+		}
+		logger := requestLog(r).With(
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", duration.Milliseconds(),
+			"client_ip", s.clientIP(r),
+		)
+		if status >= http.StatusInternalServerError {
+			logger.Warnf("⚠️ %s %s → %d in %dms", r.Method, r.URL.Path, status, duration.Milliseconds())
+		} else {
+			logger.Infof("📨 %s %s → %d in %dms", r.Method, r.URL.Path, status, duration.Milliseconds())
+		}
+	})
+}
+
+// RequestID returns the ID assigned to the request carried by ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLog returns the http logger tagged with the request's ID (and trace ID when tracing)
+func requestLog(r *http.Request) Logger {
+	logger := httpLog
+	if id := RequestID(r.Context()); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if span, _ := r.Context().Value(spanKey{}).(*Span); span != nil {
+		logger = logger.With("trace_id", span.TraceID())
+	}
+	return logger
+}
+
+// validRequestID accepts short IDs made of letters, digits, '-', '_' and '.' so
+// client-supplied values cannot inject anything into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// statusRecorder captures the response status while passing through
+// flushing (SSE) and hijacking (WebSocket)
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 before the first body write
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush forwards to the underlying writer when it supports flushing
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack forwards to the underlying writer so WebSocket upgrades keep working
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection cannot be hijacked")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// rateLimit wraps an API handler with API key checks and per-client rate limiting
+// Requests with a key are limited per key; anonymous requests per client IP.
+// Routes with a configured policy use their own bucket, all others share the global one
+func (s *APIServer) rateLimit(route string, next http.HandlerFunc) http.Handler {
+	policy, hasPolicy := s.rateLimits.Routes[route]
+	window := s.rateWindow
+	bucket := ""
+	if hasPolicy {
+		bucket = "|" + route
+		if policy.WindowSeconds > 0 {
+			window = time.Duration(policy.WindowSeconds) * time.Second
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limitKey := "ip:" + s.clientIP(r) + bucket
+		limit := s.rateLimits.Requests
+		if hasPolicy {
+			limit = policy.Requests
+		}
+
+		var key APIKey
+		hasKey := false
+		if secret := apiKeyFromRequest(r); secret != "" {
+			k, ok := s.keys.Lookup(secret)
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			key, hasKey = k, true
+			limitKey = "key:" + k.Hash + bucket
+			limit = k.EffectiveRateLimit(s.rateLimits.KeyRequests)
+			if hasPolicy {
+				limit = policy.Requests
+				if policy.KeyRequests > 0 {
+					limit = policy.KeyRequests
+				}
+			}
+		} else if s.requireAPIKey && !s.isAdmin(r) {
+			writeError(w, http.StatusUnauthorized, "API key required (X-API-Key header)")
+			return
+		}
+
+		decision := s.limiter.Allow(limitKey, limit, window)
+		if hasKey {
+			s.keys.RecordRequest(key, !decision.Allowed)
+		}
+		if decision.Limit < 1 {
+			// Unlimited: no rate limit headers
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+		if !decision.Allowed {
+			retryAfter := int(time.Until(decision.Reset).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// apiKeyFromRequest returns the API key from the X-API-Key header or the api_key query parameter
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// clientIP returns the IP of the client behind the request
+// X-Forwarded-For and X-Real-IP are only honored when the connecting peer is a trusted proxy,
+// so clients cannot spoof their address to dodge rate limits
+func (s *APIServer) clientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if !s.isTrustedProxy(remote) {
+		return remote
+	}
+
+	// Walk X-Forwarded-For from the nearest hop; the first untrusted address is the client
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		client = hop
+		if !s.isTrustedProxy(hop) {
+			return hop
+		}
+	}
+	if client != "" {
+		return client
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
+
+// isTrustedProxy reports whether ip belongs to one of the configured trusted proxy ranges
+func (s *APIServer) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP of the directly connected peer
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseTrustedProxies parses CIDRs and plain IPs, logging and skipping invalid entries
+func parseTrustedProxies(entries []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				httpLog.Warnf("⚠️ Ignoring invalid trusted proxy %q", entry)
+				continue
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			httpLog.Warnf("⚠️ Ignoring invalid trusted proxy %q: %v", entry, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// rateWindowDuration converts the configured window, falling back to one minute
+func rateWindowDuration(seconds int) time.Duration {
+	if seconds < 1 {
+		return defaultRateWindow
+	}
+	return time.Duration(seconds) * time.Second
+}
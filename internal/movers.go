@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Mover is a driver whose entry in one combination moved up or got faster between two snapshots
+type Mover struct {
+	Name          string `json:"name"`
+	TrackID       string `json:"track_id"`
+	Track         string `json:"track"`
+	ClassID       string `json:"class_id"`
+	Class         string `json:"class"`
+	OldPosition   int    `json:"old_position"`
+	NewPosition   int    `json:"new_position"`
+	Gained        int    `json:"gained"` // Positions gained, 0 when the driver only improved
+	OldLapTime    string `json:"old_laptime"`
+	NewLapTime    string `json:"new_laptime"`
+	ImprovementMs int64  `json:"improvement_ms,omitempty"`
+}
+
+// ClassMovers are the movers of one car class
+type ClassMovers struct {
+	ClassID         string  `json:"class_id"`
+	Class           string  `json:"class"`
+	PositionsGained []Mover `json:"positions_gained"`
+	Improved        []Mover `json:"improved"`
+}
+
+// MoversFeed ranks the movers of every combination whose leaderboard changed since a point in time
+type MoversFeed struct {
+	Since           time.Time     `json:"since"`
+	Combinations    int           `json:"combinations"` // Combinations with changes in the window
+	PositionsGained []Mover       `json:"positions_gained"`
+	Improved        []Mover       `json:"improved"`
+	Classes         []ClassMovers `json:"classes,omitempty"` // Per class, by class name
+}
+
+// readRecentChanges returns the stored changes of every combination whose latest snapshot is not older than since
+func readRecentChanges(since time.Time) []LeaderboardChanges {
+	var recent []LeaderboardChanges
+	files, _ := filepath.Glob(filepath.Join(SnapshotDir, "track_*", "class_*", changesFileName))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var changes LeaderboardChanges
+		if json.Unmarshal(data, &changes) != nil || changes.To.Before(since) {
+			continue
+		}
+		recent = append(recent, withoutExcludedChanges(changes))
+	}
+	return recent
+}
+
+// BuildMoversFeed ranks the drivers who gained the most positions and improved their lap time the most
+// since the given time, globally and per class. classID restricts the feed to one class; limit caps every list
+func BuildMoversFeed(since time.Time, classID string, limit int) MoversFeed {
+	feed := MoversFeed{Since: since}
+	gainedByClass := make(map[string][]Mover)
+	improvedByClass := make(map[string][]Mover)
+	for _, changes := range readRecentChanges(since) {
+		if classID != "" && changes.ClassID != classID {
+			continue
+		}
+		if len(changes.Improved) == 0 && len(changes.PositionChanges) == 0 {
+			continue
+		}
+		feed.Combinations++
+		track := reportTrackName(changes.TrackID)
+		class := GetCarClassName(changes.ClassID)
+		mover := func(change EntryChange) Mover {
+			return Mover{
+				Name:          change.Name,
+				TrackID:       changes.TrackID,
+				Track:         track,
+				ClassID:       changes.ClassID,
+				Class:         class,
+				OldPosition:   change.OldPosition,
+				NewPosition:   change.NewPosition,
+				Gained:        max(change.OldPosition-change.NewPosition, 0),
+				OldLapTime:    change.OldLapTime,
+				NewLapTime:    change.NewLapTime,
+				ImprovementMs: change.ImprovementMs,
+			}
+		}
+		for _, change := range changes.PositionChanges {
+			if change.OldPosition > change.NewPosition {
+				gainedByClass[changes.ClassID] = append(gainedByClass[changes.ClassID], mover(change))
+			}
+		}
+		for _, change := range changes.Improved {
+			improvedByClass[changes.ClassID] = append(improvedByClass[changes.ClassID], mover(change))
+		}
+	}
+
+	feed.PositionsGained, feed.Improved = []Mover{}, []Mover{}
+	classIDs := make(map[string]bool)
+	for id, movers := range gainedByClass {
+		feed.PositionsGained = append(feed.PositionsGained, movers...)
+		classIDs[id] = true
+	}
+	for id, movers := range improvedByClass {
+		feed.Improved = append(feed.Improved, movers...)
+		classIDs[id] = true
+	}
+	feed.PositionsGained = topMovers(feed.PositionsGained, byPositionsGained, limit)
+	feed.Improved = topMovers(feed.Improved, byImprovement, limit)
+	if classID != "" {
+		return feed
+	}
+
+	feed.Classes = make([]ClassMovers, 0, len(classIDs))
+	for id := range classIDs {
+		feed.Classes = append(feed.Classes, ClassMovers{
+			ClassID:         id,
+			Class:           GetCarClassName(id),
+			PositionsGained: topMovers(gainedByClass[id], byPositionsGained, limit),
+			Improved:        topMovers(improvedByClass[id], byImprovement, limit),
+		})
+	}
+	sort.Slice(feed.Classes, func(i, j int) bool {
+		if feed.Classes[i].Class != feed.Classes[j].Class {
+			return feed.Classes[i].Class < feed.Classes[j].Class
+		}
+		return feed.Classes[i].ClassID < feed.Classes[j].ClassID
+	})
+	return feed
+}
+
+// byPositionsGained orders movers by positions gained, then by their new position and name
+func byPositionsGained(a, b Mover) bool {
+	if a.Gained != b.Gained {
+		return a.Gained > b.Gained
+	}
+	if a.NewPosition != b.NewPosition {
+		return a.NewPosition < b.NewPosition
+	}
+	return a.Name < b.Name
+}
+
+// byImprovement orders movers by lap time gained, then by their new position and name
+func byImprovement(a, b Mover) bool {
+	if a.ImprovementMs != b.ImprovementMs {
+		return a.ImprovementMs > b.ImprovementMs
+	}
+	if a.NewPosition != b.NewPosition {
+		return a.NewPosition < b.NewPosition
+	}
+	return a.Name < b.Name
+}
+
+// topMovers returns a sorted copy of movers cut to limit; never nil
+func topMovers(movers []Mover, less func(a, b Mover) bool, limit int) []Mover {
+	sorted := append([]Mover{}, movers...)
+	sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
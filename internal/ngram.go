@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// ngramSize is the length in bytes of the substrings indexed by ngramIndex
+const ngramSize = 3
+
+// ngram is one indexed substring of a normalized driver name
+type ngram [ngramSize]byte
+
+// ngramIndex maps every trigram of the index keys to the positions in indexSnapshot.names of the keys
+// containing it, in increasing order, so substring searches verify a few candidates instead of every key
+type ngramIndex map[ngram][]int32
+
+// buildNgramIndex indexes the trigrams of sorted, normalized names
+func buildNgramIndex(names []string) ngramIndex {
+	index := make(ngramIndex)
+	for i, name := range names {
+		for j := 0; j+ngramSize <= len(name); j++ {
+			var gram ngram
+			copy(gram[:], name[j:j+ngramSize])
+			positions := index[gram]
+			if len(positions) > 0 && positions[len(positions)-1] == int32(i) {
+				continue // Repeated within the name
+			}
+			index[gram] = append(positions, int32(i))
+		}
+	}
+	return index
+}
+
+// candidates returns the positions of the names holding every trigram of part, in increasing order
+// The candidates still need a strings.Contains check. ok is false when part is shorter than a trigram
+func (ni ngramIndex) candidates(part string) ([]int32, bool) {
+	if len(part) < ngramSize {
+		return nil, false
+	}
+	var lists [][]int32
+	for j := 0; j+ngramSize <= len(part); j++ {
+		var gram ngram
+		copy(gram[:], part[j:j+ngramSize])
+		positions, ok := ni[gram]
+		if !ok {
+			return nil, true
+		}
+		lists = append(lists, positions)
+	}
+
+	// Intersect from the rarest trigram, so the working set is small from the start
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	result := lists[0]
+	for _, list := range lists[1:] {
+		if len(result) == 0 {
+			break
+		}
+		result = intersectPositions(result, list)
+	}
+	return result, true
+}
+
+// intersectPositions returns the positions present in both increasing lists
+// Each element of the short list is searched in the long one, which beats a merge when sizes differ a lot
+func intersectPositions(short, long []int32) []int32 {
+	var both []int32
+	for _, position := range short {
+		i := sort.Search(len(long), func(i int) bool { return long[i] >= position })
+		if i < len(long) && long[i] == position {
+			both = append(both, position)
+		}
+		long = long[i:]
+	}
+	return both
+}
+
+// driverCandidates returns the index keys that may match any of the driver terms, in key order: the keys
+// containing a term, found through the trigram index, and the keys whose alias canonical name contains one
+// ok is false when a term is too short for the trigram index and every key must be scanned
+func (ix *indexSnapshot) driverCandidates(parts []string, aliases *AliasStore) ([]string, bool) {
+	var positions []int32
+	for _, part := range parts {
+		found, ok := ix.grams.candidates(part)
+		if !ok {
+			return nil, false
+		}
+		positions = append(positions, found...)
+		for _, key := range aliases.keysMatching(part) {
+			if i := sort.SearchStrings(ix.names, key); i < len(ix.names) && ix.names[i] == key {
+				positions = append(positions, int32(i))
+			}
+		}
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	keys := make([]string, 0, len(positions))
+	for i, position := range positions {
+		if i == 0 || position != positions[i-1] {
+			keys = append(keys, ix.names[position])
+		}
+	}
+	return keys, true
+}
+
+// keysMatching returns the normalized canonical and alternate names of the aliases whose canonical name contains part
+func (as *AliasStore) keysMatching(part string) []string {
+	if as == nil {
+		return nil
+	}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	var keys []string
+	for name, canonical := range as.byName {
+		if strings.Contains(canonical, part) {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// diacriticFolds lists accented Latin letters grouped by their ASCII base form.
+// This covers Latin-1 Supplement, Latin Extended-A and the common Extended-B
+// letters seen in RaceRoom driver names, without pulling in golang.org/x/text.
+// Most entries are what NFKD + mark stripping would produce. NFKD leaves the
+// stroke letters (đ, ħ, ł, ø, ŧ, ƶ), the dotless ı and the ligatures æ and œ
+// alone; they get their usual ASCII transliteration, and ß, þ and ð their
+// two-letter spellings. Front-ends must apply this table, not NFKD (see README).
+var diacriticFolds = map[string]string{
+	"a":  "àáâãäåāăąǎǟǡǻȁȃȧ",
+	"c":  "çćĉċč",
+	"d":  "ďđ",
+	"e":  "èéêëēĕėęěȅȇȩ",
+	"g":  "ĝğġģǧǵ",
+	"h":  "ĥħȟ",
+	"i":  "ìíîïĩīĭįıǐȉȋ",
+	"j":  "ĵǰ",
+	"k":  "ķǩ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉǹ",
+	"o":  "òóôõöøōŏőǒǫǭǿȍȏȫȭȯȱ",
+	"r":  "ŕŗřȑȓ",
+	"s":  "śŝşšș",
+	"t":  "ţťŧț",
+	"u":  "ùúûüũūŭůűųǔǖǘǚǜȕȗ",
+	"w":  "ŵ",
+	"y":  "ýÿŷȳ",
+	"z":  "źżžƶ",
+	"ae": "æǣǽ",
+	"oe": "œ",
+	"ss": "ß",
+	"th": "þ",
+	"dh": "ð",
+	"fi": "ﬁ",
+	"fl": "ﬂ",
+}
+
+// foldTable maps each lowercase accented rune to its ASCII replacement
+var foldTable = buildFoldTable()
+
+// buildFoldTable expands diacriticFolds into a rune lookup table
+func buildFoldTable() map[rune]string {
+	table := make(map[rune]string, 256)
+	for base, variants := range diacriticFolds {
+		for _, r := range variants {
+			table[r] = base
+		}
+	}
+	return table
+}
+
+// NormalizeDriverName returns the search key for a driver name.
+// It case-folds the name, strips diacritics (so "Jürgen Ødegård" and
+// "jurgen odegard" share a key), maps full-width characters to ASCII and
+// collapses whitespace. The original display name is kept in DriverResult.Name.
+func NormalizeDriverName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	for _, r := range name {
+		// Combining marks (already-decomposed input) carry no base letter
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		// Full-width ASCII variants (U+FF01-U+FF5E) fold to plain ASCII
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+
+		r = unicode.ToLower(r)
+		if folded, ok := foldTable[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	// Collapse runs of whitespace and trim the ends
+	key := strings.Join(strings.Fields(b.String()), " ")
+	if key == "" {
+		// Names made only of marks/spaces keep a plain lowercase key
+		return strings.ToLower(name)
+	}
+	return key
+}
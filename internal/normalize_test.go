@@ -0,0 +1,23 @@
+package internal
+
+import "testing"
+
+func TestNormalizeDriverName(t *testing.T) {
+	tests := map[string]string{
+		"Ludo  Flender ":   "ludo flender",
+		"Jürgen Ødegård":   "jurgen odegard",
+		"Jose\u0301 Silva": "jose silva", // Decomposed: e + combining acute
+		"Đorđević":         "dordevic",   // Stroke letters have no NFKD decomposition
+		"Ħamrun Ŧest":      "hamrun test",
+		"Iğdır":            "igdir",
+		"Œuvre Þór Ðan":    "oeuvre thor dhan",
+		"Straße Łukasz":    "strasse lukasz",
+		"ＡＢＣ Racer":        "abc racer",
+		"\u0301":           "\u0301", // Only marks: lowercase name kept as the key
+	}
+	for name, want := range tests {
+		if got := NormalizeDriverName(name); got != want {
+			t.Errorf("NormalizeDriverName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
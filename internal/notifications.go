@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"r3e-leaderboard/internal/notify"
+)
+
+// webhookDefaultEvents are delivered to webhooks that don't list their events
+var webhookDefaultEvents = []string{
+	EventFetchCompleted,
+	EventFetchFailedRepeatedly,
+	EventSchemaDrift,
+	EventIndexFinished,
+	EventWorldRecord,
+}
+
+// StartNotifications forwards events from the event broker to the configured webhooks and Discord channels until ctx is done
+func StartNotifications(ctx context.Context, config NotifyConfig) {
+	webhooks := make([]notify.Webhook, 0, len(config.Webhooks))
+	for i, webhook := range config.Webhooks {
+		if webhook.URL == "" {
+			notifyLog.Warnf("⚠️ Ignoring webhook %d without a URL", i+1)
+			continue
+		}
+		if webhook.Name == "" {
+			webhook.Name = webhook.URL
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	channels := make([]notify.DiscordChannel, 0, len(config.Discord))
+	for i, channel := range config.Discord {
+		if channel.URL == "" {
+			notifyLog.Warnf("⚠️ Ignoring Discord channel %d without a URL", i+1)
+			continue
+		}
+		if channel.Name == "" {
+			channel.Name = fmt.Sprintf("discord-%d", i+1)
+		}
+		channels = append(channels, channel)
+	}
+	if len(webhooks) == 0 && len(channels) == 0 {
+		return
+	}
+
+	notifier := notify.New(webhooks, notify.Options{
+		MaxAttempts:   config.MaxAttempts,
+		RetryDelay:    time.Duration(config.RetrySeconds) * time.Second,
+		Timeout:       time.Duration(config.TimeoutSeconds) * time.Second,
+		DefaultEvents: webhookDefaultEvents,
+	}, notifyLog)
+	notifier.Start(ctx)
+
+	events, unsubscribe := eventBroker.Subscribe()
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				notifier.Notify(event.Type, event.Time, event.Data)
+				postDiscord(notifier, channels, event)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	notifyLog.Infof("📣 Delivering events to %d webhook(s) and %d Discord channel(s)", len(webhooks), len(channels))
+}
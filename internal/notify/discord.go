@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Embed colors
+const (
+	DiscordColorGreen = 0x2ecc71
+	DiscordColorRed   = 0xe74c3c
+	DiscordColorGold  = 0xf1c40f
+)
+
+// DiscordChannel is a Discord channel webhook and the messages posted to it
+type DiscordChannel struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`             // https://discord.com/api/webhooks/<id>/<token>
+	SummaryOrigins []string `json:"summary_origins"` // Refresh runs summarized after they finish, e.g. ["nightly"]
+	Records        bool     `json:"records"`         // Post every new world record
+}
+
+// DiscordMessage is the body of a Discord webhook execution
+type DiscordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []DiscordEmbed `json:"embeds"`
+}
+
+// DiscordEmbed is a rich message card
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+	Timestamp   *time.Time          `json:"timestamp,omitempty"`
+}
+
+// DiscordEmbedField is a name/value row of an embed
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// PostDiscord queues a message for a Discord channel; it is retried like any webhook delivery
+func (n *Notifier) PostDiscord(channel DiscordChannel, event string, message DiscordMessage) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		n.log.Warnf("⚠️ Failed to encode Discord %s message: %v", event, err)
+		return
+	}
+	n.enqueue(Webhook{Name: channel.Name, URL: channel.URL}, event, body)
+}
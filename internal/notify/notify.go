@@ -0,0 +1,206 @@
+// Package notify delivers events to outbound webhooks with retries and HMAC signing
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Request headers set on every delivery
+const (
+	HeaderEvent     = "X-R3E-Event"     // Event type
+	HeaderDelivery  = "X-R3E-Delivery"  // Unique delivery ID, identical across retries
+	HeaderSignature = "X-R3E-Signature" // "sha256=<hex HMAC of the body>" when the webhook has a secret
+	HeaderAttempt   = "X-R3E-Attempt"   // 1 for the first attempt
+	userAgent       = "r3e-leaderboard-webhook/1"
+)
+
+// Webhook is an HTTP endpoint receiving selected events as JSON POST requests
+type Webhook struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // Event types delivered; empty uses Options.DefaultEvents, "*" matches every event
+	Secret string   `json:"secret"` // Signs each body with HMAC-SHA256 when set
+}
+
+// Options controls delivery
+type Options struct {
+	MaxAttempts   int           // Attempts per delivery, including the first
+	RetryDelay    time.Duration // Wait before the first retry, doubled after each failure
+	Timeout       time.Duration // Per request
+	QueueSize     int           // Pending deliveries; events are dropped when the queue is full
+	DefaultEvents []string      // Events delivered to webhooks without an event filter
+}
+
+// Logger is the logging a Notifier needs
+type Logger interface {
+	Warnf(format string, args ...any)
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// delivery is one payload queued for one webhook
+type delivery struct {
+	id      string
+	webhook Webhook
+	body    []byte
+	event   string
+}
+
+// Notifier fans events out to webhooks from a single background worker
+type Notifier struct {
+	webhooks []Webhook
+	options  Options
+	client   *http.Client
+	queue    chan delivery
+	log      Logger
+	sequence atomic.Uint64
+}
+
+// New creates a notifier; call Start before Notify
+func New(webhooks []Webhook, options Options, log Logger) *Notifier {
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	if options.QueueSize < 1 {
+		options.QueueSize = 100
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	return &Notifier{
+		webhooks: webhooks,
+		options:  options,
+		client:   &http.Client{Timeout: options.Timeout},
+		queue:    make(chan delivery, options.QueueSize),
+		log:      log,
+	}
+}
+
+// Start delivers queued events until ctx is done
+func (n *Notifier) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case d := <-n.queue:
+				n.deliver(ctx, d)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Notify queues an event for every webhook subscribed to it without blocking
+func (n *Notifier) Notify(event string, at time.Time, data interface{}) {
+	var body []byte
+	for _, webhook := range n.webhooks {
+		if !n.wants(webhook, event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(Payload{Event: event, Time: at, Data: data}); err != nil {
+				n.log.Warnf("⚠️ Failed to encode %s webhook payload: %v", event, err)
+				return
+			}
+		}
+		n.enqueue(webhook, event, body)
+	}
+}
+
+// enqueue queues a body for a webhook, dropping it when the queue is full
+func (n *Notifier) enqueue(webhook Webhook, event string, body []byte) {
+	d := delivery{id: fmt.Sprintf("%d-%d", time.Now().UnixNano(), n.sequence.Add(1)), webhook: webhook, body: body, event: event}
+	select {
+	case n.queue <- d:
+	default:
+		n.log.Warnf("⚠️ Webhook queue full, dropping %s event for %s", event, webhook.Name)
+	}
+}
+
+// wants reports whether a webhook is subscribed to an event
+func (n *Notifier) wants(webhook Webhook, event string) bool {
+	filter := webhook.Events
+	if len(filter) == 0 {
+		filter = n.options.DefaultEvents
+	}
+	for _, wanted := range filter {
+		if wanted == "*" || wanted == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver posts a delivery, retrying with exponential backoff
+func (n *Notifier) deliver(ctx context.Context, d delivery) {
+	delay := n.options.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := n.post(ctx, d, attempt)
+		if err == nil {
+			return
+		}
+		if attempt >= n.options.MaxAttempts {
+			n.log.Warnf("⚠️ Webhook %s: giving up on %s after %d attempt(s): %v", d.webhook.Name, d.event, attempt, err)
+			return
+		}
+		n.log.Warnf("⚠️ Webhook %s: %s attempt %d failed: %v (retrying in %v)", d.webhook.Name, d.event, attempt, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay *= 2
+	}
+}
+
+// post sends one attempt of a delivery; any non-2xx response is an error
+func (n *Notifier) post(ctx context.Context, d delivery, attempt int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhook.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, d.event)
+	req.Header.Set(HeaderDelivery, d.id)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(attempt))
+	if d.webhook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(d.webhook.Secret, d.body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of body: "sha256=" + hex HMAC-SHA256 with secret
+// Receivers recompute it over the raw request body and compare with hmac.Equal
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
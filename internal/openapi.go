@@ -0,0 +1,439 @@
+package internal
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIParam is a query or path parameter of an operation
+type openAPIParam struct {
+	name        string
+	in          string // "query" (default) or "path"
+	kind        string // OpenAPI type; "string" when empty
+	required    bool
+	description string
+	enum        []string
+}
+
+// openAPIOperation describes one method of a route for the OpenAPI document
+type openAPIOperation struct {
+	path              string // Below the versioned prefix, with {placeholders}
+	method            string
+	id                string
+	tag               string
+	summary           string
+	params            []openAPIParam
+	body              interface{} // Zero value of the JSON request body, if any
+	bodyOptional      bool        // The request body may be omitted
+	status            int         // Success status; 200 when zero
+	response          interface{} // Zero value of the JSON response body; nil for no body
+	alternative       interface{} // Zero value of a second possible response body
+	alternativeStatus int         // Status of the alternative body when it differs from status
+	contentType       string      // Non-JSON success body (CSV, JSON Lines, SSE, pprof)
+	raw               bool        // Not wrapped in the envelope
+	admin             bool        // Requires the admin token
+}
+
+// wrap puts a response schema in the envelope unless the operation is raw
+func (op openAPIOperation) wrap(schema map[string]interface{}) map[string]interface{} {
+	if op.raw {
+		return schema
+	}
+	return envelopeSchema(schema)
+}
+
+// openAPIOperations lists every endpoint of the API
+// Keep in sync with routes(); the schemas come from the response structs themselves
+func openAPIOperations() []openAPIOperation {
+	trackClass := []openAPIParam{
+		{name: "track", required: true, description: "Track layout ID"},
+		{name: "class", required: true, description: "Car class ID"},
+	}
+	limit := func(def, max int) openAPIParam {
+		return openAPIParam{name: "limit", kind: "integer", description: "Maximum results (default " + strconv.Itoa(def) + ", max " + strconv.Itoa(max) + ")"}
+	}
+	csvFormat := openAPIParam{name: "format", enum: []string{"json", "csv"}, description: "csv streams the results as a CSV attachment instead"}
+	resultFilters := []openAPIParam{
+		{name: "track", description: "Only these track layout IDs (comma-separated)"},
+		{name: "class", description: "Only these car class IDs or names (comma-separated)"},
+		{name: "country", description: "Only results set from these countries, by name or ISO code (comma-separated)"},
+		{name: "car", description: "Only cars whose name contains one of these values (comma-separated)"},
+		{name: "team", description: "Only teams whose name contains one of these values (comma-separated)"},
+		{name: "rank", description: "Only these RaceRoom ranks (comma-separated)"},
+		{name: "difficulty", description: "Only these driving models, e.g. Get Real (comma-separated)"},
+	}
+
+	return []openAPIOperation{
+		{path: "/drivers", method: http.MethodGet, id: "autocompleteDrivers", tag: "drivers", summary: "Driver name autocomplete",
+			params:   []openAPIParam{{name: "prefix", required: true, description: "Start of the driver name (case- and accent-insensitive)"}, limit(defaultAutocompleteLimit, maxAutocompleteLimit)},
+			response: DriverSuggestionsResponse{}},
+		{path: "/driver", method: http.MethodGet, id: "getDriver", tag: "drivers", summary: "Aggregated driver profile",
+			params: append(append([]openAPIParam{{name: "name", required: true, description: "Driver name"}}, resultFilters...),
+				csvFormat),
+			response: DriverProfile{}},
+		{path: "/search", method: http.MethodGet, id: "searchResults", tag: "drivers", summary: "Structured search over every indexed result",
+			params: []openAPIParam{
+				{name: "q", description: `Query such as driver:"max" country:DE class:1703 rank:A; keys: driver, driver_id, track, class, country, car, team, rank, difficulty`},
+				{name: "driver_id", description: "RaceRoom user IDs (comma-separated); q is optional with it"},
+				limit(defaultSearchLimit, maxSearchLimit)},
+			response: SearchResponse{}},
+		{path: "/search/batch", method: http.MethodPost, id: "searchBatch", tag: "drivers", summary: "Results of up to 50 drivers, grouped by driver",
+			params: resultFilters, body: BatchSearchRequest{}, response: BatchSearchResponse{}},
+		{path: "/index/shards", method: http.MethodGet, id: "getIndexShards", tag: "drivers", summary: "Manifest of the per-letter driver index shards",
+			params:   []openAPIParam{{name: "driver", description: "Also name the shard holding this driver"}},
+			response: IndexShardsResponse{}},
+		{path: "/rivals", method: http.MethodGet, id: "getRivals", tag: "drivers", summary: "Drivers just faster and slower than a driver on each of their combinations",
+			params: []openAPIParam{
+				{name: "driver", required: true, description: "Driver name"},
+				{name: "track", description: "Only this track layout ID"},
+				{name: "class", description: "Only this car class ID"},
+				{name: "count", kind: "integer", description: "Rivals per side (default " + strconv.Itoa(defaultRivalsCount) + ", max " + strconv.Itoa(maxRivalsCount) + ")"}},
+			response: RivalsResponse{}},
+		{path: "/tracks", method: http.MethodGet, id: "listTracks", tag: "catalog", summary: "Configured tracks with cache and index statistics",
+			response: TrackListResponse{}},
+		{path: "/classes", method: http.MethodGet, id: "listClasses", tag: "catalog", summary: "Configured car classes with index statistics",
+			response: ClassListResponse{}},
+		{path: "/country", method: http.MethodGet, id: "getCountryRanking", tag: "rankings", summary: "Fastest drivers of a country per combination",
+			params:   []openAPIParam{{name: "code", description: "ISO country code"}, {name: "name", description: "Country name (when no code is given)"}},
+			response: CountryRanking{}},
+		{path: "/team", method: http.MethodGet, id: "getTeam", tag: "rankings", summary: "Drivers and results of a team",
+			params:   []openAPIParam{{name: "name", required: true, description: "Team name"}},
+			response: TeamDetail{}},
+		{path: "/teams", method: http.MethodGet, id: "listTeams", tag: "rankings", summary: "Teams by entry count",
+			params:   []openAPIParam{limit(defaultTeamsLimit, maxTeamsLimit)},
+			response: TeamListResponse{}},
+		{path: "/status", method: http.MethodGet, id: "getStatus", tag: "status", summary: "Fetch and index status",
+			response: StatusData{}},
+		{path: "/top-combinations", method: http.MethodGet, id: "listTopCombinations", tag: "leaderboards", summary: "Combinations with the most entries",
+			params:   []openAPIParam{limit(defaultTopCombinationsLimit, maxTopCombinationsLimit)},
+			response: TopCombinationsResponse{}},
+		{path: "/stats/combination", method: http.MethodGet, id: "getCombinationStats", tag: "leaderboards", summary: "Lap time distribution of a combination: percentiles, histogram and the time needed for the top N%",
+			params:   trackClass,
+			response: CombinationStatistics{}},
+		{path: "/leaderboard", method: http.MethodGet, id: "getLeaderboard", tag: "leaderboards", summary: "Page of one combination's leaderboard (202 with a job when fetched on demand)",
+			params: append(append([]openAPIParam{}, trackClass...),
+				limit(defaultLeaderboardLimit, maxLeaderboardLimit),
+				openAPIParam{name: "offset", kind: "integer", description: "Entries to skip"},
+				openAPIParam{name: "sort", enum: []string{SortByPosition, SortByLapTime, SortByCountry, SortByName}},
+				openAPIParam{name: "order", enum: []string{"asc", "desc"}},
+				csvFormat),
+			response: LeaderboardResponse{}},
+		{path: "/snapshots", method: http.MethodGet, id: "listSnapshots", tag: "leaderboards", summary: "Archived versions of a combination; with driver, their position in each",
+			params:   append(append([]openAPIParam{}, trackClass...), openAPIParam{name: "driver", description: "Driver name"}),
+			response: SnapshotListResponse{}, alternative: SnapshotHistoryResponse{}},
+		{path: "/changes", method: http.MethodGet, id: "getChanges", tag: "leaderboards", summary: "Changes between the two newest snapshots of a combination",
+			params:   append(append([]openAPIParam{}, trackClass...), limit(defaultChangesLimit, maxChangesLimit)),
+			response: ChangesResponse{}},
+		{path: "/movers", method: http.MethodGet, id: "listMovers", tag: "rankings", summary: "Drivers who gained the most positions or lap time since the last refresh, globally and per class",
+			params: []openAPIParam{
+				{name: "class", description: "Car class ID (only that class, without the per-class lists)"},
+				{name: "since", description: "RFC 3339 time (default: start of the last refresh)"},
+				limit(defaultMoversLimit, maxMoversLimit)},
+			response: MoversFeed{}},
+		{path: "/championship", method: http.MethodGet, id: "getChampionship", tag: "rankings", summary: "Virtual championship standings: leaderboard positions scored with a points table across combinations",
+			params: []openAPIParam{
+				{name: "tracks", required: true, description: "Comma-separated track layout IDs"},
+				{name: "classes", required: true, description: "Comma-separated car class IDs; every track is scored in every class"},
+				{name: "points", description: "Comma-separated points by position, P1 first (default: championship.points)"},
+				limit(defaultChampionshipLimit, maxChampionshipLimit)},
+			response: Championship{}},
+		{path: "/export/entries.jsonl", method: http.MethodGet, id: "exportEntries", tag: "exports", summary: "Every indexed entry as JSON Lines (EntryRecord per line)",
+			contentType: "application/x-ndjson", raw: true},
+		{path: "/graphql", method: http.MethodPost, id: "graphql", tag: "graphql", summary: "Read-only GraphQL query",
+			body: graphQLRequest{}, response: map[string]interface{}{}, raw: true},
+		{path: "/jobs/{id}", method: http.MethodGet, id: "getJob", tag: "jobs", summary: "State of a background job",
+			params:   []openAPIParam{{name: "id", in: "path", required: true}},
+			response: Job{}},
+		{path: "/jobs/{id}/cancel", method: http.MethodPost, id: "cancelJob", tag: "jobs", summary: "Cancel a queued or running job",
+			params: []openAPIParam{{name: "id", in: "path", required: true}},
+			status: http.StatusAccepted, response: Job{}, admin: true},
+		{path: "/refresh", method: http.MethodPost, id: "refresh", tag: "admin", summary: "Queue a full or targeted refresh",
+			params: []openAPIParam{
+				{name: "tracks", description: "Comma-separated track IDs or trackID-classID pairs; empty refreshes everything"},
+				{name: "dry_run", kind: "boolean", description: "Return the combinations that would be fetched and the estimated duration instead of queueing"},
+			},
+			body: RefreshRequest{}, bodyOptional: true, status: http.StatusAccepted, response: JobAcceptedResponse{},
+			alternative: RefreshPlan{}, alternativeStatus: http.StatusOK, admin: true},
+		{path: "/fetch/pause", method: http.MethodPost, id: "pauseFetching", tag: "admin", summary: "Pause all fetching",
+			response: FetchPauseResponse{}, admin: true},
+		{path: "/fetch/resume", method: http.MethodPost, id: "resumeFetching", tag: "admin", summary: "Resume fetching",
+			response: FetchPauseResponse{}, admin: true},
+		{path: "/fetch/failed", method: http.MethodGet, id: "failedFetches", tag: "admin", summary: "List combinations whose last fetch failed",
+			response: FailedFetchQueueResponse{}, admin: true},
+		{path: "/fetch/failed/retry", method: http.MethodPost, id: "retryFailedFetches", tag: "admin", summary: "Queue a refresh of the failed combinations",
+			status: http.StatusAccepted, response: JobAcceptedResponse{}, alternative: FailedFetchQueueResponse{}, alternativeStatus: http.StatusOK, admin: true},
+		{path: "/catalog/reload", method: http.MethodPost, id: "reloadCatalogs", tag: "admin", summary: "Reload tracks.json and classes.json",
+			response: CatalogInfo{}, admin: true},
+		{path: "/cache/validate", method: http.MethodPost, id: "validateCache", tag: "admin", summary: "Check every cache file for corrupt gzip or JSON",
+			params: []openAPIParam{
+				{name: "delete", kind: "boolean", description: "Delete broken files so the next refresh refetches them"},
+				{name: "all", kind: "boolean", description: "List valid files too"},
+			},
+			response: CacheValidation{}, admin: true},
+		{path: "/reports/generate", method: http.MethodPost, id: "generateReport", tag: "admin", summary: "Write a summary report now",
+			params:   []openAPIParam{{name: "period", enum: []string{ReportDaily, ReportWeekly}}},
+			response: Report{}, admin: true},
+		{path: "/events", method: http.MethodGet, id: "streamEvents", tag: "live", summary: "Server-Sent Events of fetch, cache and index events",
+			contentType: "text/event-stream", raw: true},
+		{path: "/ws", method: http.MethodGet, id: "webSocket", tag: "live", summary: "WebSocket live search and status updates (WSRequest messages)",
+			status: http.StatusSwitchingProtocols, raw: true},
+		{path: "/keys", method: http.MethodGet, id: "listAPIKeys", tag: "admin", summary: "API keys with usage",
+			response: APIKeyListResponse{}, admin: true},
+		{path: "/keys", method: http.MethodPost, id: "issueAPIKey", tag: "admin", summary: "Issue an API key",
+			params: []openAPIParam{{name: "name", required: true}, {name: "rate_limit", kind: "integer", description: "Requests per window (0 uses the default)"}},
+			status: http.StatusCreated, response: APIKeyIssuedResponse{}, admin: true},
+		{path: "/keys", method: http.MethodDelete, id: "revokeAPIKey", tag: "admin", summary: "Revoke an API key",
+			params: []openAPIParam{{name: "id", required: true, description: "Visible key prefix"}},
+			status: http.StatusNoContent, admin: true},
+		{path: "/aliases", method: http.MethodGet, id: "listAliases", tag: "drivers", summary: "Driver alias table",
+			response: AliasListResponse{}},
+		{path: "/aliases", method: http.MethodPost, id: "setAlias", tag: "admin", summary: "Create or replace the alias of a canonical driver name",
+			body: DriverAlias{}, response: DriverAlias{}, admin: true},
+		{path: "/aliases", method: http.MethodDelete, id: "deleteAlias", tag: "admin", summary: "Remove the alias of a canonical driver name",
+			params: []openAPIParam{{name: "canonical", required: true, description: "Canonical driver name"}},
+			status: http.StatusNoContent, admin: true},
+		{path: "/exclusions", method: http.MethodGet, id: "listExclusions", tag: "admin", summary: "Drivers removed from indexes, exports and responses",
+			response: ExclusionListResponse{}, admin: true},
+		{path: "/exclusions", method: http.MethodPost, id: "addExclusion", tag: "admin", summary: "Remove a driver by name or RaceRoom user ID",
+			body: DriverExclusion{}, response: DriverExclusion{}, status: http.StatusCreated, admin: true},
+		{path: "/exclusions", method: http.MethodDelete, id: "deleteExclusion", tag: "admin", summary: "Lift a driver exclusion",
+			params: []openAPIParam{{name: "name", description: "Excluded driver name"}, {name: "driver_id", description: "Excluded RaceRoom user ID"}},
+			status: http.StatusNoContent, admin: true},
+		{path: "/usage", method: http.MethodGet, id: "getUsage", tag: "status", summary: "Usage of the calling API key",
+			response: UsageResponse{}},
+		{path: "/debug/runtime", method: http.MethodGet, id: "getRuntimeStats", tag: "admin", summary: "Goroutine, memory and GC statistics",
+			response: RuntimeStats{}, admin: true},
+		{path: "/debug/runtime", method: http.MethodPost, id: "runtimeAction", tag: "admin", summary: "Force a GC or write a heap profile",
+			params:   []openAPIParam{{name: "action", required: true, enum: []string{"gc", "heap_profile"}}},
+			response: RuntimeStats{}, alternative: HeapProfileResponse{}, admin: true},
+		{path: "/debug/pprof/{profile}", method: http.MethodGet, id: "getProfile", tag: "admin", summary: "net/http/pprof profiles",
+			params:      []openAPIParam{{name: "profile", in: "path", required: true}},
+			contentType: "application/octet-stream", raw: true, admin: true},
+	}
+}
+
+// openAPISchemas collects the component schemas of the named struct types it has seen
+type openAPISchemas map[string]interface{}
+
+// schemaOf returns the schema of a Go type as encoding/json would write it;
+// named structs become components referenced with $ref
+func (schemas openAPISchemas) schemaOf(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := schemas.schemaOf(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemas.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemas.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			return schemas.objectSchema(t)
+		}
+		if _, seen := schemas[t.Name()]; !seen {
+			schemas[t.Name()] = nil // Placeholder for recursive types
+			schemas[t.Name()] = schemas.objectSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			return map[string]interface{}{"type": "integer", "format": "int64"}
+		}
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{} // interface{}: any value
+}
+
+// objectSchema describes the exported fields of a struct by their JSON names, flattening embedded structs
+// Fields without omitempty are required
+func (schemas openAPISchemas) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if _, shadowed := properties[name]; shadowed {
+				continue
+			}
+			properties[name] = schemas.schemaOf(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// envelopeSchema wraps a data schema in the versioned response envelope
+func envelopeSchema(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"data", "meta", "error"},
+		"properties": map[string]interface{}{
+			"data":  data,
+			"meta":  map[string]interface{}{"$ref": "#/components/schemas/EnvelopeMeta"},
+			"error": map[string]interface{}{"allOf": []interface{}{map[string]interface{}{"$ref": "#/components/schemas/EnvelopeError"}}, "nullable": true},
+		},
+	}
+}
+
+// BuildOpenAPI returns the OpenAPI 3 document of the versioned API
+func (s *APIServer) BuildOpenAPI() map[string]interface{} {
+	schemas := openAPISchemas{}
+	schemas.schemaOf(reflect.TypeOf(EnvelopeMeta{}))
+	schemas.schemaOf(reflect.TypeOf(EnvelopeError{}))
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": envelopeSchema(map[string]interface{}{"nullable": true})},
+		},
+	}
+
+	paths := make(map[string]interface{})
+	for _, op := range openAPIOperations() {
+		operation := map[string]interface{}{
+			"operationId": op.id,
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+		}
+		if op.admin {
+			operation["security"] = []interface{}{map[string]interface{}{"AdminToken": []string{}}}
+		}
+
+		var params []interface{}
+		for _, param := range op.params {
+			schema := map[string]interface{}{"type": "string"}
+			if param.kind != "" {
+				schema["type"] = param.kind
+			}
+			if len(param.enum) > 0 {
+				schema["enum"] = param.enum
+			}
+			in := param.in
+			if in == "" {
+				in = "query"
+			}
+			parameter := map[string]interface{}{"name": param.name, "in": in, "required": param.required, "schema": schema}
+			if param.description != "" {
+				parameter["description"] = param.description
+			}
+			params = append(params, parameter)
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": !op.bodyOptional,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(op.body))},
+				},
+			}
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses := map[string]interface{}{"default": errorResponse}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		switch {
+		case op.contentType != "":
+			success["content"] = map[string]interface{}{op.contentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		case op.response != nil:
+			schema := schemas.schemaOf(reflect.TypeOf(op.response))
+			if op.alternative != nil && op.alternativeStatus == 0 {
+				schema = map[string]interface{}{"oneOf": []interface{}{schema, schemas.schemaOf(reflect.TypeOf(op.alternative))}}
+			}
+			content := map[string]interface{}{"application/json": map[string]interface{}{"schema": op.wrap(schema)}}
+			for _, param := range op.params {
+				if param.name == "format" {
+					content["text/csv"] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+				}
+			}
+			success["content"] = content
+		}
+		responses[strconv.Itoa(status)] = success
+		if op.alternative != nil && op.alternativeStatus != 0 {
+			schema := op.wrap(schemas.schemaOf(reflect.TypeOf(op.alternative)))
+			responses[strconv.Itoa(op.alternativeStatus)] = map[string]interface{}{
+				"description": http.StatusText(op.alternativeStatus),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+			}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[op.path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "R3E Leaderboard API",
+			"version":     APIVersion,
+			"description": "JSON API over the indexed RaceRoom leaderboards. Responses are wrapped in an envelope (data, meta, error) except for streams and GraphQL. The unversioned paths below " + s.prefix + "/ are deprecated aliases returning the bare data.",
+		},
+		"servers":  []interface{}{map[string]interface{}{"url": s.prefix + "/" + APIVersion}},
+		"security": []interface{}{map[string]interface{}{}, map[string]interface{}{"ApiKey": []string{}}},
+		"paths":    paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"ApiKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"AdminToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// HandleOpenAPI serves the OpenAPI document: /api/openapi.json
+func (s *APIServer) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.BuildOpenAPI())
+}
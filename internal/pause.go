@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PauseFetchFile pauses all RaceRoom fetching while it exists (created by /api/fetch/pause or by hand)
+const PauseFetchFile = "cache/pause_fetch"
+
+// pauseCheckInterval is how often a paused fetch loop checks whether it may continue
+const pauseCheckInterval = 5 * time.Second
+
+// FetchPaused reports whether fetching is paused and since when
+func FetchPaused() (bool, time.Time) {
+	info, err := os.Stat(PauseFetchFile)
+	if err != nil {
+		return false, time.Time{}
+	}
+	return true, info.ModTime()
+}
+
+// PauseFetching creates the pause sentinel file; fetch loops block before their next request
+func PauseFetching() error {
+	if paused, _ := FetchPaused(); paused {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(PauseFetchFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(PauseFetchFile, nil, 0644); err != nil {
+		return err
+	}
+	loaderLog.Infof("⏸️ Fetching paused (%s created)", PauseFetchFile)
+	return nil
+}
+
+// ResumeFetching removes the pause sentinel file
+func ResumeFetching() error {
+	if err := os.Remove(PauseFetchFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	loaderLog.Infof("▶️ Fetching resumed")
+	return nil
+}
+
+// waitWhilePaused blocks while the pause file exists, keeping the caller's loop position
+// Returns ctx.Err() if the context is cancelled while paused
+func waitWhilePaused(ctx context.Context) error {
+	paused, _ := FetchPaused()
+	if !paused {
+		return nil
+	}
+
+	loaderLog.Infof("⏸️ Fetch paused - waiting for %s to be removed", PauseFetchFile)
+
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if paused, _ := FetchPaused(); !paused {
+				loaderLog.Infof("▶️ Fetch continuing after pause")
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Refresh tiers of the popularity-weighted scheduler
+const (
+	RefreshTierHot    = "hot"    // Top combinations by entries or leaderboard requests
+	RefreshTierNormal = "normal" // Populated combinations
+	RefreshTierCold   = "cold"   // Combinations without entries
+)
+
+// combinationRequests counts /api/leaderboard requests per combination since startup
+var combinationRequests = struct {
+	mu     sync.Mutex
+	counts map[string]int
+	last   map[string]time.Time // Time of the latest request, for cache eviction
+}{counts: make(map[string]int), last: make(map[string]time.Time)}
+
+// RecordCombinationRequest counts a leaderboard request for the popularity-weighted scheduler
+func RecordCombinationRequest(trackID, classID string) {
+	combinationRequests.mu.Lock()
+	defer combinationRequests.mu.Unlock()
+	key := trackID + "_" + classID
+	combinationRequests.counts[key]++
+	combinationRequests.last[key] = time.Now()
+}
+
+// lastCombinationRequests returns when each requested combination ("trackID_classID") was last requested
+func lastCombinationRequests() map[string]time.Time {
+	combinationRequests.mu.Lock()
+	defer combinationRequests.mu.Unlock()
+	last := make(map[string]time.Time, len(combinationRequests.last))
+	for key, at := range combinationRequests.last {
+		last[key] = at
+	}
+	return last
+}
+
+// forgetCombinationRequests drops the request counts of combinations ("trackID_classID")
+func forgetCombinationRequests(keys []string) {
+	combinationRequests.mu.Lock()
+	defer combinationRequests.mu.Unlock()
+	for _, key := range keys {
+		delete(combinationRequests.counts, key)
+		delete(combinationRequests.last, key)
+	}
+}
+
+// mostRequestedCombinations returns the keys ("trackID_classID") of the n most requested combinations
+func mostRequestedCombinations(n int) []string {
+	combinationRequests.mu.Lock()
+	keys := make([]string, 0, len(combinationRequests.counts))
+	counts := make(map[string]int, len(combinationRequests.counts))
+	for key, count := range combinationRequests.counts {
+		keys = append(keys, key)
+		counts[key] = count
+	}
+	combinationRequests.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// hotCombinations returns the combinations refreshed on the hot interval: the top
+// HotCount of top_combinations.json plus the HotCount most requested leaderboards
+func hotCombinations(config PopularityConfig) map[string]bool {
+	hot := make(map[string]bool)
+	if top, err := ReadTopCombinations(); err == nil {
+		for i, combo := range top.Results {
+			if i >= config.HotCount {
+				break
+			}
+			hot[combo.TrackID+"_"+combo.ClassID] = true
+		}
+	}
+	for _, key := range mostRequestedCombinations(config.HotCount) {
+		hot[key] = true
+	}
+	return hot
+}
+
+// Normalized replaces non-positive settings with their defaults
+func (c PopularityConfig) Normalized() PopularityConfig {
+	defaults := GetDefaultConfig().Schedule.Popularity
+	if c.HotCount < 1 {
+		c.HotCount = defaults.HotCount
+	}
+	if c.HotIntervalHours < 1 {
+		c.HotIntervalHours = defaults.HotIntervalHours
+	}
+	if c.NormalIntervalHours < 1 {
+		c.NormalIntervalHours = defaults.NormalIntervalHours
+	}
+	if c.ColdIntervalHours < 1 {
+		c.ColdIntervalHours = defaults.ColdIntervalHours
+	}
+	if c.CheckMinutes < 1 {
+		c.CheckMinutes = defaults.CheckMinutes
+	}
+	if c.BatchSize < 1 {
+		c.BatchSize = defaults.BatchSize
+	}
+	return c
+}
+
+// ScheduledCombination is a combination waiting in the refresh queue
+type ScheduledCombination struct {
+	TrackID string
+	ClassID string
+	Tier    string
+	Due     time.Time
+}
+
+// Token returns the "trackID-classID" token understood by targeted refreshes
+func (c ScheduledCombination) Token() string {
+	return c.TrackID + "-" + c.ClassID
+}
+
+// RefreshQueue is a priority queue of combinations ordered by due time (hot first on ties)
+type RefreshQueue struct {
+	items refreshHeap
+}
+
+// refreshHeap implements heap.Interface for RefreshQueue
+type refreshHeap []ScheduledCombination
+
+func (h refreshHeap) Len() int { return len(h) }
+func (h refreshHeap) Less(i, j int) bool {
+	if !h[i].Due.Equal(h[j].Due) {
+		return h[i].Due.Before(h[j].Due)
+	}
+	return tierRank(h[i].Tier) < tierRank(h[j].Tier)
+}
+func (h refreshHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *refreshHeap) Push(x any)   { *h = append(*h, x.(ScheduledCombination)) }
+func (h *refreshHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// tierRank orders tiers by priority
+func tierRank(tier string) int {
+	switch tier {
+	case RefreshTierHot:
+		return 0
+	case RefreshTierNormal:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// tierInterval returns how often combinations of a tier are refreshed
+func tierInterval(config PopularityConfig, tier string) time.Duration {
+	switch tier {
+	case RefreshTierHot:
+		return time.Duration(config.HotIntervalHours) * time.Hour
+	case RefreshTierNormal:
+		return time.Duration(config.NormalIntervalHours) * time.Hour
+	default:
+		return time.Duration(config.ColdIntervalHours) * time.Hour
+	}
+}
+
+// BuildRefreshQueue schedules every configured combination by tier and cache age
+// populated holds the keys ("trackID_classID") of combinations with entries; the others are cold.
+// Uncached combinations are due immediately
+func BuildRefreshQueue(config PopularityConfig, populated map[string]bool) *RefreshQueue {
+	dataCache := NewDataCache()
+	hot := hotCombinations(config)
+	now := time.Now()
+
+	queue := &RefreshQueue{}
+	for _, track := range GetTracks() {
+		for _, class := range GetCarClasses() {
+			key := track.TrackID + "_" + class.ClassID
+			tier := RefreshTierCold
+			if hot[key] {
+				tier = RefreshTierHot
+			} else if populated[key] {
+				tier = RefreshTierNormal
+			}
+
+			due := now
+			if age := dataCache.GetCacheAge(track.TrackID, class.ClassID); age >= 0 {
+				due = now.Add(tierInterval(config, tier) - age)
+			}
+			queue.items = append(queue.items, ScheduledCombination{TrackID: track.TrackID, ClassID: class.ClassID, Tier: tier, Due: due})
+		}
+	}
+	heap.Init(&queue.items)
+	return queue
+}
+
+// Len returns the number of queued combinations
+func (q *RefreshQueue) Len() int {
+	return q.items.Len()
+}
+
+// PopDue removes and returns up to limit combinations due at now, most overdue first
+func (q *RefreshQueue) PopDue(now time.Time, limit int) []ScheduledCombination {
+	due := make([]ScheduledCombination, 0)
+	for q.items.Len() > 0 && len(due) < limit && !q.items[0].Due.After(now) {
+		due = append(due, heap.Pop(&q.items).(ScheduledCombination))
+	}
+	return due
+}
+
+// NextDue returns when the next combination is due; ok is false for an empty queue
+func (q *RefreshQueue) NextDue() (time.Time, bool) {
+	if q.items.Len() == 0 {
+		return time.Time{}, false
+	}
+	return q.items[0].Due, true
+}
+
+// CountByTier returns the number of queued combinations per tier
+func (q *RefreshQueue) CountByTier() map[string]int {
+	counts := map[string]int{RefreshTierHot: 0, RefreshTierNormal: 0, RefreshTierCold: 0}
+	for _, item := range q.items {
+		counts[item.Tier]++
+	}
+	return counts
+}
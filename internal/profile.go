@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"sort"
+)
+
+const maxFavoriteClasses = 5
+
+// DriverProfile aggregates all indexed results of a single driver
+type DriverProfile struct {
+	Name              string           `json:"name"`
+	Key               string           `json:"key"`
+	Aliases           []string         `json:"aliases,omitempty"` // Other names merged into the profile (see /api/aliases)
+	TotalCombinations int              `json:"total_combinations"`
+	BestPosition      int              `json:"best_position"`
+	WorstPosition     int              `json:"worst_position"`
+	AveragePercentile float64          `json:"average_percentile"` // Mean of position/total_entries (lower is better)
+	FavoriteClasses   []ClassUsage     `json:"favorite_classes"`
+	Countries         []string         `json:"countries"`
+	Tracks            []TrackBreakdown `json:"tracks"`
+}
+
+// ClassUsage counts how many combinations a driver has set in a class
+type ClassUsage struct {
+	ClassID      string `json:"class_id"`
+	ClassName    string `json:"class_name"`
+	Combinations int    `json:"combinations"`
+}
+
+// TrackBreakdown groups a driver's results on a single track layout
+type TrackBreakdown struct {
+	TrackID           string         `json:"track_id"`
+	Track             string         `json:"track"`
+	Combinations      int            `json:"combinations"`
+	BestPosition      int            `json:"best_position"`
+	AveragePercentile float64        `json:"average_percentile"`
+	Results           []DriverResult `json:"results"`
+}
+
+// percentile returns the position as a percentage of the leaderboard size
+func percentile(result DriverResult) float64 {
+	if result.TotalEntries <= 0 {
+		return 0
+	}
+	return float64(result.Position) / float64(result.TotalEntries) * 100
+}
+
+// BuildDriverProfile aggregates a driver's results into a profile
+// Returns a zero profile when results is empty
+func BuildDriverProfile(key string, results []DriverResult) DriverProfile {
+	profile := DriverProfile{
+		Key:             key,
+		FavoriteClasses: []ClassUsage{},
+		Countries:       []string{},
+		Tracks:          []TrackBreakdown{},
+	}
+	if len(results) == 0 {
+		return profile
+	}
+
+	profile.Name = results[0].Name
+	profile.TotalCombinations = len(results)
+	profile.BestPosition = results[0].Position
+	profile.WorstPosition = results[0].Position
+
+	classCounts := make(map[string]int)
+	countries := make(map[string]bool)
+	trackIndex := make(map[string]int) // trackID -> position in profile.Tracks
+	trackPercentiles := make(map[string]float64)
+	percentileSum := 0.0
+
+	for _, result := range results {
+		if result.Position < profile.BestPosition {
+			profile.BestPosition = result.Position
+		}
+		if result.Position > profile.WorstPosition {
+			profile.WorstPosition = result.Position
+		}
+		p := percentile(result)
+		percentileSum += p
+
+		classCounts[result.ClassID]++
+		if result.Country != "" && !countries[result.Country] {
+			countries[result.Country] = true
+			profile.Countries = append(profile.Countries, result.Country)
+		}
+
+		i, exists := trackIndex[result.TrackID]
+		if !exists {
+			i = len(profile.Tracks)
+			trackIndex[result.TrackID] = i
+			profile.Tracks = append(profile.Tracks, TrackBreakdown{
+				TrackID:      result.TrackID,
+				Track:        result.Track,
+				BestPosition: result.Position,
+			})
+		}
+		breakdown := &profile.Tracks[i]
+		breakdown.Combinations++
+		breakdown.Results = append(breakdown.Results, result)
+		if result.Position < breakdown.BestPosition {
+			breakdown.BestPosition = result.Position
+		}
+		trackPercentiles[result.TrackID] += p
+	}
+
+	profile.AveragePercentile = percentileSum / float64(len(results))
+	for i := range profile.Tracks {
+		breakdown := &profile.Tracks[i]
+		breakdown.AveragePercentile = trackPercentiles[breakdown.TrackID] / float64(breakdown.Combinations)
+		sort.Slice(breakdown.Results, func(a, b int) bool {
+			return breakdown.Results[a].Position < breakdown.Results[b].Position
+		})
+	}
+	sort.Slice(profile.Tracks, func(a, b int) bool {
+		return profile.Tracks[a].Track < profile.Tracks[b].Track
+	})
+
+	// Favorite classes: most combinations first, class name as tie-breaker
+	for classID, count := range classCounts {
+		profile.FavoriteClasses = append(profile.FavoriteClasses, ClassUsage{
+			ClassID:      classID,
+			ClassName:    GetCarClassName(classID),
+			Combinations: count,
+		})
+	}
+	sort.Slice(profile.FavoriteClasses, func(a, b int) bool {
+		if profile.FavoriteClasses[a].Combinations != profile.FavoriteClasses[b].Combinations {
+			return profile.FavoriteClasses[a].Combinations > profile.FavoriteClasses[b].Combinations
+		}
+		return profile.FavoriteClasses[a].ClassName < profile.FavoriteClasses[b].ClassName
+	})
+	if len(profile.FavoriteClasses) > maxFavoriteClasses {
+		profile.FavoriteClasses = profile.FavoriteClasses[:maxFavoriteClasses]
+	}
+
+	return profile
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// FetchProgress counts the combinations handled by the current fetch run
+type FetchProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+	Failed    int `json:"failed"`
+}
+
+// FetchProgressStatus is the progress of the current (or last) fetch run with percentage and ETA
+type FetchProgressStatus struct {
+	FetchProgress
+	Origin              string     `json:"origin,omitempty"` // What started the run: startup, nightly, popularity, manual, api
+	InProgress          bool       `json:"in_progress"`
+	Percent             float64    `json:"percent"`
+	StartedAt           time.Time  `json:"started_at"`
+	FinishedAt          *time.Time `json:"finished_at,omitempty"`
+	ETASeconds          int        `json:"eta_seconds,omitempty"`
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
+	DelayMs             int64      `json:"delay_ms,omitempty"` // Current delay between fetches (see fetch.throttle)
+}
+
+// fetchProgressTracker holds the counters of the running fetch loop
+type fetchProgressTracker struct {
+	mu         sync.Mutex
+	progress   FetchProgress
+	origin     string
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// fetchProgress is updated by the refresh fetch loops
+var fetchProgress fetchProgressTracker
+
+// begin resets the counters for a run of total combinations
+func (t *fetchProgressTracker) begin(total int, origin string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress = FetchProgress{Total: total}
+	t.origin = origin
+	t.startedAt = time.Now()
+	t.finishedAt = time.Time{}
+}
+
+// finish marks the run as done (completed or cancelled) and publishes it on the event stream
+func (t *fetchProgressTracker) finish() {
+	t.mu.Lock()
+	t.finishedAt = time.Now()
+	event := FetchCompletedEvent{
+		FetchProgress: t.progress,
+		Origin:        t.origin,
+		StartedAt:     t.startedAt,
+		DurationMs:    t.finishedAt.Sub(t.startedAt).Milliseconds(),
+	}
+	t.mu.Unlock()
+
+	flushFetchStats()
+	finishSchemaCheck()
+	eventBroker.Publish(EventFetchCompleted, event)
+}
+
+// advance records one processed combination and publishes it on the event stream
+func (t *fetchProgressTracker) advance(track TrackConfig, class CarClassConfig, err error) {
+	t.mu.Lock()
+	t.progress.Processed++
+	if err != nil {
+		t.progress.Failed++
+	}
+	progress := t.progress
+	t.mu.Unlock()
+
+	eventBroker.Publish(EventFetchProgress, FetchProgressEvent{
+		FetchProgress: progress,
+		TrackID:       track.TrackID,
+		ClassID:       class.ClassID,
+		Track:         track.Name,
+		Class:         class.Name,
+	})
+	if err != nil {
+		publishError("fetch", fmt.Errorf("%s + %s: %w", track.Name, class.Name, err))
+	}
+}
+
+// CurrentFetchProgress returns the counters of the current (or last) refresh fetch
+func CurrentFetchProgress() FetchProgress {
+	fetchProgress.mu.Lock()
+	defer fetchProgress.mu.Unlock()
+	return fetchProgress.progress
+}
+
+// CurrentFetchStatus returns the current (or last) fetch run with percentage and ETA
+// The ETA extrapolates the average time per combination so far; ok is false before the first run
+func CurrentFetchStatus() (FetchProgressStatus, bool) {
+	fetchProgress.mu.Lock()
+	defer fetchProgress.mu.Unlock()
+
+	t := &fetchProgress
+	if t.startedAt.IsZero() {
+		return FetchProgressStatus{}, false
+	}
+	status := FetchProgressStatus{
+		FetchProgress: t.progress,
+		Origin:        t.origin,
+		InProgress:    t.finishedAt.IsZero(),
+		StartedAt:     t.startedAt,
+	}
+	if t.progress.Total > 0 {
+		status.Percent = math.Round(float64(t.progress.Processed)*1000/float64(t.progress.Total)) / 10
+	}
+	if !status.InProgress {
+		finished := t.finishedAt
+		status.FinishedAt = &finished
+		return status, true
+	}
+
+	status.DelayMs = FetchDelay().Milliseconds()
+	remaining := t.progress.Total - t.progress.Processed
+	if t.progress.Processed > 0 && remaining > 0 {
+		perCombination := time.Since(t.startedAt) / time.Duration(t.progress.Processed)
+		eta := perCombination * time.Duration(remaining)
+		completion := time.Now().Add(eta)
+		status.ETASeconds = int(eta.Seconds())
+		status.EstimatedCompletion = &completion
+	}
+	return status, true
+}
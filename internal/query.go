@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxBatchDrivers caps the drivers of one POST /api/search/batch
+const maxBatchDrivers = 50
+
+// BatchSearchRequest is the JSON body of POST /search/batch
+type BatchSearchRequest struct {
+	Drivers []string `json:"drivers"` // Driver names or aliases, up to 50
+}
+
+// SearchQuery is a parsed search string such as `driver:"max" country:DE class:1703 rank:A`
+type SearchQuery struct {
+	Drivers   []string // Normalized parts of the driver name; any may match
+	DriverIDs []string // RaceRoom user IDs; any may match
+	Filter    ResultFilter
+}
+
+// ParseSearchQuery parses space-separated key:value terms. Values with spaces are quoted (`difficulty:"get real"`),
+// and the words without a key together search the driver name. A key given twice, or a value listing several
+// comma-separated values, matches any of them; different keys must all match.
+// Keys: driver (or name), driver_id, track, class, country, car, team, rank, difficulty.
+func ParseSearchQuery(raw string) (SearchQuery, error) {
+	var query SearchQuery
+	terms, err := splitSearchTerms(raw)
+	if err != nil {
+		return query, err
+	}
+	var words []string
+	for _, term := range terms {
+		if term.key == "" {
+			words = append(words, term.value)
+			continue
+		}
+		key, value := strings.ToLower(term.key), term.value
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, strings.ToLower(v))
+			}
+		}
+		if len(values) == 0 {
+			return query, fmt.Errorf("empty value for %q", key)
+		}
+
+		switch key {
+		case "driver", "name":
+			for _, v := range values {
+				query.Drivers = append(query.Drivers, NormalizeDriverName(v))
+			}
+		case "driver_id":
+			query.DriverIDs = append(query.DriverIDs, values...)
+		case "track":
+			query.Filter.Track = append(query.Filter.Track, values...)
+		case "class":
+			query.Filter.Class = append(query.Filter.Class, values...)
+		case "country":
+			query.Filter.Country = append(query.Filter.Country, values...)
+		case "car":
+			query.Filter.Car = append(query.Filter.Car, values...)
+		case "team":
+			query.Filter.Team = append(query.Filter.Team, values...)
+		case "rank":
+			query.Filter.Rank = append(query.Filter.Rank, values...)
+		case "difficulty":
+			query.Filter.Difficulty = append(query.Filter.Difficulty, values...)
+		default:
+			return query, fmt.Errorf("unknown search key %q", term.key)
+		}
+	}
+	if name := NormalizeDriverName(strings.Join(words, " ")); name != "" {
+		query.Drivers = append(query.Drivers, name)
+	}
+	if len(query.Drivers) == 0 && len(query.DriverIDs) == 0 && query.Filter.IsEmpty() {
+		return query, fmt.Errorf("empty query")
+	}
+	return query, nil
+}
+
+// searchTerm is one key:value (or bare) term of a search string
+type searchTerm struct {
+	key   string
+	value string
+}
+
+// splitSearchTerms splits a search string at unquoted spaces into terms
+func splitSearchTerms(raw string) ([]searchTerm, error) {
+	var terms []searchTerm
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var term searchTerm
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != ':' && runes[i] != '"' {
+			i++
+		}
+		if i < len(runes) && runes[i] == ':' {
+			term.key = string(runes[start:i])
+			i++
+			start = i
+		}
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote in %q", string(runes[start:]))
+			}
+			term.value = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+			term.value = string(runes[start:i])
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// Match quality of a driver term against a name, best first
+const (
+	scoreExact      = 1.0  // The whole name
+	scorePrefix     = 0.75 // The start of the name
+	scoreWordPrefix = 0.5  // The start of a later word of the name
+	scoreSubstring  = 0.25 // Anywhere else
+)
+
+// SearchMatch is a search result with the match quality of its driver
+type SearchMatch struct {
+	DriverResult
+	Score float64 `json:"score"` // 1 exact name (or user ID), 0.75 name prefix, 0.5 word prefix, 0.25 substring; 0 without driver terms
+}
+
+// driverMatchScore rates how well a normalized name matches a normalized driver term; 0 when it doesn't contain it
+func driverMatchScore(name, part string) float64 {
+	switch {
+	case name == part:
+		return scoreExact
+	case strings.HasPrefix(name, part):
+		return scorePrefix
+	case strings.Contains(name, " "+part):
+		return scoreWordPrefix
+	case strings.Contains(name, part):
+		return scoreSubstring
+	}
+	return 0
+}
+
+// Search returns up to limit indexed results matching a query and the total number of matches
+// Results are grouped by driver, each driver's by position. Drivers are ranked by match quality, then by
+// their number of indexed entries, then by name; without driver terms they are in index key order
+func (se *SearchEngine) Search(query SearchQuery, limit int) ([]SearchMatch, int) {
+	type scoredDriver struct {
+		score   float64
+		entries int
+		results []DriverResult
+	}
+	var drivers []scoredDriver
+	total := 0
+
+	live := se.snapshot()
+	aliases := se.Aliases()
+	keys := live.names
+	var ids map[string]bool
+	if len(query.DriverIDs) > 0 {
+		ids = make(map[string]bool, len(query.DriverIDs))
+		seen := make(map[string]bool)
+		keys = nil
+		for _, id := range query.DriverIDs {
+			ids[id] = true
+			for _, key := range live.ids[id] {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		sort.Strings(keys)
+	} else if len(query.Drivers) > 0 {
+		if candidates, ok := live.driverCandidates(query.Drivers, aliases); ok {
+			keys = candidates
+		}
+	}
+
+	for _, key := range keys {
+		score := 0.0
+		if len(query.Drivers) > 0 {
+			canonical := aliases.canonicalKey(key)
+			for _, part := range query.Drivers {
+				score = max(score, driverMatchScore(key, part), driverMatchScore(canonical, part))
+			}
+			if score == 0 {
+				continue
+			}
+		} else if ids != nil {
+			score = scoreExact
+		}
+		driver := query.Filter.Apply(live.index[key])
+		if ids != nil {
+			var matching []DriverResult
+			for _, result := range driver {
+				if ids[result.DriverID] {
+					matching = append(matching, result)
+				}
+			}
+			driver = matching
+		}
+		if len(driver) == 0 {
+			continue
+		}
+		total += len(driver)
+		drivers = append(drivers, scoredDriver{score: score, entries: len(live.index[key]), results: driver})
+	}
+
+	// Keys are already sorted, so the stable sort breaks the remaining ties by name
+	if len(query.Drivers) > 0 {
+		sort.SliceStable(drivers, func(i, j int) bool {
+			if drivers[i].score != drivers[j].score {
+				return drivers[i].score > drivers[j].score
+			}
+			return drivers[i].entries > drivers[j].entries
+		})
+	}
+	matches := []SearchMatch{}
+	for _, driver := range drivers {
+		room := limit - len(matches)
+		if room <= 0 {
+			break
+		}
+		results := append([]DriverResult(nil), driver.results...)
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Position < results[j].Position })
+		for _, result := range results[:min(room, len(results))] {
+			matches = append(matches, SearchMatch{DriverResult: result, Score: driver.score})
+		}
+	}
+	return matches, total
+}
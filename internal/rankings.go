@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// CountrySummary holds country-level statistics
+type CountrySummary struct {
+	Drivers       int `json:"drivers"`
+	Entries       int `json:"entries"`
+	Wins          int `json:"wins"`
+	Top10Finishes int `json:"top10_finishes"`
+}
+
+// CountryBest is the fastest driver of a country on one track/class combination
+type CountryBest struct {
+	TrackID      string `json:"track_id"`
+	Track        string `json:"track"`
+	ClassID      string `json:"class_id"`
+	ClassName    string `json:"class_name"`
+	Driver       string `json:"driver"`
+	Position     int    `json:"position"`
+	LapTime      string `json:"laptime"`
+	TotalEntries int    `json:"total_entries"`
+}
+
+// CountryRanking is the response for a country query
+type CountryRanking struct {
+	Country      string         `json:"country"`
+	CountryCode  string         `json:"country_code,omitempty"`
+	Summary      CountrySummary `json:"summary"`
+	Combinations []CountryBest  `json:"combinations"`
+}
+
+// matchesCountry reports whether a result belongs to the queried country (name or code)
+func matchesCountry(result DriverResult, query string) bool {
+	return strings.EqualFold(result.Country, query) ||
+		(result.CountryCode != "" && strings.EqualFold(result.CountryCode, query))
+}
+
+// BuildCountryRanking scans the index for a country's drivers and returns the
+// fastest driver per combination plus a country summary
+func BuildCountryRanking(engine *SearchEngine, query string) CountryRanking {
+	ranking := CountryRanking{
+		Combinations: []CountryBest{},
+	}
+	best := make(map[string]CountryBest) // trackID_classID -> fastest entry
+
+	engine.Scan(func(key string, results []DriverResult) {
+		matched := false
+		for _, result := range results {
+			if !matchesCountry(result, query) {
+				continue
+			}
+			if !matched {
+				matched = true
+				ranking.Summary.Drivers++
+				if ranking.Country == "" {
+					ranking.Country = result.Country
+					ranking.CountryCode = result.CountryCode
+				}
+			}
+
+			ranking.Summary.Entries++
+			if result.Position == 1 {
+				ranking.Summary.Wins++
+			}
+			if result.Position <= 10 {
+				ranking.Summary.Top10Finishes++
+			}
+
+			comboKey := result.TrackID + "_" + result.ClassID
+			if current, exists := best[comboKey]; !exists || result.Position < current.Position {
+				best[comboKey] = CountryBest{
+					TrackID:      result.TrackID,
+					Track:        result.Track,
+					ClassID:      result.ClassID,
+					ClassName:    GetCarClassName(result.ClassID),
+					Driver:       result.Name,
+					Position:     result.Position,
+					LapTime:      result.LapTime,
+					TotalEntries: result.TotalEntries,
+				}
+			}
+		}
+	})
+
+	for _, entry := range best {
+		ranking.Combinations = append(ranking.Combinations, entry)
+	}
+	sort.Slice(ranking.Combinations, func(a, b int) bool {
+		ca, cb := ranking.Combinations[a], ranking.Combinations[b]
+		if ca.Track != cb.Track {
+			return ca.Track < cb.Track
+		}
+		return ca.ClassName < cb.ClassName
+	})
+
+	return ranking
+}
+
+// TeamSummary is a single row of the teams listing
+type TeamSummary struct {
+	Team    string `json:"team"`
+	Drivers int    `json:"drivers"`
+	Entries int    `json:"entries"`
+}
+
+// TeamCombination groups a team's results on one track/class combination
+type TeamCombination struct {
+	TrackID   string         `json:"track_id"`
+	Track     string         `json:"track"`
+	ClassID   string         `json:"class_id"`
+	ClassName string         `json:"class_name"`
+	Results   []DriverResult `json:"results"`
+}
+
+// TeamDetail is the response for a single team query
+type TeamDetail struct {
+	Team         string            `json:"team"`
+	Drivers      []string          `json:"drivers"`
+	Entries      int               `json:"entries"`
+	Combinations []TeamCombination `json:"combinations"`
+}
+
+// BuildTeamDetail collects all drivers and results of a team (case-insensitive match)
+// grouped by track/class combination
+func BuildTeamDetail(engine *SearchEngine, team string) TeamDetail {
+	detail := TeamDetail{
+		Drivers:      []string{},
+		Combinations: []TeamCombination{},
+	}
+	comboIndex := make(map[string]int) // trackID_classID -> position in detail.Combinations
+
+	engine.Scan(func(key string, results []DriverResult) {
+		matched := false
+		for _, result := range results {
+			if result.Team == "" || !strings.EqualFold(result.Team, team) {
+				continue
+			}
+			if !matched {
+				matched = true
+				detail.Drivers = append(detail.Drivers, result.Name)
+				if detail.Team == "" {
+					detail.Team = result.Team
+				}
+			}
+			detail.Entries++
+
+			comboKey := result.TrackID + "_" + result.ClassID
+			i, exists := comboIndex[comboKey]
+			if !exists {
+				i = len(detail.Combinations)
+				comboIndex[comboKey] = i
+				detail.Combinations = append(detail.Combinations, TeamCombination{
+					TrackID:   result.TrackID,
+					Track:     result.Track,
+					ClassID:   result.ClassID,
+					ClassName: GetCarClassName(result.ClassID),
+				})
+			}
+			detail.Combinations[i].Results = append(detail.Combinations[i].Results, result)
+		}
+	})
+
+	sort.Strings(detail.Drivers)
+	for i := range detail.Combinations {
+		results := detail.Combinations[i].Results
+		sort.Slice(results, func(a, b int) bool { return results[a].Position < results[b].Position })
+	}
+	sort.Slice(detail.Combinations, func(a, b int) bool {
+		ca, cb := detail.Combinations[a], detail.Combinations[b]
+		if ca.Track != cb.Track {
+			return ca.Track < cb.Track
+		}
+		return ca.ClassName < cb.ClassName
+	})
+
+	return detail
+}
+
+// ListTeams returns all teams found in the index, most entries first
+func ListTeams(engine *SearchEngine) []TeamSummary {
+	type teamAccumulator struct {
+		name    string
+		drivers int
+		entries int
+	}
+	teams := make(map[string]*teamAccumulator) // lowercase team name -> totals
+
+	engine.Scan(func(key string, results []DriverResult) {
+		seen := make(map[string]bool)
+		for _, result := range results {
+			if result.Team == "" {
+				continue
+			}
+			teamKey := strings.ToLower(result.Team)
+			acc, exists := teams[teamKey]
+			if !exists {
+				acc = &teamAccumulator{name: result.Team}
+				teams[teamKey] = acc
+			}
+			acc.entries++
+			if !seen[teamKey] {
+				seen[teamKey] = true
+				acc.drivers++
+			}
+		}
+	})
+
+	summaries := make([]TeamSummary, 0, len(teams))
+	for _, acc := range teams {
+		summaries = append(summaries, TeamSummary{Team: acc.name, Drivers: acc.drivers, Entries: acc.entries})
+	}
+	sort.Slice(summaries, func(a, b int) bool {
+		if summaries[a].Entries != summaries[b].Entries {
+			return summaries[a].Entries > summaries[b].Entries
+		}
+		return summaries[a].Team < summaries[b].Team
+	})
+	return summaries
+}
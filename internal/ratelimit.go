@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// rateSweepInterval is how often expired windows are dropped
+const rateSweepInterval = 5 * time.Minute
+
+// RateDecision is the outcome of a rate limit check
+type RateDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time // When the current window ends
+}
+
+// rateWindow counts requests of one client in the current fixed window
+type rateWindow struct {
+	start  time.Time
+	window time.Duration
+	count  int
+}
+
+// RateLimiter is a fixed-window request limiter keyed by client (IP or API key)
+type RateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates an empty rate limiter
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		windows:   make(map[string]*rateWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow counts one request for key and reports whether it fits in limit requests per window
+// A limit below 1 means unlimited
+func (rl *RateLimiter) Allow(key string, limit int, window time.Duration) RateDecision {
+	now := time.Now()
+	if limit < 1 {
+		return RateDecision{Allowed: true, Limit: limit, Reset: now}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now.Sub(rl.lastSweep) > rateSweepInterval {
+		rl.sweepLocked(now)
+	}
+
+	w, ok := rl.windows[key]
+	if !ok || now.Sub(w.start) >= w.window || w.window != window {
+		w = &rateWindow{start: now, window: window}
+		rl.windows[key] = w
+	}
+
+	decision := RateDecision{Limit: limit, Reset: w.start.Add(w.window)}
+	if w.count >= limit {
+		return decision
+	}
+	w.count++
+	decision.Allowed = true
+	decision.Remaining = limit - w.count
+	return decision
+}
+
+// sweepLocked removes windows that have expired; rl.mu must be held
+func (rl *RateLimiter) sweepLocked(now time.Time) {
+	for key, w := range rl.windows {
+		if now.Sub(w.start) >= w.window {
+			delete(rl.windows, key)
+		}
+	}
+	rl.lastSweep = now
+}
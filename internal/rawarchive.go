@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RawArchiveDir holds the raw listing responses of the combinations selected by fetch.raw_archive
+const RawArchiveDir = "cache/raw"
+
+// RawFetch is an archived fetch of one combination: the raw body of every listing page as RaceRoom sent it
+type RawFetch struct {
+	TrackID   string    `json:"track_id"`
+	ClassID   string    `json:"class_id"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Error     string    `json:"error,omitempty"` // Why the fetch failed, if it did
+	Pages     []RawPage `json:"pages"`           // By start
+}
+
+// RawPage is one listing response
+type RawPage struct {
+	Start  int             `json:"start"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"text,omitempty"` // A body that isn't valid JSON, verbatim
+}
+
+// rawArchive holds the archive settings
+var rawArchive = struct {
+	mu     sync.Mutex
+	config RawArchiveConfig
+}{config: GetDefaultConfig().Fetch.RawArchive}
+
+// setRawArchiveConfig applies fetch.raw_archive; unset values fall back to the defaults and invalid
+// combination tokens are logged and skipped
+func setRawArchiveConfig(cfg RawArchiveConfig) {
+	defaults := GetDefaultConfig().Fetch.RawArchive
+	if cfg.KeepPerCombination <= 0 {
+		cfg.KeepPerCombination = defaults.KeepPerCombination
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = defaults.MaxSizeMB
+	}
+	combinations := make([]string, 0, len(cfg.Combinations))
+	for _, token := range cfg.Combinations {
+		trackID, classID, hasClass := strings.Cut(strings.TrimSpace(token), "-")
+		if trackID == "" || (hasClass && classID == "") || (trackID == AllTracksToken && !hasClass) {
+			configLog.Warnf("⚠️ Ignoring fetch.raw_archive combination %q (expected trackID, trackID-classID or *-classID)", token)
+			continue
+		}
+		combinations = append(combinations, strings.TrimSpace(token))
+	}
+	cfg.Combinations = combinations
+	if cfg.Enabled {
+		configLog.Infof("🗄️ Archiving raw responses of %d listed combination(s) and %.1f%% of the others to %s", len(combinations), cfg.SampleRate*100, RawArchiveDir)
+	}
+
+	rawArchive.mu.Lock()
+	defer rawArchive.mu.Unlock()
+	rawArchive.config = cfg
+}
+
+// newRawCapture returns a capture for the fetch of a combination, or nil when it isn't archived
+// Listed combinations are always archived; of the others a stable sample_rate share (by hash), so the
+// same combinations are archived on every run and can be compared
+func newRawCapture(trackID, classID string) *rawCapture {
+	rawArchive.mu.Lock()
+	cfg := rawArchive.config
+	rawArchive.mu.Unlock()
+	if !cfg.Enabled {
+		return nil
+	}
+
+	selected := false
+	for _, token := range cfg.Combinations {
+		tokenTrack, tokenClass, hasClass := strings.Cut(token, "-")
+		if (tokenTrack == AllTracksToken || tokenTrack == trackID) && (!hasClass || tokenClass == classID) {
+			selected = true
+			break
+		}
+	}
+	if !selected && cfg.SampleRate > 0 {
+		hasher := fnv.New32a()
+		hasher.Write([]byte(trackID + "_" + classID))
+		selected = float64(hasher.Sum32()%10000) < cfg.SampleRate*10000
+	}
+	if !selected {
+		return nil
+	}
+	return &rawCapture{fetch: RawFetch{TrackID: trackID, ClassID: classID, FetchedAt: time.Now().UTC()}}
+}
+
+// rawCapture collects the pages of one fetch; pages may be added concurrently
+type rawCapture struct {
+	mu    sync.Mutex
+	fetch RawFetch
+}
+
+// add records a listing response
+func (c *rawCapture) add(start, status int, body []byte) {
+	if c == nil {
+		return
+	}
+	page := RawPage{Start: start, Status: status}
+	if json.Valid(body) {
+		page.Body = append(json.RawMessage(nil), body...)
+	} else {
+		page.Text = string(body)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetch.Pages = append(c.fetch.Pages, page)
+}
+
+// save writes the captured fetch to RawArchiveDir/track_<id>/class_<id>/<time>.json.gz and rotates the archive
+// Failures are logged; archiving never fails a fetch
+func (c *rawCapture) save(fetchErr error) {
+	if c == nil || len(c.fetch.Pages) == 0 {
+		return
+	}
+	c.mu.Lock()
+	fetch := c.fetch
+	fetch.Pages = append([]RawPage(nil), c.fetch.Pages...)
+	c.mu.Unlock()
+	sort.Slice(fetch.Pages, func(i, j int) bool { return fetch.Pages[i].Start < fetch.Pages[j].Start })
+	if fetchErr != nil {
+		fetch.Error = fetchErr.Error()
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	err := json.NewEncoder(gz).Encode(fetch)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	dir := filepath.Join(RawArchiveDir, "track_"+fetch.TrackID, "class_"+fetch.ClassID)
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(dir, fetch.FetchedAt.Format("20060102T150405.000Z")+".json.gz"), buf.Bytes())
+	}
+	if err != nil {
+		loaderLog.Warnf("⚠️ Failed to archive raw responses of track %s class %s: %v", fetch.TrackID, fetch.ClassID, err)
+		return
+	}
+
+	rawArchive.mu.Lock()
+	cfg := rawArchive.config
+	rawArchive.mu.Unlock()
+	rotateRawArchive(dir, cfg.KeepPerCombination, int64(cfg.MaxSizeMB*1024*1024))
+}
+
+// rotateRawArchive keeps the newest keep files of a combination, then deletes the oldest files of the whole
+// archive while it is larger than maxBytes
+func rotateRawArchive(dir string, keep int, maxBytes int64) {
+	names, _ := filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	sort.Strings(names) // Timestamped, oldest first
+	for len(names) > keep {
+		os.Remove(names[0])
+		names = names[1:]
+	}
+
+	type archived struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []archived
+	var total int64
+	filepath.WalkDir(RawArchiveDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, archived{path, info.Size(), info.ModTime()})
+			total += info.Size()
+		}
+		return nil
+	})
+	if total <= maxBytes {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	removed := 0
+	for _, file := range files {
+		if total <= maxBytes {
+			break
+		}
+		if os.Remove(file.path) == nil {
+			total -= file.size
+			removed++
+		}
+	}
+	loaderLog.Debugf("🗄️ Raw archive over its size limit - removed %d oldest file(s)", removed)
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// WorldRecordsFile keeps the most recent world records across restarts
+const WorldRecordsFile = "cache/world_records.json"
+
+const (
+	maxWorldRecords   = 100 // Records kept in WorldRecordsFile
+	statusRecordCount = 10  // Records shown in status.json
+)
+
+// WorldRecord is an improvement of a combination's P1 lap time (payload of EventWorldRecord)
+type WorldRecord struct {
+	TrackID    string    `json:"track_id"`
+	ClassID    string    `json:"class_id"`
+	Track      string    `json:"track"`
+	Class      string    `json:"class"`
+	OldHolder  string    `json:"old_holder"`
+	OldLapTime string    `json:"old_laptime"`
+	NewHolder  string    `json:"new_holder"`
+	NewLapTime string    `json:"new_laptime"`
+	DeltaMs    int64     `json:"delta_ms"` // How much faster the new record is
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// worldRecords holds the recent records, newest first; loaded from WorldRecordsFile on first use
+var worldRecords = struct {
+	sync.Mutex
+	loaded bool
+	list   []WorldRecord
+}{}
+
+// detectWorldRecord compares the P1 lap time of a combination's cache file with the file about to replace it
+// A faster P1 is logged, published as an EventWorldRecord event and kept for status.json.
+// Combinations without a previous cache file are new rather than records and are ignored
+func detectWorldRecord(trackID, classID, oldFile, newFile string) {
+	if _, err := os.Stat(oldFile); err != nil {
+		return
+	}
+	if oldHash := readContentHash(oldFile); oldHash != "" && oldHash == readContentHash(newFile) {
+		return
+	}
+	before, err := loadCacheFile(oldFile)
+	if err != nil {
+		return
+	}
+	after, err := loadCacheFile(newFile)
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to check %s + %s for a world record: %v", trackID, classID, err)
+		return
+	}
+
+	oldLeader, oldTime, ok := fastestEntry(before)
+	if !ok {
+		return
+	}
+	newLeader, newTime, ok := fastestEntry(after)
+	if !ok || newTime >= oldTime {
+		return
+	}
+
+	record := WorldRecord{
+		TrackID:    trackID,
+		ClassID:    classID,
+		Track:      after.Name,
+		Class:      GetCarClassName(classID),
+		OldHolder:  oldLeader.Driver.Name,
+		OldLapTime: oldLeader.LapTime,
+		NewHolder:  newLeader.Driver.Name,
+		NewLapTime: newLeader.LapTime,
+		DeltaMs:    (oldTime - newTime).Milliseconds(),
+		DetectedAt: time.Now(),
+	}
+	cacheLog.Infof("🏆 New world record on %s / %s: %s %s (-%.3fs, previously %s %s)",
+		record.Track, record.Class, record.NewHolder, record.NewLapTime,
+		float64(record.DeltaMs)/1000, record.OldHolder, record.OldLapTime)
+	eventBroker.Publish(EventWorldRecord, record)
+	storeWorldRecord(record)
+}
+
+// fastestEntry returns the entry with the best lap time of a leaderboard, ignoring excluded drivers
+func fastestEntry(track TrackInfo) (LeaderboardEntry, time.Duration, bool) {
+	var best LeaderboardEntry
+	var bestTime time.Duration
+	found := false
+	for i := range track.Data {
+		entry := &track.Data[i]
+		lapTime := time.Duration(entry.lapTimeMs()) * time.Millisecond
+		if lapTime <= 0 || entry.Driver.Name == "" || (found && lapTime >= bestTime) || isDriverExcluded(entry.Driver.Name, string(entry.Driver.ID)) {
+			continue
+		}
+		best, bestTime, found = *entry, lapTime, true
+	}
+	return best, bestTime, found
+}
+
+// storeWorldRecord adds a record to the recent records and rewrites WorldRecordsFile
+func storeWorldRecord(record WorldRecord) {
+	worldRecords.Lock()
+	defer worldRecords.Unlock()
+	loadWorldRecordsLocked()
+
+	worldRecords.list = append([]WorldRecord{record}, worldRecords.list...)
+	if len(worldRecords.list) > maxWorldRecords {
+		worldRecords.list = worldRecords.list[:maxWorldRecords]
+	}
+	data, err := json.MarshalIndent(worldRecords.list, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(WorldRecordsFile, data)
+	}
+	if err != nil {
+		cacheLog.Warnf("⚠️ Failed to write %s: %v", WorldRecordsFile, err)
+	}
+}
+
+// loadWorldRecordsLocked reads WorldRecordsFile once; callers hold worldRecords
+func loadWorldRecordsLocked() {
+	if worldRecords.loaded {
+		return
+	}
+	worldRecords.loaded = true
+	data, err := os.ReadFile(WorldRecordsFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &worldRecords.list); err != nil {
+		cacheLog.Warnf("⚠️ Ignoring invalid %s: %v", WorldRecordsFile, err)
+		worldRecords.list = nil
+	}
+}
+
+// RecentWorldRecords returns up to n of the most recent world records, newest first
+// Records involving an excluded driver are left out
+func RecentWorldRecords(n int) []WorldRecord {
+	worldRecords.Lock()
+	defer worldRecords.Unlock()
+	loadWorldRecordsLocked()
+
+	var records []WorldRecord
+	for _, record := range worldRecords.list {
+		if len(records) == n {
+			break
+		}
+		if !isDriverExcluded(record.NewHolder, "") && !isDriverExcluded(record.OldHolder, "") {
+			records = append(records, record)
+		}
+	}
+	return records
+}
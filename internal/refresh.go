@@ -2,13 +2,14 @@ package internal
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"strings"
 )
 
 // PerformFullRefresh executes a full force-fetch refresh of all combinations
 // Returns the merged result of cached + fetched tracks
 func PerformFullRefresh(ctx context.Context, progressCallback func([]TrackInfo), origin string) []TrackInfo {
-	log.Println("🔄 Starting full refresh (force fetch all)...")
+	loaderLog.Infof("🔄 Starting full refresh (force fetch all)...")
 
 	// Bootstrap: load ALL cached data first so we never start from zero
 	cachedTracks := LoadAllCachedData(ctx)
@@ -27,13 +28,13 @@ func PerformFullRefresh(ctx context.Context, progressCallback func([]TrackInfo),
 	// Build final merged result
 	finalMerged := MergeTracks(cachedTracks, fetchedTracks)
 
-	log.Printf("✅ Full refresh complete: %d total combinations", len(finalMerged))
+	loaderLog.Infof("✅ Full refresh complete: %d total combinations", len(finalMerged))
 
 	return finalMerged
 }
 
 // PerformTargetedRefresh executes a targeted refresh for specific track IDs or track-class couples
-// trackIDs can contain "trackID" (all classes) or "trackID-classID" (specific class)
+// trackIDs can contain "trackID" (all classes), "trackID-classID" (specific class) or "*-classID" (class on every track)
 // Returns the merged result of cached + fetched tracks
 func PerformTargetedRefresh(ctx context.Context, trackIDs []string, progressCallback func([]TrackInfo), origin string) []TrackInfo {
 	// Bootstrap: load ALL cached data first
@@ -53,7 +54,7 @@ func PerformTargetedRefresh(ctx context.Context, trackIDs []string, progressCall
 	// Build final merged result
 	finalMerged := MergeTracks(cachedTracks, fetchedTracks)
 
-	log.Printf("✅ Targeted refresh complete: %d total combinations", len(finalMerged))
+	loaderLog.Infof("✅ Targeted refresh complete: %d total combinations", len(finalMerged))
 
 	return finalMerged
 }
@@ -86,3 +87,94 @@ func MergeTracks(cached, fetched []TrackInfo) []TrackInfo {
 	}
 	return out
 }
+
+// RefreshRequest is the JSON body of POST /api/refresh
+type RefreshRequest struct {
+	Tracks  []string `json:"tracks,omitempty"`  // Track IDs or "trackID-classID" couples
+	Classes []string `json:"classes,omitempty"` // Class IDs: crossed with tracks, or with every track when tracks is empty
+	Force   *bool    `json:"force,omitempty"`   // Refetch combinations whose cache is still fresh (default true)
+	DryRun  bool     `json:"dry_run,omitempty"` // Report what would be fetched instead of queueing the refresh
+}
+
+// Plan is the dry run of the request (see PlanRefresh)
+func (req RefreshRequest) Plan() (RefreshPlan, error) {
+	force := req.Force == nil || *req.Force
+	all := true
+	req.Force = &all // Every combination in scope, fresh or not
+	targets, _, err := req.Targets()
+	if err != nil {
+		return RefreshPlan{}, err
+	}
+	return PlanRefresh(targets, force), nil
+}
+
+// Targets validates the request and returns its refresh targets; empty means a full refresh
+// Without force, combinations whose cache is still fresh are left out, and fresh counts them
+func (req RefreshRequest) Targets() (targets []string, fresh int, err error) {
+	tracks, err := ParseRefreshTargets(req.Tracks)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, classID := range req.Classes {
+		if _, ok := FindCarClass(classID); !ok {
+			return nil, 0, fmt.Errorf("unknown class %q", classID)
+		}
+	}
+	force := req.Force == nil || *req.Force
+
+	switch {
+	case len(req.Classes) > 0 && len(tracks) == 0 && force:
+		// Class-scoped refresh of every track (see FetchSelectedClassesDataWithCallback)
+		for _, classID := range req.Classes {
+			targets = append(targets, AllTracksToken+"-"+classID)
+		}
+	case len(req.Classes) > 0:
+		if len(tracks) == 0 {
+			for _, track := range GetTracks() {
+				tracks = append(tracks, track.TrackID)
+			}
+		}
+		for _, track := range tracks {
+			if strings.Contains(track, "-") {
+				return nil, 0, fmt.Errorf("%q: track-class couples can't be combined with classes", track)
+			}
+			for _, classID := range req.Classes {
+				targets = append(targets, track+"-"+classID)
+			}
+		}
+	case !force:
+		// Spell out every combination so fresh ones can be left out
+		if len(tracks) == 0 {
+			for _, track := range GetTracks() {
+				tracks = append(tracks, track.TrackID)
+			}
+		}
+		for _, track := range tracks {
+			if strings.Contains(track, "-") {
+				targets = append(targets, track)
+				continue
+			}
+			for _, class := range GetCarClasses() {
+				targets = append(targets, track+"-"+class.ClassID)
+			}
+		}
+	default:
+		return tracks, 0, nil
+	}
+
+	cache := NewDataCache()
+	seen := make(map[string]bool, len(targets))
+	kept := targets[:0]
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		if trackID, classID, _ := strings.Cut(target, "-"); !force && cache.IsCacheValid(trackID, classID) {
+			fresh++
+			continue
+		}
+		kept = append(kept, target)
+	}
+	return kept, fresh, nil
+}
@@ -0,0 +1,385 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ReportDir holds the generated summary reports: <date>.json (daily) and <date>-weekly.json
+const ReportDir = "cache/reports"
+
+// Report periods
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// reportListSize is how many tracks and movers a report lists
+const reportListSize = 10
+
+// Report summarizes the leaderboard activity of a day or week
+type Report struct {
+	Period         string               `json:"period"`
+	Date           string               `json:"date"` // YYYY-MM-DD of generation
+	GeneratedAt    time.Time            `json:"generated_at"`
+	Since          time.Time            `json:"since"`                     // Start of the covered window
+	PreviousReport string               `json:"previous_report,omitempty"` // Report the growth is measured against
+	Totals         ReportTotals         `json:"totals"`
+	Growth         *ReportTotals        `json:"growth,omitempty"` // Change since the previous report of the same period
+	ActiveTracks   []ReportTrack        `json:"active_tracks"`    // Most new entries and improvements in the window
+	Movers         []ReportMover        `json:"movers"`           // Biggest position gains in the window
+	Records        []WorldRecord        `json:"records"`          // World records set in the window, newest first
+	FetchHealth    ReportFetchHealth    `json:"fetch_health"`
+	LastFetch      *FetchProgressStatus `json:"last_fetch,omitempty"`
+}
+
+// ReportTotals counts the indexed data
+type ReportTotals struct {
+	Drivers      int `json:"drivers"`
+	Entries      int `json:"entries"`
+	Combinations int `json:"combinations"`
+}
+
+// ReportTrack is a track's activity in the report window
+type ReportTrack struct {
+	TrackID      string `json:"track_id"`
+	Track        string `json:"track"`
+	NewEntries   int    `json:"new_entries"`
+	Improvements int    `json:"improvements"`
+	Entries      int    `json:"entries"` // Indexed entries now
+}
+
+// ReportMover is a driver who climbed a leaderboard in the report window
+type ReportMover struct {
+	Name        string `json:"name"`
+	TrackID     string `json:"track_id"`
+	Track       string `json:"track"`
+	ClassID     string `json:"class_id"`
+	Class       string `json:"class"`
+	OldPosition int    `json:"old_position"`
+	NewPosition int    `json:"new_position"`
+	Gained      int    `json:"gained"`
+	LapTime     string `json:"laptime"`
+}
+
+// ReportFetchHealth describes the last scrape and its failures
+type ReportFetchHealth struct {
+	LastScrapeStart  time.Time `json:"last_scrape_start"`
+	LastScrapeEnd    time.Time `json:"last_scrape_end"`
+	FailedFetchCount int       `json:"failed_fetch_count"`
+	RetriedCount     int       `json:"retried_fetch_count"`
+}
+
+// reportConfig is set once at startup by SetReportConfig
+var reportConfig ReportConfig
+
+// SetReportConfig sets the report schedule and extra output formats
+func SetReportConfig(cfg ReportConfig) {
+	reportConfig = cfg
+}
+
+// GenerateReport builds the report of a period ending now and writes it to ReportDir
+// Markdown and HTML copies are written next to the JSON when enabled in the config
+func GenerateReport(period string) (Report, error) {
+	window := 24 * time.Hour
+	switch period {
+	case ReportDaily:
+	case ReportWeekly:
+		window = 7 * 24 * time.Hour
+	default:
+		return Report{}, fmt.Errorf("unknown report period %q (use %s or %s)", period, ReportDaily, ReportWeekly)
+	}
+
+	now := time.Now()
+	report := Report{
+		Period:       period,
+		Date:         now.Format("2006-01-02"),
+		GeneratedAt:  now,
+		Since:        now.Add(-window),
+		ActiveTracks: []ReportTrack{},
+		Movers:       []ReportMover{},
+		Records:      []WorldRecord{},
+	}
+
+	engine := GetSearchEngine()
+	trackStats := engine.StatsByTrack()
+	report.Totals.Drivers = engine.DriverCount()
+	for _, stats := range trackStats {
+		report.Totals.Entries += stats.Entries
+		report.Totals.Combinations += stats.Combinations
+	}
+	if previous, name, ok := previousReport(period, report.Date); ok {
+		report.PreviousReport = name
+		report.Growth = &ReportTotals{
+			Drivers:      report.Totals.Drivers - previous.Totals.Drivers,
+			Entries:      report.Totals.Entries - previous.Totals.Entries,
+			Combinations: report.Totals.Combinations - previous.Totals.Combinations,
+		}
+	}
+
+	report.ActiveTracks, report.Movers = reportActivity(report.Since, trackStats)
+	for _, record := range RecentWorldRecords(maxWorldRecords) {
+		if record.DetectedAt.After(report.Since) {
+			report.Records = append(report.Records, record)
+		}
+	}
+
+	status := ReadStatusData()
+	report.FetchHealth = ReportFetchHealth{
+		LastScrapeStart:  status.LastScrapeStart,
+		LastScrapeEnd:    status.LastScrapeEnd,
+		FailedFetchCount: status.FailedFetchCount,
+		RetriedCount:     status.RetriedFetchCount,
+	}
+	if progress, ok := CurrentFetchStatus(); ok {
+		report.LastFetch = &progress
+	}
+
+	if err := writeReport(report); err != nil {
+		return report, err
+	}
+	exportLog.Infof("📰 %s report written: %d drivers, %d entries, %d record(s), %d mover(s)",
+		period, report.Totals.Drivers, report.Totals.Entries, len(report.Records), len(report.Movers))
+	return report, nil
+}
+
+// reportFileBase returns the file name without extension of a period's report
+func reportFileBase(period, date string) string {
+	if period == ReportWeekly {
+		return date + "-weekly"
+	}
+	return date
+}
+
+// previousReport loads the newest report of the period written before date
+func previousReport(period, date string) (Report, string, bool) {
+	files, _ := filepath.Glob(filepath.Join(ReportDir, "*.json"))
+	current := reportFileBase(period, date) + ".json"
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name >= current || strings.HasSuffix(name, "-weekly.json") != (period == ReportWeekly) {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var report Report
+		if json.Unmarshal(data, &report) == nil && report.Period == period {
+			return report, name, true
+		}
+	}
+	return Report{}, "", false
+}
+
+// reportActivity ranks tracks by new entries and drivers by positions gained, using the
+// changes.json of every combination whose latest snapshot falls inside the window
+func reportActivity(since time.Time, trackStats map[string]CombinationStats) ([]ReportTrack, []ReportMover) {
+	tracks := make(map[string]*ReportTrack)
+	movers := []ReportMover{}
+
+	for _, changes := range readRecentChanges(since) {
+		if len(changes.NewEntries) == 0 && len(changes.Improved) == 0 && len(changes.PositionChanges) == 0 {
+			continue
+		}
+
+		track, ok := tracks[changes.TrackID]
+		if !ok {
+			track = &ReportTrack{TrackID: changes.TrackID, Track: reportTrackName(changes.TrackID), Entries: trackStats[changes.TrackID].Entries}
+			tracks[changes.TrackID] = track
+		}
+		track.NewEntries += len(changes.NewEntries)
+		track.Improvements += len(changes.Improved)
+
+		for _, change := range changes.PositionChanges {
+			if gained := change.OldPosition - change.NewPosition; gained > 0 {
+				movers = append(movers, ReportMover{
+					Name:        change.Name,
+					TrackID:     changes.TrackID,
+					Track:       track.Track,
+					ClassID:     changes.ClassID,
+					Class:       GetCarClassName(changes.ClassID),
+					OldPosition: change.OldPosition,
+					NewPosition: change.NewPosition,
+					Gained:      gained,
+					LapTime:     change.NewLapTime,
+				})
+			}
+		}
+	}
+
+	active := make([]ReportTrack, 0, len(tracks))
+	for _, track := range tracks {
+		active = append(active, *track)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].NewEntries != active[j].NewEntries {
+			return active[i].NewEntries > active[j].NewEntries
+		}
+		return active[i].Improvements > active[j].Improvements
+	})
+	sort.Slice(movers, func(i, j int) bool { return movers[i].Gained > movers[j].Gained })
+	if len(active) > reportListSize {
+		active = active[:reportListSize]
+	}
+	if len(movers) > reportListSize {
+		movers = movers[:reportListSize]
+	}
+	return active, movers
+}
+
+// reportTrackName returns the catalog name of a track, or its ID when unknown
+func reportTrackName(trackID string) string {
+	if track, ok := FindTrack(trackID); ok {
+		return track.Name
+	}
+	return "Track " + trackID
+}
+
+// writeReport writes the JSON report and the configured Markdown/HTML copies
+func writeReport(report Report) error {
+	if err := os.MkdirAll(ReportDir, 0755); err != nil {
+		return err
+	}
+	base := filepath.Join(ReportDir, reportFileBase(report.Period, report.Date))
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(base+".json", data); err != nil {
+		return err
+	}
+
+	for _, format := range reportConfig.Formats {
+		var rendered strings.Builder
+		ext := ""
+		switch format {
+		case "markdown":
+			err, ext = reportMarkdownTemplate.Execute(&rendered, report), ".md"
+		case "html":
+			err, ext = reportHTMLTemplate.Execute(&rendered, report), ".html"
+		default:
+			exportLog.Warnf("⚠️ Unknown report format %q (use markdown or html)", format)
+			continue
+		}
+		if err == nil {
+			err = writeFileAtomic(base+ext, []byte(rendered.String()))
+		}
+		if err != nil {
+			exportLog.Warnf("⚠️ Failed to write %s report: %v", format, err)
+		}
+	}
+	return nil
+}
+
+// reportTemplateFuncs are shared by the Markdown and HTML templates
+var reportTemplateFuncs = map[string]any{
+	"delta":    formatDeltaMs,
+	"signed":   func(n int) string { return fmt.Sprintf("%+d", n) },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}
+
+// reportMarkdownTemplate renders a Report as Markdown
+var reportMarkdownTemplate = template.Must(template.New("markdown").Funcs(reportTemplateFuncs).Parse(`# R3E Leaderboard {{.Period}} report — {{.Date}}
+
+Covering {{datetime .Since}} to {{datetime .GeneratedAt}}.
+
+## Totals
+- Drivers: {{.Totals.Drivers}}{{with .Growth}} ({{signed .Drivers}}){{end}}
+- Entries: {{.Totals.Entries}}{{with .Growth}} ({{signed .Entries}}){{end}}
+- Combinations: {{.Totals.Combinations}}{{with .Growth}} ({{signed .Combinations}}){{end}}
+
+## Most active tracks
+{{range .ActiveTracks}}- {{.Track}}: {{.NewEntries}} new entries, {{.Improvements}} improvements
+{{else}}No activity recorded.
+{{end}}
+## Biggest movers
+{{range .Movers}}- {{.Name}}: P{{.OldPosition}} → P{{.NewPosition}} on {{.Track}} / {{.Class}} ({{.LapTime}})
+{{else}}No position changes recorded.
+{{end}}
+## World records
+{{range .Records}}- {{.Track}} / {{.Class}}: {{.NewHolder}} {{.NewLapTime}} ({{delta .DeltaMs}}, previously {{.OldHolder}})
+{{else}}No new records.
+{{end}}
+## Fetch health
+- Last scrape: {{datetime .FetchHealth.LastScrapeStart}} to {{datetime .FetchHealth.LastScrapeEnd}}
+- Failed fetches: {{.FetchHealth.FailedFetchCount}} ({{.FetchHealth.RetriedCount}} retried)
+`))
+
+// reportHTMLTemplate renders a Report as a standalone HTML page
+var reportHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(reportTemplateFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>R3E Leaderboard {{.Period}} report — {{.Date}}</title></head>
+<body>
+<h1>R3E Leaderboard {{.Period}} report — {{.Date}}</h1>
+<p>Covering {{datetime .Since}} to {{datetime .GeneratedAt}}.</p>
+<h2>Totals</h2>
+<ul>
+<li>Drivers: {{.Totals.Drivers}}{{with .Growth}} ({{signed .Drivers}}){{end}}</li>
+<li>Entries: {{.Totals.Entries}}{{with .Growth}} ({{signed .Entries}}){{end}}</li>
+<li>Combinations: {{.Totals.Combinations}}{{with .Growth}} ({{signed .Combinations}}){{end}}</li>
+</ul>
+<h2>Most active tracks</h2>
+<table><tr><th>Track</th><th>New entries</th><th>Improvements</th></tr>
+{{range .ActiveTracks}}<tr><td>{{.Track}}</td><td>{{.NewEntries}}</td><td>{{.Improvements}}</td></tr>
+{{end}}</table>
+<h2>Biggest movers</h2>
+<table><tr><th>Driver</th><th>Combination</th><th>Position</th><th>Lap time</th></tr>
+{{range .Movers}}<tr><td>{{.Name}}</td><td>{{.Track}} / {{.Class}}</td><td>P{{.OldPosition}} → P{{.NewPosition}}</td><td>{{.LapTime}}</td></tr>
+{{end}}</table>
+<h2>World records</h2>
+<table><tr><th>Combination</th><th>Holder</th><th>Lap time</th><th>Improvement</th><th>Previous holder</th></tr>
+{{range .Records}}<tr><td>{{.Track}} / {{.Class}}</td><td>{{.NewHolder}}</td><td>{{.NewLapTime}}</td><td>{{delta .DeltaMs}}</td><td>{{.OldHolder}}</td></tr>
+{{end}}</table>
+<h2>Fetch health</h2>
+<ul>
+<li>Last scrape: {{datetime .FetchHealth.LastScrapeStart}} to {{datetime .FetchHealth.LastScrapeEnd}}</li>
+<li>Failed fetches: {{.FetchHealth.FailedFetchCount}} ({{.FetchHealth.RetriedCount}} retried)</li>
+</ul>
+</body></html>
+`))
+
+// StartReportSchedule generates the enabled reports every day at the configured hour until ctx is done
+// Weekly reports are generated on Mondays
+func StartReportSchedule(ctx context.Context, config ReportConfig) {
+	if !config.Daily && !config.Weekly {
+		return
+	}
+	go func() {
+		for {
+			now := time.Now()
+			next := time.Date(now.Year(), now.Month(), now.Day(), config.Hour, 0, 0, 0, now.Location())
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			schedulerLog.Infof("📰 Next report generation at %s", next.Format("2006-01-02 15:04"))
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+
+			if config.Daily {
+				if _, err := GenerateReport(ReportDaily); err != nil {
+					exportLog.Warnf("⚠️ Daily report failed: %v", err)
+				}
+			}
+			if config.Weekly && next.Weekday() == time.Monday {
+				if _, err := GenerateReport(ReportWeekly); err != nil {
+					exportLog.Warnf("⚠️ Weekly report failed: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,193 @@
+package internal
+
+import "time"
+
+// Response bodies of the JSON API. Legacy routes write them as they are; /api/v1 wraps them in an Envelope
+
+// ErrorResponse is the body of a failed legacy request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// DriverSuggestionsResponse is the body of /drivers
+type DriverSuggestionsResponse struct {
+	Prefix  string             `json:"prefix"`
+	Count   int                `json:"count"`
+	Results []DriverSuggestion `json:"results"`
+}
+
+// TrackListResponse is the body of /tracks
+type TrackListResponse struct {
+	Count   int            `json:"count"`
+	Results []TrackSummary `json:"results"`
+}
+
+// ClassListResponse is the body of /classes
+type ClassListResponse struct {
+	Count   int            `json:"count"`
+	Results []ClassSummary `json:"results"`
+}
+
+// TeamListResponse is the body of /teams
+type TeamListResponse struct {
+	Total   int           `json:"total"` // Teams before the limit
+	Count   int           `json:"count"`
+	Results []TeamSummary `json:"results"`
+}
+
+// TopCombinationsResponse is the body of /top-combinations
+type TopCombinationsResponse struct {
+	Total   int                `json:"total"` // Combinations before the limit
+	Count   int                `json:"count"`
+	Results []TrackCombination `json:"results"`
+}
+
+// LeaderboardResponse is a page of one combination's leaderboard (/leaderboard and the GraphQL leaderboard field)
+type LeaderboardResponse struct {
+	Track     string              `json:"track"`
+	TrackID   string              `json:"track_id"`
+	ClassID   string              `json:"class_id"`
+	ClassName string              `json:"class_name"`
+	Total     int                 `json:"total"`
+	Offset    int                 `json:"offset"`
+	Limit     int                 `json:"limit"`
+	Sort      string              `json:"sort"`
+	Order     string              `json:"order"`
+	Count     int                 `json:"count"`
+	Results   []LeaderboardResult `json:"results"`
+}
+
+// LeaderboardResult is a leaderboard entry with its gaps, computed from the parsed lap times (see leaderboardGaps)
+type LeaderboardResult struct {
+	DriverResult
+	GapMs           *int64 `json:"gap_ms"`             // To the fastest lap; null when the lap time can't be parsed
+	GapToPreviousMs *int64 `json:"gap_to_previous_ms"` // To the entry one position ahead; null for P1 or when either lap time can't be parsed
+}
+
+// SnapshotListResponse is the body of /snapshots
+type SnapshotListResponse struct {
+	TrackID   string         `json:"track_id"`
+	ClassID   string         `json:"class_id"`
+	Count     int            `json:"count"`
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
+// SnapshotHistoryResponse is the body of /snapshots with a driver
+type SnapshotHistoryResponse struct {
+	TrackID string                `json:"track_id"`
+	ClassID string                `json:"class_id"`
+	Driver  string                `json:"driver"`
+	Count   int                   `json:"count"`
+	History []DriverSnapshotPoint `json:"history"`
+}
+
+// ChangesResponse is the body of /changes; the lists are truncated, the counts are totals
+type ChangesResponse struct {
+	TrackID              string        `json:"track_id"`
+	ClassID              string        `json:"class_id"`
+	From                 time.Time     `json:"from"`
+	To                   time.Time     `json:"to"`
+	NewEntriesCount      int           `json:"new_entries_count"`
+	ImprovedCount        int           `json:"improved_count"`
+	PositionChangesCount int           `json:"position_changes_count"`
+	NewEntries           []EntryChange `json:"new_entries"`
+	Improved             []EntryChange `json:"improved"`
+	PositionChanges      []EntryChange `json:"position_changes"`
+}
+
+// JobAcceptedResponse answers a request that queued a background job
+type JobAcceptedResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+// FailedFetchQueueResponse is the body of /fetch/failed
+type FailedFetchQueueResponse struct {
+	Count    int             `json:"count"`
+	Failures []QueuedFailure `json:"failures"`
+}
+
+// FetchPauseResponse is the body of /fetch/pause and /fetch/resume
+type FetchPauseResponse struct {
+	Paused bool       `json:"paused"`
+	Since  *time.Time `json:"since,omitempty"` // Set while paused
+}
+
+// APIKeyListResponse is the body of GET /keys
+type APIKeyListResponse struct {
+	Since   time.Time     `json:"since"` // Start of the usage counters
+	Count   int           `json:"count"`
+	Results []APIKeyUsage `json:"results"`
+}
+
+// APIKeyIssuedResponse is the body of POST /keys; the only time the secret is returned
+type APIKeyIssuedResponse struct {
+	Key       string    `json:"key"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	RateLimit int       `json:"rate_limit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsageResponse is the body of /usage
+type UsageResponse struct {
+	Since time.Time   `json:"since"`
+	Usage APIKeyUsage `json:"usage"`
+}
+
+// HeapProfileResponse is the body of the heap_profile action of /debug/runtime
+type HeapProfileResponse struct {
+	Path string `json:"path"`
+}
+
+// RivalsResponse is the body of /rivals
+type RivalsResponse struct {
+	Driver            string              `json:"driver"`
+	Count             int                 `json:"count"`              // Rivals per side
+	TotalCombinations int                 `json:"total_combinations"` // Combinations of the driver matching track and class
+	Results           []CombinationRivals `json:"results"`
+}
+
+// SearchResponse is the body of /search
+type SearchResponse struct {
+	Query   string        `json:"query"`
+	Total   int           `json:"total"` // Matching results before the limit
+	Count   int           `json:"count"`
+	Results []SearchMatch `json:"results"`
+	Shards  []string      `json:"shards,omitempty"` // Driver index shards holding the matched drivers
+}
+
+// IndexShardsResponse is the driver index manifest, plus the shard of the requested driver
+type IndexShardsResponse struct {
+	ShardManifest
+	Shard string `json:"shard,omitempty"`
+}
+
+// BatchDriverResults are the results of one driver of a batch search
+type BatchDriverResults struct {
+	Query   string         `json:"query"`          // Name as requested
+	Name    string         `json:"name,omitempty"` // Display (or canonical) name; empty when not found
+	Found   bool           `json:"found"`          // The driver is indexed, even if the filters leave no result
+	Count   int            `json:"count"`
+	Results []DriverResult `json:"results"`
+}
+
+// BatchSearchResponse is the body of POST /search/batch
+type BatchSearchResponse struct {
+	Count   int                  `json:"count"` // Drivers requested
+	Found   int                  `json:"found"` // Drivers found in the index
+	Results []BatchDriverResults `json:"results"`
+}
+
+// AliasListResponse is the body of GET /aliases
+type AliasListResponse struct {
+	Count   int           `json:"count"`
+	Results []DriverAlias `json:"results"`
+}
+
+// ExclusionListResponse is the body of GET /exclusions
+type ExclusionListResponse struct {
+	Count   int               `json:"count"`
+	Results []DriverExclusion `json:"results"`
+}
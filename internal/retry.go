@@ -2,7 +2,6 @@ package internal
 
 import (
 	"context"
-	"log"
 	"time"
 )
 
@@ -19,7 +18,7 @@ func retryFailedFetches(ctx context.Context, apiClient *APIClient, tempCache *Da
 		return nil
 	}
 
-	log.Printf("🔄 Phase 4: Retrying %d failed fetches...", len(failedFetches))
+	loaderLog.Infof("🔄 Phase 4: Retrying %d failed fetches...", len(failedFetches))
 	retriedTracks := make([]TrackInfo, 0, len(failedFetches)/2)
 	retriedCount := 0
 
@@ -27,20 +26,26 @@ retryLoop:
 	for i, failed := range failedFetches {
 		select {
 		case <-ctx.Done():
-			log.Printf("🛑 Retry cancelled at %d/%d", i+1, len(failedFetches))
+			loaderLog.Infof("🛑 Retry cancelled at %d/%d", i+1, len(failedFetches))
 			break retryLoop
 		default:
 		}
 
-		log.Printf("🔁 Retry %d/%d: %s + %s", i+1, len(failedFetches), failed.Track.Name, failed.Class.Name)
+		loaderLog.Infof("🔁 Retry %d/%d: %s + %s", i+1, len(failedFetches), failed.Track.Name, failed.Class.Name)
 
-		// Create a context with timeout for retry
-		fetchCtx, fetchCancel := context.WithTimeout(ctx, 120*time.Second)
-		data, duration, err := apiClient.FetchLeaderboardData(fetchCtx, failed.Track.TrackID, failed.Class.ClassID)
-		fetchCancel()
+		data, duration, err := fetchWithTimeout(ctx, apiClient, failed.Track, failed.Class)
 
 		if err != nil {
-			log.Printf("⚠️ Retry failed %s + %s: %v", failed.Track.Name, failed.Class.Name, err)
+			loaderLog.Warnf("⚠️ Retry failed %s + %s: %v", failed.Track.Name, failed.Class.Name, err)
+			if ctx.Err() == nil {
+				eventBroker.Publish(EventFetchFailedRepeatedly, FetchFailedEvent{
+					TrackID: failed.Track.TrackID,
+					ClassID: failed.Class.ClassID,
+					Track:   failed.Track.Name,
+					Class:   failed.Class.Name,
+					Message: err.Error(),
+				})
+			}
 			continue
 		}
 
@@ -51,30 +56,44 @@ retryLoop:
 			Data:    data,
 		}
 
-		// Save to temp cache
-		if saveErr := tempCache.SaveTrackData(trackInfo); saveErr != nil {
-			log.Printf("⚠️ Warning: Could not save to temp cache %s + %s: %v", failed.Track.Name, failed.Class.Name, saveErr)
+		// Save to temp cache (unchanged data only renews the existing cache file's age)
+		if _, saveErr := tempCache.SaveIfChanged(trackInfo); saveErr != nil {
+			loaderLog.Warnf("⚠️ Warning: Could not save to temp cache %s + %s: %v", failed.Track.Name, failed.Class.Name, saveErr)
 		}
 
 		if len(data) > 0 {
-			log.Printf("✅ Retry succeeded %s + %s: %.2fs → %d entries", failed.Track.Name, failed.Class.Name, duration.Seconds(), len(data))
+			loaderLog.Infof("✅ Retry succeeded %s + %s: %.2fs → %d entries", failed.Track.Name, failed.Class.Name, duration.Seconds(), len(data))
 			retriedTracks = append(retriedTracks, trackInfo)
 			retriedCount++
 		} else {
-			log.Printf("ℹ️ Retry succeeded %s + %s: %.2fs → no data", failed.Track.Name, failed.Class.Name, duration.Seconds())
+			loaderLog.Infof("ℹ️ Retry succeeded %s + %s: %.2fs → no data", failed.Track.Name, failed.Class.Name, duration.Seconds())
 		}
-
-		// Rate limiting
-		time.Sleep(20 * time.Millisecond)
 	}
 
-	log.Printf("✅ Retry phase complete: %d/%d succeeded", retriedCount, len(failedFetches))
+	loaderLog.Infof("✅ Retry phase complete: %d/%d succeeded", retriedCount, len(failedFetches))
 	return retriedTracks
 }
 
 // fetchWithTimeout performs a single fetch with timeout and error handling
-func fetchWithTimeout(ctx context.Context, apiClient *APIClient, track TrackConfig, class CarClassConfig) ([]map[string]interface{}, time.Duration, error) {
-	fetchCtx, fetchCancel := context.WithTimeout(ctx, 120*time.Second)
+// It first waits while fetching is paused (see PauseFetchFile) and for the adaptive throttle, and records the
+// outcome in the throttle, the failed-fetch queue and the fetch statistics; fetched entries go to the schema check
+func fetchWithTimeout(ctx context.Context, apiClient *APIClient, track TrackConfig, class CarClassConfig) ([]LeaderboardEntry, time.Duration, error) {
+	if err := waitWhilePaused(ctx); err != nil {
+		return nil, 0, err
+	}
+	if err := fetchThrottle.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	fetchCtx, fetchCancel := context.WithTimeout(ctx, apiClient.combinationTimeout)
 	defer fetchCancel()
-	return apiClient.FetchLeaderboardData(fetchCtx, track.TrackID, class.ClassID)
+	data, duration, err := apiClient.FetchLeaderboardData(fetchCtx, track.TrackID, class.ClassID)
+	if err == nil || ctx.Err() == nil {
+		fetchThrottle.observe(len(data), duration, err)
+	}
+	if err == nil {
+		observeSchema(data)
+	}
+	recordFetchOutcome(ctx, track, class, err)
+	recordFetchStats(ctx, track, class, len(data), duration, err)
+	return data, duration, err
 }
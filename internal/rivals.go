@@ -0,0 +1,95 @@
+package internal
+
+import "errors"
+
+// maxRivalCombinations caps the leaderboards loaded by one rivals request
+const maxRivalCombinations = 50
+
+// Rival is an entry next to the driver's, with the gap to the driver's lap
+type Rival struct {
+	DriverResult
+	GapMs *int64 `json:"gap_ms"` // Rival's lap minus the driver's: negative when faster; null when either lap time can't be parsed
+}
+
+// CombinationRivals are the drivers just ahead of and just behind a driver in one combination
+type CombinationRivals struct {
+	TrackID      string  `json:"track_id"`
+	Track        string  `json:"track"`
+	ClassID      string  `json:"class_id"`
+	ClassName    string  `json:"class_name"`
+	Position     int     `json:"position"`
+	LapTime      string  `json:"laptime"`
+	LapTimeMs    int64   `json:"laptime_ms"`
+	TotalEntries int     `json:"total_entries"`
+	Faster       []Rival `json:"faster"` // Up to count entries directly ahead, by position
+	Slower       []Rival `json:"slower"` // Up to count entries directly behind, by position
+}
+
+// FindRivals returns the count entries directly ahead of and behind a driver on each combination of
+// results (the driver's indexed results), read from the main cache. At most maxRivalCombinations are
+// loaded, in the order of results; combinations no longer cached or no longer listing the driver are skipped
+func FindRivals(results []DriverResult, count int) ([]CombinationRivals, error) {
+	rivals := []CombinationRivals{}
+	if len(results) > maxRivalCombinations {
+		results = results[:maxRivalCombinations]
+	}
+	for _, result := range results {
+		trackInfo, leaderboard, err := LoadLeaderboard(result.TrackID, result.ClassID)
+		if errors.Is(err, ErrCombinationNotCached) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		at := driverRow(leaderboard, result)
+		if at < 0 {
+			continue
+		}
+		driver := leaderboard[at]
+		rivals = append(rivals, CombinationRivals{
+			TrackID:      driver.TrackID,
+			Track:        trackInfo.Name,
+			ClassID:      driver.ClassID,
+			ClassName:    GetCarClassName(driver.ClassID),
+			Position:     driver.Position,
+			LapTime:      driver.LapTime,
+			LapTimeMs:    driver.LapTimeMs,
+			TotalEntries: len(leaderboard),
+			Faster:       withRivalGaps(leaderboard[max(at-count, 0):at], driver),
+			Slower:       withRivalGaps(leaderboard[at+1:min(at+1+count, len(leaderboard))], driver),
+		})
+	}
+	return rivals, nil
+}
+
+// driverRow returns the leaderboard row of the driver of an indexed result, -1 when it isn't listed
+// The RaceRoom user ID decides when both carry one; otherwise the exact name, as folded names such as
+// "José Silva" and "Jose Silva" belong to different drivers
+func driverRow(leaderboard []DriverResult, result DriverResult) int {
+	if result.DriverID != "" {
+		for i := range leaderboard {
+			if leaderboard[i].DriverID == result.DriverID {
+				return i
+			}
+		}
+	}
+	for i := range leaderboard {
+		if leaderboard[i].Name == result.Name && (leaderboard[i].DriverID == "" || result.DriverID == "") {
+			return i
+		}
+	}
+	return -1
+}
+
+// withRivalGaps wraps entries with their gap to the driver
+func withRivalGaps(entries []DriverResult, driver DriverResult) []Rival {
+	rivals := make([]Rival, len(entries))
+	for i, entry := range entries {
+		rivals[i].DriverResult = entry
+		if entry.LapTimeMs > 0 && driver.LapTimeMs > 0 {
+			gap := entry.LapTimeMs - driver.LapTimeMs
+			rivals[i].GapMs = &gap
+		}
+	}
+	return rivals
+}
@@ -0,0 +1,36 @@
+package internal
+
+import "testing"
+
+func TestFindRivalsMatchesDriverID(t *testing.T) {
+	chdirTemp(t)
+
+	// "José Silva" and "Jose Silva" share a search key but are different drivers
+	err := NewDataCache().SaveTrackData(TrackInfo{Name: "Hockenheimring - Grand Prix", TrackID: "1693", ClassID: "1703", Data: []LeaderboardEntry{
+		{Index: 0, LapTime: "1m 37.000s", Driver: EntryDriver{Name: "José Silva", ID: "101"}},
+		{Index: 1, LapTime: "1m 38.000s", Driver: EntryDriver{Name: "Other Driver", ID: "303"}},
+		{Index: 2, LapTime: "1m 39.000s", Driver: EntryDriver{Name: "Jose Silva", ID: "202"}},
+		{Index: 3, LapTime: "1m 40.000s", Driver: EntryDriver{Name: "No ID Driver"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		result   DriverResult
+		position int
+	}{
+		{DriverResult{Name: "Jose Silva", DriverID: "202", TrackID: "1693", ClassID: "1703"}, 3},
+		{DriverResult{Name: "José Silva", DriverID: "101", TrackID: "1693", ClassID: "1703"}, 1},
+		{DriverResult{Name: "No ID Driver", TrackID: "1693", ClassID: "1703"}, 4},
+	}
+	for _, test := range tests {
+		rivals, err := FindRivals([]DriverResult{test.result}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rivals) != 1 || rivals[0].Position != test.position {
+			t.Errorf("FindRivals(%s, %q) = %+v, want position %d", test.result.Name, test.result.DriverID, rivals, test.position)
+		}
+	}
+}
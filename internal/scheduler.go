@@ -1,29 +1,56 @@
 package internal
 
 import (
-	"log"
+	"math/rand"
 	"time"
 )
 
 // Scheduler handles automatic data refresh at scheduled times
 type Scheduler struct {
-	refreshHour   int // Hour of day (0-23) to refresh
-	refreshMinute int // Minute of hour (0-59) to refresh
-	stopChan      chan bool
-	stopped       bool
+	schedule schedule      // Daily time, list of times or cron expression
+	jitter   time.Duration // Random delay of up to this much added to every run
+	stopChan chan bool
+	stopped  bool
 }
 
 // NewScheduler creates a new scheduler with the specified refresh time
 // refreshHour: 0-23, refreshMinute: 0-59
 func NewScheduler(refreshHour, refreshMinute int) *Scheduler {
 	return &Scheduler{
-		refreshHour:   refreshHour,
-		refreshMinute: refreshMinute,
-		stopChan:      make(chan bool),
-		stopped:       false,
+		schedule: dailyTimes{refreshHour*60 + refreshMinute},
+		stopChan: make(chan bool),
+		stopped:  false,
 	}
 }
 
+// NewConfiguredScheduler creates the scheduler of a ScheduleConfig: its cron expression, else its times,
+// else refresh_hour:refresh_minute, with jitter_minutes of random delay
+// An invalid cron expression or time list is logged and the next option is used
+func NewConfiguredScheduler(config ScheduleConfig) *Scheduler {
+	scheduler := NewScheduler(config.RefreshHour, config.RefreshMinute)
+	if config.JitterMinutes > 0 {
+		scheduler.jitter = time.Duration(config.JitterMinutes) * time.Minute
+	}
+
+	if config.Cron != "" {
+		cron, err := parseCron(config.Cron)
+		if err == nil {
+			scheduler.schedule = cron
+			return scheduler
+		}
+		configLog.Warnf("⚠️ Ignoring schedule.cron: %v", err)
+	}
+	if len(config.Times) > 0 {
+		times, err := parseDailyTimes(config.Times)
+		if err == nil {
+			scheduler.schedule = times
+			return scheduler
+		}
+		configLog.Warnf("⚠️ Ignoring schedule.times: %v", err)
+	}
+	return scheduler
+}
+
 // Start begins the background scheduler
 func (s *Scheduler) Start(refreshCallback func()) {
 	go s.runScheduler(refreshCallback)
@@ -34,7 +61,7 @@ func (s *Scheduler) Stop() {
 	if !s.stopped {
 		s.stopped = true
 		close(s.stopChan)
-		log.Println("📅 Scheduler stop signal sent")
+		schedulerLog.Infof("📅 Scheduler stop signal sent")
 	}
 }
 
@@ -42,21 +69,25 @@ func (s *Scheduler) Stop() {
 func (s *Scheduler) runScheduler(refreshCallback func()) {
 	defer func() {
 		// Clean up on exit
-		log.Println("📅 Scheduler goroutine exiting")
+		schedulerLog.Debugf("📅 Scheduler goroutine exiting")
 	}()
 
+	schedulerLog.Infof("📅 Automatic refresh %s", s.schedule)
+	last := time.Now()
 	for {
-		// Calculate time until next refresh time
-		now := time.Now()
-		nextRefresh := time.Date(now.Year(), now.Month(), now.Day(), s.refreshHour, s.refreshMinute, 0, 0, now.Location())
-
-		// If it's already past refresh time today, schedule for tomorrow
-		if now.After(nextRefresh) {
-			nextRefresh = nextRefresh.Add(24 * time.Hour)
+		// Calculate time until next refresh time (after the previous one, so a run ending early can't repeat it)
+		nextRefresh := s.schedule.Next(last)
+		if nextRefresh.IsZero() {
+			schedulerLog.Warnf("⚠️ %s never matches - automatic refresh disabled", s.schedule)
+			return
+		}
+		last = nextRefresh
+		if s.jitter > 0 {
+			nextRefresh = nextRefresh.Add(time.Duration(rand.Int63n(int64(s.jitter))))
 		}
 
 		timeUntilRefresh := time.Until(nextRefresh)
-		log.Printf("📅 Next automatic refresh scheduled in %v (at %s)", timeUntilRefresh.Round(time.Minute), nextRefresh.Format("2006-01-02 15:04"))
+		schedulerLog.Infof("📅 Next automatic refresh scheduled in %v (at %s)", timeUntilRefresh.Round(time.Minute), nextRefresh.Format("2006-01-02 15:04"))
 
 		// Use a timer instead of time.After to allow cleanup
 		timer := time.NewTimer(timeUntilRefresh)
@@ -64,11 +95,14 @@ func (s *Scheduler) runScheduler(refreshCallback func()) {
 		// Wait until refresh time or stop signal
 		select {
 		case <-timer.C:
-			log.Printf("🕓 Automatic refresh triggered at %02d:%02d", s.refreshHour, s.refreshMinute)
+			schedulerLog.Infof("🕓 Automatic refresh triggered at %s", time.Now().Format("15:04"))
 			refreshCallback()
+			if now := time.Now(); now.After(last) {
+				last = now // Skip the runs missed while refreshing
+			}
 		case <-s.stopChan:
 			timer.Stop()
-			log.Println("📅 Scheduler stopped")
+			schedulerLog.Infof("📅 Scheduler stopped")
 			return
 		}
 	}
@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaStatsFile keeps the field missing rates of the last accepted refresh and of the latest one
+const SchemaStatsFile = "cache/schema_stats.json"
+
+// ErrSchemaDrift is returned by PromoteTempCache while the running refresh shows a spike in missing fields
+var ErrSchemaDrift = errors.New("RaceRoom response schema drift")
+
+// Fields every leaderboard entry is expected to carry
+const (
+	SchemaFieldDriverName = "driver.name"
+	SchemaFieldLaptime    = "laptime"
+	SchemaFieldIndex      = "index"
+	SchemaFieldCar        = "car_class.car"
+)
+
+// schemaFields lists the checked fields in report order
+var schemaFields = []string{SchemaFieldDriverName, SchemaFieldLaptime, SchemaFieldIndex, SchemaFieldCar}
+
+// SchemaCheck is the field check of a refresh: how many fetched entries lacked each expected field
+type SchemaCheck struct {
+	Entries    int                `json:"entries"`
+	Missing    map[string]int     `json:"missing"`       // By field
+	Rates      map[string]float64 `json:"missing_rates"` // By field
+	Baseline   map[string]float64 `json:"baseline_rates,omitempty"`
+	Drift      []string           `json:"drift,omitempty"` // Fields whose missing rate spiked
+	DetectedAt *time.Time         `json:"detected_at,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	Quarantine string             `json:"quarantine,omitempty"` // Where the refresh's temp cache was moved
+}
+
+// withRates returns a copy with the missing rates computed from the counts
+func (c SchemaCheck) withRates() SchemaCheck {
+	missing := make(map[string]int, len(c.Missing))
+	c.Rates = make(map[string]float64, len(c.Missing))
+	for field, count := range c.Missing {
+		missing[field] = count
+		if c.Entries > 0 {
+			c.Rates[field] = math.Round(float64(count)/float64(c.Entries)*10000) / 10000
+		}
+	}
+	c.Missing = missing
+	c.Drift = append([]string(nil), c.Drift...)
+	return c
+}
+
+// schemaStatsData is the content of SchemaStatsFile
+type schemaStatsData struct {
+	Baseline        map[string]float64 `json:"baseline_rates"` // Missing rates of the last refresh without drift
+	BaselineEntries int                `json:"baseline_entries"`
+	BaselineAt      time.Time          `json:"baseline_at"`
+	LastRun         *SchemaCheck       `json:"last_run,omitempty"`
+}
+
+// schemaCheck holds the settings and the check of the current (or last) refresh of this process
+var schemaCheck = struct {
+	mu      sync.Mutex
+	config  SchemaCheckConfig
+	loaded  bool
+	stats   schemaStatsData
+	current *SchemaCheck
+	drifted bool
+}{config: GetDefaultConfig().Fetch.Schema}
+
+// setSchemaCheckConfig applies fetch.schema; unset values fall back to the defaults
+func setSchemaCheckConfig(cfg SchemaCheckConfig) {
+	defaults := GetDefaultConfig().Fetch.Schema
+	if cfg.MinEntries <= 0 {
+		cfg.MinEntries = defaults.MinEntries
+	}
+	if cfg.SpikeThreshold <= 0 || cfg.SpikeThreshold > 1 {
+		cfg.SpikeThreshold = defaults.SpikeThreshold
+	}
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	schemaCheck.config = cfg
+}
+
+// loadSchemaStatsLocked reads SchemaStatsFile once; without it there is no baseline yet
+func loadSchemaStatsLocked() {
+	if schemaCheck.loaded {
+		return
+	}
+	schemaCheck.loaded = true
+	data, err := os.ReadFile(SchemaStatsFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &schemaCheck.stats); err != nil {
+		loaderLog.Warnf("⚠️ Ignoring unreadable %s: %v", SchemaStatsFile, err)
+		schemaCheck.stats = schemaStatsData{}
+	}
+}
+
+// saveSchemaStatsLocked writes SchemaStatsFile
+func saveSchemaStatsLocked() {
+	data, err := json.MarshalIndent(schemaCheck.stats, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(SchemaStatsFile), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(SchemaStatsFile, data)
+	}
+	if err != nil {
+		loaderLog.Warnf("⚠️ Failed to save %s: %v", SchemaStatsFile, err)
+	}
+}
+
+// beginSchemaCheck starts the field check of a refresh
+func beginSchemaCheck() {
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	loadSchemaStatsLocked()
+	schemaCheck.current = &SchemaCheck{StartedAt: time.Now().UTC(), Missing: make(map[string]int)}
+	for _, field := range schemaFields {
+		schemaCheck.current.Missing[field] = 0
+	}
+	schemaCheck.drifted = false
+}
+
+// observeSchema counts the missing fields of a fetched combination and checks for drift once
+// min_entries entries were seen. The first detection is logged and published as a schema_drift event.
+func observeSchema(entries []LeaderboardEntry) {
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	check := schemaCheck.current
+	if !schemaCheck.config.Enabled || check == nil || check.FinishedAt != nil {
+		return
+	}
+
+	for i, entry := range entries {
+		if entry.Driver.Name == "" {
+			check.Missing[SchemaFieldDriverName]++
+		}
+		if entry.LapTime == "" {
+			check.Missing[SchemaFieldLaptime]++
+		}
+		if i > 0 && entry.Index == 0 { // Zero-based, so only the first entry may be 0
+			check.Missing[SchemaFieldIndex]++
+		}
+		if entry.CarClass.Car.Name == "" {
+			check.Missing[SchemaFieldCar]++
+		}
+	}
+	check.Entries += len(entries)
+
+	baseline := schemaCheck.stats.Baseline
+	if schemaCheck.drifted || baseline == nil || check.Entries < schemaCheck.config.MinEntries {
+		return // Without a baseline the refresh sets it
+	}
+	var drift []string
+	for _, field := range schemaFields {
+		rate := float64(check.Missing[field]) / float64(check.Entries)
+		if rate-baseline[field] > schemaCheck.config.SpikeThreshold {
+			drift = append(drift, fmt.Sprintf("%s missing in %.0f%% of entries (usually %.0f%%)", field, rate*100, baseline[field]*100))
+			check.Drift = append(check.Drift, field)
+		}
+	}
+	if len(drift) == 0 {
+		return
+	}
+
+	schemaCheck.drifted = true
+	detected := time.Now().UTC()
+	check.DetectedAt = &detected
+	check.Baseline = baseline
+	err := fmt.Errorf("%w: %s - the refresh won't be promoted", ErrSchemaDrift, strings.Join(drift, ", "))
+	loaderLog.Errorf("🧬 %v", err)
+	eventBroker.Publish(EventSchemaDrift, check.withRates())
+}
+
+// schemaDriftError returns an ErrSchemaDrift error while the running refresh has drifted
+func schemaDriftError() error {
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	if !schemaCheck.drifted || schemaCheck.current.FinishedAt != nil {
+		return nil
+	}
+	return fmt.Errorf("%w in %s", ErrSchemaDrift, strings.Join(schemaCheck.current.Drift, ", "))
+}
+
+// finishSchemaCheck ends the field check of a refresh. A drifted refresh's temp cache is moved to
+// cache/quarantine for inspection, so it isn't promoted at the next startup either. A refresh without
+// drift and with at least min_entries entries becomes the new baseline.
+func finishSchemaCheck() {
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	check := schemaCheck.current
+	if !schemaCheck.config.Enabled || check == nil || check.FinishedAt != nil {
+		return
+	}
+	finished := time.Now().UTC()
+	check.FinishedAt = &finished
+	check.Baseline = schemaCheck.stats.Baseline
+	if schemaCheck.drifted {
+		check.Quarantine = quarantineTempCache("schema-drift")
+	}
+
+	last := check.withRates()
+	if !schemaCheck.drifted && check.Entries >= schemaCheck.config.MinEntries {
+		schemaCheck.stats.Baseline = last.Rates
+		schemaCheck.stats.BaselineEntries = check.Entries
+		schemaCheck.stats.BaselineAt = finished
+	}
+	schemaCheck.stats.LastRun = &last
+	saveSchemaStatsLocked()
+}
+
+// CurrentSchemaCheck returns the field check of the running refresh, or of the last finished one
+func CurrentSchemaCheck() (SchemaCheck, bool) {
+	schemaCheck.mu.Lock()
+	defer schemaCheck.mu.Unlock()
+	loadSchemaStatsLocked()
+	check := schemaCheck.current
+	if check == nil {
+		check = schemaCheck.stats.LastRun
+	}
+	if check == nil {
+		return SchemaCheck{}, false
+	}
+	report := check.withRates()
+	if report.FinishedAt == nil {
+		report.Baseline = schemaCheck.stats.Baseline
+	}
+	return report, true
+}
+
+// quarantineTempCache moves the temp cache directory to cache/quarantine/<label>-<time>
+// Returns the new location, or "" when there was nothing to move or the move failed
+func quarantineTempCache(label string) string {
+	tempCache := NewTempDataCache()
+	if _, err := os.Stat(tempCache.tempCacheDir); err != nil {
+		return ""
+	}
+	dir := filepath.Join(tempCache.cacheDir, "quarantine")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cacheLog.Warnf("⚠️ Failed to create quarantine directory: %v", err)
+		return ""
+	}
+	target := filepath.Join(dir, label+"-"+time.Now().UTC().Format("20060102-150405"))
+	if err := os.Rename(tempCache.tempCacheDir, target); err != nil {
+		cacheLog.Warnf("⚠️ Failed to quarantine %s: %v", tempCache.tempCacheDir, err)
+		return ""
+	}
+	cacheLog.Warnf("☣️ Quarantined %s → %s", tempCache.tempCacheDir, target)
+	return target
+}
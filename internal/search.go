@@ -0,0 +1,350 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SearchEngine keeps the most recently built driver index in memory
+// so the HTTP API can answer lookups without reading driver_index.json
+// A new index is prepared off to the side and swapped in as a whole, so readers never see a partial index
+type SearchEngine struct {
+	mu      sync.RWMutex // Guards aliases and serializes index swaps; readers of the index don't take it
+	current atomic.Pointer[indexSnapshot]
+	aliases *AliasStore // Merges the results of renamed drivers; nil without aliases
+}
+
+// indexSnapshot is one installed index with its lookup structures; never modified once installed
+type indexSnapshot struct {
+	index      DriverIndex
+	names      []string            // Sorted index keys for prefix lookups
+	ids        map[string][]string // RaceRoom user ID -> index keys holding results of that driver
+	grams      ngramIndex          // Trigrams of the keys for substring searches
+	counts     map[string]int      // trackID_classID -> entry count from the last build
+	lastUpdate time.Time
+}
+
+// newIndexSnapshot builds the lookup structures of an index
+func newIndexSnapshot(index DriverIndex, trackEntryCounts map[string]int, builtAt time.Time) *indexSnapshot {
+	if trackEntryCounts == nil {
+		trackEntryCounts = make(map[string]int)
+	}
+	names, ids := indexKeys(index)
+	return &indexSnapshot{
+		index:      index,
+		names:      names,
+		ids:        ids,
+		grams:      buildNgramIndex(names),
+		counts:     trackEntryCounts,
+		lastUpdate: builtAt,
+	}
+}
+
+// CombinationStats summarizes indexed combinations for a track or a class
+type CombinationStats struct {
+	Combinations int
+	Entries      int
+}
+
+// DriverSuggestion is a single autocomplete match
+type DriverSuggestion struct {
+	Name    string `json:"name"`    // Display name as seen on the leaderboard
+	Key     string `json:"key"`     // Normalized index key
+	Entries int    `json:"entries"` // Number of track/class results
+}
+
+// searchEngine is the process-wide engine refreshed by BuildAndExportIndex
+var searchEngine = NewSearchEngine()
+
+// NewSearchEngine creates an empty search engine
+func NewSearchEngine() *SearchEngine {
+	se := &SearchEngine{}
+	se.current.Store(newIndexSnapshot(make(DriverIndex), nil, time.Time{}))
+	return se
+}
+
+// snapshot returns the live index; callers making several reads use one snapshot so they all see the same index
+// Snapshots are shared by every concurrent reader, so nothing reachable from one may be modified
+func (se *SearchEngine) snapshot() *indexSnapshot {
+	return se.current.Load()
+}
+
+// GetSearchEngine returns the shared search engine updated on every index build
+func GetSearchEngine() *SearchEngine {
+	return searchEngine
+}
+
+// SetIndex replaces the in-memory index with a freshly built one
+// trackEntryCounts maps trackID_classID to the number of entries in that combination
+func (se *SearchEngine) SetIndex(index DriverIndex, trackEntryCounts map[string]int) {
+	se.install(index, trackEntryCounts, time.Now(), false)
+}
+
+// LoadPersisted loads the last exported index from disk (binary first, JSON as fallback)
+// It only installs the loaded index if no index has been built in this process yet,
+// so a slow load never overwrites fresher data
+func (se *SearchEngine) LoadPersisted() error {
+	start := time.Now()
+
+	var index DriverIndex
+	var counts map[string]int
+	var builtAt time.Time
+	source := DriverIndexBinaryFile
+
+	payload, binErr := ReadDriverIndexBinary()
+	if binErr == nil {
+		index, counts, builtAt = payload.Index, payload.TrackEntryCounts, payload.BuiltAt
+	} else {
+		jsonIndex, modTime, jsonErr := ReadDriverIndexJSON()
+		if jsonErr != nil {
+			return fmt.Errorf("binary index: %v; json index: %w", binErr, jsonErr)
+		}
+		index, counts, builtAt = jsonIndex, entryCountsFromIndex(jsonIndex), modTime
+		source = DriverIndexFile
+	}
+
+	// Indexes written before lap times were parsed lack laptime_ms
+	// Decoding gives every result its own strings, so they are interned like a freshly built index
+	interner := newStringInterner()
+	for _, results := range index {
+		for i := range results {
+			interner.internResult(&results[i])
+			if results[i].LapTimeMs == 0 {
+				if lapTime, ok := ParseLapTime(results[i].LapTime); ok {
+					results[i].LapTimeMs = lapTime.Milliseconds()
+				}
+			}
+		}
+	}
+
+	if !se.install(index, counts, builtAt, true) {
+		searchLog.Infof("ℹ️ Persisted driver index skipped - a fresher index is already live")
+		return nil
+	}
+	searchLog.Infof("📂 Loaded persisted driver index from %s in %.3f seconds (%d drivers, built %s)",
+		source, time.Since(start).Seconds(), len(index), builtAt.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// install swaps in a new index; with onlyIfEmpty it refuses to replace a built index
+// Returns whether the index was installed
+func (se *SearchEngine) install(index DriverIndex, trackEntryCounts map[string]int, builtAt time.Time, onlyIfEmpty bool) bool {
+	index = withoutExcludedDrivers(index) // A persisted index may predate an exclusion
+	next := newIndexSnapshot(index, trackEntryCounts, builtAt)
+
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	if onlyIfEmpty && !se.snapshot().lastUpdate.IsZero() {
+		return false
+	}
+	se.current.Store(next)
+	return true
+}
+
+// ApplyExclusions drops the results of excluded drivers from the live index without waiting for a rebuild
+func (se *SearchEngine) ApplyExclusions() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	live := se.snapshot()
+	se.current.Store(newIndexSnapshot(withoutExcludedDrivers(live.index), live.counts, live.lastUpdate))
+}
+
+// indexKeys returns the sorted keys of an index and the keys holding each RaceRoom user ID
+func indexKeys(index DriverIndex) ([]string, map[string][]string) {
+	names := make([]string, 0, len(index))
+	for key := range index {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	ids := make(map[string][]string)
+	for _, key := range names {
+		var last string
+		for _, result := range index[key] {
+			if result.DriverID != "" && result.DriverID != last {
+				last = result.DriverID
+				if keys := ids[last]; len(keys) == 0 || keys[len(keys)-1] != key {
+					ids[last] = append(keys, key)
+				}
+			}
+		}
+	}
+	return names, ids
+}
+
+// entryCountsFromIndex derives per-combination entry counts from the results themselves
+// (every result carries its leaderboard's TotalEntries)
+func entryCountsFromIndex(index DriverIndex) map[string]int {
+	counts := make(map[string]int)
+	for _, results := range index {
+		for _, result := range results {
+			counts[result.TrackID+"_"+result.ClassID] = result.TotalEntries
+		}
+	}
+	return counts
+}
+
+// DriverCount returns the number of distinct drivers in the index
+func (se *SearchEngine) DriverCount() int {
+	return len(se.snapshot().index)
+}
+
+// LastUpdate returns when the in-memory index was last replaced
+func (se *SearchEngine) LastUpdate() time.Time {
+	return se.snapshot().lastUpdate
+}
+
+// SetAliases sets the alias table consulted by Lookup, Search and DriverProfile
+func (se *SearchEngine) SetAliases(aliases *AliasStore) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.aliases = aliases
+}
+
+// Aliases returns the alias table, nil when none was set
+func (se *SearchEngine) Aliases() *AliasStore {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+	return se.aliases
+}
+
+// Lookup returns all results for a driver name (normalized before lookup)
+// A name with an alias returns the results of the canonical name, every alternate name and every aliased user ID
+// Without an alias the slice is shared with the live index and other readers: callers must not modify it
+func (se *SearchEngine) Lookup(name string) []DriverResult {
+	key := NormalizeDriverName(name)
+
+	live := se.snapshot()
+	alias, ok := se.Aliases().Resolve(key)
+	if !ok {
+		return live.index[key]
+	}
+
+	var results []DriverResult
+	seen := make(map[string]bool)
+	add := func(result DriverResult) {
+		id := result.TrackID + "_" + result.ClassID + "_" + strconv.Itoa(result.Position)
+		if !seen[id] {
+			seen[id] = true
+			results = append(results, result)
+		}
+	}
+	for _, name := range append([]string{alias.Canonical}, alias.Names...) {
+		for _, result := range live.index[NormalizeDriverName(name)] {
+			add(result)
+		}
+	}
+	for _, id := range alias.DriverIDs {
+		for _, key := range live.ids[id] {
+			for _, result := range live.index[key] {
+				if result.DriverID == id {
+					add(result)
+				}
+			}
+		}
+	}
+	sortDriverResults(results)
+	return results
+}
+
+// DriverProfile builds the profile of a driver from their Lookup results; an aliased driver is shown
+// under the canonical name with the alternate names listed
+func (se *SearchEngine) DriverProfile(name string, results []DriverResult) DriverProfile {
+	alias, ok := se.Aliases().Resolve(name)
+	if !ok {
+		return BuildDriverProfile(NormalizeDriverName(name), results)
+	}
+	profile := BuildDriverProfile(NormalizeDriverName(alias.Canonical), results)
+	profile.Name = alias.Canonical
+	profile.Aliases = append([]string{}, alias.Names...)
+	return profile
+}
+
+// LookupID returns all results of a RaceRoom user ID, across every name the driver used
+func (se *SearchEngine) LookupID(id string) []DriverResult {
+	live := se.snapshot()
+	var results []DriverResult
+	for _, key := range live.ids[id] {
+		for _, result := range live.index[key] {
+			if result.DriverID == id {
+				results = append(results, result)
+			}
+		}
+	}
+	return results
+}
+
+// Scan calls fn for every driver of the live index; an index swapped in meanwhile isn't seen
+// fn must not modify the results slice
+func (se *SearchEngine) Scan(fn func(key string, results []DriverResult)) {
+	for key, results := range se.snapshot().index {
+		fn(key, results)
+	}
+}
+
+// StatsByTrack aggregates indexed combinations and entries per track ID
+func (se *SearchEngine) StatsByTrack() map[string]CombinationStats {
+	return se.aggregateCounts(func(trackID, classID string) string { return trackID })
+}
+
+// StatsByClass aggregates indexed combinations and entries per class ID
+func (se *SearchEngine) StatsByClass() map[string]CombinationStats {
+	return se.aggregateCounts(func(trackID, classID string) string { return classID })
+}
+
+// aggregateCounts groups combination entry counts by the key returned from keyFn
+func (se *SearchEngine) aggregateCounts(keyFn func(trackID, classID string) string) map[string]CombinationStats {
+	stats := make(map[string]CombinationStats)
+	for combo, entries := range se.snapshot().counts {
+		if entries == 0 {
+			continue
+		}
+		trackID, classID, ok := strings.Cut(combo, "_")
+		if !ok {
+			continue
+		}
+		key := keyFn(trackID, classID)
+		s := stats[key]
+		s.Combinations++
+		s.Entries += entries
+		stats[key] = s
+	}
+	return stats
+}
+
+// Autocomplete returns up to limit drivers whose normalized name starts with prefix
+func (se *SearchEngine) Autocomplete(prefix string, limit int) []DriverSuggestion {
+	prefix = NormalizeDriverName(prefix)
+	suggestions := make([]DriverSuggestion, 0, limit)
+	if prefix == "" || limit <= 0 {
+		return suggestions
+	}
+
+	live := se.snapshot()
+
+	// Binary search to the first key >= prefix, then walk while keys still match
+	start := sort.SearchStrings(live.names, prefix)
+	for i := start; i < len(live.names) && len(suggestions) < limit; i++ {
+		key := live.names[i]
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+
+		results := live.index[key]
+		name := key
+		if len(results) > 0 {
+			name = results[0].Name
+		}
+		suggestions = append(suggestions, DriverSuggestion{
+			Name:    name,
+			Key:     key,
+			Entries: len(results),
+		})
+	}
+
+	return suggestions
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// chdirTemp runs the rest of the test in a fresh temporary working directory, so relative cache paths stay inside it
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// testIndex builds an index of drivers "Driver 0" to "Driver <drivers-1>", each with one result on trackID
+func testIndex(trackID string, drivers int) (DriverIndex, map[string]int) {
+	index := make(DriverIndex, drivers)
+	for i := 0; i < drivers; i++ {
+		name := fmt.Sprintf("Driver %d", i)
+		index[NormalizeDriverName(name)] = []DriverResult{{
+			Name:      name,
+			DriverID:  strconv.Itoa(i),
+			Position:  i + 1,
+			LapTime:   "1m 40.000s",
+			LapTimeMs: 100000 + int64(i),
+			TrackID:   trackID,
+			ClassID:   "1703",
+		}}
+	}
+	return index, map[string]int{trackID + "_1703": drivers}
+}
+
+// singleTrack fails the test when results come from more than one index, i.e. a read mixed two snapshots
+func singleTrack(t *testing.T, what string, results []DriverResult) {
+	for _, result := range results {
+		if result.TrackID != results[0].TrackID {
+			t.Errorf("%s mixes indexes: track %s and %s", what, results[0].TrackID, result.TrackID)
+			return
+		}
+	}
+}
+
+func TestSearchEngineConcurrentReadsAndSwaps(t *testing.T) {
+	chdirTemp(t)
+
+	// Two indexes of different sizes, so a reader can tell which one it saw
+	indexes := map[string]int{"1": 50, "2": 80}
+	se := NewSearchEngine()
+	first, firstCounts := testIndex("1", indexes["1"])
+	se.SetIndex(first, firstCounts)
+
+	aliasStores := []*AliasStore{nil}
+	for i, names := range [][]string{{"Driver 8"}, {"Driver 8", "Driver 9"}} {
+		store := LoadAliasStore(filepath.Join("cache", fmt.Sprintf("aliases_%d.json", i)))
+		if _, err := store.Set(DriverAlias{Canonical: "Driver 7", Names: names, DriverIDs: []string{"10"}}); err != nil {
+			t.Fatal(err)
+		}
+		aliasStores = append(aliasStores, store)
+	}
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				singleTrack(t, "Lookup", se.Lookup("Driver 7"))
+				singleTrack(t, "LookupID", se.LookupID("12"))
+
+				for _, suggestion := range se.Autocomplete("driver 1", 5) {
+					if suggestion.Entries != 1 {
+						t.Errorf("Autocomplete %s: %d entries, want 1", suggestion.Key, suggestion.Entries)
+					}
+				}
+
+				var scanned []DriverResult
+				se.Scan(func(key string, results []DriverResult) {
+					scanned = append(scanned, results...)
+				})
+				singleTrack(t, "Scan", scanned)
+
+				stats := se.StatsByTrack()
+				if len(stats) != 1 {
+					t.Errorf("StatsByTrack returned %d tracks, want 1", len(stats))
+				}
+				for trackID, stat := range stats {
+					if stat.Combinations != 1 || stat.Entries != indexes[trackID] {
+						t.Errorf("StatsByTrack[%s] = %+v, want 1 combination with %d entries", trackID, stat, indexes[trackID])
+					}
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	writers.Add(3)
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 200; i++ {
+			trackID := "1"
+			if i%2 == 0 {
+				trackID = "2"
+			}
+			index, counts := testIndex(trackID, indexes[trackID])
+			se.SetIndex(index, counts)
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := AddDriverExclusion(DriverExclusion{Name: "Driver 3"}); err != nil {
+				t.Error(err)
+				return
+			}
+			se.ApplyExclusions()
+			if err := RemoveDriverExclusion("Driver 3", ""); err != nil {
+				t.Error(err)
+				return
+			}
+			se.ApplyExclusions()
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 500; i++ {
+			se.SetAliases(aliasStores[i%len(aliasStores)])
+		}
+	}()
+
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	if got := len(DriverExclusions()); got != 0 {
+		t.Errorf("%d exclusions left after the test", got)
+	}
+}
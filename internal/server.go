@@ -0,0 +1,1468 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAutocompleteLimit    = 20
+	maxAutocompleteLimit        = 100
+	defaultTeamsLimit           = 100
+	maxTeamsLimit               = 1000
+	defaultLeaderboardLimit     = 100
+	maxLeaderboardLimit         = 5000
+	defaultTopCombinationsLimit = 100
+	defaultChangesLimit         = 100
+	maxChangesLimit             = 1000
+	maxTopCombinationsLimit     = 1000
+	defaultMoversLimit          = 20
+	maxMoversLimit              = 100
+	defaultChampionshipLimit    = 100
+	maxChampionshipLimit        = 1000
+	defaultRivalsCount          = 5
+	maxRivalsCount              = 50
+	defaultSearchLimit          = 100
+	maxSearchLimit              = 1000
+	sseHeartbeatInterval        = 30 * time.Second
+)
+
+// DefaultAPIPrefix is the path the JSON API is mounted under unless configured otherwise
+const DefaultAPIPrefix = "/api"
+
+// APIServer exposes the in-memory index over a small JSON HTTP API
+type APIServer struct {
+	engine         *SearchEngine
+	jobs           *JobQueue
+	keys           *APIKeyStore
+	limiter        *RateLimiter
+	onDemandFetch  bool   // Queue a fetch for uncached leaderboard requests
+	adminToken     string // Required by admin endpoints; empty disables them
+	requireAPIKey  bool   // Reject requests without a valid API key
+	rateLimits     RateLimitConfig
+	rateWindow     time.Duration
+	trustedProxies []*net.IPNet
+	prefix         string    // Mount path without trailing slash ("" at the root)
+	legacyRoutes   bool      // Also serve the unversioned paths
+	legacySunset   time.Time // Advertised end of the legacy paths; zero when unset
+}
+
+// NewAPIServer creates an API server backed by the given search engine, job queue and key store
+func NewAPIServer(engine *SearchEngine, jobs *JobQueue, keys *APIKeyStore, config ServerConfig) *APIServer {
+	return &APIServer{
+		engine:         engine,
+		jobs:           jobs,
+		keys:           keys,
+		limiter:        NewRateLimiter(),
+		onDemandFetch:  config.OnDemandFetch,
+		adminToken:     config.AdminToken,
+		requireAPIKey:  config.RequireAPIKey,
+		rateLimits:     config.RateLimit,
+		rateWindow:     rateWindowDuration(config.RateLimit.WindowSeconds),
+		trustedProxies: parseTrustedProxies(config.RateLimit.TrustedProxies),
+		prefix:         normalizeAPIPrefix(config.APIPrefix),
+		legacyRoutes:   config.LegacyRoutes.Enabled,
+		legacySunset:   parseSunset(config.LegacyRoutes.Sunset),
+	}
+}
+
+// normalizeAPIPrefix returns prefix with a leading and without a trailing slash
+// An empty prefix selects DefaultAPIPrefix; "/" mounts the API at the root
+func normalizeAPIPrefix(prefix string) string {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return DefaultAPIPrefix
+	}
+	return "/" + strings.Trim(prefix, "/")
+}
+
+// Prefix returns the path the API is mounted under
+func (s *APIServer) Prefix() string {
+	return s.prefix
+}
+
+// RegisterRoutes registers all API endpoints on the given mux, behind the rate limiter:
+// enveloped under <prefix>/v1 and, while legacy routes are enabled, unwrapped at their deprecated unversioned paths
+func (s *APIServer) RegisterRoutes(mux *http.ServeMux) {
+	registered := make(map[string]bool)
+	handle := func(pattern string, handler http.Handler) {
+		mux.Handle(pattern, s.logRequests(pattern, handler))
+	}
+	for _, route := range s.routes() {
+		legacy := s.prefix + route.path
+		registered[legacy] = true
+		// Both paths share the rate limit policy of the legacy path
+		handle(s.versionPath(legacy), s.versioned(route, s.rateLimit(legacy, route.handler)))
+		if s.legacyRoutes {
+			handle(legacy, s.deprecated(s.rateLimit(legacy, route.handler)))
+		}
+	}
+
+	// The OpenAPI document describes the versioned routes from a fixed, unversioned path
+	openAPIPath := s.prefix + "/openapi.json"
+	registered[openAPIPath] = true
+	handle(openAPIPath, s.rateLimit(openAPIPath, s.HandleOpenAPI))
+
+	for route := range s.rateLimits.Routes {
+		if !registered[route] {
+			httpLog.Warnf("⚠️ Rate limit policy for unknown route %s is ignored", route)
+		}
+	}
+}
+
+// routes lists the endpoints of the JSON API below the prefix
+func (s *APIServer) routes() []apiRoute {
+	return []apiRoute{
+		{path: "/drivers", handler: s.HandleDrivers},
+		{path: "/tracks", handler: s.HandleTracks},
+		{path: "/classes", handler: s.HandleClasses},
+		{path: "/driver", handler: s.HandleDriverProfile},
+		{path: "/search", handler: s.HandleSearch},
+		{path: "/search/batch", handler: s.HandleSearchBatch},
+		{path: "/index/shards", handler: s.HandleIndexShards},
+		{path: "/country", handler: s.HandleCountry},
+		{path: "/team", handler: s.HandleTeam},
+		{path: "/teams", handler: s.HandleTeams},
+		{path: "/status", handler: s.HandleStatus},
+		{path: "/top-combinations", handler: s.HandleTopCombinations},
+		{path: "/stats/combination", handler: s.HandleCombinationStats},
+		{path: "/leaderboard", handler: s.HandleLeaderboard},
+		{path: "/snapshots", handler: s.HandleSnapshots},
+		{path: "/changes", handler: s.HandleChanges},
+		{path: "/movers", handler: s.HandleMovers},
+		{path: "/championship", handler: s.HandleChampionship},
+		{path: "/rivals", handler: s.HandleRivals},
+		{path: "/export/entries.jsonl", handler: s.HandleExportEntries},
+		{path: "/graphql", handler: s.HandleGraphQL, raw: true},
+		{path: "/jobs/", handler: s.HandleJob},
+		{path: "/refresh", handler: s.HandleRefresh},
+		{path: "/fetch/pause", handler: s.HandleFetchPause},
+		{path: "/fetch/resume", handler: s.HandleFetchPause},
+		{path: "/fetch/failed", handler: s.HandleFailedFetches},
+		{path: "/fetch/failed/retry", handler: s.HandleFailedFetches},
+		{path: "/catalog/reload", handler: s.HandleCatalogReload},
+		{path: "/cache/validate", handler: s.HandleCacheValidate},
+		{path: "/reports/generate", handler: s.HandleReportGenerate},
+		{path: "/events", handler: s.HandleEvents, raw: true},
+		{path: "/ws", handler: s.HandleWebSocket, raw: true},
+		{path: "/keys", handler: s.HandleAPIKeys},
+		{path: "/aliases", handler: s.HandleAliases},
+		{path: "/exclusions", handler: s.HandleExclusions},
+		{path: "/usage", handler: s.HandleUsage},
+		{path: "/debug/runtime", handler: s.HandleDebugRuntime},
+		{path: debugPprofPath, handler: s.HandleDebugPprof, raw: true},
+	}
+}
+
+// TrackSummary describes a configured track layout and its cached data
+type TrackSummary struct {
+	TrackID             string `json:"track_id"`
+	Name                string `json:"name"`
+	Venue               string `json:"venue"`  // Circuit name shared by all layouts
+	Layout              string `json:"layout"` // Layout within the venue
+	CachedCombinations  int    `json:"cached_combinations"`
+	IndexedCombinations int    `json:"indexed_combinations"`
+	Entries             int    `json:"entries"`
+}
+
+// ClassSummary describes a configured car class and its indexed data
+type ClassSummary struct {
+	ClassID             string `json:"class_id"`
+	Name                string `json:"name"`
+	IndexedCombinations int    `json:"indexed_combinations"`
+	Entries             int    `json:"entries"`
+}
+
+// HandleDrivers serves driver name autocomplete: /api/drivers?prefix=xyz&limit=20
+func (s *APIServer) HandleDrivers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, "missing prefix parameter")
+		return
+	}
+
+	limit := parseLimit(r.URL.Query().Get("limit"), defaultAutocompleteLimit, maxAutocompleteLimit)
+	suggestions := s.engine.Autocomplete(prefix, limit)
+
+	writeJSON(w, http.StatusOK, DriverSuggestionsResponse{
+		Prefix:  prefix,
+		Count:   len(suggestions),
+		Results: suggestions,
+	})
+}
+
+// HandleTracks lists all configured tracks with cache and index statistics: /api/tracks
+func (s *APIServer) HandleTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	results := s.trackSummaries()
+	writeJSON(w, http.StatusOK, TrackListResponse{
+		Count:   len(results),
+		Results: results,
+	})
+}
+
+// trackSummaries describes every configured track with its cache and index statistics
+func (s *APIServer) trackSummaries() []TrackSummary {
+	cachedByTrack := NewDataCache().CountCachedCombinationsByTrack()
+	statsByTrack := s.engine.StatsByTrack()
+
+	tracks := GetTracks()
+	results := make([]TrackSummary, 0, len(tracks))
+	for _, track := range tracks {
+		venue, layout, _ := strings.Cut(track.Name, " - ")
+		stats := statsByTrack[track.TrackID]
+		results = append(results, TrackSummary{
+			TrackID:             track.TrackID,
+			Name:                track.Name,
+			Venue:               venue,
+			Layout:              layout,
+			CachedCombinations:  cachedByTrack[track.TrackID],
+			IndexedCombinations: stats.Combinations,
+			Entries:             stats.Entries,
+		})
+	}
+	return results
+}
+
+// HandleClasses lists all configured car classes with index statistics: /api/classes
+func (s *APIServer) HandleClasses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	results := s.classSummaries()
+	writeJSON(w, http.StatusOK, ClassListResponse{
+		Count:   len(results),
+		Results: results,
+	})
+}
+
+// classSummaries describes every configured car class with its index statistics
+func (s *APIServer) classSummaries() []ClassSummary {
+	statsByClass := s.engine.StatsByClass()
+
+	classes := GetCarClasses()
+	results := make([]ClassSummary, 0, len(classes))
+	for _, class := range classes {
+		stats := statsByClass[class.ClassID]
+		results = append(results, ClassSummary{
+			ClassID:             class.ClassID,
+			Name:                class.Name,
+			IndexedCombinations: stats.Combinations,
+			Entries:             stats.Entries,
+		})
+	}
+	return results
+}
+
+// HandleDriverProfile returns an aggregated profile for one driver: /api/driver?name=X
+// track, class, country, car, team, rank and difficulty narrow the results (see ResultFilter)
+// With &format=csv it returns the driver's results as CSV instead
+func (s *APIServer) HandleDriverProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing name parameter")
+		return
+	}
+
+	all := s.engine.Lookup(name)
+	if len(all) == 0 {
+		writeError(w, http.StatusNotFound, "driver not found")
+		return
+	}
+	results := ParseResultFilter(r.URL.Query()).Apply(all)
+
+	if wantsCSV(r) {
+		if err := writeResultsCSV(w, "driver_"+NormalizeDriverName(name)+".csv", results); err != nil {
+			requestLog(r).Warnf("⚠️ CSV export of driver %s interrupted: %v", name, err)
+		}
+		return
+	}
+	profile := s.engine.DriverProfile(name, results)
+	if profile.Name == "" {
+		profile.Name = all[0].Name // Every result was filtered out
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// HandleSearch runs a structured query over the indexed results: /api/search?q=driver:"max" country:DE class:1703&limit=100
+// driver_id=12345 (with or without q) selects a driver by RaceRoom user ID
+func (s *APIServer) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	raw := r.URL.Query().Get("q")
+	driverIDs := parseIDList(r.URL.Query().Get("driver_id"))
+	if raw == "" && len(driverIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "missing q or driver_id parameter")
+		return
+	}
+	var query SearchQuery
+	if raw != "" {
+		var err error
+		if query, err = ParseSearchQuery(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid query: "+err.Error())
+			return
+		}
+	}
+	query.DriverIDs = append(query.DriverIDs, driverIDs...)
+	limit := parseLimit(r.URL.Query().Get("limit"), defaultSearchLimit, maxSearchLimit)
+	if notModified(w, r, s.engine.indexETag()) {
+		return
+	}
+
+	results, total := s.engine.Search(query, limit)
+	writeJSON(w, http.StatusOK, SearchResponse{
+		Query:   raw,
+		Total:   total,
+		Count:   len(results),
+		Results: results,
+		Shards:  shardsOf(results),
+	})
+}
+
+// maxBatchBodyBytes limits the JSON body of POST /search/batch
+const maxBatchBodyBytes = 64 << 10
+
+// HandleSearchBatch looks up several drivers in one request: POST /api/search/batch with {"drivers": [...]}
+// Results are grouped by driver in request order; the /api/driver filters apply as query parameters
+func (s *APIServer) HandleSearchBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req BatchSearchRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBatchBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range req.Drivers {
+		key := NormalizeDriverName(name)
+		if key != "" && !seen[key] {
+			seen[key] = true
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	if len(names) == 0 {
+		writeError(w, http.StatusBadRequest, "missing drivers")
+		return
+	}
+	if len(names) > maxBatchDrivers {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("too many drivers: %d (max %d)", len(names), maxBatchDrivers))
+		return
+	}
+
+	filter := ParseResultFilter(r.URL.Query())
+	response := BatchSearchResponse{Count: len(names), Results: make([]BatchDriverResults, 0, len(names))}
+	for _, name := range names {
+		driver := BatchDriverResults{Query: name, Results: []DriverResult{}}
+		if all := s.engine.Lookup(name); len(all) > 0 {
+			driver.Found = true
+			driver.Name = all[0].Name
+			if alias, ok := s.engine.Aliases().Resolve(name); ok {
+				driver.Name = alias.Canonical
+			}
+			driver.Results = filter.Apply(all)
+			driver.Count = len(driver.Results)
+			response.Found++
+		}
+		response.Results = append(response.Results, driver)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleRivals returns the drivers just faster and slower than a driver on each of their combinations:
+// /api/rivals?driver=X&track=1693&class=1703&count=5 (track and class are optional filters)
+func (s *APIServer) HandleRivals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("driver")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing driver parameter")
+		return
+	}
+	trackID, classID := query.Get("track"), query.Get("class")
+	count := parseLimit(query.Get("count"), defaultRivalsCount, maxRivalsCount)
+
+	var results []DriverResult
+	for _, result := range s.engine.Lookup(name) {
+		if (trackID == "" || result.TrackID == trackID) && (classID == "" || result.ClassID == classID) {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		writeError(w, http.StatusNotFound, "driver not found")
+		return
+	}
+
+	rivals, err := FindRivals(results, count)
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to find rivals of %s: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "failed to load leaderboards")
+		return
+	}
+	writeJSON(w, http.StatusOK, RivalsResponse{
+		Driver:            results[0].Name,
+		Count:             count,
+		TotalCombinations: len(results),
+		Results:           rivals,
+	})
+}
+
+// HandleCountry returns the fastest drivers of a country per combination: /api/country?code=DE or ?name=Germany
+func (s *APIServer) HandleCountry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query().Get("code")
+	if query == "" {
+		query = r.URL.Query().Get("name")
+	}
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing code or name parameter")
+		return
+	}
+
+	ranking := BuildCountryRanking(s.engine, query)
+	if ranking.Summary.Drivers == 0 {
+		writeError(w, http.StatusNotFound, "country not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ranking)
+}
+
+// HandleTeam returns all drivers and results of a team grouped by combination: /api/team?name=X
+func (s *APIServer) HandleTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing name parameter")
+		return
+	}
+
+	detail := BuildTeamDetail(s.engine, name)
+	if detail.Entries == 0 {
+		writeError(w, http.StatusNotFound, "team not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// HandleTeams lists teams by entry count: /api/teams?limit=100
+func (s *APIServer) HandleTeams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit := parseLimit(r.URL.Query().Get("limit"), defaultTeamsLimit, maxTeamsLimit)
+	teams := ListTeams(s.engine)
+	total := len(teams)
+	if len(teams) > limit {
+		teams = teams[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, TeamListResponse{
+		Total:   total,
+		Count:   len(teams),
+		Results: teams,
+	})
+}
+
+// HandleStatus returns the current fetch/index status (the contents of cache/status.json): /api/status
+func (s *APIServer) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// status.json is only rewritten every few hundred combinations; report live progress
+	status := ReadStatusData()
+	if progress, ok := CurrentFetchStatus(); ok {
+		status.FetchProgress = &progress
+	}
+	if notModified(w, r, contentETag(status)) {
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// HandleIndexShards serves the manifest of the sharded driver index: /api/index/shards?driver=name
+// With driver, the response also names the shard holding that driver
+func (s *APIServer) HandleIndexShards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	manifest, err := ReadShardManifest()
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusServiceUnavailable, "driver index shards not exported yet")
+		return
+	} else if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to read driver index manifest: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to read driver index manifest")
+		return
+	}
+
+	response := IndexShardsResponse{ShardManifest: manifest}
+	if driver := r.URL.Query().Get("driver"); driver != "" {
+		response.Shard = DriverIndexShard(driver)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleTopCombinations lists the combinations with the most entries: /api/top-combinations?limit=100
+func (s *APIServer) HandleTopCombinations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	top, err := ReadTopCombinations()
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusServiceUnavailable, "top combinations not exported yet")
+		return
+	} else if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to read top combinations: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to read top combinations")
+		return
+	}
+
+	limit := parseLimit(r.URL.Query().Get("limit"), defaultTopCombinationsLimit, maxTopCombinationsLimit)
+	results := top.Results
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	writeJSON(w, http.StatusOK, TopCombinationsResponse{
+		Total:   len(top.Results),
+		Count:   len(results),
+		Results: results,
+	})
+}
+
+// HandleCombinationStats serves the lap time distribution of a combination: /api/stats/combination?track=X&class=Y
+func (s *APIServer) HandleCombinationStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	trackID := query.Get("track")
+	classID := query.Get("class")
+	if trackID == "" || classID == "" {
+		writeError(w, http.StatusBadRequest, "missing track or class parameter")
+		return
+	}
+
+	stats, ok := GetCombinationStats(trackID, classID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no lap time statistics for this combination")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// HandleLeaderboard serves one combination's leaderboard with paging and sorting:
+// /api/leaderboard?track=1693&class=1703&limit=100&offset=0&sort=position&order=asc
+// With &format=csv the (whole, unless limited) sorted leaderboard is streamed as CSV
+func (s *APIServer) HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	trackID := query.Get("track")
+	classID := query.Get("class")
+	if trackID == "" || classID == "" {
+		writeError(w, http.StatusBadRequest, "missing track or class parameter")
+		return
+	}
+
+	limit := parseLimit(query.Get("limit"), defaultLeaderboardLimit, maxLeaderboardLimit)
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	sortKey := query.Get("sort")
+	if sortKey == "" {
+		sortKey = SortByPosition
+	}
+	order := "asc"
+	if strings.EqualFold(query.Get("order"), "desc") {
+		order = "desc"
+	}
+
+	view, err := LoadLeaderboardView(trackID, classID, sortKey, order == "desc")
+	if errors.Is(err, ErrCombinationNotCached) {
+		s.queueLeaderboardFetch(w, trackID, classID)
+		return
+	} else if errors.Is(err, ErrUnknownSortKey) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to load leaderboard %s + %s: %v", trackID, classID, err)
+		writeError(w, http.StatusInternalServerError, "failed to load leaderboard")
+		return
+	}
+	RecordCombinationRequest(trackID, classID)
+	if etag, ok := leaderboardETag(trackID, classID); ok && notModified(w, r, etag) {
+		return
+	}
+
+	total := len(view.Results)
+	if offset > total {
+		offset = total
+	}
+	if wantsCSV(r) && query.Get("limit") == "" {
+		limit = total // CSV exports the whole leaderboard unless a limit is given
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	if wantsCSV(r) {
+		if err := writeResultsCSV(w, "leaderboard_"+trackID+"_"+classID+".csv", view.Results[offset:end]); err != nil {
+			requestLog(r).Warnf("⚠️ CSV export of %s + %s interrupted: %v", trackID, classID, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, LeaderboardResponse{
+		Track:     view.Track,
+		TrackID:   trackID,
+		ClassID:   classID,
+		ClassName: GetCarClassName(classID),
+		Total:     total,
+		Offset:    offset,
+		Limit:     limit,
+		Sort:      sortKey,
+		Order:     order,
+		Count:     end - offset,
+		Results:   view.Page(offset, end),
+	})
+}
+
+// HandleExportEntries streams every indexed entry as JSON Lines: /api/export/entries.jsonl
+func (s *APIServer) HandleExportEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.engine.DriverCount() == 0 {
+		writeError(w, http.StatusServiceUnavailable, "index not built yet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="entries.jsonl"`)
+	if version := ReadStatusData().DataVersion; version != "" {
+		w.Header().Set("X-Data-Version", version)
+	}
+	written, err := s.engine.WriteJSONL(w)
+	if err != nil {
+		requestLog(r).Warnf("⚠️ JSONL export interrupted after %d entries: %v", written, err)
+	}
+}
+
+// HandleSnapshots lists the archived versions of a combination: /api/snapshots?track=1693&class=1703
+// With &driver=name it returns the driver's position and lap time in each snapshot instead
+func (s *APIServer) HandleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	trackID := query.Get("track")
+	classID := query.Get("class")
+	if trackID == "" || classID == "" {
+		writeError(w, http.StatusBadRequest, "missing track or class parameter")
+		return
+	}
+
+	if driver := strings.TrimSpace(query.Get("driver")); driver != "" {
+		history, err := DriverSnapshotHistory(trackID, classID, driver)
+		if err != nil {
+			requestLog(r).Warnf("⚠️ Failed to read snapshots %s + %s: %v", trackID, classID, err)
+			writeError(w, http.StatusInternalServerError, "failed to read snapshots")
+			return
+		}
+		writeJSON(w, http.StatusOK, SnapshotHistoryResponse{
+			TrackID: trackID,
+			ClassID: classID,
+			Driver:  driver,
+			Count:   len(history),
+			History: history,
+		})
+		return
+	}
+
+	snapshots, err := ListSnapshots(trackID, classID)
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to list snapshots %s + %s: %v", trackID, classID, err)
+		writeError(w, http.StatusInternalServerError, "failed to list snapshots")
+		return
+	}
+	writeJSON(w, http.StatusOK, SnapshotListResponse{
+		TrackID:   trackID,
+		ClassID:   classID,
+		Count:     len(snapshots),
+		Snapshots: snapshots,
+	})
+}
+
+// HandleMovers lists the drivers who gained the most positions or time since the last refresh, globally and
+// per class: /api/movers?class=1703&since=2024-05-01T00:00:00Z&limit=20
+func (s *APIServer) HandleMovers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	since := ReadStatusData().LastScrapeStart
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+			return
+		}
+		since = parsed
+	}
+	limit := parseLimit(query.Get("limit"), defaultMoversLimit, maxMoversLimit)
+	writeJSON(w, http.StatusOK, BuildMoversFeed(since, query.Get("class"), limit))
+}
+
+// HandleChampionship scores leaderboard positions into championship standings across the given combinations:
+// /api/championship?tracks=1693,9473&classes=1703&points=25,18,15&limit=100
+func (s *APIServer) HandleChampionship(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	trackIDs := parseIDList(query.Get("tracks"))
+	classIDs := parseIDList(query.Get("classes"))
+	if len(trackIDs) == 0 || len(classIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "missing tracks or classes parameter")
+		return
+	}
+	var points []int
+	if raw := query.Get("points"); raw != "" {
+		var err error
+		if points, err = ParseChampionshipPoints(raw); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	limit := parseLimit(query.Get("limit"), defaultChampionshipLimit, maxChampionshipLimit)
+
+	championship, err := BuildChampionship(trackIDs, classIDs, points, limit)
+	if errors.Is(err, ErrTooManyRounds) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to build championship: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to build championship")
+		return
+	}
+	writeJSON(w, http.StatusOK, championship)
+}
+
+// HandleChanges serves the changes between the two newest snapshots of a combination:
+// /api/changes?track=1693&class=1703&limit=100 (limit applies to each list; counts are totals)
+func (s *APIServer) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	trackID := query.Get("track")
+	classID := query.Get("class")
+	if trackID == "" || classID == "" {
+		writeError(w, http.StatusBadRequest, "missing track or class parameter")
+		return
+	}
+	limit := parseLimit(query.Get("limit"), defaultChangesLimit, maxChangesLimit)
+
+	changes, err := ReadChanges(trackID, classID)
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, "no changes recorded for this combination yet")
+		return
+	} else if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to read changes %s + %s: %v", trackID, classID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read changes")
+		return
+	}
+
+	truncate := func(list []EntryChange) []EntryChange {
+		if len(list) > limit {
+			return list[:limit]
+		}
+		return list
+	}
+	writeJSON(w, http.StatusOK, ChangesResponse{
+		TrackID:              changes.TrackID,
+		ClassID:              changes.ClassID,
+		From:                 changes.From,
+		To:                   changes.To,
+		NewEntriesCount:      len(changes.NewEntries),
+		ImprovedCount:        len(changes.Improved),
+		PositionChangesCount: len(changes.PositionChanges),
+		NewEntries:           truncate(changes.NewEntries),
+		Improved:             truncate(changes.Improved),
+		PositionChanges:      truncate(changes.PositionChanges),
+	})
+}
+
+// queueLeaderboardFetch answers a request for an uncached combination:
+// 202 with a job ID when on-demand fetching is enabled and the combination is configured, 404 otherwise
+func (s *APIServer) queueLeaderboardFetch(w http.ResponseWriter, trackID, classID string) {
+	track, trackOK := FindTrack(trackID)
+	class, classOK := FindCarClass(classID)
+	if !s.onDemandFetch || !trackOK || !classOK {
+		writeError(w, http.StatusNotFound, "combination not found")
+		return
+	}
+
+	job, err := s.jobs.EnqueueFetch(track, class)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.writeJobAccepted(w, job)
+}
+
+// maxRefreshBodyBytes limits the JSON body of /refresh
+const maxRefreshBodyBytes = 64 << 10
+
+// HandleRefresh queues a full or targeted refresh (admin): POST /api/refresh?tracks=1693,5276-8600
+// or with a JSON body: {"tracks": ["1693"], "classes": ["1703"], "force": false}
+// dry_run (query or body) returns the RefreshPlan instead of queueing a job
+func (s *APIServer) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req RefreshRequest
+	if raw := r.URL.Query().Get("tracks"); raw != "" {
+		req.Tracks = strings.Split(raw, ",")
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRefreshBodyBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if len(body) > maxRefreshBodyBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if req.Tracks != nil {
+			writeError(w, http.StatusBadRequest, "give tracks in the query or the body, not both")
+			return
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+	}
+
+	if req.DryRun || r.URL.Query().Get("dry_run") == "true" {
+		plan, err := req.Plan()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+		return
+	}
+
+	targets, fresh, err := req.Targets()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(targets) == 0 && (fresh > 0 || len(req.Classes) > 0) {
+		// Everything requested is fresh (an empty target list would mean a full refresh): report the plan instead
+		plan, _ := req.Plan()
+		plan.DryRun = false
+		writeJSON(w, http.StatusOK, plan)
+		return
+	}
+
+	job, err := s.jobs.EnqueueRefresh(targets)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.writeJobAccepted(w, job)
+}
+
+// HandleJob reports the state of a background job: GET /api/jobs/{id}
+// and cancels it (admin): POST /api/jobs/{id}/cancel
+func (s *APIServer) HandleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, s.prefix+"/jobs/")
+	if cancelID, ok := strings.CutSuffix(id, "/cancel"); ok {
+		s.handleJobCancel(w, r, cancelID)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, ok := s.jobs.Get(id)
+	if id == "" || !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleJobCancel cancels a queued or running job, leaving other jobs untouched
+func (s *APIServer) handleJobCancel(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	if _, ok := s.jobs.Get(id); !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	job, err := s.jobs.Cancel(id)
+	if errors.Is(err, ErrJobFinished) {
+		writeError(w, http.StatusConflict, "job already "+job.Status)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	// A running job reports "cancelled" once its runner has stopped
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// HandleFetchPause pauses or resumes all fetching (admin): POST /api/fetch/pause, POST /api/fetch/resume
+// Running fetch loops block before their next request and keep their position
+func (s *APIServer) HandleFetchPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	var err error
+	if strings.HasSuffix(r.URL.Path, "/pause") {
+		err = PauseFetching()
+	} else {
+		err = ResumeFetching()
+	}
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Failed to update fetch pause state: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to update pause state")
+		return
+	}
+
+	paused, since := FetchPaused()
+	response := FetchPauseResponse{Paused: paused}
+	if paused {
+		response.Since = &since
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleFailedFetches lists the failed-fetch queue (admin): GET /api/fetch/failed
+// and queues a refresh of its combinations (admin): POST /api/fetch/failed/retry
+func (s *APIServer) HandleFailedFetches(w http.ResponseWriter, r *http.Request) {
+	retry := strings.HasSuffix(r.URL.Path, "/retry")
+	if (retry && r.Method != http.MethodPost) || (!retry && r.Method != http.MethodGet) {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	failures := QueuedFailures()
+	if !retry || len(failures) == 0 {
+		writeJSON(w, http.StatusOK, FailedFetchQueueResponse{Count: len(failures), Failures: failures})
+		return
+	}
+
+	job, err := s.jobs.EnqueueRefresh(QueuedFailureTargets())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.writeJobAccepted(w, job)
+}
+
+// HandleCatalogReload reloads tracks.json and classes.json (admin): POST /api/catalog/reload
+// An invalid file is rejected with 422 and the current catalogs stay in use; the next fetch uses the new lists
+func (s *APIServer) HandleCatalogReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	info, err := LoadCatalogs()
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Catalog reload rejected: %v", err)
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	requestLog(r).Infof("🗺️ Catalog reloaded via API: %d tracks, %d classes", info.Tracks, info.Classes)
+	writeJSON(w, http.StatusOK, info)
+}
+
+// HandleCacheValidate checks every cache file (admin): POST /api/cache/validate?delete=true&all=true
+// Lists only the broken files unless all is set; delete removes them so the next refresh refetches them
+func (s *APIServer) HandleCacheValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	validation, err := NewDataCache().ValidateCache(query.Get("delete") == "true")
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Cache validation failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "cache validation failed")
+		return
+	}
+	if query.Get("all") != "true" {
+		broken := make([]CacheFileStatus, 0, validation.Broken)
+		for _, file := range validation.Files {
+			if file.Status != CacheFileOK {
+				broken = append(broken, file)
+			}
+		}
+		validation.Files = broken
+	}
+	writeJSON(w, http.StatusOK, validation)
+}
+
+// HandleReportGenerate writes a summary report now and returns it (admin): POST /api/reports/generate?period=daily
+func (s *APIServer) HandleReportGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = ReportDaily
+	}
+	if period != ReportDaily && period != ReportWeekly {
+		writeError(w, http.StatusBadRequest, "period must be daily or weekly")
+		return
+	}
+	report, err := GenerateReport(period)
+	if err != nil {
+		requestLog(r).Warnf("⚠️ Report generation failed: %v", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	requestLog(r).Infof("📰 %s report generated via API", period)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// HandleEvents streams fetch, cache and index events as Server-Sent Events: /api/events
+func (s *APIServer) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	events, unsubscribe := GetEventBroker().Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			// Comment line keeps proxies from closing an idle stream
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// HandleAPIKeys manages API keys (admin):
+// GET /api/keys lists keys with usage, POST /api/keys?name=X&rate_limit=600 issues a key,
+// DELETE /api/keys?id=r3e_1a2b3c revokes one
+func (s *APIServer) HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		usage, since := s.keys.Usage(s.rateLimits.KeyRequests)
+		writeJSON(w, http.StatusOK, APIKeyListResponse{
+			Since:   since,
+			Count:   len(usage),
+			Results: usage,
+		})
+	case http.MethodPost:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "missing name parameter")
+			return
+		}
+		rateLimit := 0
+		if raw := r.URL.Query().Get("rate_limit"); raw != "" {
+			var err error
+			if rateLimit, err = strconv.Atoi(raw); err != nil || rateLimit < 0 {
+				writeError(w, http.StatusBadRequest, "invalid rate_limit parameter")
+				return
+			}
+		}
+		secret, key, err := s.keys.Issue(name, rateLimit)
+		if err != nil {
+			requestLog(r).Warnf("⚠️ Failed to issue API key: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to issue key")
+			return
+		}
+		writeJSON(w, http.StatusCreated, APIKeyIssuedResponse{
+			Key:       secret, // Only returned here
+			ID:        key.ID,
+			Name:      key.Name,
+			RateLimit: key.EffectiveRateLimit(s.rateLimits.KeyRequests),
+			CreatedAt: key.CreatedAt,
+		})
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeError(w, http.StatusBadRequest, "missing id parameter")
+			return
+		}
+		if err := s.keys.Revoke(id); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// maxAliasBodyBytes limits the JSON body of POST /aliases
+const maxAliasBodyBytes = 64 << 10
+
+// HandleAliases lists the driver alias table (GET) and edits it (admin): POST a DriverAlias to create or
+// replace the alias of its canonical name, DELETE /api/aliases?canonical=X to remove one
+func (s *APIServer) HandleAliases(w http.ResponseWriter, r *http.Request) {
+	aliases := s.engine.Aliases()
+	switch r.Method {
+	case http.MethodGet:
+		list := aliases.List()
+		writeJSON(w, http.StatusOK, AliasListResponse{Count: len(list), Results: list})
+	case http.MethodPost:
+		if !s.authorizeAdmin(w, r) {
+			return
+		}
+		if aliases == nil {
+			writeError(w, http.StatusServiceUnavailable, "driver aliases not loaded")
+			return
+		}
+		var alias DriverAlias
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxAliasBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&alias); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid alias: "+err.Error())
+			return
+		}
+		saved, err := aliases.Set(alias)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, saved)
+	case http.MethodDelete:
+		if !s.authorizeAdmin(w, r) {
+			return
+		}
+		canonical := r.URL.Query().Get("canonical")
+		if canonical == "" {
+			writeError(w, http.StatusBadRequest, "missing canonical parameter")
+			return
+		}
+		if aliases == nil {
+			writeError(w, http.StatusNotFound, "no alias for "+strconv.Quote(canonical))
+			return
+		}
+		if err := aliases.Delete(canonical); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// maxExclusionBodyBytes limits the JSON body of POST /exclusions
+const maxExclusionBodyBytes = 16 << 10
+
+// HandleExclusions manages the drivers removed on request (admin): GET lists them, POST a DriverExclusion
+// (name and/or driver_id, optional reason) to remove a driver, DELETE /api/exclusions?name=X or ?driver_id=N
+// to lift an exclusion. A new exclusion is dropped from the live index at once; exports follow on the next rebuild
+func (s *APIServer) HandleExclusions(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list := DriverExclusions()
+		writeJSON(w, http.StatusOK, ExclusionListResponse{Count: len(list), Results: list})
+	case http.MethodPost:
+		var exclusion DriverExclusion
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxExclusionBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&exclusion); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid exclusion: "+err.Error())
+			return
+		}
+		if NormalizeDriverName(exclusion.Name) == "" && strings.TrimSpace(exclusion.DriverID) == "" {
+			writeError(w, http.StatusBadRequest, "missing name or driver_id")
+			return
+		}
+		saved, err := AddDriverExclusion(exclusion)
+		if errors.Is(err, ErrAlreadyExcluded) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			requestLog(r).Warnf("⚠️ Failed to save driver exclusion: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to save exclusion")
+			return
+		}
+		s.engine.ApplyExclusions()
+		writeJSON(w, http.StatusCreated, saved)
+	case http.MethodDelete:
+		name, driverID := r.URL.Query().Get("name"), r.URL.Query().Get("driver_id")
+		if name == "" && driverID == "" {
+			writeError(w, http.StatusBadRequest, "missing name or driver_id parameter")
+			return
+		}
+		if err := RemoveDriverExclusion(name, driverID); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleUsage reports the calling key's own usage: /api/usage with X-API-Key
+func (s *APIServer) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	key, ok := s.keys.Lookup(apiKeyFromRequest(r))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "API key required (X-API-Key header)")
+		return
+	}
+	usage, since := s.keys.Usage(s.rateLimits.KeyRequests)
+	for _, entry := range usage {
+		if entry.ID == key.ID {
+			writeJSON(w, http.StatusOK, UsageResponse{
+				Since: since,
+				Usage: entry,
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "api key not found")
+}
+
+// authorizeAdmin checks the admin token and writes the error response when the request is not allowed
+func (s *APIServer) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.adminToken == "" {
+		writeError(w, http.StatusForbidden, "admin endpoints disabled (no admin_token configured)")
+		return false
+	}
+	if !s.isAdmin(r) {
+		writeError(w, http.StatusUnauthorized, "invalid admin token")
+		return false
+	}
+	return true
+}
+
+// isAdmin reports whether the request carries the admin token (Authorization: Bearer or X-Admin-Token)
+func (s *APIServer) isAdmin(r *http.Request) bool {
+	if s.adminToken == "" {
+		return false
+	}
+	token := r.Header.Get("X-Admin-Token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// writeJobAccepted answers 202 with the job ID and where to poll its status
+func (s *APIServer) writeJobAccepted(w http.ResponseWriter, job Job) {
+	statusURL := s.prefix + "/jobs/" + job.ID
+	if _, ok := w.(*envelopeWriter); ok {
+		statusURL = s.versionPath(statusURL)
+	}
+	w.Header().Set("Location", statusURL)
+	writeJSON(w, http.StatusAccepted, JobAcceptedResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: statusURL,
+	})
+}
+
+// parseIDList splits a comma-separated list of IDs, dropping blanks and duplicates
+func parseIDList(raw string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseLimit parses a positive limit parameter, falling back to def and capping at max
+func parseLimit(raw string, def, max int) int {
+	if raw == "" {
+		return def
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if ew, ok := w.(*envelopeWriter); ok {
+		v = ew.envelope(status, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		httpLog.Warnf("⚠️ Failed to write API response: %v", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DriverIndexShardDir     = "cache/driver_index"
+	DriverIndexManifestFile = "cache/driver_index/manifest.json"
+)
+
+// DriverIndexShardOther holds the drivers whose normalized name doesn't start with a-z (digits, symbols, other scripts)
+const DriverIndexShardOther = "other"
+
+// DriverIndexShards lists every shard name: a to z, then other
+var DriverIndexShards = func() []string {
+	shards := make([]string, 0, 27)
+	for letter := 'a'; letter <= 'z'; letter++ {
+		shards = append(shards, string(letter))
+	}
+	return append(shards, DriverIndexShardOther)
+}()
+
+// ShardInfo describes one shard file of the driver index
+type ShardInfo struct {
+	Name    string `json:"name"`
+	File    string `json:"file"` // Path relative to the site root, e.g. cache/driver_index/a.json
+	Drivers int    `json:"drivers"`
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"` // Gzipped size
+}
+
+// ShardManifest lists the shards of the last exported driver index
+// Clients fetch the manifest, then only the shards of the names they look up (see DriverIndexShard)
+type ShardManifest struct {
+	BuiltAt     time.Time   `json:"built_at"`
+	DataVersion string      `json:"data_version"`
+	Drivers     int         `json:"drivers"`
+	Shards      []ShardInfo `json:"shards"`
+}
+
+// DriverIndexShard returns the shard holding a driver: the first letter of the normalized name,
+// or DriverIndexShardOther when it isn't a-z
+func DriverIndexShard(name string) string {
+	key := NormalizeDriverName(name)
+	if key != "" && key[0] >= 'a' && key[0] <= 'z' {
+		return key[:1]
+	}
+	return DriverIndexShardOther
+}
+
+// driverIndexShardPath returns the gzipped file of a shard
+func driverIndexShardPath(shard string) string {
+	return filepath.Join(DriverIndexShardDir, shard+".json.gz")
+}
+
+// ExportDriverIndexShards writes the driver index split by DriverIndexShard into gzipped shard files,
+// then the manifest. Every shard is written, empty ones as {}, so clients never get a 404 for a letter
+func ExportDriverIndexShards(index DriverIndex, dataVersion string) error {
+	start := time.Now()
+	if err := os.MkdirAll(DriverIndexShardDir, 0755); err != nil {
+		exportLog.Errorf("❌ Failed to create shard directory: %v", err)
+		return err
+	}
+
+	byShard := make(map[string]DriverIndex, len(DriverIndexShards))
+	for key, results := range index {
+		shard := DriverIndexShard(key)
+		if byShard[shard] == nil {
+			byShard[shard] = make(DriverIndex)
+		}
+		byShard[shard][key] = results
+	}
+
+	manifest := ShardManifest{
+		BuiltAt:     time.Now().UTC(),
+		DataVersion: dataVersion,
+		Drivers:     len(index),
+		Shards:      make([]ShardInfo, 0, len(DriverIndexShards)),
+	}
+	totalBytes := 0
+	for _, shard := range DriverIndexShards {
+		shardIndex := byShard[shard]
+		if shardIndex == nil {
+			shardIndex = DriverIndex{}
+		}
+		data, err := gzipShard(shard, shardIndex)
+		if err == nil {
+			err = writeFileAtomic(driverIndexShardPath(shard), data)
+		}
+		if err != nil {
+			exportLog.Errorf("❌ Failed to write driver index shard %s: %v", shard, err)
+			return err
+		}
+
+		info := ShardInfo{Name: shard, File: DriverIndexShardDir + "/" + shard + ".json", Drivers: len(shardIndex), Bytes: len(data)}
+		for _, results := range shardIndex {
+			info.Entries += len(results)
+		}
+		manifest.Shards = append(manifest.Shards, info)
+		totalBytes += len(data)
+	}
+
+	// The manifest goes last so it never lists shards older than the files on disk
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(DriverIndexManifestFile, data)
+	}
+	if err != nil {
+		exportLog.Errorf("❌ Failed to write driver index manifest: %v", err)
+		return err
+	}
+
+	largest := manifest.Shards[0]
+	for _, info := range manifest.Shards[1:] {
+		if info.Bytes > largest.Bytes {
+			largest = info
+		}
+	}
+	exportLog.Infof("💾 Driver index exported as %d shards to %s (%.3f seconds, %.2f MB, largest %s: %.2f MB)",
+		len(manifest.Shards), DriverIndexShardDir, time.Since(start).Seconds(),
+		float64(totalBytes)/(1024*1024), largest.Name, float64(largest.Bytes)/(1024*1024))
+	return nil
+}
+
+// gzipShard encodes one shard as gzipped compact JSON
+func gzipShard(shard string, index DriverIndex) ([]byte, error) {
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	gzWriter.Name = shard + ".json"
+	if err := json.NewEncoder(gzWriter).Encode(index); err != nil {
+		gzWriter.Close()
+		return nil, err
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadShardManifest reads the manifest of the last sharded export from disk
+func ReadShardManifest() (ShardManifest, error) {
+	var manifest ShardManifest
+	data, err := os.ReadFile(DriverIndexManifestFile)
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(data, &manifest)
+	return manifest, err
+}
+
+// DriverIndexShardFile returns the gzipped file on disk of a shard; ok is false for unknown shard names
+func DriverIndexShardFile(shard string) (string, bool) {
+	for _, name := range DriverIndexShards {
+		if name == shard {
+			return driverIndexShardPath(shard), true
+		}
+	}
+	return "", false
+}
+
+// shardsOf returns the shards holding the drivers of the matches, in shard order
+func shardsOf(matches []SearchMatch) []string {
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		seen[DriverIndexShard(match.Name)] = true
+	}
+	var shards []string
+	for _, shard := range DriverIndexShards {
+		if seen[shard] {
+			shards = append(shards, shard)
+		}
+	}
+	return shards
+}
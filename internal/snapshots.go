@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotDir archives every version of each combination's leaderboard
+const SnapshotDir = "snapshots"
+
+// snapshotTimeFormat names snapshot files: snapshots/track_<id>/class_<id>/<time>.json.gz
+const snapshotTimeFormat = "20060102T150405Z"
+
+// snapshotConfig is set once at startup by SetSnapshotConfig
+var snapshotConfig SnapshotConfig
+
+// SetSnapshotConfig enables or disables snapshot archiving and sets its retention
+func SetSnapshotConfig(cfg SnapshotConfig) {
+	snapshotConfig = cfg
+}
+
+// SnapshotInfo describes one archived version of a combination
+type SnapshotInfo struct {
+	Time        time.Time `json:"time"`
+	SizeBytes   int64     `json:"size_bytes"`
+	ContentHash string    `json:"content_hash"`
+	path        string
+}
+
+// snapshotDirFor returns the snapshot directory of a combination
+func snapshotDirFor(trackID, classID string) string {
+	return filepath.Join(SnapshotDir, "track_"+trackID, "class_"+classID)
+}
+
+// combinationFromCachePath parses "track_<id>/class_<id>.json.gz" (relative to a cache dir)
+func combinationFromCachePath(relPath string) (string, string, bool) {
+	trackDir, file := filepath.Split(relPath)
+	trackID, okTrack := strings.CutPrefix(filepath.Base(trackDir), "track_")
+	classID, okClass := strings.CutPrefix(strings.TrimSuffix(file, ".json.gz"), "class_")
+	return trackID, classID, okTrack && okClass && trackID != "" && classID != ""
+}
+
+// archiveSnapshot records cacheFile as the newest version of its combination
+// Empty leaderboards and data identical to the newest snapshot are skipped. The file is
+// hard-linked when possible (no extra space until the cache file is replaced), copied otherwise
+func archiveSnapshot(trackID, classID, cacheFile string) {
+	if !snapshotConfig.Enabled {
+		return
+	}
+	hash := readContentHash(cacheFile)
+	if hash == "" || hash == HashEntries(nil) {
+		return
+	}
+	snapshots, _ := ListSnapshots(trackID, classID)
+	if len(snapshots) > 0 && snapshots[len(snapshots)-1].ContentHash == hash {
+		return
+	}
+
+	dir := snapshotDirFor(trackID, classID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cacheLog.Warnf("⚠️ Failed to create snapshot directory %s: %v", dir, err)
+		return
+	}
+	path := filepath.Join(dir, time.Now().UTC().Format(snapshotTimeFormat)+".json.gz")
+	if err := os.Link(cacheFile, path); err != nil {
+		if err := copyFile(cacheFile, path); err != nil {
+			cacheLog.Warnf("⚠️ Failed to archive snapshot %s: %v", path, err)
+			return
+		}
+	}
+	recordChanges(trackID, classID)
+	pruneSnapshots(trackID, classID)
+}
+
+// copyFile copies src to dst through a temp file
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst + ".tmp")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst + ".tmp")
+		return err
+	}
+	return os.Rename(dst+".tmp", dst)
+}
+
+// pruneSnapshots removes snapshots beyond the configured age and count (the newest is always kept)
+func pruneSnapshots(trackID, classID string) {
+	snapshots, err := ListSnapshots(trackID, classID)
+	if err != nil || len(snapshots) < 2 {
+		return
+	}
+	cutoff := time.Time{}
+	if snapshotConfig.RetentionDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -snapshotConfig.RetentionDays)
+	}
+	excess := 0
+	if max := snapshotConfig.MaxPerCombination; max > 0 && len(snapshots) > max {
+		excess = len(snapshots) - max
+	}
+	for i, snapshot := range snapshots[:len(snapshots)-1] {
+		if i < excess || snapshot.Time.Before(cutoff) {
+			if err := os.Remove(snapshot.path); err != nil {
+				cacheLog.Warnf("⚠️ Failed to prune snapshot %s: %v", snapshot.path, err)
+			}
+		}
+	}
+}
+
+// ListSnapshots returns the archived versions of a combination, oldest first
+func ListSnapshots(trackID, classID string) ([]SnapshotInfo, error) {
+	files, err := filepath.Glob(filepath.Join(snapshotDirFor(trackID, classID), "*.json.gz"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]SnapshotInfo, 0, len(files))
+	for _, file := range files {
+		at, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(filepath.Base(file), ".json.gz"))
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, SnapshotInfo{Time: at, SizeBytes: info.Size(), ContentHash: readContentHash(file), path: file})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// LoadSnapshot loads an archived version of a combination
+func LoadSnapshot(snapshot SnapshotInfo) (TrackInfo, error) {
+	return loadCacheFile(snapshot.path)
+}
+
+// DriverSnapshotPoint is a driver's standing in one snapshot
+type DriverSnapshotPoint struct {
+	Time         time.Time `json:"time"`
+	Found        bool      `json:"found"`
+	Position     int       `json:"position,omitempty"`
+	LapTime      string    `json:"laptime,omitempty"`
+	TotalEntries int       `json:"total_entries"`
+}
+
+// DriverSnapshotHistory returns a driver's position and lap time in every snapshot of a combination
+func DriverSnapshotHistory(trackID, classID, driver string) ([]DriverSnapshotPoint, error) {
+	snapshots, err := ListSnapshots(trackID, classID)
+	if err != nil {
+		return nil, err
+	}
+	wanted := NormalizeDriverName(driver)
+	history := make([]DriverSnapshotPoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		trackInfo, err := LoadSnapshot(snapshot)
+		if err != nil {
+			cacheLog.Warnf("⚠️ Skipping unreadable snapshot: %v", err)
+			continue
+		}
+		point := DriverSnapshotPoint{Time: snapshot.Time, TotalEntries: len(trackInfo.Data)}
+		for i := range trackInfo.Data {
+			if NormalizeDriverName(trackInfo.Data[i].Driver.Name) != wanted {
+				continue
+			}
+			if result, ok := extractDriverResult(&trackInfo, &trackInfo.Data[i], 0); ok { // Only position and lap time are kept
+				point.Found, point.Position, point.LapTime = true, result.Position, result.LapTime
+			}
+			break
+		}
+		history = append(history, point)
+	}
+	return history, nil
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// streamDriverIndex builds an index build from the main cache files of the given combinations, one file
+// at a time per worker (decode, extract, append, release), so peak memory holds the index plus one
+// leaderboard per worker however many combinations there are. A combination whose cache file can't be
+// read falls back to its Data when the caller still holds it. Tracks of the returned build carry no Data;
+// the version and combination statistics are computed while streaming
+func streamDriverIndex(combinations []TrackInfo) *IndexBuild {
+	// Per-combination outputs, written by the worker owning the combination's chunk
+	tracks := make([]TrackInfo, len(combinations))
+	entries := make([]int, len(combinations))
+	versions := make([]uint64, len(combinations))
+	stats := make([]*CombinationStatistics, len(combinations))
+
+	workers := runtime.GOMAXPROCS(0)
+	chunkCount := max(min(workers*indexChunksPerWorker, len(combinations)), 1)
+	chunkSize := (len(combinations) + chunkCount - 1) / chunkCount
+
+	shards := make([]DriverIndex, chunkCount)
+	jobs := make(chan int, chunkCount)
+	for i := 0; i < chunkCount; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	dataCache := NewDataCache()
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < chunkCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interner := newStringInterner()
+			for chunk := range jobs {
+				start := min(chunk*chunkSize, len(combinations))
+				end := min(start+chunkSize, len(combinations))
+				shard := make(DriverIndex)
+				for i := start; i < end; i++ {
+					combination := &combinations[i]
+					track, err := dataCache.LoadTrackData(combination.TrackID, combination.ClassID)
+					if err != nil {
+						if len(combination.Data) == 0 {
+							indexerLog.Warnf("⚠️ Skipping %s + %s in streaming index build: %v", combination.TrackID, combination.ClassID, err)
+							continue
+						}
+						track = *combination
+					}
+					track.TrackID, track.ClassID = combination.TrackID, combination.ClassID
+					if track.Name == "" {
+						track.Name = combination.Name
+					}
+					if len(track.Data) == 0 {
+						continue
+					}
+
+					buildIndexShardInto(shard, &track, interner)
+					entries[i] = len(track.Data)
+					versions[i] = combinationVersion(&track)
+					if combinationStats, ok := ComputeCombinationStats(track); ok {
+						stats[i] = &combinationStats
+					}
+					tracks[i] = TrackInfo{Name: track.Name, TrackID: track.TrackID, ClassID: track.ClassID}
+					// track.Data goes out of scope here and is released with the next GC
+				}
+				shards[chunk] = shard
+			}
+		}()
+	}
+	wg.Wait()
+
+	build := &IndexBuild{
+		Tracks:           make([]TrackInfo, 0, len(combinations)),
+		TrackEntryCounts: make(map[string]int, len(combinations)),
+		CombinationStats: make(map[string]CombinationStatistics, len(combinations)),
+	}
+	uniqueTracks := make(map[string]bool)
+	updatedAt := time.Now().UTC()
+	var sum uint64
+	for i := range tracks {
+		if entries[i] == 0 {
+			continue
+		}
+		key := tracks[i].TrackID + "_" + tracks[i].ClassID
+		build.Tracks = append(build.Tracks, tracks[i])
+		build.TrackEntryCounts[key] = entries[i]
+		build.TotalEntries += entries[i]
+		if stats[i] != nil {
+			stats[i].UpdatedAt = updatedAt
+			build.CombinationStats[key] = *stats[i]
+		}
+		uniqueTracks[tracks[i].TrackID] = true
+		sum += versions[i]
+	}
+	build.UniqueTracks = len(uniqueTracks)
+	build.DataVersion = formatDataVersion(sum, len(build.Tracks))
+
+	build.Index = mergeIndexShards(shards)
+	for _, results := range build.Index {
+		sortDriverResults(results)
+	}
+	return build
+}
+
+// buildAndExportStreamed builds the index of the combinations with streamDriverIndex and exports it unless
+// the data version is unchanged. Returns the combinations without their Data; callers hold indexBuildMu
+func buildAndExportStreamed(combinations []TrackInfo) ([]TrackInfo, error) {
+	indexBuilding.Store(true)
+	defer indexBuilding.Store(false)
+
+	ctx, span := StartSpan(context.Background(), "index.build_and_export", "combinations", len(combinations), "streaming", true)
+	defer span.End()
+
+	indexStart := time.Now()
+	eventBroker.Publish(EventIndexStarted, map[string]int{"combinations": len(combinations)})
+
+	// The version is only known once every file has been read, so an unchanged index is built but not exported
+	_, buildSpan := StartSpan(ctx, "index.build")
+	build := streamDriverIndex(combinations)
+	build.BuildDuration = time.Since(indexStart)
+	buildSpan.SetAttrs("drivers", len(build.Index), "entries", build.TotalEntries)
+	buildSpan.End()
+	span.SetAttrs("data_version", build.DataVersion)
+
+	exclusions := exclusionsRevision()
+	if build.DataVersion == lastIndexedVersion && exclusions == lastIndexedExclusions {
+		indexerLog.Infof("⏭️ Index unchanged since last export (data version %s) - skipping export", build.DataVersion)
+		eventBroker.Publish(EventIndexFinished, IndexFinishedEvent{DataVersion: build.DataVersion, Skipped: true})
+		return build.Tracks, nil
+	}
+
+	err := exportIndexBuild(ctx, build)
+	span.SetError(err)
+	if err == nil {
+		lastIndexedVersion = build.DataVersion
+		lastIndexedExclusions = exclusions
+	}
+	return build.Tracks, err
+}
+
+// BuildAndExportIndexFromCache builds and exports the index of every configured combination with a cache
+// file, streaming the files from disk. Returns the indexed combinations without their Data, for callers
+// that only need the list; used instead of LoadAllCachedData + BuildAndExportIndex with export.streaming_build
+func BuildAndExportIndexFromCache(ctx context.Context) ([]TrackInfo, error) {
+	dataCache := NewDataCache()
+	var combinations []TrackInfo
+	for _, track := range GetTracks() {
+		for _, class := range GetCarClasses() {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if dataCache.CacheExists(track.TrackID, class.ClassID) {
+				combinations = append(combinations, TrackInfo{Name: track.Name, TrackID: track.TrackID, ClassID: class.ClassID})
+			}
+		}
+	}
+	if len(combinations) == 0 {
+		return nil, nil
+	}
+
+	indexBuildMu.Lock()
+	defer indexBuildMu.Unlock()
+	return buildAndExportStreamed(combinations)
+}
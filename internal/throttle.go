@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Delay adjustments of the fetch throttle: gentle speed-ups, sharp back-offs
+const (
+	throttleSpeedUp       = 0.9 // After a fast, successful fetch
+	throttleSlowDown      = 2.0 // After a slow fetch, a timeout or a network error
+	throttleRateLimitedBy = 4.0 // After 429 or 5xx
+)
+
+// fetchThrottleState is the adaptive delay between leaderboard fetches
+type fetchThrottleState struct {
+	mu        sync.Mutex
+	config    ThrottleConfig
+	delay     time.Duration
+	next      time.Time // Earliest start of the next fetch
+	backedOff bool      // The delay grew since it was last at the minimum
+}
+
+// fetchThrottle spaces out the fetches of the refresh loops
+var fetchThrottle = &fetchThrottleState{
+	config: GetDefaultConfig().Fetch.Throttle,
+	delay:  time.Duration(GetDefaultConfig().Fetch.Throttle.InitialDelayMs) * time.Millisecond,
+}
+
+// configure applies the throttle settings; unset or inconsistent values fall back to the defaults
+func (t *fetchThrottleState) configure(throttle ThrottleConfig) {
+	defaults := GetDefaultConfig().Fetch.Throttle
+	if throttle.MinDelayMs <= 0 {
+		throttle.MinDelayMs = defaults.MinDelayMs
+	}
+	if throttle.MaxDelayMs < throttle.MinDelayMs {
+		throttle.MaxDelayMs = max(defaults.MaxDelayMs, throttle.MinDelayMs)
+	}
+	if throttle.InitialDelayMs <= 0 {
+		throttle.InitialDelayMs = defaults.InitialDelayMs
+	}
+	throttle.InitialDelayMs = min(max(throttle.InitialDelayMs, throttle.MinDelayMs), throttle.MaxDelayMs)
+	if throttle.SlowResponseMs <= 0 {
+		throttle.SlowResponseMs = defaults.SlowResponseMs
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = throttle
+	t.delay = time.Duration(throttle.InitialDelayMs) * time.Millisecond
+}
+
+// FetchDelay returns the current delay between fetches
+func FetchDelay() time.Duration {
+	fetchThrottle.mu.Lock()
+	defer fetchThrottle.mu.Unlock()
+	return fetchThrottle.delay
+}
+
+// reset starts a run at the initial delay, so a back-off of an earlier run doesn't slow down the next one
+func (t *fetchThrottleState) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.delay = time.Duration(t.config.InitialDelayMs) * time.Millisecond
+	t.backedOff = false
+}
+
+// wait blocks until the current delay has passed since the previous fetch
+// Returns ctx.Err() if the context is cancelled while waiting
+func (t *fetchThrottleState) wait(ctx context.Context) error {
+	t.mu.Lock()
+	wait := time.Until(t.next)
+	t.mu.Unlock()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe adapts the delay to a fetch of entries that took duration and schedules the next fetch
+// The response time is averaged over the session request and the listing pages of the combination.
+// A Retry-After of a failed fetch holds the next fetch back at least that long.
+func (t *fetchThrottleState) observe(entries int, duration time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	minDelay := time.Duration(t.config.MinDelayMs) * time.Millisecond
+	maxDelay := time.Duration(t.config.MaxDelayMs) * time.Millisecond
+	previous := t.delay
+
+	var statusErr *APIStatusError
+	factor := throttleSpeedUp
+	reason := ""
+	switch {
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500):
+		factor, reason = throttleRateLimitedBy, statusErr.Error()
+	case errors.As(err, &statusErr):
+		factor = 1 // Other statuses (e.g. 404) say nothing about RaceRoom's load
+	case err != nil:
+		factor, reason = throttleSlowDown, err.Error()
+	default:
+		requests := 2 + entries/leaderboardPageSize // Session request, full pages and the last (partial) page
+		if perRequest := duration / time.Duration(requests); perRequest > time.Duration(t.config.SlowResponseMs)*time.Millisecond {
+			factor, reason = throttleSlowDown, "slow responses ("+perRequest.Round(time.Millisecond).String()+" per request)"
+		}
+	}
+
+	t.delay = min(max(time.Duration(float64(t.delay)*factor), minDelay), maxDelay)
+	t.next = time.Now().Add(t.delay)
+	if statusErr != nil && statusErr.RetryAfter > t.delay {
+		t.next = time.Now().Add(statusErr.RetryAfter)
+	}
+
+	if t.delay > previous {
+		t.backedOff = true
+		loaderLog.Warnf("🐢 %s - slowing down to %v between fetches", reason, t.delay.Round(time.Millisecond))
+	} else if t.delay == minDelay && t.backedOff {
+		t.backedOff = false
+		loaderLog.Infof("🐇 RaceRoom is answering quickly again - back to %v between fetches", minDelay)
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ACMEChallengePath is served by the HTTP redirect listener from the working directory, so certbot's
+// webroot plugin (certbot certonly --webroot -w <working directory>) can issue and renew certificates
+const ACMEChallengePath = "/.well-known/acme-challenge/"
+
+// certificateCheckInterval is how often the certificate files are checked for a renewal
+const certificateCheckInterval = time.Minute
+
+// CertificateReloader serves a certificate and key pair from disk, reloading it when the files change
+// so a renewed certificate is used without restarting the server
+type CertificateReloader struct {
+	certFile, keyFile string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time // Newest modification time of the two files when certificate was loaded
+	checkedAt   time.Time
+}
+
+// NewCertificateReloader loads the certificate and key; fails when they can't be read or don't match
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// load reads the certificate files; callers hold mu or own the reloader
+func (cr *CertificateReloader) load() error {
+	modTime, err := cr.filesModTime()
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	cr.certificate = &certificate
+	cr.modTime = modTime
+	cr.checkedAt = time.Now()
+	return nil
+}
+
+// filesModTime returns the newest modification time of the certificate and key files
+func (cr *CertificateReloader) filesModTime() (time.Time, error) {
+	var newest time.Time
+	for _, file := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("TLS certificate: %w", err)
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+// The files are checked at most once per certificateCheckInterval; a renewal that fails to load keeps the
+// previous certificate in use
+func (cr *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if time.Since(cr.checkedAt) < certificateCheckInterval {
+		return cr.certificate, nil
+	}
+	cr.checkedAt = time.Now()
+	if modTime, err := cr.filesModTime(); err != nil || !modTime.After(cr.modTime) {
+		return cr.certificate, nil
+	}
+	if err := cr.load(); err != nil {
+		httpLog.Warnf("⚠️ Keeping the current TLS certificate: %v", err)
+		return cr.certificate, nil
+	}
+	httpLog.Infof("🔐 Reloaded TLS certificate from %s", cr.certFile)
+	return cr.certificate, nil
+}
+
+// NewTLSConfig returns the TLS settings of the HTTPS server, serving the reloader's certificate
+// HTTP/2 is negotiated through ALPN by net/http when serving with this config
+func NewTLSConfig(reloader *CertificateReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+}
+
+// HTTPSRedirectHandler redirects plain HTTP requests to the HTTPS server on httpsPort, except ACME
+// HTTP-01 challenges, which are served from the working directory
+func HTTPSRedirectHandler(httpsPort int) http.Handler {
+	challenges := http.FileServer(http.Dir("."))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(path.Clean(r.URL.Path)+"/", ACMEChallengePath) {
+			challenges.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "use HTTPS", http.StatusBadRequest)
+			return
+		}
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		} else {
+			host = strings.Trim(host, "[]") // IPv6 literal without a port
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,387 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OpenTelemetry span kinds and status codes (OTLP trace.proto)
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+
+	spanStatusError = 2
+)
+
+const (
+	traceQueueSize     = 4096
+	traceBatchSize     = 512
+	traceFlushInterval = 5 * time.Second
+	traceExportTimeout = 10 * time.Second
+	traceparentHeader  = "traceparent"
+	defaultServiceName = "r3e-leaderboard"
+)
+
+// activeTracer is nil while tracing is disabled; spans are then never allocated
+var activeTracer atomic.Pointer[tracer]
+
+// spanKey is the context key holding the current span
+type spanKey struct{}
+
+// Span is a timed operation of a trace, exported to an OpenTelemetry collector via OTLP
+// All methods are safe to call on a nil span (tracing disabled)
+type Span struct {
+	tracer   *tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu     sync.Mutex
+	attrs  []spanAttr
+	errMsg string
+	ended  bool
+}
+
+// spanAttr is a single span attribute
+type spanAttr struct {
+	key   string
+	value any
+}
+
+// StartSpan starts a span as a child of the span in ctx (or a new trace) and returns a context carrying it
+// attrs are key/value pairs like the Logger's; End must be called on the returned span
+func StartSpan(ctx context.Context, name string, attrs ...any) (context.Context, *Span) {
+	return startSpan(ctx, name, spanKindInternal, attrs...)
+}
+
+// startSpan starts a span of the given kind
+func startSpan(ctx context.Context, name string, kind int, attrs ...any) (context.Context, *Span) {
+	t := activeTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	span.SetAttrs(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// startServerSpan starts the span of an incoming HTTP request, continuing a W3C traceparent if present
+func startServerSpan(r *http.Request, route string) (context.Context, *Span) {
+	ctx, span := startSpan(r.Context(), "HTTP "+r.Method+" "+route, spanKindServer,
+		"http.method", r.Method,
+		"http.route", route,
+		"http.target", r.URL.RequestURI(),
+	)
+	if span == nil {
+		return ctx, nil
+	}
+	if traceID, parentID, ok := parseTraceparent(r.Header.Get(traceparentHeader)); ok {
+		span.traceID = traceID
+		span.parentID = parentID
+	}
+	return ctx, span
+}
+
+// SetAttrs adds key/value attributes to the span
+func (s *Span) SetAttrs(attrs ...any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key, ok := attrs[i].(string)
+		if !ok {
+			continue
+		}
+		s.attrs = append(s.attrs, spanAttr{key: key, value: attrs[i+1]})
+	}
+}
+
+// SetError marks the span as failed; a nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export; later calls are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	record := s.toOTLP(end)
+	s.mu.Unlock()
+	s.tracer.enqueue(record)
+}
+
+// TraceID returns the hex trace ID, or "" for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// parseTraceparent parses a W3C "00-<trace-id>-<parent-id>-<flags>" header
+func parseTraceparent(header string) ([16]byte, [8]byte, bool) {
+	var traceID [16]byte
+	var parentID [8]byte
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil || parentID == [8]byte{} {
+		return traceID, parentID, false
+	}
+	return traceID, parentID, true
+}
+
+// tracer batches finished spans and posts them to the collector
+type tracer struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	client      *http.Client
+	spans       chan otlpSpan
+	stop        chan struct{}
+	done        chan struct{}
+	failing     bool // Export is failing; logged once until it recovers
+}
+
+// SetupTracing starts exporting spans to the OTLP/HTTP collector in config.Endpoint
+// Tracing stays disabled when no endpoint is configured
+func SetupTracing(config TracingConfig) {
+	if config.Endpoint == "" {
+		return
+	}
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	t := &tracer{
+		endpoint:    strings.TrimRight(config.Endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		headers:     config.Headers,
+		client:      &http.Client{Timeout: traceExportTimeout},
+		spans:       make(chan otlpSpan, traceQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	activeTracer.Store(t)
+	tracingLog.Infof("🔭 Exporting traces to %s as %s", t.endpoint, serviceName)
+}
+
+// ShutdownTracing stops tracing and exports the spans still queued, waiting at most until ctx is done
+func ShutdownTracing(ctx context.Context) {
+	t := activeTracer.Swap(nil)
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+	}
+}
+
+// enqueue hands a finished span to the exporter, dropping it when the queue is full
+func (t *tracer) enqueue(span otlpSpan) {
+	select {
+	case t.spans <- span:
+	default:
+	}
+}
+
+// run batches spans and exports them every traceFlushInterval or traceBatchSize spans
+func (t *tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, traceBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			t.export(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) >= traceBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.spans:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export posts one batch as an OTLP/HTTP JSON ExportTraceServiceRequest
+func (t *tracer) export(batch []otlpSpan) {
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttr{toOTLPAttr("service.name", t.serviceName)},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": defaultServiceName},
+				"spans": batch,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.reportFailure(err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		t.reportFailure(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.reportFailure(err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		t.reportFailure(fmt.Errorf("collector returned status %d", resp.StatusCode))
+		return
+	}
+	if t.failing {
+		t.failing = false
+		tracingLog.Infof("🔭 Trace export recovered")
+	}
+}
+
+// reportFailure logs the first of a run of failed exports
+func (t *tracer) reportFailure(err error) {
+	if !t.failing {
+		t.failing = true
+		tracingLog.Warnf("⚠️ Trace export to %s failed: %v (spans dropped until it recovers)", t.endpoint, err)
+	}
+}
+
+// otlpSpan is the OTLP JSON encoding of a span
+type otlpSpan struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr  `json:"attributes,omitempty"`
+	Status            *otlpStatus `json:"status,omitempty"`
+}
+
+// otlpAttr is an OTLP JSON key/value attribute
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpStatus is an OTLP JSON span status
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// toOTLP converts the span for export; s.mu must be held
+func (s *Span) toOTLP(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for _, attr := range s.attrs {
+		span.Attributes = append(span.Attributes, toOTLPAttr(attr.key, attr.value))
+	}
+	if s.errMsg != "" {
+		span.Status = &otlpStatus{Code: spanStatusError, Message: s.errMsg}
+	}
+	return span
+}
+
+// toOTLPAttr encodes a Go value as an OTLP AnyValue (64-bit integers are strings in OTLP JSON)
+func toOTLPAttr(key string, value any) otlpAttr {
+	var v map[string]any
+	switch x := value.(type) {
+	case string:
+		v = map[string]any{"stringValue": x}
+	case bool:
+		v = map[string]any{"boolValue": x}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(x)}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case float64:
+		v = map[string]any{"doubleValue": x}
+	case time.Duration:
+		v = map[string]any{"intValue": strconv.FormatInt(x.Milliseconds(), 10)}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+	return otlpAttr{Key: key, Value: v}
+}
@@ -2,98 +2,279 @@ package internal
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 )
 
-// RefreshTriggerCallback is called when a refresh is triggered
-type RefreshTriggerCallback func(trackIDs []string, origin string)
+// CommandDir holds command files (*.cmd) for hosts where only file access is available
+const CommandDir = "cache/commands"
 
-// RefreshWatcher watches a file for refresh triggers
-type RefreshWatcher struct {
+// CommandHandler runs a command with its arguments and returns a short result message
+type CommandHandler func(args []string) (string, error)
+
+// CommandResult is the outcome of one command line, written to the .result file next to the command file
+type CommandResult struct {
+	Command     string    `json:"command"`
+	OK          bool      `json:"ok"`
+	Message     string    `json:"message"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// CommandWatcher processes the refresh trigger file and the command files of CommandDir
+type CommandWatcher struct {
 	triggerPath   string
+	commandDir    string
 	checkInterval time.Duration
 	ctx           context.Context
-	onRefresh     RefreshTriggerCallback
+	handlers      map[string]CommandHandler
 	isBusy        func() bool
 }
 
-// NewRefreshWatcher creates a new refresh file watcher
-func NewRefreshWatcher(ctx context.Context, triggerPath string, checkIntervalSeconds int, onRefresh RefreshTriggerCallback, isBusy func() bool) *RefreshWatcher {
+// NewCommandWatcher creates a watcher with the built-in pause, resume, prune and clear commands
+// The refresh command (also run for the trigger file) and reindex are added by the caller with Handle
+func NewCommandWatcher(ctx context.Context, triggerPath, commandDir string, checkIntervalSeconds int, isBusy func() bool) *CommandWatcher {
 	if checkIntervalSeconds < 1 {
 		checkIntervalSeconds = 30
 	}
-	return &RefreshWatcher{
+	w := &CommandWatcher{
 		triggerPath:   triggerPath,
+		commandDir:    commandDir,
 		checkInterval: time.Duration(checkIntervalSeconds) * time.Second,
 		ctx:           ctx,
-		onRefresh:     onRefresh,
+		handlers:      make(map[string]CommandHandler),
 		isBusy:        isBusy,
 	}
+	w.Handle("pause", func([]string) (string, error) {
+		return "fetching paused", PauseFetching()
+	})
+	w.Handle("resume", func([]string) (string, error) {
+		return "fetching resumed", ResumeFetching()
+	})
+	w.Handle("prune", commandPrune)
+	w.Handle("clear", w.commandClear)
+	return w
 }
 
-// Start begins watching for the trigger file
-func (w *RefreshWatcher) Start() {
+// Handle registers the handler of a command
+func (w *CommandWatcher) Handle(name string, handler CommandHandler) {
+	w.handlers[strings.ToLower(name)] = handler
+}
+
+// triggerSettleDelay lets consecutive writes to the trigger file (echo ... >> refresh_now) finish before it is read
+const triggerSettleDelay = 500 * time.Millisecond
+
+// Start begins watching for the trigger file and command files
+// Writes are picked up immediately through file notifications where available; polling stays as a fallback
+// and catches triggers left in place while a refresh was running
+func (w *CommandWatcher) Start() {
 	go func() {
-		log.Printf("🪙 Refresh file trigger watching %s every %v", w.triggerPath, w.checkInterval)
+		if err := os.MkdirAll(w.commandDir, 0755); err != nil {
+			schedulerLog.Warnf("⚠️ Failed to create %s: %v", w.commandDir, err)
+		}
+		triggerName := filepath.Base(w.triggerPath)
+		triggerNotifications, err := watchDir(w.ctx, filepath.Dir(w.triggerPath), func(name string) bool {
+			return name == triggerName
+		})
+		var commandNotifications <-chan struct{}
+		if err == nil {
+			commandNotifications, err = watchDir(w.ctx, w.commandDir, isCommandFile)
+		}
+		if err != nil {
+			schedulerLog.Infof("🪙 Polling %s and %s/*.cmd every %v (no file notifications: %v)", w.triggerPath, w.commandDir, w.checkInterval, err)
+		} else {
+			schedulerLog.Infof("🪙 Watching %s and %s/*.cmd (polling every %v as fallback)", w.triggerPath, w.commandDir, w.checkInterval)
+		}
+
 		ticker := time.NewTicker(w.checkInterval)
 		defer ticker.Stop()
+		settle := time.NewTimer(triggerSettleDelay)
+		settle.Stop()
+		defer settle.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				w.checkTrigger()
+				w.check()
+			case _, ok := <-triggerNotifications:
+				if !ok {
+					triggerNotifications = nil // Watch stopped; keep polling
+					continue
+				}
+				settle.Reset(triggerSettleDelay)
+			case _, ok := <-commandNotifications:
+				if !ok {
+					commandNotifications = nil
+					continue
+				}
+				settle.Reset(triggerSettleDelay)
+			case <-settle.C:
+				w.check()
 			case <-w.ctx.Done():
-				log.Println("⏹️ Refresh file trigger watcher stopping")
+				schedulerLog.Infof("⏹️ Command file watcher stopping")
 				return
 			}
 		}
 	}()
 }
 
-// checkTrigger checks for the trigger file and handles it
-func (w *RefreshWatcher) checkTrigger() {
+// ParseRefreshArgs reads refresh targets: track IDs and track-class couples written as "1693-1703" or
+// "1693:1703", separated by whitespace or commas. The keyword "full" requests a full refresh whatever else
+// is listed; no targets do too.
+func ParseRefreshArgs(args []string) (trackIDs []string, full bool) {
+	fields := strings.FieldsFunc(strings.Join(args, " "), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, field := range fields {
+		if strings.EqualFold(field, "full") {
+			full = true
+			continue
+		}
+		trackIDs = append(trackIDs, strings.Replace(field, ":", "-", 1))
+	}
+	return trackIDs, full || len(trackIDs) == 0
+}
+
+// check handles the trigger file, then the command files
+func (w *CommandWatcher) check() {
+	w.checkTrigger()
+	w.checkCommands()
+}
+
+// checkTrigger checks for the trigger file and runs its contents as a refresh command
+func (w *CommandWatcher) checkTrigger() {
 	// Ultra-lightweight existence check
 	if _, err := os.Stat(w.triggerPath); err != nil {
 		// File doesn't exist, nothing to do
 		return
 	}
 
+	// Leave the file in place while a refresh runs; it is picked up once that finishes
+	if w.isBusy != nil && w.isBusy() {
+		schedulerLog.Debugf("⏳ Refresh trigger file %s queued - refresh already in progress", w.triggerPath)
+		return
+	}
+
 	// Found trigger file
-	log.Printf("🪙 Refresh trigger file detected: %s", w.triggerPath)
+	schedulerLog.Infof("🪙 Refresh trigger file detected: %s", w.triggerPath)
 
 	// Read file contents before deleting to check for track IDs
-	fileContent, readErr := os.ReadFile(w.triggerPath)
-	var trackIDs []string
-	if readErr == nil {
-		// Parse track IDs from file (space or newline separated)
-		content := strings.TrimSpace(string(fileContent))
-		if content != "" {
-			// Split by whitespace (spaces, tabs, newlines)
-			fields := strings.Fields(content)
-			for _, field := range fields {
-				if field != "" {
-					trackIDs = append(trackIDs, field)
-				}
-			}
-		}
-	}
+	fileContent, _ := os.ReadFile(w.triggerPath)
 
 	// Attempt to remove to avoid repeated triggers
 	if rmErr := os.Remove(w.triggerPath); rmErr != nil {
-		log.Printf("⚠️ Could not remove trigger file: %v", rmErr)
+		schedulerLog.Warnf("⚠️ Could not remove trigger file: %v", rmErr)
 	}
 
-	// Skip if already fetching
-	if w.isBusy != nil && w.isBusy() {
-		log.Println("⏭️ Skipping manual refresh - fetch already in progress")
+	w.run("refresh", strings.Fields(string(fileContent)))
+}
+
+// isCommandFile matches the names of command files
+func isCommandFile(name string) bool {
+	return strings.HasSuffix(name, ".cmd") && !strings.HasPrefix(name, ".")
+}
+
+// checkCommands runs the command files in name order, one command per line, and replaces each
+// with a .result file listing the outcome of its commands
+func (w *CommandWatcher) checkCommands() {
+	entries, err := os.ReadDir(w.commandDir)
+	if err != nil {
 		return
 	}
+	for _, entry := range entries { // Sorted by name
+		if entry.IsDir() || !isCommandFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(w.commandDir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			schedulerLog.Warnf("⚠️ Failed to read command file %s: %v", path, err)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			schedulerLog.Warnf("⚠️ Could not remove command file %s: %v", path, err)
+			continue // Running it again on every check would be worse than not at all
+		}
+		schedulerLog.Infof("📨 Command file detected: %s", path)
+
+		results := []CommandResult{}
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			results = append(results, w.run(fields[0], fields[1:]))
+		}
+
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err == nil {
+			err = writeFileAtomic(strings.TrimSuffix(path, ".cmd")+".result", data)
+		}
+		if err != nil {
+			schedulerLog.Warnf("⚠️ Failed to write result of %s: %v", path, err)
+		}
+	}
+}
+
+// run executes one command and logs its outcome
+func (w *CommandWatcher) run(name string, args []string) CommandResult {
+	result := CommandResult{Command: strings.TrimSpace(name + " " + strings.Join(args, " ")), ProcessedAt: time.Now().UTC()}
+	handler, ok := w.handlers[strings.ToLower(name)]
+	if !ok {
+		result.Message = fmt.Sprintf("unknown command %q", name)
+		schedulerLog.Warnf("⚠️ Command %q failed: %s", result.Command, result.Message)
+		return result
+	}
+
+	message, err := handler(args)
+	if err != nil {
+		result.Message = err.Error()
+		schedulerLog.Warnf("⚠️ Command %q failed: %v", result.Command, err)
+		return result
+	}
+	result.OK, result.Message = true, message
+	schedulerLog.Infof("📨 Command %q: %s", result.Command, message)
+	return result
+}
+
+// commandPrune removes cached combinations that are no longer configured: prune [dry-run]
+func commandPrune(args []string) (string, error) {
+	dryRun := len(args) > 0 && strings.TrimLeft(args[0], "-") == "dry-run"
+	result, err := NewDataCache().PruneStaleCombinations(dryRun)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return fmt.Sprintf("%d of %d cached combinations would be pruned", len(result.Stale), result.Checked), nil
+	}
+	return fmt.Sprintf("pruned %d of %d cached combinations", result.Removed, result.Checked), nil
+}
 
-	// Trigger the refresh callback
-	if w.onRefresh != nil {
-		w.onRefresh(trackIDs, "manual")
+// commandClear removes the cache files of tracks or combinations so the next refresh refetches them:
+// clear 1693 5276-8600, or clear temp to remove an abandoned temporary cache
+func (w *CommandWatcher) commandClear(args []string) (string, error) {
+	trackIDs, _ := ParseRefreshArgs(args)
+	if len(trackIDs) == 0 {
+		return "", fmt.Errorf("clear needs track IDs, track-class couples or \"temp\"")
+	}
+	if len(trackIDs) == 1 && trackIDs[0] == "temp" {
+		if w.isBusy != nil && w.isBusy() {
+			return "", fmt.Errorf("a refresh is writing the temporary cache")
+		}
+		return "temporary cache cleared", NewDataCache().ClearTempCache()
+	}
+
+	targets, err := ParseRefreshTargets(trackIDs)
+	if err != nil {
+		return "", err
+	}
+	removed, err := NewDataCache().RemoveCombinations(targets)
+	if err != nil {
+		return "", err
 	}
+	return fmt.Sprintf("removed %d cache files", removed), nil
 }
@@ -0,0 +1,61 @@
+//go:build linux
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// watchDir reports writes to the files of dir whose name matches, through inotify
+// A signal is sent when a file is closed after writing or moved into place, so a half-written file isn't read
+func watchDir(ctx context.Context, dir string, match func(name string) bool) (<-chan struct{}, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	// Non-blocking, so reads go through the runtime poller and Close unblocks them
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	events := make(chan struct{}, 1)
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+	go func() {
+		defer close(events)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					schedulerLog.Warnf("⚠️ inotify watch on %s stopped: %v", dir, err)
+				}
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+				if !match(string(bytes.TrimRight(nameBytes, "\x00"))) {
+					continue
+				}
+				select {
+				case events <- struct{}{}:
+				default: // A signal is already pending
+				}
+			}
+		}
+	}()
+	return events, nil
+}
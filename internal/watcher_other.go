@@ -0,0 +1,13 @@
+//go:build !linux
+
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+// watchDir is only implemented with inotify; other platforms rely on polling
+func watchDir(ctx context.Context, dir string, match func(name string) bool) (<-chan struct{}, error) {
+	return nil, errors.New("file notifications are not supported on this platform")
+}
@@ -0,0 +1,349 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2)
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+const (
+	wsAcceptGUID      = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageSize  = 64 * 1024 // Client messages are small JSON requests
+	wsPingInterval    = 30 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+	wsCloseNormal     = 1000
+	wsCloseGoingAway  = 1001
+	wsCloseProtocol   = 1002
+	wsCloseTooBig     = 1009
+	wsMaxSearchResult = 500 // Results per search reply
+)
+
+// errWSClosed is returned by readMessage when the client closed the connection
+var errWSClosed = fmt.Errorf("websocket closed")
+
+// wsConn is a minimal server-side WebSocket connection (text messages, ping/pong, close)
+type wsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// WSRequest is a message sent by a WebSocket client
+type WSRequest struct {
+	Type   string `json:"type"`             // "search", "autocomplete" or "status"
+	ID     string `json:"id,omitempty"`     // Echoed in the reply so clients can match responses
+	Name   string `json:"name,omitempty"`   // search
+	Prefix string `json:"prefix,omitempty"` // autocomplete
+	Limit  int    `json:"limit,omitempty"`  // autocomplete
+}
+
+// WSMessage is a message sent to a WebSocket client
+type WSMessage struct {
+	Type    string      `json:"type"` // "search", "autocomplete", "status", "event" or "error"
+	ID      string      `json:"id,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message,omitempty"` // Error text
+}
+
+// HandleWebSocket serves live search and status updates over a WebSocket: /api/ws
+// The server pushes the current status on connect, every event from the event stream,
+// and a fresh status after each index rebuild; clients send WSRequest messages
+func (s *APIServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer ws.conn.Close()
+
+	events, unsubscribe := GetEventBroker().Subscribe()
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// Writer side: events, status pushes and keepalive pings
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-r.Context().Done():
+				// Server shutting down: the hijacked connection isn't tracked by http.Server.Shutdown
+				ws.writeClose(wsCloseGoingAway)
+				ws.conn.Close()
+				return
+			case <-ticker.C:
+				if err := ws.writeFrame(wsOpPing, nil); err != nil {
+					ws.conn.Close()
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := ws.writeJSON(WSMessage{Type: "event", Data: event}); err != nil {
+					ws.conn.Close()
+					return
+				}
+				if event.Type == EventIndexFinished {
+					ws.writeJSON(WSMessage{Type: "status", Data: ReadStatusData()})
+				}
+			}
+		}
+	}()
+
+	if err := ws.writeJSON(WSMessage{Type: "status", Data: ReadStatusData()}); err != nil {
+		return
+	}
+
+	for {
+		payload, err := ws.readMessage()
+		if err == errWSClosed || err == io.EOF {
+			return
+		} else if err != nil {
+			requestLog(r).Warnf("⚠️ WebSocket read error: %v", err)
+			return
+		}
+
+		var req WSRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			ws.writeJSON(WSMessage{Type: "error", Message: "invalid JSON message"})
+			continue
+		}
+		if err := ws.writeJSON(s.handleWSRequest(req)); err != nil {
+			return
+		}
+	}
+}
+
+// handleWSRequest answers a single client request
+func (s *APIServer) handleWSRequest(req WSRequest) WSMessage {
+	switch req.Type {
+	case "search":
+		if req.Name == "" {
+			return WSMessage{Type: "error", ID: req.ID, Message: "missing name"}
+		}
+		results := s.engine.Lookup(req.Name)
+		if len(results) > wsMaxSearchResult {
+			results = results[:wsMaxSearchResult]
+		}
+		return WSMessage{Type: "search", ID: req.ID, Data: map[string]interface{}{
+			"name":    req.Name,
+			"count":   len(results),
+			"results": results,
+		}}
+	case "autocomplete":
+		if req.Prefix == "" {
+			return WSMessage{Type: "error", ID: req.ID, Message: "missing prefix"}
+		}
+		limit := req.Limit
+		if limit < 1 {
+			limit = defaultAutocompleteLimit
+		} else if limit > maxAutocompleteLimit {
+			limit = maxAutocompleteLimit
+		}
+		return WSMessage{Type: "autocomplete", ID: req.ID, Data: s.engine.Autocomplete(req.Prefix, limit)}
+	case "status":
+		return WSMessage{Type: "status", ID: req.ID, Data: ReadStatusData()}
+	default:
+		return WSMessage{Type: "error", ID: req.ID, Message: fmt.Sprintf("unknown message type %q", req.Type)}
+	}
+}
+
+// upgradeWebSocket performs the RFC 6455 opening handshake and hijacks the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("websocket upgrade requires GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("connection cannot be upgraded")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+// headerContainsToken reports whether a comma-separated header contains token (case-insensitive)
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the next complete text or binary message, answering pings on the way
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.writeClose(wsCloseNormal)
+			return nil, errWSClosed
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if len(message) > wsMaxMessageSize {
+				c.writeClose(wsCloseTooBig)
+				return nil, fmt.Errorf("message exceeds %d bytes", wsMaxMessageSize)
+			}
+			if fin {
+				return message, nil
+			}
+		default:
+			c.writeClose(wsCloseProtocol)
+			return nil, fmt.Errorf("unknown opcode %d", opcode)
+		}
+	}
+}
+
+// readFrame reads and unmasks a single frame
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageSize {
+		c.writeClose(wsCloseTooBig)
+		return false, 0, nil, fmt.Errorf("frame exceeds %d bytes", wsMaxMessageSize)
+	}
+	// Clients must mask every frame
+	if !masked {
+		c.writeClose(wsCloseProtocol)
+		return false, 0, nil, fmt.Errorf("unmasked client frame")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single unmasked frame (server frames are never masked)
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|opcode)
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// writeJSON sends v as a text message
+func (c *wsConn) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(wsOpText, data)
+}
+
+// writeClose sends a close frame with the given status code
+func (c *wsConn) writeClose(code uint16) {
+	c.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, code))
+}
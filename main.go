@@ -3,12 +3,16 @@ package main
 import (
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
+	"path/filepath"
 	"r3e-leaderboard/internal"
 	"runtime"
 	"runtime/debug"
@@ -21,11 +25,41 @@ import (
 var orchestrator *Orchestrator
 var httpServer *http.Server
 
+// instanceLock is the cache lock of this instance; nil when serving read-only
+var instanceLock *internal.InstanceLock
+
+// stopHTTPRequests cancels the base context of in-flight requests so long-lived
+// streams (SSE, WebSocket) end and Shutdown doesn't wait for them to time out
+var stopHTTPRequests context.CancelFunc
+
+var mainLog = internal.NewLogger("main")
+
 func main() {
 	// Remove timestamps from log output (systemd/journalctl already provides them)
 	log.SetFlags(0)
+	// Text logging until the configuration (and its logging section) is loaded
+	internal.SetupLogging(internal.GetDefaultConfig().Logging)
+
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// runServe runs the daemon: fetching, scheduled refreshes, indexing and the HTTP server
+// Returns the exit code once a shutdown signal has been handled
+func runServe(args []string) int {
+	flags := newFlagSet("serve", "")
+	discoverTracks := flags.Bool("discover-tracks", false, "deprecated: use the discover-tracks command")
+	exportJSONL := flags.String("export-jsonl", "", "deprecated: use export --format jsonl --output PATH")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *discoverTracks {
+		return runTrackDiscovery(nil)
+	}
+	if *exportJSONL != "" {
+		return runExport([]string{"--format", internal.EntryFormatJSONL, "--output", *exportJSONL})
+	}
 
-	log.Println("🏎️  RaceRoom Leaderboard Cache Generator")
+	mainLog.Infof("🏎️  RaceRoom Leaderboard Cache Generator")
 
 	// Use default Go GC strategy (GOGC ~100). No explicit override.
 
@@ -34,14 +68,21 @@ func main() {
 		if mb, err := strconv.Atoi(ml); err == nil && mb > 0 {
 			limitBytes := int64(mb) * 1024 * 1024
 			debug.SetMemoryLimit(limitBytes)
-			log.Printf("🧠 Memory limit set to %d MB via MEMORY_LIMIT_MB", mb)
+			mainLog.Infof("🧠 Memory limit set to %d MB via MEMORY_LIMIT_MB", mb)
 		} else {
-			log.Printf("⚠️ Invalid MEMORY_LIMIT_MB value: %q (expected integer MB)", ml)
+			mainLog.Warnf("⚠️ Invalid MEMORY_LIMIT_MB value: %q (expected integer MB)", ml)
 		}
 	}
 
-	// Load configuration
-	config := internal.GetDefaultConfig()
+	config := loadConfig()
+	internal.SetupTracing(config.Tracing)
+
+	// Only one instance may fetch into and promote the cache
+	readOnly, err := acquireInstanceLock(config.Cache.Lock)
+	if err != nil {
+		mainLog.Errorf("❌ %v - stop the other instance, use a separate working directory, or set cache.lock.on_conflict to \"wait\" or \"read_only\"", err)
+		return 1
+	}
 
 	// Initialize cancelable context
 	fetchContext, fetchCancel := context.WithCancel(context.Background())
@@ -49,88 +90,410 @@ func main() {
 	// Create orchestrator to coordinate all operations
 	orchestrator = NewOrchestrator(fetchContext, fetchCancel)
 
+	// Deliver events (records found during promotion included) to the configured webhooks
+	internal.StartNotifications(fetchContext, config.Notify)
+
+	if readOnly {
+		return runReadOnly(fetchContext, config)
+	}
+
 	// Promote any leftover temporary cache from previous runs before starting
 	tempCache := internal.NewTempDataCache()
 	promotedCount, err := tempCache.PromoteTempCache()
 	if err != nil {
-		log.Printf("⚠️ Startup cache promotion error: %v", err)
+		mainLog.Warnf("⚠️ Startup cache promotion error: %v", err)
 	} else if promotedCount > 0 {
-		log.Printf("🔄 Startup: promoted %d temp cache files", promotedCount)
+		mainLog.Infof("🔄 Startup: promoted %d temp cache files", promotedCount)
 	}
 
+	// Serve the last exported index right away while the cache is being loaded
+	go func() {
+		if err := internal.GetSearchEngine().LoadPersisted(); err != nil {
+			mainLog.Infof("ℹ️ No persisted driver index loaded: %v", err)
+		}
+	}()
+
 	// Start background operations
 	orchestrator.StartBackgroundDataLoading(config.Schedule.IndexingMinutes)
-	orchestrator.StartScheduledRefresh(config.Schedule.RefreshHour, config.Schedule.RefreshMinute, config.Schedule.IndexingMinutes)
-	// Ultra-lightweight manual trigger via file sentinel
-	orchestrator.StartRefreshFileTrigger("cache/refresh_now", 60, config.Schedule.IndexingMinutes)
+	if config.Schedule.Popularity.Enabled {
+		orchestrator.StartPopularityRefresh(config.Schedule.Popularity, config.Schedule.HealthCheck, config.Schedule.IndexingMinutes)
+	} else {
+		orchestrator.StartScheduledRefresh(config.Schedule, config.Schedule.IndexingMinutes)
+	}
+	// Ultra-lightweight manual trigger via file sentinel, and command files for other operations
+	orchestrator.StartCommandWatcher("cache/refresh_now", internal.CommandDir, 60, config.Schedule.IndexingMinutes)
+
+	// Compare the RaceRoom class list with the catalog
+	if hours := config.Discovery.ClassCheckHours; hours > 0 {
+		internal.StartClassDiscovery(fetchContext, time.Duration(hours)*time.Hour)
+	}
+
+	// Daily and weekly summary reports
+	internal.StartReportSchedule(fetchContext, config.Reports)
 
 	// Start periodic memory monitoring and GC
 	go periodicMemoryMonitoring(fetchContext)
 
+	// Background jobs requested through the API (on-demand fetches and refreshes)
+	jobs := internal.NewJobQueue()
+	jobs.Handle(internal.JobKindFetch, internal.RunFetchJob)
+	jobs.Handle(internal.JobKindRefresh, func(ctx context.Context, job internal.Job, report func(internal.FetchProgress)) (int, error) {
+		return 0, orchestrator.RunRefreshJob(ctx, job.Targets, config.Schedule.IndexingMinutes, report)
+	})
+	jobs.Start(fetchContext)
+
 	// Start HTTP server to serve static files
-	startHTTPServer(config.Server.Port)
+	if err := startHTTPServer(fetchContext, config.Server, jobs); err != nil {
+		mainLog.Errorf("❌ HTTP server failed to start: %v", err)
+		orchestrator.Cleanup()
+		instanceLock.Release()
+		return 1
+	}
 
 	// Wait for shutdown signal
 	waitForShutdown()
+	return 0
+}
+
+// acquireInstanceLock takes cache/instance.lock, handling a lock held by another instance as configured
+// Returns true when the instance should serve read-only
+func acquireInstanceLock(config internal.LockConfig) (bool, error) {
+	stale := time.Duration(config.StaleSeconds) * time.Second
+	lock, err := internal.AcquireInstanceLock(internal.InstanceLockFile, stale)
+	if errors.Is(err, internal.ErrLockHeld) {
+		switch config.OnConflict {
+		case internal.LockConflictWait:
+			mainLog.Warnf("⏳ %v - waiting for it to exit", err)
+			ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			if config.WaitSeconds > 0 {
+				var cancelTimeout context.CancelFunc
+				ctx, cancelTimeout = context.WithTimeout(ctx, time.Duration(config.WaitSeconds)*time.Second)
+				defer cancelTimeout()
+			}
+			defer cancel()
+			lock, err = internal.WaitInstanceLock(ctx, internal.InstanceLockFile, stale)
+		case internal.LockConflictReadOnly:
+			mainLog.Warnf("👀 %v - serving the persisted index read-only", err)
+			return true, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	instanceLock = lock
+	return false, nil
+}
+
+// runReadOnly serves the persisted index of the instance holding the cache lock, without fetching,
+// refreshing or promoting; jobs that would write the cache fail
+func runReadOnly(ctx context.Context, config internal.Config) int {
+	if err := internal.GetSearchEngine().LoadPersisted(); err != nil {
+		mainLog.Warnf("⚠️ No persisted driver index loaded: %v", err)
+	}
+
+	errReadOnly := errors.New("read-only instance: another instance holds " + internal.InstanceLockFile)
+	jobs := internal.NewJobQueue()
+	jobs.Handle(internal.JobKindFetch, func(context.Context, internal.Job, func(internal.FetchProgress)) (int, error) {
+		return 0, errReadOnly
+	})
+	jobs.Handle(internal.JobKindRefresh, func(context.Context, internal.Job, func(internal.FetchProgress)) (int, error) {
+		return 0, errReadOnly
+	})
+	jobs.Start(ctx)
+
+	if err := startHTTPServer(ctx, config.Server, jobs); err != nil {
+		mainLog.Errorf("❌ HTTP server failed to start: %v", err)
+		return 1
+	}
+	waitForShutdown()
+	return 0
 }
 
-func startHTTPServer(port int) {
+// startHTTPServer binds the configured port and serves in the background
+// Requests inherit ctx, so they are cancelled along with it; a bind failure is returned
+func startHTTPServer(ctx context.Context, serverConfig internal.ServerConfig, jobs *internal.JobQueue) error {
+	// Dedicated mux: nothing registered on http.DefaultServeMux by imported packages is served
+	mux := http.NewServeMux()
+
 	// Serve static files from current directory
 	fs := http.FileServer(http.Dir("."))
 
 	// Specialized handler to serve driver_index with gzip when supported
-	http.HandleFunc("/cache/driver_index.json", func(w http.ResponseWriter, r *http.Request) {
-		accept := r.Header.Get("Accept-Encoding")
-		wantGzip := strings.Contains(accept, "gzip")
-		gzPath := "cache/driver_index.json.gz"
+	mux.HandleFunc("/cache/driver_index.json", serveDriverIndex)
+	mux.HandleFunc("/"+internal.DriverIndexShardDir+"/", serveDriverIndexShard)
+	mux.HandleFunc("/"+internal.DriverIndexDeltaFile, serveDriverIndexDelta)
 
-		f, err := os.Open(gzPath)
-		if err != nil {
-			log.Printf("❌ Failed to open %s: %v", gzPath, err)
+	// JSON API backed by the in-memory driver index
+	apiKeys := internal.LoadAPIKeyStore(internal.APIKeysFile)
+	internal.GetSearchEngine().SetAliases(internal.LoadAliasStore(internal.AliasesFile))
+	apiServer := internal.NewAPIServer(internal.GetSearchEngine(), jobs, apiKeys, serverConfig)
+	apiServer.RegisterRoutes(mux)
+	mainLog.Infof("🔌 JSON API mounted at %s/%s/", apiServer.Prefix(), internal.APIVersion)
+	if serverConfig.LegacyRoutes.Enabled {
+		mainLog.Infof("🔌 Deprecated unversioned routes still served at %s/", apiServer.Prefix())
+	}
+	if serverConfig.OnDemandFetch {
+		mainLog.Infof("📥 On-demand fetching enabled for uncached leaderboards")
+	}
+
+	// Default handler for all other paths
+	mux.Handle("/", hidePrivateFiles(fs))
+
+	tlsConfig := serverConfig.TLS
+	var certificates *internal.CertificateReloader
+	if tlsConfig.Enabled() {
+		var err error
+		if certificates, err = internal.NewCertificateReloader(tlsConfig.CertFile, tlsConfig.KeyFile); err != nil {
+			return err
+		}
+		hideFile(tlsConfig.KeyFile)
+	} else if tlsConfig.CertFile != "" || tlsConfig.KeyFile != "" {
+		mainLog.Warnf("⚠️ TLS needs both server.tls.cert_file and server.tls.key_file - serving plain HTTP")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", serverConfig.Port))
+	if err != nil {
+		return err
+	}
+
+	requestContext, cancel := context.WithCancel(ctx)
+	stopHTTPRequests = cancel
+	httpServer = &http.Server{
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return requestContext },
+	}
+
+	if certificates == nil {
+		go func() {
+			mainLog.Infof("🌐 HTTP server listening on port %d", serverConfig.Port)
+			if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				mainLog.Errorf("❌ HTTP server error: %v", err)
+			}
+		}()
+		return nil
+	}
+
+	// ServeTLS enables HTTP/2 alongside HTTP/1.1 (negotiated through ALPN)
+	httpServer.TLSConfig = internal.NewTLSConfig(certificates)
+	go func() {
+		mainLog.Infof("🔐 HTTPS server (HTTP/2) listening on port %d", serverConfig.Port)
+		if err := httpServer.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+			mainLog.Errorf("❌ HTTPS server error: %v", err)
+		}
+	}()
+	if tlsConfig.RedirectPort > 0 {
+		return startHTTPSRedirect(tlsConfig.RedirectPort, serverConfig.Port)
+	}
+	return nil
+}
+
+// redirectServer redirects plain HTTP to HTTPS when server.tls.redirect_port is set
+var redirectServer *http.Server
+
+// startHTTPSRedirect binds the plain HTTP port that redirects to the HTTPS server and serves ACME challenges
+func startHTTPSRedirect(port, httpsPort int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	redirectServer = &http.Server{
+		Handler:           internal.HTTPSRedirectHandler(httpsPort),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		mainLog.Infof("↪️ Redirecting HTTP on port %d to HTTPS", port)
+		if err := redirectServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			mainLog.Errorf("❌ HTTP redirect server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// privateFiles are never served by the static file server (they hold secrets)
+var privateFiles = map[string]bool{
+	"/" + internal.ConfigFile:  true,
+	"/" + internal.APIKeysFile: true,
+}
+
+// privateDirs are directories never served by the static file server
+var privateDirs = []string{
+	"/" + internal.DebugProfileDir + "/", // Heap profiles expose memory contents
+}
+
+// hideFile adds a file under the working directory to privateFiles; files elsewhere can't be served anyway
+func hideFile(file string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return
+	}
+	if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		privateFiles[strings.ToLower(path.Clean("/"+filepath.ToSlash(rel)))] = true
+	}
+}
+
+// hidePrivateFiles wraps the static file server so configuration and key files return 404
+func hidePrivateFiles(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Lowercased so case-insensitive filesystems (Windows) can't be used to bypass the check
+		cleaned := strings.ToLower(path.Clean("/" + r.URL.Path))
+		if privateFiles[cleaned] {
 			http.NotFound(w, r)
 			return
 		}
-		defer f.Close()
+		for _, dir := range privateDirs {
+			if strings.HasPrefix(cleaned+"/", dir) {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		w.Header().Set("Vary", "Accept-Encoding")
+// serveDriverIndex serves the driver index, negotiating gzip Content-Encoding
+func serveDriverIndex(w http.ResponseWriter, r *http.Request) {
+	serveGzipJSON(w, r, internal.DriverIndexFile+".gz", internal.DriverIndexFile)
+}
 
-		if wantGzip {
-			w.Header().Set("Content-Encoding", "gzip")
-			w.Header().Set("Content-Type", "application/json")
-			if _, copyErr := io.Copy(w, f); copyErr != nil {
-				log.Printf("⚠️ Failed streaming gz driver index: %v", copyErr)
-			}
+// serveDriverIndexDelta serves the delta of the last driver index export, negotiating gzip Content-Encoding
+func serveDriverIndexDelta(w http.ResponseWriter, r *http.Request) {
+	serveGzipJSON(w, r, internal.DriverIndexDeltaFile+".gz", "")
+}
+
+// serveDriverIndexShard serves the shards and manifest under /cache/driver_index/
+// Shards are only stored gzipped; unknown names return 404
+func serveDriverIndexShard(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	if name == path.Base(internal.DriverIndexManifestFile) {
+		serveIndexFile(w, r, internal.DriverIndexManifestFile)
+		return
+	}
+	shard, ok := strings.CutSuffix(name, ".json")
+	gzPath, known := internal.DriverIndexShardFile(shard)
+	if !ok || !known || path.Dir(r.URL.Path) != "/"+internal.DriverIndexShardDir {
+		http.NotFound(w, r)
+		return
+	}
+	serveGzipJSON(w, r, gzPath, "")
+}
+
+// indexFileCacheControl makes browsers and CDNs revalidate index files on every use: unchanged files cost a 304,
+// and a new export is picked up at once
+const indexFileCacheControl = "public, no-cache"
+
+// fileETag returns a strong entity tag of a file's modification time and size; variant tells apart
+// representations of the same file (gzipped or decompressed)
+func fileETag(info os.FileInfo, variant string) string {
+	return fmt.Sprintf(`"%x-%x%s"`, info.ModTime().UnixNano(), info.Size(), variant)
+}
+
+// serveIndexFile serves an uncompressed index file with its ETag, answering 304 to a matching If-None-Match
+func serveIndexFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	if info, err := os.Stat(filePath); err == nil {
+		w.Header().Set("ETag", fileETag(info, ""))
+	}
+	w.Header().Set("Cache-Control", indexFileCacheControl)
+	http.ServeFile(w, r, filePath)
+}
+
+// serveGzipJSON serves a gzipped JSON file, negotiating gzip Content-Encoding
+// Gzip-capable clients get the pre-compressed file as-is; others get the raw JSON
+// file when rawPath is set and present, or the gz file decompressed on the fly
+// Every representation carries an ETag, and conditional requests are answered with 304
+func serveGzipJSON(w http.ResponseWriter, r *http.Request, gzPath, rawPath string) {
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("Cache-Control", indexFileCacheControl)
+	name := strings.TrimSuffix(path.Base(gzPath), ".gz")
+
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		f, err := os.Open(gzPath)
+		if err != nil {
+			mainLog.Errorf("❌ Failed to open %s: %v", gzPath, err)
+			http.NotFound(w, r)
 			return
 		}
+		defer f.Close()
 
-		// Client does not accept gzip: decompress server-side
-		gr, zerr := gzip.NewReader(f)
-		if zerr != nil {
-			log.Printf("⚠️ Failed to create gzip reader: %v", zerr)
-			http.Error(w, "Failed to read driver index", http.StatusInternalServerError)
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "Failed to read "+name, http.StatusInternalServerError)
 			return
 		}
-		defer gr.Close()
+		w.Header().Set("ETag", fileETag(info, "-gzip"))
+		w.Header().Set("Content-Encoding", "gzip")
 		w.Header().Set("Content-Type", "application/json")
-		if _, copyErr := io.Copy(w, gr); copyErr != nil {
-			log.Printf("⚠️ Failed streaming decompressed driver index: %v", copyErr)
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+
+	// Client does not accept gzip: prefer the raw export if it was written
+	if rawPath != "" {
+		if info, err := os.Stat(rawPath); err == nil && !info.IsDir() {
+			serveIndexFile(w, r, rawPath)
+			return
 		}
-	})
+	}
 
-	// Default handler for all other paths
-	http.Handle("/", fs)
+	f, err := os.Open(gzPath)
+	if err != nil {
+		mainLog.Errorf("❌ Failed to open %s: %v", gzPath, err)
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
 
-	httpServer = &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: nil, // Use DefaultServeMux
+	// Decompressed on the fly, so conditional requests are checked here rather than by http.ServeContent
+	if info, err := f.Stat(); err == nil {
+		etag := fileETag(info, "-json")
+		w.Header().Set("ETag", etag)
+		if internal.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 	}
 
-	go func() {
-		log.Printf("🌐 HTTP server starting on port %d", port)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("⚠️ HTTP server error: %v", err)
+	// Decompress server-side
+	gr, zerr := gzip.NewReader(f)
+	if zerr != nil {
+		mainLog.Warnf("⚠️ Failed to create gzip reader: %v", zerr)
+		http.Error(w, "Failed to read "+name, http.StatusInternalServerError)
+		return
+	}
+	defer gr.Close()
+	w.Header().Set("Content-Type", "application/json")
+	if _, copyErr := io.Copy(w, gr); copyErr != nil {
+		mainLog.Warnf("⚠️ Failed streaming decompressed %s: %v", name, copyErr)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+// Honors q-values so "gzip;q=0" is treated as a refusal, and "*" as acceptance
+func acceptsGzip(header string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
 		}
-	}()
+
+		accepted := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				accepted = false
+			}
+		}
+
+		if coding == "gzip" {
+			return accepted
+		}
+		wildcard = accepted
+	}
+	return wildcard
 }
 
 func waitForShutdown() {
@@ -138,21 +501,25 @@ func waitForShutdown() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-sigChan
-	log.Printf("🛑 Received %s signal, shutting down...", sig)
+	mainLog.Infof("🛑 Received %s signal, shutting down...", sig)
 
-	// Shutdown HTTP server gracefully
+	// Shutdown HTTP server gracefully: stop accepting, end streams, wait for requests to finish
 	if httpServer != nil {
+		stopHTTPRequests()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
 		if err := httpServer.Shutdown(ctx); err != nil {
-			log.Printf("⚠️ HTTP server shutdown error: %v", err)
+			mainLog.Warnf("⚠️ HTTP server shutdown error: %v", err)
 		}
+		cancel()
+	}
+	if redirectServer != nil {
+		redirectServer.Close()
 	}
 
 	if orchestrator != nil {
 		_, _, inProgress := orchestrator.GetScrapeTimestamps()
 		if inProgress {
-			log.Printf("⚠️ Data fetch in progress - canceling and exiting...")
+			mainLog.Warnf("⚠️ Data fetch in progress - canceling and exiting...")
 			orchestrator.CancelFetch()
 			// Give it 2 seconds to clean up, then force exit
 			time.Sleep(2 * time.Second)
@@ -162,7 +529,14 @@ func waitForShutdown() {
 		orchestrator.Cleanup()
 	}
 
-	log.Printf("✅ Shutdown complete")
+	instanceLock.Release()
+
+	// Export the spans still queued
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	internal.ShutdownTracing(tracingCtx)
+	cancelTracing()
+
+	mainLog.Infof("✅ Shutdown complete")
 	os.Exit(0)
 }
 
@@ -176,10 +550,10 @@ func periodicMemoryMonitoring(ctx context.Context) {
 			// Log memory stats (no forced GC)
 			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
-			log.Printf("💾 Memory stats: Alloc=%dMB, Sys=%dMB, NumGC=%d",
+			mainLog.Infof("💾 Memory stats: Alloc=%dMB, Sys=%dMB, NumGC=%d",
 				m.Alloc/1024/1024, m.Sys/1024/1024, m.NumGC)
 		case <-ctx.Done():
-			log.Println("⏹️ Memory monitoring stopped")
+			mainLog.Infof("⏹️ Memory monitoring stopped")
 			return
 		}
 	}
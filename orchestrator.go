@@ -2,25 +2,38 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"r3e-leaderboard/internal"
 	"runtime"
 	"runtime/debug"
+	"strings"
+	"sync"
 	"time"
 )
 
+// refreshJobPollInterval is how often API refresh jobs publish progress
+const refreshJobPollInterval = 2 * time.Second
+
+var orchestratorLog = internal.NewLogger("orchestrator")
+
 // Orchestrator coordinates data loading, refreshing, and indexing
 type Orchestrator struct {
-	fetchContext     context.Context
-	fetchCancel      context.CancelFunc
+	fetchContext context.Context
+	fetchCancel  context.CancelFunc
+	totalDrivers int
+	totalEntries int
+	scheduler    *internal.Scheduler
+	refresh      *refreshCoordinator
+
+	// mu guards the state below: refreshes and their progress callbacks write it while the periodic
+	// indexer, the popularity check, status exports and the API read it. Never held across a fetch or build
+	mu               sync.RWMutex
 	fetchInProgress  bool
 	lastScrapeStart  time.Time
 	lastScrapeEnd    time.Time
-	tracks           []internal.TrackInfo
-	totalDrivers     int
-	totalEntries     int
-	lastIndexedCount int // Track last indexed count to avoid unnecessary rebuilds
-	scheduler        *internal.Scheduler
+	tracks           []internal.TrackInfo   // Replaced, never modified in place, so a copied slice stays valid
+	lastIndexedCount int                    // Track last indexed count to avoid unnecessary rebuilds
+	refreshQueue     *internal.RefreshQueue // Popularity-weighted refresh queue, rebuilt on every check
 }
 
 // NewOrchestrator creates a new orchestrator instance
@@ -29,81 +42,206 @@ func NewOrchestrator(ctx context.Context, cancel context.CancelFunc) *Orchestrat
 		fetchContext: ctx,
 		fetchCancel:  cancel,
 		tracks:       make([]internal.TrackInfo, 0),
+		refresh:      newRefreshCoordinator(),
+	}
+}
+
+// refreshCoordinator admits one refresh at a time across all trigger sources (startup fetch, nightly
+// schedule, popularity checks, trigger file and API jobs), so they never fetch concurrently or race on
+// the temp cache. Scheduled sources skip a busy slot; manual ones wait for it.
+type refreshCoordinator struct {
+	slot   chan struct{} // Holds a token while a refresh runs
+	mu     sync.Mutex
+	origin string // Origin of the running refresh
+	since  time.Time
+}
+
+// newRefreshCoordinator creates an idle coordinator
+func newRefreshCoordinator() *refreshCoordinator {
+	return &refreshCoordinator{slot: make(chan struct{}, 1)}
+}
+
+// tryAcquire claims the slot for origin without waiting; when busy it returns the origin holding it
+func (c *refreshCoordinator) tryAcquire(origin string) (string, bool) {
+	select {
+	case c.slot <- struct{}{}:
+		c.claim(origin)
+		return "", true
+	default:
+		holder, _ := c.running()
+		return holder, false
 	}
 }
 
-// GetFetchProgress returns current fetch progress for status endpoint
+// acquire waits for the slot until ctx is done
+func (c *refreshCoordinator) acquire(ctx context.Context, origin string) error {
+	select {
+	case c.slot <- struct{}{}:
+		c.claim(origin)
+		return nil
+	default:
+	}
+	holder, since := c.running()
+	orchestratorLog.Infof("⏳ %s refresh queued behind the %s refresh running since %s", origin, holder, since.Format("15:04:05"))
+	select {
+	case c.slot <- struct{}{}:
+		c.claim(origin)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// claim records the origin of the refresh that took the slot
+func (c *refreshCoordinator) claim(origin string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.origin, c.since = origin, time.Now()
+}
+
+// release frees the slot for the next refresh
+func (c *refreshCoordinator) release() {
+	c.mu.Lock()
+	c.origin, c.since = "", time.Time{}
+	c.mu.Unlock()
+	<-c.slot
+}
+
+// running returns the origin and start of the running refresh; empty when idle
+func (c *refreshCoordinator) running() (string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.origin, c.since
+}
+
+// busy reports whether a refresh holds the slot
+func (c *refreshCoordinator) busy() bool {
+	return len(c.slot) > 0
+}
+
+// GetFetchProgress returns whether a fetch is running and its processed/total combination counts
 func (o *Orchestrator) GetFetchProgress() (bool, int, int) {
-	return o.fetchInProgress, 0, 0
+	progress := internal.CurrentFetchProgress()
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.fetchInProgress, progress.Processed, progress.Total
 }
 
 // GetScrapeTimestamps returns the last scraping start and end times
 func (o *Orchestrator) GetScrapeTimestamps() (time.Time, time.Time, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
 	return o.lastScrapeStart, o.lastScrapeEnd, o.fetchInProgress
 }
 
+// setTracks replaces the in-memory combinations
+func (o *Orchestrator) setTracks(tracks []internal.TrackInfo) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.tracks = tracks
+}
+
+// trackCount returns the number of in-memory combinations
+func (o *Orchestrator) trackCount() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.tracks)
+}
+
+// setFetchInProgress sets the fetch flag exported in the status
+func (o *Orchestrator) setFetchInProgress(inProgress bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fetchInProgress = inProgress
+}
+
+// setIndexedCount records the number of combinations in the last built index
+func (o *Orchestrator) setIndexedCount(count int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lastIndexedCount = count
+}
+
+// beginFetch marks a refresh's fetch as running; a full refresh also records the scrape start
+func (o *Orchestrator) beginFetch(full bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if full {
+		o.lastScrapeStart = time.Now()
+	}
+	o.fetchInProgress = true
+	o.lastIndexedCount = 0
+}
+
 // StartBackgroundDataLoading initiates the background data loading process
 func (o *Orchestrator) StartBackgroundDataLoading(indexingIntervalMinutes int) {
 	go func() {
+		// Hold the refresh slot so no trigger fetches into the temp cache alongside the startup fetch
+		if err := o.refresh.acquire(o.fetchContext, "startup"); err != nil {
+			return
+		}
+		defer o.refresh.release()
+
 		// Do not mark scrape start yet; only do so if we actually fetch
-		o.fetchInProgress = false
+		o.setFetchInProgress(false)
 		o.exportStatus()
 
 		// Create a callback to update status incrementally during loading
 		progressCallback := func(currentTracks []internal.TrackInfo) {
-			o.tracks = currentTracks
+			o.setTracks(currentTracks)
 			// Reduced logging - only show major milestones (skip initial 0)
 			if len(currentTracks)%500 == 0 && len(currentTracks) > 0 {
-				log.Printf("📊 %d track/class combinations loaded", len(currentTracks))
+				orchestratorLog.Infof("📊 %d track/class combinations loaded", len(currentTracks))
 			}
 		}
 
 		// Callback when cache loading is complete - build index from cache if present
 		cacheCompleteCallback := func(cachedTracks []internal.TrackInfo, willFetchFresh bool) {
-			o.tracks = cachedTracks
+			o.setTracks(cachedTracks)
 
 			if len(cachedTracks) > 0 {
-				log.Println("🔄 Building initial search index from cache...")
+				orchestratorLog.Infof("🔄 Building initial search index from cache...")
 				if err := internal.BuildAndExportIndex(cachedTracks); err != nil {
-					log.Printf("⚠️ Failed to export index: %v", err)
+					orchestratorLog.Warnf("⚠️ Failed to export index: %v", err)
 				} else {
-					o.lastIndexedCount = len(cachedTracks)
+					o.setIndexedCount(len(cachedTracks))
 				}
 				o.exportStatus()
 			} else {
-				log.Println("ℹ️ No cached combinations found — skipping initial index")
+				orchestratorLog.Infof("ℹ️ No cached combinations found — skipping initial index")
 			}
 
 			// Only start periodic indexing and mark scrape start if we will fetch
 			if willFetchFresh {
 				// Mark actual scrape start only when a network fetch will occur
+				o.mu.Lock()
 				o.lastScrapeStart = time.Now()
 				o.fetchInProgress = true
+				o.mu.Unlock()
 				o.exportStatus()
 
-				log.Printf("⏱️ Starting periodic indexing every %d minutes during fetch...", indexingIntervalMinutes)
+				orchestratorLog.Infof("⏱️ Starting periodic indexing every %d minutes during fetch...", indexingIntervalMinutes)
 				o.StartPeriodicIndexing(indexingIntervalMinutes)
 			} else {
-				log.Println("✅ All data is cached - skipping periodic indexing")
+				orchestratorLog.Infof("✅ All data is cached - skipping periodic indexing")
 			}
 		}
 
 		tracks := internal.LoadAllTrackDataWithCallback(o.fetchContext, progressCallback, cacheCompleteCallback)
 
-		log.Println("🔄 Building final search index...")
+		orchestratorLog.Infof("🔄 Building final search index...")
 		if err := internal.BuildAndExportIndex(tracks); err != nil {
-			log.Printf("⚠️ Failed to export index: %v", err)
+			orchestratorLog.Warnf("⚠️ Failed to export index: %v", err)
 		}
-		log.Println("✅ Final index complete")
+		orchestratorLog.Infof("✅ Final index complete")
 
 		// Final update with all data
-		o.tracks = tracks
-
 		// Don't update scrape timestamps during normal startup loading
 		// Only explicit refresh operations (full/targeted) should update these
-		if o.fetchInProgress {
-			o.fetchInProgress = false
-		}
+		o.mu.Lock()
+		o.tracks = tracks
+		o.fetchInProgress = false
+		o.mu.Unlock()
 		o.exportStatus()
 
 		// Compact in-memory track data after indexing to reduce memory footprint
@@ -111,9 +249,9 @@ func (o *Orchestrator) StartBackgroundDataLoading(indexingIntervalMinutes int) {
 		runtime.GC()
 		// Proactively return unused memory to the OS after heavy work
 		debug.FreeOSMemory()
-		log.Printf("🧹 Compacted in-memory track data. %d combinations retained (metadata only)", len(o.tracks))
+		orchestratorLog.Infof("🧹 Compacted in-memory track data. %d combinations retained (metadata only)", o.trackCount())
 
-		log.Printf("✅ Data loading complete! %d track/class combinations indexed", len(tracks))
+		orchestratorLog.Infof("✅ Data loading complete! %d track/class combinations indexed", len(tracks))
 	}()
 }
 
@@ -121,154 +259,305 @@ func (o *Orchestrator) StartBackgroundDataLoading(indexingIntervalMinutes int) {
 // mechanisms as the startup load & fetch phase, but forces a full refresh
 // of all combinations (ignoring cache age and content) and runs periodic
 // indexing during the fetch phase.
-func (o *Orchestrator) StartScheduledRefresh(refreshHour, refreshMinute, indexingIntervalMinutes int) {
-	o.scheduler = internal.NewScheduler(refreshHour, refreshMinute)
+func (o *Orchestrator) StartScheduledRefresh(schedule internal.ScheduleConfig, indexingIntervalMinutes int) {
+	o.scheduler = internal.NewConfiguredScheduler(schedule)
 	o.scheduler.Start(func() {
-		// Skip scheduled refresh if manual fetch is already in progress
-		if o.fetchInProgress {
-			log.Println("⏭️ Skipping scheduled refresh - manual fetch already in progress")
+		// Postpone while RaceRoom fails the health check rather than collecting thousands of errors
+		if err := internal.WaitForRaceRoom(o.fetchContext, schedule.HealthCheck, "nightly"); err != nil {
+			orchestratorLog.Warnf("⏭️ Skipping scheduled refresh: %v", err)
+			return
+		}
+
+		// Skip scheduled refresh if another refresh is already in progress
+		holder, ok := o.refresh.tryAcquire("nightly")
+		if !ok {
+			orchestratorLog.Infof("⏭️ Skipping scheduled refresh - %s refresh already in progress", holder)
 			return
 		}
-		o.performFullRefresh(indexingIntervalMinutes, "nightly")
+		defer o.refresh.release()
+		o.performFullRefresh(o.fetchContext, indexingIntervalMinutes, "nightly")
 	})
 }
 
-// performFullRefresh executes the full-force refresh flow
-func (o *Orchestrator) performFullRefresh(indexingIntervalMinutes int, origin string) {
-	o.lastScrapeStart = time.Now()
-	o.fetchInProgress = true
-	o.lastIndexedCount = 0
+// StartPopularityRefresh refreshes combinations as they come due by popularity tier instead of all at
+// once: hot combinations every few hours, populated ones daily and empty ones weekly
+// The queue is rebuilt on every check so tier changes and refreshes from other triggers are picked up
+// Due combinations wait for the next check while RaceRoom fails the health check
+func (o *Orchestrator) StartPopularityRefresh(config internal.PopularityConfig, health internal.HealthCheckConfig, indexingIntervalMinutes int) {
+	config = config.Normalized()
+	interval := time.Duration(config.CheckMinutes) * time.Minute
+	orchestratorLog.Infof("🔥 Popularity-weighted refresh: top %d every %dh, others every %dh, empty every %dh (checked every %v)",
+		config.HotCount, config.HotIntervalHours, config.NormalIntervalHours, config.ColdIntervalHours, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				o.refreshDueCombinations(config, health, indexingIntervalMinutes)
+			case <-o.fetchContext.Done():
+				orchestratorLog.Infof("⏹️ Popularity-weighted refresh stopping")
+				return
+			}
+		}
+	}()
+}
+
+// refreshDueCombinations runs a targeted refresh of the combinations due in the priority queue
+func (o *Orchestrator) refreshDueCombinations(config internal.PopularityConfig, health internal.HealthCheckConfig, indexingIntervalMinutes int) {
+	holder, ok := o.refresh.tryAcquire("popularity")
+	if !ok {
+		orchestratorLog.Debugf("⏭️ Skipping popularity check - %s refresh already in progress", holder)
+		return
+	}
+	defer o.refresh.release()
+
+	o.mu.RLock()
+	populated := make(map[string]bool, len(o.tracks))
+	for _, track := range o.tracks {
+		populated[track.TrackID+"_"+track.ClassID] = true
+	}
+	o.mu.RUnlock()
+	queue := internal.BuildRefreshQueue(config, populated)
+	o.mu.Lock()
+	o.refreshQueue = queue
+	o.mu.Unlock()
+
+	due := queue.PopDue(time.Now(), config.BatchSize)
+	if len(due) == 0 {
+		if next, ok := queue.NextDue(); ok {
+			orchestratorLog.Debugf("🔥 No combinations due (next at %s)", next.Format("2006-01-02 15:04"))
+		}
+		return
+	}
+	if health.Enabled {
+		if err := internal.CheckRaceRoomHealth(o.fetchContext, health.Normalized()); err != nil {
+			orchestratorLog.Warnf("🩺 Postponing %d due combinations to the next check: %v", len(due), err)
+			return
+		}
+	}
+
+	tokens := make([]string, len(due))
+	dueByTier := make(map[string]int)
+	for i, combo := range due {
+		tokens[i] = combo.Token()
+		dueByTier[combo.Tier]++
+	}
+	orchestratorLog.Infof("🔥 %d combinations due (hot %d, normal %d, cold %d)",
+		len(due), dueByTier[internal.RefreshTierHot], dueByTier[internal.RefreshTierNormal], dueByTier[internal.RefreshTierCold])
+	o.performTargetedRefresh(o.fetchContext, tokens, indexingIntervalMinutes, "popularity")
+}
+
+// performFullRefresh executes the full-force refresh flow; cancelling ctx stops the fetch
+func (o *Orchestrator) performFullRefresh(ctx context.Context, indexingIntervalMinutes int, origin string) {
+	ctx, span := internal.StartSpan(ctx, "refresh.full", "origin", origin)
+	defer span.End()
+
+	o.beginFetch(true)
 	o.exportStatus()
 
 	// Build initial index from cache if available
-	o.buildBootstrapIndex()
+	o.buildBootstrapIndex(ctx)
 
 	// Start periodic indexing during refresh
-	log.Printf("⏱️ Starting periodic indexing every %d minutes...", indexingIntervalMinutes)
+	orchestratorLog.Infof("⏱️ Starting periodic indexing every %d minutes...", indexingIntervalMinutes)
 	o.StartPeriodicIndexing(indexingIntervalMinutes)
 
 	// Progress callback for status updates
 	progressCallback := func(merged []internal.TrackInfo) {
-		o.tracks = merged
+		o.setTracks(merged)
 		if len(merged)%500 == 0 && len(merged) > 0 {
-			log.Printf("📊 %d track/class combinations available", len(merged))
+			orchestratorLog.Infof("📊 %d track/class combinations available", len(merged))
 			o.exportStatus()
 		}
 	}
 
 	// Perform the actual refresh (delegated to internal package)
-	finalTracks := internal.PerformFullRefresh(o.fetchContext, progressCallback, origin)
+	finalTracks := internal.PerformFullRefresh(ctx, progressCallback, origin)
 
 	// Finalize scrape timestamps BEFORE building index
 	// This ensures UpdateStatusWithIndexMetrics preserves the correct end time
+	o.mu.Lock()
 	o.tracks = finalTracks
 	o.lastScrapeEnd = time.Now()
 	o.fetchInProgress = false
+	o.mu.Unlock()
 	o.exportStatus()
 
 	// Build final index (will preserve the scrape timestamps we just wrote)
-	log.Println("🔄 Building final search index...")
+	orchestratorLog.Infof("🔄 Building final search index...")
 	if err := internal.BuildAndExportIndex(finalTracks); err != nil {
-		log.Printf("⚠️ Failed to export index: %v", err)
+		orchestratorLog.Warnf("⚠️ Failed to export index: %v", err)
 	} else {
-		o.lastIndexedCount = len(finalTracks)
+		o.setIndexedCount(len(finalTracks))
 	}
 
 	o.CompactTrackData()
 	runtime.GC()
 	debug.FreeOSMemory()
-	log.Println("✅ Full refresh completed")
+	orchestratorLog.Infof("✅ Full refresh completed")
 }
 
 // performTargetedRefresh executes a targeted refresh for specific track IDs or track-class couples
-func (o *Orchestrator) performTargetedRefresh(trackIDs []string, indexingIntervalMinutes int, origin string) {
-	log.Printf("🎯 Starting targeted refresh for %d token(s)...", len(trackIDs))
+func (o *Orchestrator) performTargetedRefresh(ctx context.Context, trackIDs []string, indexingIntervalMinutes int, origin string) {
+	ctx, span := internal.StartSpan(ctx, "refresh.targeted", "origin", origin, "targets", strings.Join(trackIDs, ","))
+	defer span.End()
+
+	orchestratorLog.Infof("🎯 Starting targeted refresh for %d token(s)...", len(trackIDs))
 	// Don't update lastScrapeStart - that's only for full refreshes
-	o.fetchInProgress = true
-	o.lastIndexedCount = 0
+	o.beginFetch(false)
 	o.exportStatus()
 
 	// Build initial index from cache
-	o.buildBootstrapIndex()
+	o.buildBootstrapIndex(ctx)
 
 	// Start periodic indexing
-	log.Printf("⏱️ Starting periodic indexing every %d minutes during targeted refresh...", indexingIntervalMinutes)
+	orchestratorLog.Infof("⏱️ Starting periodic indexing every %d minutes during targeted refresh...", indexingIntervalMinutes)
 	o.StartPeriodicIndexing(indexingIntervalMinutes)
 
 	// Progress callback for status updates
 	progressCallback := func(merged []internal.TrackInfo) {
-		o.tracks = merged
+		o.setTracks(merged)
 		if len(merged)%50 == 0 && len(merged) > 0 {
-			log.Printf("📊 %d track/class combinations available (cached + refreshed)", len(merged))
+			orchestratorLog.Infof("📊 %d track/class combinations available (cached + refreshed)", len(merged))
 			o.exportStatus()
 		}
 	}
 
 	// Perform the targeted refresh (delegated to internal package)
-	finalTracks := internal.PerformTargetedRefresh(o.fetchContext, trackIDs, progressCallback, origin)
+	finalTracks := internal.PerformTargetedRefresh(ctx, trackIDs, progressCallback, origin)
 
 	// Build final index
-	log.Println("🔄 Building final search index (targeted refresh)...")
+	orchestratorLog.Infof("🔄 Building final search index (targeted refresh)...")
 	if err := internal.BuildAndExportIndex(finalTracks); err != nil {
-		log.Printf("⚠️ Failed to export index: %v", err)
+		orchestratorLog.Warnf("⚠️ Failed to export index: %v", err)
 	} else {
-		o.lastIndexedCount = len(finalTracks)
+		o.setIndexedCount(len(finalTracks))
 	}
-	log.Println("✅ Final index complete (targeted refresh)")
+	orchestratorLog.Infof("✅ Final index complete (targeted refresh)")
 
 	// Finalize
+	o.mu.Lock()
 	o.tracks = finalTracks
 	o.fetchInProgress = false
+	o.mu.Unlock()
 	o.exportStatus()
 
 	// Compact memory
 	o.CompactTrackData()
 	runtime.GC()
 	debug.FreeOSMemory()
-	log.Println("🧹 Compacted in-memory track data after targeted refresh")
+	orchestratorLog.Infof("🧹 Compacted in-memory track data after targeted refresh")
 
-	log.Println("✅ Targeted refresh completed")
+	orchestratorLog.Infof("✅ Targeted refresh completed")
 }
 
-// StartRefreshFileTrigger watches for a lightweight file trigger to start a full refresh
-// The check is ultra-lightweight: a single stat per interval (defaults recommended: 30s)
-func (o *Orchestrator) StartRefreshFileTrigger(triggerPath string, checkIntervalSeconds int, indexingIntervalMinutes int) {
-	// Create watcher with callbacks
-	watcher := internal.NewRefreshWatcher(
-		o.fetchContext,
-		triggerPath,
-		checkIntervalSeconds,
-		func(trackIDs []string, origin string) {
-			// Launch targeted or full refresh based on file contents
+// StartCommandWatcher processes the refresh trigger file and the command files of commandDir
+// Writes are noticed immediately on Linux; otherwise a single stat per interval (defaults recommended: 30s)
+func (o *Orchestrator) StartCommandWatcher(triggerPath, commandDir string, checkIntervalSeconds int, indexingIntervalMinutes int) {
+	watcher := internal.NewCommandWatcher(o.fetchContext, triggerPath, commandDir, checkIntervalSeconds, o.refresh.busy)
+
+	// refresh [full] [trackID | trackID-classID ...]: queued behind a running refresh
+	watcher.Handle("refresh", func(args []string) (string, error) {
+		trackIDs, full := internal.ParseRefreshArgs(args)
+		if full {
 			if len(trackIDs) > 0 {
-				log.Printf("🎯 Targeted refresh requested for %d track(s)", len(trackIDs))
-				o.performTargetedRefresh(trackIDs, indexingIntervalMinutes, origin)
+				orchestratorLog.Infof("🪙 \"full\" keyword given - ignoring %d listed target(s)", len(trackIDs))
+			}
+			trackIDs = nil
+		}
+		targets, err := internal.ParseRefreshTargets(trackIDs)
+		if err != nil {
+			return "", err
+		}
+
+		go func() {
+			// Wait for a refresh that started since the watcher's busy check
+			if err := o.refresh.acquire(o.fetchContext, "manual"); err != nil {
+				return
+			}
+			defer o.refresh.release()
+
+			// Launch targeted or full refresh based on the arguments
+			if len(targets) > 0 {
+				orchestratorLog.Infof("🎯 Targeted refresh requested for %d track(s)", len(targets))
+				o.performTargetedRefresh(o.fetchContext, targets, indexingIntervalMinutes, "manual")
 			} else {
-				log.Println("🔄 Full refresh requested (no track IDs specified)")
-				o.performFullRefresh(indexingIntervalMinutes, origin)
+				orchestratorLog.Infof("🔄 Full refresh requested (no track IDs specified)")
+				o.performFullRefresh(o.fetchContext, indexingIntervalMinutes, "manual")
 			}
-		},
-		func() bool {
-			return o.fetchInProgress
-		},
-	)
+		}()
+		if len(targets) > 0 {
+			return fmt.Sprintf("targeted refresh of %s queued", strings.Join(targets, ", ")), nil
+		}
+		return "full refresh queued", nil
+	})
+
+	// reindex: rebuild the search index from the cache, e.g. after clearing combinations
+	watcher.Handle("reindex", func([]string) (string, error) {
+		holder, ok := o.refresh.tryAcquire("reindex")
+		if !ok {
+			return "", fmt.Errorf("%s refresh in progress - the index is rebuilt when it finishes", holder)
+		}
+		go func() {
+			defer o.refresh.release()
+			o.buildBootstrapIndex(o.fetchContext)
+		}()
+		return "index rebuild started", nil
+	})
+
 	watcher.Start()
 }
 
+// RunRefreshJob runs a refresh queued through the API, reporting fetch progress until it finishes
+// Cancelling ctx stops only this refresh's fetch. It waits for any refresh already in progress (startup, nightly,
+// popularity, file trigger, other jobs) instead of overlapping it
+func (o *Orchestrator) RunRefreshJob(ctx context.Context, trackIDs []string, indexingIntervalMinutes int, report func(internal.FetchProgress)) error {
+	if err := o.refresh.acquire(ctx, "api"); err != nil {
+		return err
+	}
+	defer o.refresh.release()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(refreshJobPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				report(internal.CurrentFetchProgress())
+			}
+		}
+	}()
+
+	if len(trackIDs) > 0 {
+		o.performTargetedRefresh(ctx, trackIDs, indexingIntervalMinutes, "api")
+	} else {
+		o.performFullRefresh(ctx, indexingIntervalMinutes, "api")
+	}
+	close(done)
+	report(internal.CurrentFetchProgress())
+
+	return ctx.Err()
+}
+
 // StartPeriodicIndexing starts periodic index updates during data loading
 func (o *Orchestrator) StartPeriodicIndexing(intervalMinutes int) {
 	// Create indexer with callbacks to access orchestrator state
 	indexer := internal.NewPeriodicIndexer(o.fetchContext, intervalMinutes, internal.IndexerCallbacks{
 		GetState: func() internal.IndexerState {
+			o.mu.RLock()
+			defer o.mu.RUnlock()
 			return internal.IndexerState{
-				Tracks:           o.tracks,
+				Tracks:           append([]internal.TrackInfo(nil), o.tracks...),
 				FetchInProgress:  o.fetchInProgress,
 				LastIndexedCount: o.lastIndexedCount,
 			}
 		},
-		UpdateIndexed: func(count int) {
-			o.lastIndexedCount = count
-		},
+		UpdateIndexed: o.setIndexedCount,
 		ExportStatus: func() {
 			o.exportStatus()
 		},
@@ -283,12 +572,14 @@ func (o *Orchestrator) exportStatus() {
 	// Read existing status to preserve all indexing-related metrics
 	existingStatus := internal.ReadStatusData()
 
+	o.mu.RLock()
+	fetchInProgress, scrapeStart, scrapeEnd, trackCount := o.fetchInProgress, o.lastScrapeStart, o.lastScrapeEnd, len(o.tracks)
+	o.mu.RUnlock()
+
 	// Preserve scrape timestamps if orchestrator values are zero (haven't been set yet)
-	scrapeStart := o.lastScrapeStart
 	if scrapeStart.IsZero() {
 		scrapeStart = existingStatus.LastScrapeStart
 	}
-	scrapeEnd := o.lastScrapeEnd
 	if scrapeEnd.IsZero() {
 		scrapeEnd = existingStatus.LastScrapeEnd
 	}
@@ -300,10 +591,10 @@ func (o *Orchestrator) exportStatus() {
 	// Update ONLY the fetch/scrape status fields that the orchestrator manages
 	// All other fields (metrics from indexing) are preserved from the last BuildAndExportIndex call
 	status := internal.StatusData{
-		FetchInProgress:          o.fetchInProgress,
+		FetchInProgress:          fetchInProgress,
 		LastScrapeStart:          scrapeStart,
 		LastScrapeEnd:            scrapeEnd,
-		TrackCount:               len(o.tracks),
+		TrackCount:               trackCount,
 		TotalFetchedCombinations: existingStatus.TotalFetchedCombinations, // Preserved from indexing
 		TotalUniqueTracks:        existingStatus.TotalUniqueTracks,        // Preserved from indexing
 		TotalDrivers:             existingStatus.TotalDrivers,             // Preserved from indexing
@@ -315,10 +606,12 @@ func (o *Orchestrator) exportStatus() {
 		FailedFetchCount:         existingStatus.FailedFetchCount,  // Preserved from loader
 		FailedFetches:            existingStatus.FailedFetches,     // Preserved from loader
 		RetriedFetchCount:        existingStatus.RetriedFetchCount, // Preserved from loader
+		DataVersion:              existingStatus.DataVersion,       // Preserved from indexing
+		Cache:                    existingStatus.Cache,             // Preserved from indexing
 	}
 
 	if err := internal.ExportStatusData(status); err != nil {
-		log.Printf("⚠️ Failed to export status: %v", err)
+		orchestratorLog.Warnf("⚠️ Failed to export status: %v", err)
 	}
 }
 
@@ -331,7 +624,7 @@ func (o *Orchestrator) CancelFetch() {
 
 // Cleanup releases resources and stops background operations
 func (o *Orchestrator) Cleanup() {
-	log.Println("🧹 Cleaning up orchestrator resources...")
+	orchestratorLog.Infof("🧹 Cleaning up orchestrator resources...")
 
 	// Stop scheduler first
 	if o.scheduler != nil {
@@ -346,37 +639,60 @@ func (o *Orchestrator) Cleanup() {
 	}
 
 	// Clear large data structures to help GC
-	o.tracks = nil
+	o.setTracks(nil)
 
-	log.Println("✅ Orchestrator cleanup complete")
+	orchestratorLog.Infof("✅ Orchestrator cleanup complete")
 }
 
 // CompactTrackData frees heavy per-track entry payloads while retaining metadata
 // This reduces steady-state memory usage without impacting index/exported JSON.
+// The metadata is copied to a new slice: readers may still hold the old one
 func (o *Orchestrator) CompactTrackData() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	if o.tracks == nil {
 		return
 	}
-	for i := range o.tracks {
+	compacted := make([]internal.TrackInfo, len(o.tracks))
+	for i, track := range o.tracks {
 		// Retain Name/TrackID/ClassID, drop Data to free memory
-		o.tracks[i].Data = nil
+		compacted[i] = internal.TrackInfo{Name: track.Name, TrackID: track.TrackID, ClassID: track.ClassID}
 	}
+	o.tracks = compacted
 }
 
 // buildBootstrapIndex loads cached data and builds an initial search index
 // This is used by refresh operations to provide immediate search results
-func (o *Orchestrator) buildBootstrapIndex() {
-	cachedTracks := internal.LoadAllCachedData(o.fetchContext)
+// With export.streaming_build the cache files are indexed one at a time instead of being loaded together
+func (o *Orchestrator) buildBootstrapIndex(ctx context.Context) {
+	if internal.StreamingBuildEnabled() {
+		orchestratorLog.Infof("🔄 Building initial search index from existing cache (streaming)...")
+		cachedTracks, err := internal.BuildAndExportIndexFromCache(ctx)
+		if err != nil {
+			orchestratorLog.Warnf("⚠️ Failed to export initial index: %v", err)
+		} else if len(cachedTracks) > 0 {
+			o.setIndexedCount(len(cachedTracks))
+		}
+		if len(cachedTracks) > 0 {
+			o.setTracks(cachedTracks)
+			o.exportStatus()
+		} else {
+			orchestratorLog.Infof("ℹ️ No cached combinations found for bootstrap index")
+		}
+		return
+	}
+
+	cachedTracks := internal.LoadAllCachedData(ctx)
 	if len(cachedTracks) > 0 {
-		log.Println("🔄 Building initial search index from existing cache...")
+		orchestratorLog.Infof("🔄 Building initial search index from existing cache...")
 		if err := internal.BuildAndExportIndex(cachedTracks); err != nil {
-			log.Printf("⚠️ Failed to export initial index: %v", err)
+			orchestratorLog.Warnf("⚠️ Failed to export initial index: %v", err)
 		} else {
-			o.lastIndexedCount = len(cachedTracks)
+			o.setIndexedCount(len(cachedTracks))
 		}
-		o.tracks = cachedTracks
+		o.setTracks(cachedTracks)
 		o.exportStatus()
 	} else {
-		log.Println("ℹ️ No cached combinations found for bootstrap index")
+		orchestratorLog.Infof("ℹ️ No cached combinations found for bootstrap index")
 	}
 }
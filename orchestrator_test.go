@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"r3e-leaderboard/internal"
+)
+
+// TestRefreshJobConcurrentStatusReads runs a fixture-replay refresh while the status readers poll the
+// orchestrator, so the race detector sees every write the refresh and its callbacks make
+func TestRefreshJobConcurrentStatusReads(t *testing.T) {
+	fixtureDir, err := filepath.Abs(filepath.Join("testdata", "raceroom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	internal.SetSelectionConfig(internal.SelectionConfig{IncludeTracks: []string{"1693"}, IncludeClasses: []string{"1703"}})
+	fetchConfig := internal.GetDefaultConfig().Fetch
+	fetchConfig.Fixtures = internal.FixtureConfig{Mode: internal.FixtureModeReplay, Dir: fixtureDir}
+	fetchConfig.Throttle = internal.ThrottleConfig{MinDelayMs: 1, MaxDelayMs: 10, InitialDelayMs: 1, SlowResponseMs: 10000}
+	internal.SetFetchConfig(fetchConfig)
+	defer internal.SetFetchConfig(internal.GetDefaultConfig().Fetch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orchestrator := NewOrchestrator(ctx, cancel)
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			orchestrator.GetFetchProgress()
+			orchestrator.GetScrapeTimestamps()
+			orchestrator.exportStatus()
+			orchestrator.CompactTrackData()
+		}
+	}()
+
+	for _, trackIDs := range [][]string{nil, {"1693"}} {
+		if err := orchestrator.RunRefreshJob(ctx, trackIDs, 1, func(internal.FetchProgress) {}); err != nil {
+			t.Fatalf("RunRefreshJob(%v): %v", trackIDs, err)
+		}
+	}
+	close(stop)
+	readers.Wait()
+
+	start, end, inProgress := orchestrator.GetScrapeTimestamps()
+	if inProgress || start.IsZero() || end.Before(start) {
+		t.Errorf("after the refreshes: scrape %v to %v, in progress %v; want a finished scrape", start, end, inProgress)
+	}
+	if results := internal.GetSearchEngine().Lookup("Fixture Driver One"); len(results) != 1 || results[0].TrackID != "1693" {
+		t.Errorf("Lookup(Fixture Driver One) = %+v, want one result on 1693", results)
+	}
+}